@@ -0,0 +1,11 @@
+#!/usr/bin/env node
+const { main } = require('../src/cli');
+
+main(process.argv.slice(2))
+  .then(code => {
+    process.exitCode = code;
+  })
+  .catch(error => {
+    console.error(`💔 ${error.message}`);
+    process.exitCode = 1;
+  });
@@ -0,0 +1,447 @@
+const fs = require('fs');
+const { validateProject, formatProblem } = require('./core/validator');
+const { generateRangeChangelog } = require('./core/changelogRange');
+const { createLocalGitProvider } = require('./providers/localGitProvider');
+const { getConfig, validateConfig } = require('./utils/config');
+const { formatReleaseOutput, formatErrorOutput } = require('./core/releaseOutput');
+const { getStepOutputs, writeStepOutputs } = require('./utils/stepOutputs');
+const { resolveBranch } = require('./utils/branch');
+const { logger, configureLogger, withLogLevel, LOG_LEVELS, LOG_FORMATS } = require('./utils/logger');
+
+const USAGE = `Usage: release-boss <command> [options]
+
+Commands:
+  validate    Check the config, version files and templates without releasing
+  changelog   Print the changelog for a commit range (nothing is bumped or pushed)
+  release     Run the release workflow - prepare the release PR, or tag a merged one
+              (token from GITHUB_TOKEN, GITLAB_TOKEN or GITEA_TOKEN)
+  finalize    Tag a merged release PR and run the postRelease hooks - for merge webhooks
+  rollback    Undo a release - rollback <version> deletes its tag (remote and local) and its
+              release, and --revert reverts its version bump commit on the release branch
+
+Options:
+  --config <path>    Config file (default: .release-boss.yml, .release-boss.yaml or .release-boss.json)
+  --dry-run          release, finalize, rollback: log every change instead of making it
+  --output <format>  release, finalize: text (default) or json - json prints one object to stdout, logs go to stderr
+  --pr <number>      finalize: the merged release PR (default: the one merged into the current commit)
+  --branch <name>    Branch the run is for, e.g. on a detached HEAD (default: GITHUB_REF, CI_COMMIT_BRANCH,
+                     then whatever the platform reports)
+  --force            rollback: go ahead even though a newer release is out
+  --revert           rollback: revert the version bump commit too
+  --since <ref>      changelog: start of the range, not included (tag, branch or SHA)
+  --until <ref>      changelog: end of the range, included (default: HEAD)
+  --commits <file>   release, --version-only: release exactly the commit SHAs listed in the file (- reads
+                     stdin), e.g. cherry-picks - the rest of the range is ignored
+  --max-retries <n>  release, finalize, rollback, --version-only: retries for an API call that hits a 5xx,
+                     rate limit or network timeout (overrides retry.maxAttempts in config, 0 turns them off)
+  --version-only     Print just the next version (e.g. VERSION=$(release-boss --version-only)) - nothing is written
+  --log-level <lvl>  Lowest log level to print: debug, info (default), warn or error
+  --log-format <f>   human (default) or json - one JSON record per line, with the branch, version and PR
+  --help             Show this help`;
+
+/**
+ * Options that take a value
+ */
+const VALUE_OPTIONS = {
+  '--config': 'config',
+  '-c': 'config',
+  '--since': 'since',
+  '--until': 'until',
+  '--output': 'output',
+  '--pr': 'pr',
+  '--branch': 'branch',
+  '--commits': 'commits',
+  '--max-retries': 'maxRetries',
+  '--log-level': 'logLevel',
+  '--log-format': 'logFormat'
+};
+
+/**
+ * Options that are just switches
+ */
+const FLAG_OPTIONS = {
+  '--dry-run': 'dryRun',
+  '--version-only': 'versionOnly',
+  '--force': 'force',
+  '--revert': 'revert'
+};
+
+/**
+ * Commands that take an argument after their name, and the option it's stored as
+ */
+const COMMAND_ARGUMENTS = {
+  rollback: 'version'
+};
+
+/**
+ * Formats --output takes
+ */
+const OUTPUT_FORMATS = ['text', 'json'];
+
+/**
+ * Report a mistake on the command line, with the usage to help fix it
+ * @param {String} message - What was wrong
+ * @returns {Number} - Exit code 2
+ */
+function usageError(message) {
+  logger.error(message);
+  process.stderr.write(`\n${USAGE}\n`);
+  return 2;
+}
+
+/**
+ * Parse command line arguments
+ * @param {Array<String>} args - Arguments after the script name
+ * @returns {Object} - { command, options }
+ */
+function parseArgs(args) {
+  const options = {};
+  let command = null;
+
+  for (let i = 0; i < args.length; i++) {
+    const arg = args[i];
+
+    // --name=value works as well as --name value
+    const equals = arg.startsWith('--') ? arg.indexOf('=') : -1;
+    const name = equals > 0 ? arg.substring(0, equals) : arg;
+    const inlineValue = equals > 0 ? arg.substring(equals + 1) : undefined;
+
+    if (arg === '--help' || arg === '-h') {
+      options.help = true;
+    } else if (FLAG_OPTIONS[arg]) {
+      options[FLAG_OPTIONS[arg]] = true;
+    } else if (VALUE_OPTIONS[name]) {
+      const value = inlineValue !== undefined ? inlineValue : args[++i];
+      if (!value) {
+        throw new Error(`${name} needs a value`);
+      }
+      options[VALUE_OPTIONS[name]] = value;
+    } else if (arg.startsWith('-')) {
+      throw new Error(`Unknown option: ${arg}`);
+    } else if (!command) {
+      command = arg;
+    } else if (COMMAND_ARGUMENTS[command] && options[COMMAND_ARGUMENTS[command]] === undefined) {
+      options[COMMAND_ARGUMENTS[command]] = arg;
+    } else {
+      throw new Error(`Unexpected argument: ${arg}`);
+    }
+  }
+
+  if (options.output && !OUTPUT_FORMATS.includes(options.output)) {
+    throw new Error(`--output must be one of: ${OUTPUT_FORMATS.join(', ')}`);
+  }
+
+  if (options.logLevel && !LOG_LEVELS.includes(options.logLevel)) {
+    throw new Error(`--log-level must be one of: ${LOG_LEVELS.join(', ')}`);
+  }
+
+  if (options.logFormat && !LOG_FORMATS.includes(options.logFormat)) {
+    throw new Error(`--log-format must be one of: ${LOG_FORMATS.join(', ')}`);
+  }
+
+  if (options.pr !== undefined && !/^[1-9][0-9]*$/.test(options.pr)) {
+    throw new Error(`--pr must be a PR number, got "${options.pr}"`);
+  }
+
+  if (options.maxRetries !== undefined && !/^\d+$/.test(options.maxRetries)) {
+    throw new Error(`--max-retries must be a whole number, got "${options.maxRetries}"`);
+  }
+
+  return { command, options };
+}
+
+/**
+ * Read the commit SHAs given with --commits
+ * One or more per line, separated by spaces or commas - so git log --format=%H output works as is.
+ * Anything after a # is a comment.
+ * @param {String} source - File path, or - for stdin
+ * @returns {Array<String>} - The SHAs, in the order listed
+ * @throws {Error} - If the file can't be read, or lists something that isn't a SHA (or nothing at all)
+ */
+function readCommitList(source) {
+  const label = source === '-' ? 'stdin' : source;
+  let text;
+  try {
+    text = fs.readFileSync(source === '-' ? 0 : source, 'utf8');
+  } catch (error) {
+    throw new Error(`Couldn't read --commits from ${label}: ${error.message}`);
+  }
+
+  const shas = text.split('\n')
+    .flatMap(line => line.replace(/#.*/, '').split(/[\s,]+/))
+    .filter(Boolean);
+  const invalid = shas.find(sha => !/^[0-9a-f]{7,40}$/i.test(sha));
+  if (invalid) {
+    throw new Error(`--commits from ${label} lists "${invalid}", which isn't a commit SHA`);
+  }
+  if (shas.length === 0) {
+    throw new Error(`--commits from ${label} doesn't list any commit SHAs`);
+  }
+  return shas;
+}
+
+/**
+ * Check the config and the files it references, printing every problem found
+ * @param {Object} options - Parsed options
+ * @returns {Promise<Number>} - Exit code
+ */
+async function validate(options) {
+  // The config loader chats a lot - keep the output to the problems themselves
+  const problems = await withLogLevel('warn', () => validateProject(options.config));
+
+  if (problems.length === 0) {
+    logger.info('✨ Config and version files look flawless, honey! 💅');
+    return 0;
+  }
+
+  for (const problem of problems) {
+    logger.error(formatProblem(problem));
+  }
+  logger.error(`\n❌ Found ${problems.length} problem${problems.length === 1 ? '' : 's'} - fix them before releasing!`);
+  return 1;
+}
+
+/**
+ * Print the changelog for a commit range to stdout
+ * @param {Object} options - Parsed options (since is required, until defaults to HEAD)
+ * @returns {Promise<Number>} - Exit code
+ */
+async function changelog(options) {
+  if (!options.since) {
+    return usageError('changelog needs --since <ref>');
+  }
+
+  // stdout is for the changelog alone, so it can be piped straight into a file - only warnings and
+  // errors are logged meanwhile, and their annotations (written to stdout inside Actions) go to stderr
+  const write = process.stdout.write;
+  process.stdout.write = process.stderr.write.bind(process.stderr);
+  let result;
+  try {
+    result = await withLogLevel('warn', async () => {
+      const config = await getConfig(options.config);
+      validateConfig(config);
+      const provider = await createLocalGitProvider(config);
+      return generateRangeChangelog(provider, config, { since: options.since, until: options.until });
+    });
+    if (!result.changelog) {
+      logger.warn(`No changelog-worthy commits between ${options.since} and ${options.until || 'HEAD'}, sweetie`);
+    }
+  } catch (error) {
+    logger.error(`❌ ${error.message}`);
+    return 1;
+  } finally {
+    process.stdout.write = write;
+  }
+
+  if (result.changelog) {
+    process.stdout.write(result.changelog);
+  }
+  return 0;
+}
+
+/**
+ * Load the config for a command that talks to the platform API
+ * --max-retries wins over the retry policy in the config file, like the action's max-retries input.
+ * @param {Object} options - Parsed options
+ * @returns {Promise<Object>} - Release Boss configuration
+ */
+async function loadConfig(options) {
+  const config = await getConfig(options.config);
+  if (options.maxRetries !== undefined) {
+    config.retry = { ...config.retry, maxAttempts: Number(options.maxRetries) + 1 };
+  }
+  return config;
+}
+
+/**
+ * Read the API token for the configured platform
+ * @param {Object} config - Release Boss configuration
+ * @returns {String}
+ * @throws {Error} - If there isn't one
+ */
+function getToken(config) {
+  const platformTokens = {
+    gitlab: process.env.GITLAB_TOKEN,
+    gitea: process.env.GITEA_TOKEN
+  };
+  const token = platformTokens[config.platform] || process.env.GITHUB_TOKEN || process.env.GITLAB_TOKEN;
+  if (!token) {
+    throw new Error('No token found - set GITHUB_TOKEN (or GITLAB_TOKEN on GitLab, GITEA_TOKEN on Gitea)');
+  }
+  return token;
+}
+
+/**
+ * Print the next version and nothing else
+ * stdout gets the bare version so it can be captured straight into a variable - the workflow
+ * only logs errors (unless --log-level asks for more), and those, like why there's nothing to
+ * release, go to stderr.
+ * @param {Object} options - Parsed options
+ * @returns {Promise<Number>} - Exit code
+ */
+async function versionOnly(options) {
+  // Annotations are written to stdout inside Actions - keep them out of the version
+  const write = process.stdout.write;
+  process.stdout.write = process.stderr.write.bind(process.stderr);
+  let result;
+  let config;
+  try {
+    result = await withLogLevel(options.logLevel || 'error', async () => {
+      const { ReleaseBoss } = require('./releaseBoss');
+      config = await loadConfig(options);
+      // Dry-run too, so even a provider that wanted to write couldn't
+      return new ReleaseBoss(config, {
+        token: getToken(config),
+        dryRun: true,
+        branch: resolveBranch(options),
+        commits: options.commits ? readCommitList(options.commits) : null
+      }).nextVersion();
+    });
+    if (!result.bumpType) {
+      logger.warn(`Nothing to release: ${result.reason}`);
+    }
+  } catch (error) {
+    logger.error(`❌ ${error.message}`);
+    return 1;
+  } finally {
+    process.stdout.write = write;
+  }
+
+  process.stdout.write(`${result.nextVersion}\n`);
+  return result.bumpType ? 0 : config.noReleaseExitCode || 0;
+}
+
+/**
+ * Run the release workflow from the command line
+ * With --output json, everything the workflow logs goes to stderr and stdout gets a single
+ * JSON object (see src/core/releaseOutput.js) - an error object when the run fails.
+ * When GITHUB_OUTPUT is set, the step outputs are written there too.
+ * @param {Object} options - Parsed options
+ * @param {Object} phase - { finalize } - only tag an already merged release PR (the finalize command)
+ * @returns {Promise<Number>} - Exit code
+ */
+async function release(options, { finalize = false } = {}) {
+  const json = options.output === 'json';
+  const write = process.stdout.write;
+  if (json) {
+    process.stdout.write = process.stderr.write.bind(process.stderr);
+  }
+
+  let result;
+  let config;
+  try {
+    // The ReleaseBoss class is loaded lazily - it pulls in the whole workflow
+    const { ReleaseBoss } = require('./releaseBoss');
+    config = await loadConfig(options);
+    const releaseBoss = new ReleaseBoss(config, {
+      token: getToken(config),
+      dryRun: options.dryRun === true,
+      branch: resolveBranch(options),
+      commits: options.commits ? readCommitList(options.commits) : null
+    });
+    result = finalize ? await releaseBoss.finalize({ pr: options.pr && Number(options.pr) }) : await releaseBoss.run();
+  } catch (error) {
+    process.stdout.write = write;
+    if (json) {
+      process.stdout.write(`${JSON.stringify(formatErrorOutput(error), null, 2)}\n`);
+    } else {
+      logger.error(`❌ ${error.message}`);
+    }
+    return 1;
+  }
+
+  process.stdout.write = write;
+  // Inside a GitHub Actions run: step, later steps can use steps.<id>.outputs.version and friends
+  writeStepOutputs(getStepOutputs(result));
+  if (json) {
+    process.stdout.write(`${JSON.stringify(formatReleaseOutput(result), null, 2)}\n`);
+  } else {
+    logger.info(`\n💅 ${result.runType === 'none' ? `Nothing to release: ${result.reason}` : `${result.previousVersion} → ${result.nextVersion} (${result.runType} run)`}${result.dryRun ? ' [dry-run]' : ''}`);
+  }
+  return result.runType === 'none' ? config.noReleaseExitCode || 0 : 0;
+}
+
+/**
+ * Roll back a release from the command line
+ * @param {Object} options - Parsed options (version is required)
+ * @returns {Promise<Number>} - Exit code
+ */
+async function rollback(options) {
+  if (!options.version) {
+    return usageError('rollback needs the version to roll back, e.g. release-boss rollback 1.2.0');
+  }
+
+  let result;
+  try {
+    const { ReleaseBoss } = require('./releaseBoss');
+    const config = await loadConfig(options);
+    const releaseBoss = new ReleaseBoss(config, { token: getToken(config), dryRun: options.dryRun === true });
+    result = await releaseBoss.rollback(options.version, { force: options.force === true, revert: options.revert === true });
+  } catch (error) {
+    logger.error(`❌ ${error.message}`);
+    return 1;
+  }
+
+  logger.info(`\n💅 ${result.tag} rolled back${result.revertSha ? ` - the version bump was reverted in ${result.revertSha.substring(0, 7)}` : ''}${options.dryRun ? ' [dry-run]' : ''}`);
+  return 0;
+}
+
+/**
+ * Run the release-boss command line
+ * @param {Array<String>} args - Arguments after the script name
+ * @returns {Promise<Number>} - Exit code
+ */
+async function main(args) {
+  let parsed;
+  try {
+    parsed = parseArgs(args);
+  } catch (error) {
+    return usageError(error.message);
+  }
+
+  const { command, options } = parsed;
+
+  configureLogger({
+    level: options.logLevel || 'info',
+    format: options.logFormat || 'human'
+  });
+
+  // Only working out a new release cares which commits are in it
+  if (options.commits && !options.help && command && command !== 'release') {
+    return usageError('--commits only goes with release or --version-only');
+  }
+
+  if (options.versionOnly && !options.help) {
+    if (command && command !== 'release') {
+      return usageError(`--version-only doesn't go with ${command}`);
+    }
+    return versionOnly(options);
+  }
+
+  if (options.help || !command) {
+    process.stdout.write(`${USAGE}\n`);
+    return options.help ? 0 : 2;
+  }
+
+  switch (command) {
+    case 'validate':
+      return validate(options);
+    case 'changelog':
+      return changelog(options);
+    case 'release':
+      return release(options);
+    case 'finalize':
+      return release(options, { finalize: true });
+    case 'rollback':
+      return rollback(options);
+    default:
+      return usageError(`Unknown command: ${command}`);
+  }
+}
+
+module.exports = {
+  main,
+  parseArgs,
+  loadConfig,
+  readCommitList
+};
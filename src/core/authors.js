@@ -0,0 +1,89 @@
+const { mapWithConcurrency } = require('../utils/pool');
+
+/**
+ * Matches a Co-authored-by trailer: "Co-authored-by: Name <email>"
+ */
+const CO_AUTHOR_TRAILER = /^co-authored-by:\s*(.+?)\s*<([^>]+)>\s*$/gim;
+
+/**
+ * Read the Co-authored-by trailers from a commit message
+ * @param {String} message - Full commit message
+ * @returns {Array<Object>} - [{ name, email }] in the order they appear, duplicates removed
+ */
+function extractCoAuthors(message) {
+  const coAuthors = [];
+  for (const [, name, email] of (message || '').matchAll(CO_AUTHOR_TRAILER)) {
+    if (!coAuthors.some(author => author.email.toLowerCase() === email.toLowerCase())) {
+      coAuthors.push({ name, email });
+    }
+  }
+  return coAuthors;
+}
+
+/**
+ * Work out who to credit for each commit
+ * Usernames come from the commit itself, from other commits in the list by the same email,
+ * or from the provider's resolveUsername. Anyone left without one is credited by name.
+ * The primary author always comes first and is never repeated as a co-author.
+ * @param {Array} commits - Analyzed commits
+ * @param {Object} provider - VCS provider (resolveUsername is used when it has one)
+ * @param {Object} options - Options
+ * @param {Number} options.concurrency - Username lookups to run at once (default: 8)
+ * @returns {Promise<Map>} - Commit hash → [display names like "@alice" or "Bob Smith"]
+ */
+async function resolveCommitAuthors(commits, provider, { concurrency } = {}) {
+  // Emails we've already seen with a username, so most co-authors don't need an API call
+  const usernames = new Map();
+  for (const commit of commits) {
+    if (commit.authorEmail && commit.username) {
+      usernames.set(commit.authorEmail.toLowerCase(), commit.username);
+    }
+  }
+
+  // Look the rest up side by side, once per email
+  const unknown = new Map();
+  for (const commit of commits) {
+    const emails = [commit.username ? null : commit.authorEmail, ...(commit.coAuthors || []).map(coAuthor => coAuthor.email)];
+    for (const email of emails.filter(Boolean)) {
+      const key = email.toLowerCase();
+      if (!usernames.has(key) && !unknown.has(key)) {
+        unknown.set(key, email);
+      }
+    }
+  }
+  const found = await mapWithConcurrency([...unknown.values()], concurrency, email =>
+    Promise.resolve(provider.resolveUsername ? provider.resolveUsername(email) : null).catch(() => null));
+  [...unknown.keys()].forEach((key, index) => usernames.set(key, found[index]));
+  const lookup = email => usernames.get(email.toLowerCase()) || null;
+
+  const credits = new Map();
+  for (const commit of commits) {
+    const people = [];
+    const primaryUsername = commit.username || (commit.authorEmail ? lookup(commit.authorEmail) : null);
+    people.push({ email: commit.authorEmail, username: primaryUsername, name: commit.authorName || commit.author });
+
+    for (const coAuthor of commit.coAuthors || []) {
+      people.push({ email: coAuthor.email, username: lookup(coAuthor.email), name: coAuthor.name });
+    }
+
+    const names = [];
+    const seen = new Set();
+    for (const person of people) {
+      const display = person.username ? `@${person.username}` : person.name;
+      const keys = [display && display.toLowerCase(), person.email && person.email.toLowerCase()].filter(Boolean);
+      if (!display || keys.some(key => seen.has(key))) {
+        continue;
+      }
+      keys.forEach(key => seen.add(key));
+      names.push(display);
+    }
+    credits.set(commit.hash, names);
+  }
+
+  return credits;
+}
+
+module.exports = {
+  extractCoAuthors,
+  resolveCommitAuthors
+};
@@ -0,0 +1,99 @@
+const { logger } = require('../utils/logger');
+const { parseDuration } = require('../utils/date');
+
+/**
+ * Merge methods autoMerge can use
+ */
+const MERGE_METHODS = ['merge', 'squash', 'rebase'];
+
+/**
+ * How long to wait for the release PR's checks when autoMergeTimeout isn't set
+ */
+const DEFAULT_AUTO_MERGE_TIMEOUT = '30m';
+
+/**
+ * How often to ask whether the release PR can be merged yet
+ */
+const MERGE_STATUS_INTERVAL = 15 * 1000;
+
+/**
+ * Wait until a PR can be merged - or until it can't, or we run out of time
+ * @param {Object} provider - VCS provider
+ * @param {Number} number - PR number
+ * @param {Object} options - Polling options
+ * @param {Number} options.timeout - Milliseconds to wait for pending checks
+ * @param {Number} options.interval - Milliseconds between status checks
+ * @param {Function} options.sleep - Waits the given milliseconds (for tests)
+ * @param {Function} options.now - Current time in milliseconds (for tests)
+ * @returns {Promise<Object>} - { state, reason, sha } - state is 'timeout' when checks were still pending at the end
+ */
+async function waitForMergeable(provider, number, {
+  timeout,
+  interval = MERGE_STATUS_INTERVAL,
+  sleep = ms => new Promise(resolve => setTimeout(resolve, ms)),
+  now = Date.now
+}) {
+  const deadline = now() + timeout;
+
+  for (;;) {
+    const status = await provider.getPRMergeStatus(number);
+    if (status.state !== 'pending') {
+      return status;
+    }
+    if (now() + interval > deadline) {
+      return { ...status, state: 'timeout' };
+    }
+
+    logger.info(`PR #${number} can't be merged yet (${status.reason}) - checking again in ${Math.round(interval / 1000)}s ⏳`);
+    await sleep(interval);
+  }
+}
+
+/**
+ * Merge the release PR once its required checks pass
+ * A PR that's blocked (failed checks, conflicts) or still pending at the timeout is left
+ * open for a human - that's a warning, not an error, since the release PR itself is fine.
+ * @param {Object} provider - VCS provider
+ * @param {Object} config - Release Boss configuration
+ * @param {Number} number - Release PR number
+ * @param {Object} options - { dryRun, ...waitForMergeable options }
+ * @returns {Promise<Object|null>} - { sha } of the merge commit, or null when the PR wasn't merged
+ */
+async function mergeReleasePR(provider, config, number, { dryRun, ...waitOptions } = {}) {
+  const method = config.mergeMethod || 'merge';
+
+  if (dryRun) {
+    await provider.mergePR(number, { method });
+    return null;
+  }
+
+  const timeout = parseDuration(config.autoMergeTimeout || DEFAULT_AUTO_MERGE_TIMEOUT);
+  logger.info(`Waiting up to ${config.autoMergeTimeout || DEFAULT_AUTO_MERGE_TIMEOUT} for PR #${number} to be mergeable 🤖`);
+  const status = await waitForMergeable(provider, number, { timeout, ...waitOptions });
+
+  if (status.state === 'timeout') {
+    logger.warn(`PR #${number} still can't be merged after ${config.autoMergeTimeout || DEFAULT_AUTO_MERGE_TIMEOUT} (${status.reason}) - leaving it open; the next run will try again`);
+    return null;
+  }
+  if (status.state !== 'ready') {
+    logger.warn(`Not auto-merging PR #${number}: ${status.reason} - leaving it for a human, darling 💅`);
+    return null;
+  }
+
+  // Merging by sha fails if someone pushed after the checks we just looked at
+  const merge = await provider.mergePR(number, { method, sha: status.sha });
+  if (!merge.merged || !merge.sha) {
+    logger.warn(`PR #${number} didn't merge - leaving it open`);
+    return null;
+  }
+
+  logger.info(`Merged PR #${number} with the ${method} method at ${merge.sha.substring(0, 7)} 🎉`);
+  return { sha: merge.sha };
+}
+
+module.exports = {
+  MERGE_METHODS,
+  DEFAULT_AUTO_MERGE_TIMEOUT,
+  waitForMergeable,
+  mergeReleasePR
+};
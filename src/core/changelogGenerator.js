@@ -1,46 +1,367 @@
+const { getReleaseTagName } = require('../utils/tags');
+const { resolveCommitAuthors } = require('./authors');
+const { getCommitTypes, hasScope, ISSUE_FOOTER } = require('./commitAnalyzer');
+const { logger } = require('../utils/logger');
+
+/**
+ * Heading for the highlighted breaking changes section at the top of each release
+ */
+const BREAKING_CHANGES_SECTION = '⚠️ BREAKING CHANGES';
+
+/**
+ * Sections used when the config doesn't list any
+ */
+const DEFAULT_CHANGELOG_SECTIONS = [
+  { type: 'feat', section: 'Features', hidden: false },
+  { type: 'fix', section: 'Bug Fixes', hidden: false },
+  { type: 'perf', section: 'Performance Improvements', hidden: false },
+  { type: 'revert', section: 'Reverts', hidden: false },
+  { type: 'refactor', section: 'Code Refactoring', hidden: false },
+  { type: 'docs', section: 'Documentation', hidden: false },
+  { type: 'test', section: 'Tests', hidden: false },
+  { type: 'ci', section: 'Continuous Integration', hidden: false },
+  { type: 'build', section: 'Build System', hidden: false }
+];
+
+/**
+ * How commit subjects are cleaned up for the changelog, when changelogSubject doesn't say
+ */
+const DEFAULT_SUBJECT_RULES = {
+  stripType: true,      // A "feat(api): " still on the subject (a squash merge's PR title) - the section heading says it already
+  trim: true,           // Whitespace at either end, and runs of it in between
+  capitalize: false,    // Upper-case the first letter
+  stripPRNumber: true   // A trailing "(#12)" when the entry links that PR anyway
+};
+
+/**
+ * Sort changelog entries by scope, then commit date (oldest first)
+ * Unscoped entries come first. The hash breaks ties so the order never depends on the API.
+ * @param {Array} commits - Analyzed commits
+ * @returns {Array} - Sorted copy
+ */
+function sortCommits(commits) {
+  return [...commits].sort((a, b) => {
+    const scopeA = a.parsed.scope || '';
+    const scopeB = b.parsed.scope || '';
+    if (scopeA !== scopeB) {
+      return scopeA < scopeB ? -1 : 1;
+    }
+    
+    const dateA = Date.parse(a.date) || 0;
+    const dateB = Date.parse(b.date) || 0;
+    if (dateA !== dateB) {
+      return dateA - dateB;
+    }
+    
+    return a.hash < b.hash ? -1 : a.hash > b.hash ? 1 : 0;
+  });
+}
+
+/**
+ * A first line with a trailing PR reference, e.g. "feat: add thing (#12)"
+ */
+const TRAILING_PR_REF = /\s*\(#\d+\)\s*$/;
+
+/**
+ * The line git adds to a revert commit's body: "This reverts commit 1a2b3c4..."
+ */
+const REVERTS_COMMIT = /This reverts commit ([0-9a-f]{7,40})/i;
+
+/**
+ * Header of a revert commit: "revert: feat: add thing" or git's own 'Revert "feat: add thing"'
+ */
+const REVERT_HEADER = /^(?:revert(?:\([^)]*\))?:\s*|Revert\s+)"?(.+?)"?$/i;
+
+/**
+ * The first line of a commit message, without a trailing PR reference
+ * Cherry-picks and squash merges can pick up a different "(#12)" for the same change.
+ * @param {String} message - Commit message
+ * @returns {String}
+ */
+function commitHeader(message) {
+  return message.split('\n')[0].replace(TRAILING_PR_REF, '').trim();
+}
+
+/**
+ * A commit message without its Closes/Fixes/Resolves footers
+ * Those are issues, so they mustn't be picked up as the commit's PR number.
+ * @param {String} message - Commit message
+ * @returns {String}
+ */
+function withoutIssueFooters(message) {
+  return message.split('\n').filter((line, index) => index === 0 || !ISSUE_FOOTER.test(line.trim())).join('\n');
+}
+
+/**
+ * The PR a commit was merged in, from a "#12" in its message
+ * @param {Object} commit - Analyzed commit
+ * @returns {String|null} - PR number
+ */
+function findPRNumber(commit) {
+  const match = withoutIssueFooters(commit.message).match(/#(\d+)/);
+  return match ? match[1] : null;
+}
+
+/**
+ * Build the subject rules from config
+ * @param {Object} config - Release Boss configuration
+ * @returns {Object} - { stripType, trim, capitalize, stripPRNumber }
+ */
+function getSubjectRules(config) {
+  return {
+    ...DEFAULT_SUBJECT_RULES,
+    ...(config.changelogSubject || {})
+  };
+}
+
+/**
+ * Clean up a commit subject for its changelog entry
+ * Only known commit types count as a prefix, so a subject like "Note: ..." is left alone.
+ * A subject that would end up empty is kept as it was.
+ * @param {String} subject - Subject as parsed from the commit
+ * @param {Object} rules - Rules from getSubjectRules
+ * @param {Object} options - { types, prNumber } - the commit types that make a prefix, and the PR the entry links
+ * @returns {String}
+ */
+function cleanSubject(subject, rules, { types = [], prNumber = null } = {}) {
+  const original = subject || '';
+  const tidy = text => rules.trim ? text.replace(/\s+/g, ' ').trim() : text;
+  let text = tidy(original);
+  
+  if (rules.stripType) {
+    // Squash merges can stack them up: "feat(api): feat: add thing"
+    let prefix;
+    while ((prefix = text.match(/^([\w-]+)(?:\([^)]*\))?!?:\s+/)) && types.includes(prefix[1].toLowerCase())) {
+      text = text.substring(prefix[0].length);
+    }
+  }
+  
+  if (rules.stripPRNumber && prNumber) {
+    text = text.replace(new RegExp(`\\s*\\(#${prNumber}\\)\\s*$`), '');
+  }
+  
+  text = tidy(text);
+  if (!text) {
+    return tidy(original);
+  }
+  
+  return rules.capitalize ? text.charAt(0).toUpperCase() + text.substring(1) : text;
+}
+
+/**
+ * Split text into the characters a reader sees
+ * Grapheme clusters where Intl.Segmenter is around, code points otherwise - either way an
+ * emoji or a CJK character is never cut in half.
+ * @param {String} text - Text to split
+ * @returns {Array<String>}
+ */
+function splitCharacters(text) {
+  if (typeof Intl !== 'undefined' && typeof Intl.Segmenter === 'function') {
+    return Array.from(new Intl.Segmenter(undefined, { granularity: 'grapheme' }).segment(text), part => part.segment);
+  }
+  return Array.from(text);
+}
+
+/**
+ * Shorten an entry's body to at most maxLength characters, cutting at a word boundary
+ * A single word longer than the limit is cut mid-word, since there's nowhere better.
+ * @param {String} text - Body text
+ * @param {Number|null} maxLength - Character limit (null or 0 for no limit)
+ * @returns {Object} - { text, truncated }
+ */
+function truncateEntryBody(text, maxLength) {
+  const characters = splitCharacters(text);
+  if (!maxLength || characters.length <= maxLength) {
+    return { text, truncated: false };
+  }
+
+  // Back up to the last space, unless the cut already falls on one
+  let end = maxLength;
+  if (!/^\s/.test(characters[end])) {
+    while (end > 0 && !/^\s/.test(characters[end - 1])) {
+      end--;
+    }
+    if (end === 0) {
+      end = maxLength;
+    }
+  }
+  return { text: characters.slice(0, end).join('').trimEnd(), truncated: true };
+}
+
+/**
+ * Drop commits that are the same change as one earlier in the range
+ * A cherry-picked commit keeps its subject and author but gets a new SHA,
+ * so both copies end up in the range - only the first one is kept.
+ * @param {Array} commits - Analyzed commits
+ * @returns {Array} - Commits without the duplicates
+ */
+function dedupeCommits(commits) {
+  const seen = new Set();
+  
+  return commits.filter(commit => {
+    const author = commit.author || commit.authorEmail || commit.authorName || '';
+    const key = `${commitHeader(commit.message)}\n${author}`;
+    if (seen.has(key)) {
+      logger.info(`Skipping ${commit.hash.substring(0, 7)} - it's a copy of a commit already in this release 💅`);
+      return false;
+    }
+    seen.add(key);
+    return true;
+  });
+}
+
+/**
+ * Work out which commit a revert commit reverts
+ * @param {Object} commit - Analyzed commit
+ * @returns {Object|null} - { sha, header } (either may be null), or null if it isn't a revert
+ */
+function parseRevert(commit) {
+  const shaMatch = commit.message.match(REVERTS_COMMIT);
+  const headerMatch = commit.parsed.type === 'revert' || /^Revert\s/.test(commit.message)
+    ? commitHeader(commit.message).match(REVERT_HEADER)
+    : null;
+  
+  if (!shaMatch && !headerMatch) {
+    return null;
+  }
+  return {
+    sha: shaMatch ? shaMatch[1].toLowerCase() : null,
+    header: headerMatch ? headerMatch[1].replace(TRAILING_PR_REF, '').trim() : null
+  };
+}
+
+/**
+ * Drop reverts together with the commits they revert
+ * A revert is matched by the SHA in its "This reverts commit" line, or failing
+ * that by the reverted commit's header. Reverts of commits outside the range stay
+ * in the changelog, since the thing they undo shipped in an earlier release.
+ * @param {Array} commits - Analyzed commits
+ * @returns {Array} - Commits without the reverted pairs
+ */
+function collapseReverts(commits) {
+  const dropped = new Set();
+  
+  for (const revert of commits) {
+    const target = parseRevert(revert);
+    if (!target || dropped.has(revert)) continue;
+    
+    const reverted = commits.find(commit => commit !== revert && !dropped.has(commit) && (
+      target.sha ? commit.hash.toLowerCase().startsWith(target.sha) : commitHeader(commit.message) === target.header
+    ));
+    if (reverted) {
+      logger.info(`${revert.hash.substring(0, 7)} reverts ${reverted.hash.substring(0, 7)} - leaving both out of the changelog ✨`);
+      dropped.add(revert);
+      dropped.add(reverted);
+    }
+  }
+  
+  return commits.filter(commit => !dropped.has(commit));
+}
+
+/**
+ * Get the changelog sections, with the headings commitTypes declares
+ * A commitTypes heading replaces the changelogSections one for that type; types
+ * that aren't in changelogSections yet are added at the end, in commitTypes order.
+ * @param {Object} config - Release Boss configuration
+ * @returns {Array} - [{ type, section, hidden }]
+ */
+function getChangelogSections(config) {
+  const sections = (config.changelogSections || DEFAULT_CHANGELOG_SECTIONS).map(section => ({ ...section }));
+  
+  for (const [type, entry] of Object.entries(config.commitTypes || {})) {
+    if (typeof entry !== 'object' || !entry.section) continue;
+    
+    const existing = sections.find(section => section.type === type);
+    if (existing) {
+      existing.section = entry.section;
+      existing.hidden = entry.hidden === true;
+    } else {
+      sections.push({ type, section: entry.section, hidden: entry.hidden === true });
+    }
+  }
+  
+  return sections;
+}
+
+/**
+ * Group the configured sections by heading
+ * Several types can share a heading (e.g. refactor and style); the heading sits
+ * wherever its first type appears in the list.
+ * @param {Array} sections - changelogSections from config
+ * @returns {Array} - [{ section, types }] in display order, hidden types left out
+ */
+function groupSections(sections) {
+  const headings = [];
+  
+  for (const { type, section, hidden } of sections) {
+    if (hidden) continue;
+    
+    let heading = headings.find(entry => entry.section === section);
+    if (!heading) {
+      heading = { section, types: [] };
+      headings.push(heading);
+    }
+    heading.types.push(type);
+  }
+  
+  return headings;
+}
+
 /**
  * Generate a changelog from analyzed commits
  * @param {Array} commits - Array of parsed commits
  * @param {String} newVersion - New version to be released
  * @param {String} currentVersion - Current version
- * @param {Object} octokit - GitHub API client
- * @param {Object} context - GitHub context
+ * @param {Object} provider - VCS provider (used for links)
  * @param {Object} config - Release Boss configuration
+ * @param {Object} options - Heading overrides, for changelogs of an arbitrary range
+ * @param {Date} options.date - Date shown in the heading (default: today)
+ * @param {String} options.from - Compare link base (default: the current version's tag)
+ * @param {String} options.to - Compare link head (default: the new version's tag)
  * @returns {String} - Generated changelog
  */
-async function generateChangelog(commits, newVersion, currentVersion, octokit, context, config) {
-  const { owner, repo } = context.repo;
-  const repoUrl = `https://github.com/${owner}/${repo}`;
-  const date = new Date().toISOString().split('T')[0]; // YYYY-MM-DD
+async function generateChangelog(commits, newVersion, currentVersion, provider, config, options = {}) {
+  const date = (options.date || new Date()).toISOString().split('T')[0]; // YYYY-MM-DD
+  
+  // The same change shouldn't be listed twice, and a change that was undone in the same release isn't news
+  commits = dedupeCommits(commits);
+  if (config.collapseReverts !== false) {
+    commits = collapseReverts(commits);
+  }
+  
+  // hiddenScopes commits can still bump the version - they just never show up, breaking or not
+  if (config.hiddenScopes && config.hiddenScopes.length > 0) {
+    commits = commits.filter(commit => !hasScope(commit.parsed, config.hiddenScopes));
+  }
   
   // Group commits by their type
   const groupedCommits = {};
   
-  // Define the default sections if not specified in config
-  const sections = config.changelogSections || [
-    { type: 'feat', section: 'Features', hidden: false },
-    { type: 'fix', section: 'Bug Fixes', hidden: false },
-    { type: 'perf', section: 'Performance Improvements', hidden: false },
-    { type: 'refactor', section: 'Code Refactoring', hidden: false },
-    { type: 'docs', section: 'Documentation', hidden: false },
-    { type: 'test', section: 'Tests', hidden: false },
-    { type: 'ci', section: 'Continuous Integration', hidden: false },
-    { type: 'build', section: 'Build System', hidden: false }
-  ];
+  // Use the default sections if not specified in config
+  const sections = groupSections(getChangelogSections(config));
+  const commitTypes = getCommitTypes(config);
+  const subjectRules = getSubjectRules(config);
+  const types = Object.keys(commitTypes).map(type => type.toLowerCase());
+  
+  // A revert's subject is the header it reverts - its type is the news there, so it stays
+  const entrySubject = (commit, prNumber) => commit.parsed.type === 'revert'
+    ? cleanSubject(commit.parsed.subject, { ...subjectRules, stripType: false }, { prNumber })
+    : cleanSubject(commit.parsed.subject, subjectRules, { types, prNumber });
   
   // Filter and exclude commits like 'chore' or with scope 'no-release'
   const filteredCommits = commits.filter(commit => {
     // Skip commits with no type
     if (!commit.parsed.type) return false;
     
-    // Exclude chore commits from changelog
-    if (commit.parsed.type === 'chore') return false;
+    // Exclude hidden types (chore, unless commitTypes or releaseTypes says otherwise)
+    if (commitTypes[commit.parsed.type] && commitTypes[commit.parsed.type].hidden) return false;
     
     // Exclude commits with scope no-release
     if (commit.parsed.scope === 'no-release') return false;
     
     return true;
   });
+
   
   // Group commits by type
   for (const commit of filteredCommits) {
@@ -55,14 +376,45 @@ async function generateChangelog(commits, newVersion, currentVersion, octokit, c
   
   // Generate changelog
   let changelog = '';
-  changelog += `## [${newVersion}](${repoUrl}/compare/v${currentVersion}...v${newVersion}) (${date})\n\n`;
+  const compareUrl = provider.compareUrl(
+    options.from || getReleaseTagName(currentVersion, config),
+    options.to || getReleaseTagName(newVersion, config)
+  );
+  changelog += `## [${newVersion}](${compareUrl}) (${date})\n\n`;
+  
+  // Breaking changes go first so nobody can miss them - one entry per footer
+  const breakingCommits = sortCommits(commits.filter(commit => commit.breaking));
+  if (breakingCommits.length > 0) {
+    changelog += `### ${BREAKING_CHANGES_SECTION}\n\n`;
+    
+    for (const commit of breakingCommits) {
+      const scope = commit.parsed.scope ? `**${commit.parsed.scope}:** ` : '';
+      const shortHash = commit.hash.substring(0, 7);
+      
+      const prNumber = findPRNumber(commit);
+      
+      for (const text of commit.breakingChanges) {
+        // A note that's just the subject (feat!: ...) is never shortened
+        const isSubject = text === (commit.parsed.subject || commit.message.split('\n')[0]);
+        const body = isSubject ? { text: entrySubject(commit, prNumber), truncated: false } : truncateEntryBody(text, config.maxEntryLength);
+        const more = !body.truncated ? ''
+          : prNumber ? `… ([see PR #${prNumber}](${provider.pullRequestUrl(prNumber)}))` : `… ([see the full commit](${commit.url}))`;
+        changelog += `* ${scope}${body.text}${more} ([${shortHash}](${commit.url}))\n`;
+      }
+    }
+    
+    changelog += '\n';
+  }
+  
+  // Credit authors and co-authors after each entry when asked to
+  const credits = config.showAuthors ? await resolveCommitAuthors(filteredCommits, provider, { concurrency: config.fetchConcurrency }) : new Map();
   
   // Add sections according to defined order
   for (const section of sections) {
-    const commits = groupedCommits[section.type] || [];
+    const commits = sortCommits(section.types.flatMap(type => groupedCommits[type] || []));
     
-    // Skip if section is hidden or has no commits
-    if (section.hidden || commits.length === 0) continue;
+    // Skip sections with no commits
+    if (commits.length === 0) continue;
     
     changelog += `### ${section.section}\n\n`;
     
@@ -78,18 +430,31 @@ async function generateChangelog(commits, newVersion, currentVersion, octokit, c
         entry += `**${commit.parsed.scope}:** `;
       }
       
+      // Look for PR number in commit message
+      const prNumber = findPRNumber(commit);
+      
       // Add commit message
-      entry += commit.parsed.subject;
+      const subject = entrySubject(commit, prNumber);
+      entry += subject;
       
       // Add commit link
       const shortHash = commit.hash.substring(0, 7);
       entry += ` ([${shortHash}](${commit.url}))`;
       
-      // Look for PR number in commit message
-      const prMatch = commit.message.match(/#(\d+)/);
-      if (prMatch) {
-        const prNumber = prMatch[1];
-        entry += ` ([#${prNumber}](${repoUrl}/pull/${prNumber}))`;
+      if (prNumber) {
+        entry += ` ([#${prNumber}](${provider.pullRequestUrl(prNumber)}))`;
+      }
+      
+      // Issues the commit closes - unless the subject (or the PR link) already shows the number
+      for (const issue of commit.issues || []) {
+        if (!new RegExp(`#${issue}\\b`).test(subject) && (!prNumber || Number(prNumber) !== issue)) {
+          entry += ` ([#${issue}](${provider.issueUrl(issue)}))`;
+        }
+      }
+      
+      const authors = credits.get(commit.hash);
+      if (authors && authors.length > 0) {
+        entry += ` (${authors.join(', ')})`;
       }
       
       changelog += `${entry}\n`;
@@ -102,5 +467,15 @@ async function generateChangelog(commits, newVersion, currentVersion, octokit, c
 }
 
 module.exports = {
-  generateChangelog
+  generateChangelog,
+  sortCommits, // Exported for testing
+  getChangelogSections,
+  dedupeCommits,
+  collapseReverts,
+  truncateEntryBody,
+  cleanSubject,
+  getSubjectRules,
+  BREAKING_CHANGES_SECTION,
+  DEFAULT_SUBJECT_RULES,
+  DEFAULT_CHANGELOG_SECTIONS
 };
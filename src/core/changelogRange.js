@@ -0,0 +1,53 @@
+const { analyzeCommits } = require('./commitAnalyzer');
+const { generateChangelog } = require('./changelogGenerator');
+const { parseVersionFromTag } = require('../utils/tags');
+
+/**
+ * Heading used when the end of the range isn't a release tag
+ */
+const UNRELEASED = 'Unreleased';
+
+/**
+ * Generate the changelog for an arbitrary commit range, without releasing anything
+ * Handy for backfilling releases the automation missed. The heading uses the version
+ * from the until tag (or "Unreleased") and the date of the newest commit in the range.
+ * @param {Object} provider - VCS provider (must implement resolveRef)
+ * @param {Object} config - Release Boss configuration
+ * @param {Object} range - Commit range
+ * @param {String} range.since - Start of the range (exclusive) - a tag, branch or SHA
+ * @param {String} range.until - End of the range (inclusive, default: HEAD)
+ * @returns {Promise<Object>} - { changelog, commits } - changelog is '' when the range is empty
+ * @throws {Error} - If either end of the range doesn't exist
+ */
+async function generateRangeChangelog(provider, config, { since, until = null }) {
+  const head = until || 'HEAD';
+  const [sinceSha, headSha] = await Promise.all([since, head].map(async ref => {
+    const sha = await provider.resolveRef(ref).catch(() => '');
+    if (!sha) {
+      throw new Error(`Couldn't find ${ref} - is it a tag, branch or commit in this repo?`);
+    }
+    return sha;
+  }));
+
+  const commits = await analyzeCommits(provider, config, sinceSha, headSha);
+  if (commits.length === 0) {
+    return { changelog: '', commits };
+  }
+
+  const newVersion = (until && parseVersionFromTag(until, config)) || UNRELEASED;
+  const currentVersion = parseVersionFromTag(since, config) || since;
+  const newest = commits.reduce((latest, commit) => (Date.parse(commit.date) > Date.parse(latest.date) ? commit : latest));
+
+  const changelog = await generateChangelog(commits, newVersion, currentVersion, provider, config, {
+    date: new Date(newest.date),
+    from: since,
+    to: until || headSha
+  });
+
+  return { changelog, commits };
+}
+
+module.exports = {
+  generateRangeChangelog,
+  UNRELEASED
+};
@@ -1,5 +1,11 @@
 const semver = require('semver');
 const conventionalCommitsParser = require('conventional-commits-parser');
+const { parseVersionFromTag } = require('../utils/tags');
+const { createPathMatcher } = require('../utils/glob');
+const { extractCoAuthors } = require('./authors');
+const { getNextPrereleaseVersion } = require('./prerelease');
+const { mapWithConcurrency } = require('../utils/pool');
+const { logger } = require('../utils/logger');
 
 /**
  * Convert commit objects to changelog entry objects
@@ -25,137 +31,419 @@ function commitsToChangelogEntries(commits) {
 }
 
 /**
- * Commit types that trigger a minor version bump (new features)
+ * Check whether a commit's scope is in a list of scopes (case doesn't matter)
+ * @param {Object} parsedCommit - Parsed conventional commit
+ * @param {Array<String>} scopes - Scopes like ['ci', 'test']
+ * @returns {Boolean}
  */
-const MINOR_BUMP_TYPES = ['feat'];
+function hasScope(parsedCommit, scopes) {
+  if (!parsedCommit.scope || !scopes || scopes.length === 0) {
+    return false;
+  }
+  const scope = parsedCommit.scope.trim().toLowerCase();
+  return scopes.some(candidate => candidate.toLowerCase() === scope);
+}
+
+/**
+ * Bump levels a commit type can have
+ */
+const BUMP_LEVELS = ['major', 'minor', 'patch', 'none'];
+
+/**
+ * What each conventional commit type does when the config doesn't say
+ * bump: how far the type moves the version; hidden: left out of the changelog entirely.
+ * Types that aren't listed (here or in commitTypes) don't bump anything.
+ */
+const DEFAULT_COMMIT_TYPES = {
+  feat: { bump: 'minor' },
+  fix: { bump: 'patch' },
+  perf: { bump: 'patch' },
+  refactor: { bump: 'patch' },
+  docs: { bump: 'none' },
+  style: { bump: 'none' },
+  test: { bump: 'none' },
+  ci: { bump: 'none' },
+  build: { bump: 'none' },
+  chore: { bump: 'none', hidden: true }
+};
+
+/**
+ * Footer keywords that mark a breaking change
+ */
+const BREAKING_CHANGE_KEYWORDS = ['BREAKING CHANGE', 'BREAKING-CHANGE', 'BREAKING CHANGES', 'BREAKING-CHANGES'];
+
+/**
+ * A breaking change footer line, e.g. "BREAKING CHANGE: config moved to YAML"
+ */
+const BREAKING_CHANGE_FOOTER = /^BREAKING[ -]CHANGES?:\s*(.*)$/;
 
 /**
- * Commit types that trigger a patch version bump (bug fixes and non-breaking changes)
+ * Any other git trailer style footer ("Refs: #12", "Reviewed-by: Kaity"), which ends a breaking change note
  */
-const PATCH_BUMP_TYPES = ['fix', 'perf', 'refactor'];
+const FOOTER_TOKEN = /^[\w-]+(?::\s| #)/;
 
 /**
- * Commit types that are included in the changelog but don't trigger a version bump
+ * An issue-closing footer, e.g. "Closes #12, #14" or "fixes: #3"
  */
-const NO_BUMP_TYPES = ['docs', 'style', 'test', 'ci', 'build'];
+const ISSUE_FOOTER = /^(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?)(?::\s*|\s+)(#\d+(?:\s*,\s*#\d+)*)\s*$/i;
 
 /**
- * Commit types that are excluded from the changelog and don't trigger a version bump
+ * A conventional commit header inside a squash commit body, optionally bulleted ("* feat(api): add thing")
  */
-const EXCLUDED_TYPES = ['chore'];
+const SQUASH_ENTRY = /^(?:[*-]\s+)?([a-z]+!?(?:\([\w$.\-*\s]*\))?!?: .+)$/;
+
+/**
+ * Any bulleted line in a squash commit body - each one starts a new original commit
+ */
+const SQUASH_BULLET = /^[*-]\s+/;
+
+/**
+ * Read an explicit list of commits instead of a range
+ * Each SHA is looked up on its own, so commits outside the release range (cherry-pick
+ * sources, say) are fine - but every one has to exist. Duplicates only count once.
+ * @param {Object} provider - VCS provider
+ * @param {Object} config - Release Boss configuration (fetchConcurrency)
+ * @param {Array<String>} shas - Commit SHAs, in the order they should be released
+ * @returns {Promise<Array>} - Normalised commits, in the order given
+ * @throws {Error} - Naming the first SHA the provider can't find
+ */
+async function fetchCommitsBySha(provider, config, shas) {
+  const commits = await mapWithConcurrency([...new Set(shas)], config.fetchConcurrency, async sha => {
+    const commit = await provider.getCommit(sha).catch(error => {
+      logger.debug(`Looking up commit ${sha} failed: ${error.message}`);
+      return null;
+    });
+    if (!commit) {
+      throw new Error(`Commit ${sha} isn't in this repo - check the SHAs you passed (and that the commit has been pushed)`);
+    }
+    return commit;
+  });
+  
+  // An abbreviated and a full SHA for the same commit still only count once
+  const seen = new Set();
+  return commits.filter(commit => !seen.has(commit.sha) && seen.add(commit.sha));
+}
 
 /**
  * Analyze commits between two references (branches, commits, etc.)
- * @param {Object} octokit - GitHub API client
- * @param {Object} context - GitHub context
+ * @param {Object} provider - VCS provider
  * @param {Object} config - Release Boss configuration
- * @param {String} baseRef - Base reference (default: config.releaseBranch)
+ * @param {String} baseRef - Base reference (default: config.releaseBranch, or the whole history on a first release)
  * @param {String} headRef - Head reference (default: config.mergeBranch)
+ * @param {Object} options - Additional options
+ * @param {Array<String>} options.shas - Analyze exactly these commits instead of the range (optional)
  * @returns {Array} - Array of parsed and analyzed commits
  */
-async function analyzeCommits(octokit, context, config, baseRef, headRef) {
-  const { owner, repo } = context.repo;
-  
+async function analyzeCommits(provider, config, baseRef, headRef, options = {}) {
   // Use provided refs or fall back to config values
-  const base = baseRef || config.releaseBranch;
   const head = headRef || config.mergeBranch;
+  let commits;
   
-  console.log(`Analyzing commits between ${base} and ${head}...`);
-  
-  // Get commits between base and head references
-  const compareResponse = await octokit.rest.repos.compareCommits({
-    owner,
-    repo,
-    base,
-    head
-  });
+  if (options.shas) {
+    // Cherry-picked release candidates - only the commits we were handed count, whatever else is in the range
+    logger.info(`Analyzing the ${options.shas.length} commits we were given, instead of a range...`);
+    commits = await fetchCommitsBySha(provider, config, options.shas);
+  } else if (!baseRef && config.firstRelease) {
+    // Nothing released yet, so there's nothing to diff against - everything up to head counts
+    logger.info(`First release - analyzing the full history of ${head}...`);
+    commits = await provider.listCommits(head);
+  } else {
+    const base = baseRef || config.releaseBranch;
+    logger.info(`Analyzing commits between ${base} and ${head}...`);
+    
+    // Get commits between base and head references
+    commits = await provider.compareCommits(base, head);
+  }
   
-  if (!compareResponse.data.commits || compareResponse.data.commits.length === 0) {
-    console.log('No commits found to analyze');
+  if (!commits || commits.length === 0) {
+    logger.info('No commits found to analyze');
     return [];
   }
   
-  console.log(`Found ${compareResponse.data.commits.length} commits to analyze`);
+  logger.info(`Found ${commits.length} commits to analyze`);
+  
+  // Commits that only touch ignored paths (docs, CI, fixtures...) don't count, whatever their type
+  const ignoredCommits = await findIgnoredCommits(commits, provider, config);
+  
+  // Squash merges can hide several conventional commits in one body
+  // Co-author trailers come from the full message, so every commit split out of a squash keeps them
+  const logicalCommits = commits.flatMap(commit => {
+    const coAuthors = extractCoAuthors(commit.message);
+    if (!config.parseSquashCommits) {
+      return [{ ...commit, coAuthors }];
+    }
+    
+    const messages = splitSquashCommit(commit.message);
+    if (messages[0] !== commit.message) {
+      logger.info(`Squash commit ${commit.sha.substring(0, 7)} holds ${messages.length} conventional commits 💅`);
+    }
+    return messages.map(message => ({ ...commit, message, coAuthors }));
+  });
+  
+  const commitTypes = getCommitTypes(config);
   
   // Parse commits using conventional-commits-parser
-  const parsedCommits = compareResponse.data.commits.map(commit => {
-    const parsed = conventionalCommitsParser.sync(commit.commit.message, {
-      headerPattern: /^(\w*)(?:\(([\w\$\.\-\*\s]*)\))?\: (.*)$/,
-      headerCorrespondence: ['type', 'scope', 'subject'],
-      noteKeywords: ['BREAKING CHANGE', 'BREAKING-CHANGE'],
+  const parsedCommits = logicalCommits.map(commit => {
+    const parsed = conventionalCommitsParser.sync(commit.message, {
+      headerPattern: /^(\w*)(?:\(([\w\$\.\-\*\s]*)\))?(!)?\: (.*)$/,
+      headerCorrespondence: ['type', 'scope', 'breaking', 'subject'],
+      noteKeywords: BREAKING_CHANGE_KEYWORDS,
       revertPattern: /^revert:\s([\s\S]*?)/,
       revertCorrespondence: ['header'],
       issuePrefixes: ['#']
     });
     
-    // Determine the bump type for this commit
-    let bumpType = getBumpTypeForCommit(parsed);
+    // Breaking change footers anywhere in the body always mean a major bump,
+    // so even an otherwise-excluded commit (like a chore) has to count
+    const breakingChanges = extractBreakingChanges(commit.message)
+      .map(text => text || parsed.subject || commit.message.split('\n')[0]);
+    // "feat!: ..." without a footer is still breaking - the subject is its note
+    if (parsed.breaking && breakingChanges.length === 0) {
+      breakingChanges.push(parsed.subject);
+    }
+    
+    const issues = extractIssueReferences(commit.message);
+    
+    // Determine the bump type for this commit - noBumpScopes commits still reach the changelog
+    let bumpType = breakingChanges.length > 0 ? 'major' : getBumpTypeForCommit(parsed, commitTypes);
+    if (hasScope(parsed, config.noBumpScopes)) {
+      bumpType = null;
+    }
     
     // Determine if this commit should be excluded from changelog
-    const excluded = isExcludedFromChangelog(parsed);
+    const excluded = ignoredCommits.has(commit.sha) ||
+      (breakingChanges.length === 0 && isExcludedFromChangelog(parsed, commitTypes));
     
     return {
       hash: commit.sha,
-      message: commit.commit.message,
+      message: commit.message,
       parsed,
-      url: commit.html_url,
-      author: commit.author ? commit.author.login : null,
-      date: commit.commit.author.date,
+      url: commit.url,
+      author: commit.author,
+      authorName: commit.authorName,
+      authorEmail: commit.authorEmail,
+      username: commit.username,
+      coAuthors: commit.coAuthors,
+      date: commit.date,
+      files: commit.files,
       bumpType,
-      excluded
+      excluded,
+      breaking: breakingChanges.length > 0,
+      breakingChanges,
+      issues
     };
   });
   
   // Filter out the excluded commits
   const filteredCommits = parsedCommits.filter(commit => !commit.excluded);
   
-  console.log(`${filteredCommits.length} commits will be included in analysis`);
+  logger.info(`${filteredCommits.length} commits will be included in analysis`);
   return filteredCommits;
 }
 
+/**
+ * Find the commits whose changed files are all in ignorePaths
+ * The changed files are fetched (and kept on the commit as files) only when ignorePaths is set,
+ * fetchConcurrency commits at a time.
+ * A commit whose files can't be listed is kept - better a noisy changelog than a missed release.
+ * @param {Array} commits - Provider commits
+ * @param {Object} provider - VCS provider (used to list changed files)
+ * @param {Object} config - Release Boss configuration
+ * @returns {Promise<Set<String>>} - SHAs of the ignored commits
+ */
+async function findIgnoredCommits(commits, provider, config) {
+  const ignored = new Set();
+  if (!config.ignorePaths || config.ignorePaths.length === 0) {
+    return ignored;
+  }
+  
+  const isIgnored = createPathMatcher(config.ignorePaths);
+  await mapWithConcurrency(commits, config.fetchConcurrency, async commit => {
+    try {
+      commit.files = commit.files || await provider.getCommitFiles(commit.sha) || [];
+    } catch (error) {
+      logger.info(`Couldn't list files for commit ${commit.sha.substring(0, 7)}: ${error.message}`);
+      return;
+    }
+    
+    if (commit.files.length > 0 && commit.files.every(isIgnored)) {
+      ignored.add(commit.sha);
+    }
+  });
+  
+  if (ignored.size > 0) {
+    logger.info(`Ignoring ${ignored.size} commit${ignored.size === 1 ? '' : 's'} that only touch ignorePaths 🙈`);
+  }
+  return ignored;
+}
+
+/**
+ * Split a squash-merge commit into the conventional commits listed in its body
+ *
+ * GitHub squash merges put every original commit in the body as a bullet, with
+ * that commit's own body (and footers) underneath. Each conventional entry
+ * becomes its own message carrying the squash's PR number; bullets that aren't
+ * conventional commits are dropped along with anything under them.
+ * @param {String} message - Full squash commit message
+ * @returns {Array<String>} - Logical commit messages (just the original message when the body has none)
+ */
+function splitSquashCommit(message) {
+  const [header, ...body] = message.split('\n');
+  const prMatch = header.match(/\(#(\d+)\)\s*$/);
+  const entries = [];
+  let current = null;
+  
+  for (const line of body) {
+    const entry = line.trim().match(SQUASH_ENTRY);
+    
+    if (entry) {
+      current = [entry[1]];
+      entries.push(current);
+    } else if (SQUASH_BULLET.test(line.trim())) {
+      current = null;
+    } else if (current) {
+      current.push(line);
+    }
+  }
+  
+  if (entries.length === 0) {
+    return [message];
+  }
+  
+  return entries.map(([entryHeader, ...entryBody]) => {
+    const withPR = prMatch && !entryHeader.includes(`#${prMatch[1]}`) ? `${entryHeader} (#${prMatch[1]})` : entryHeader;
+    return [withPR, ...entryBody].join('\n').trim();
+  });
+}
+
+/**
+ * Build the commit type table the analyzer works from
+ *
+ * Starts from DEFAULT_COMMIT_TYPES. releaseTypes narrows down which types bump
+ * (feat minor, the rest patch), then every commitTypes entry wins over both - a
+ * bare level like "patch" or { bump, section, hidden }. Listed types are only
+ * hidden from the changelog when their entry says so.
+ * @param {Object} config - Release Boss configuration (releaseTypes and commitTypes are used)
+ * @returns {Object} - Type → { bump, section, hidden }
+ */
+function getCommitTypes(config = {}) {
+  const types = {};
+  for (const [type, entry] of Object.entries(DEFAULT_COMMIT_TYPES)) {
+    types[type] = { section: null, hidden: false, ...entry };
+  }
+  
+  if (Array.isArray(config.releaseTypes)) {
+    for (const type of Object.keys(types)) {
+      types[type].bump = 'none';
+    }
+    for (const type of config.releaseTypes) {
+      types[type] = { section: null, ...types[type], hidden: false, bump: type === 'feat' ? 'minor' : 'patch' };
+    }
+  }
+  
+  for (const [type, entry] of Object.entries(config.commitTypes || {})) {
+    const override = typeof entry === 'string' ? { bump: entry } : entry;
+    types[type] = { bump: 'none', section: null, ...types[type], ...override, hidden: override.hidden === true };
+  }
+  
+  return types;
+}
+
+/**
+ * Get the commit types that count toward a release
+ * @param {Object} commitTypes - Table from getCommitTypes (default: the built-in types)
+ * @returns {Array<String>}
+ */
+function getReleaseTypes(commitTypes = getCommitTypes()) {
+  return Object.keys(commitTypes).filter(type => commitTypes[type].bump !== 'none');
+}
+
 /**
  * Determine the bump type for a single commit based on its type and content
  * @param {Object} parsedCommit - Parsed commit object from conventional-commits-parser
+ * @param {Object} [commitTypes] - Table from getCommitTypes (default: the built-in types)
  * @returns {string|null} - 'major', 'minor', 'patch', or null for no bump
  */
-function getBumpTypeForCommit(parsedCommit) {
+function getBumpTypeForCommit(parsedCommit, commitTypes = getCommitTypes()) {
   // First check for breaking changes which always trigger a major bump
-  const hasBreakingChangeMarker = parsedCommit.type && parsedCommit.type.endsWith('!');
+  const hasBreakingChangeMarker = Boolean(parsedCommit.breaking) || Boolean(parsedCommit.type && parsedCommit.type.endsWith('!'));
   const hasBreakingChangeNote = parsedCommit.notes && parsedCommit.notes.some(note => 
-    note.title === 'BREAKING CHANGE' || note.title === 'BREAKING-CHANGE'
+    BREAKING_CHANGE_KEYWORDS.includes(note.title)
   );
   
   if (hasBreakingChangeMarker || hasBreakingChangeNote) {
     return 'major';
   }
   
-  // Check for feature commits (minor bump)
-  if (MINOR_BUMP_TYPES.includes(parsedCommit.type)) {
-    return 'minor';
-  }
+  // Unknown types don't bump anything
+  const entry = Object.prototype.hasOwnProperty.call(commitTypes, parsedCommit.type) ? commitTypes[parsedCommit.type] : null;
+  return entry && entry.bump !== 'none' ? entry.bump : null;
+}
+
+/**
+ * Pull every breaking change footer out of a commit message
+ *
+ * Footers can appear anywhere after the header and there can be several of
+ * them. A note runs on over following lines until a blank line or the next
+ * footer token.
+ * @param {String} message - Full commit message
+ * @returns {Array<String>} - Breaking change descriptions (empty string if a footer had no text)
+ */
+function extractBreakingChanges(message) {
+  const notes = [];
+  let current = null;
   
-  // Check for fix commits (patch bump)
-  if (PATCH_BUMP_TYPES.includes(parsedCommit.type)) {
-    return 'patch';
+  // Skip the header - the ! in "feat!: ..." is read by the header pattern instead
+  for (const line of message.split('\n').slice(1)) {
+    const match = line.trim().match(BREAKING_CHANGE_FOOTER);
+    
+    if (match) {
+      current = [match[1].trim()];
+      notes.push(current);
+    } else if (current && (!line.trim() || FOOTER_TOKEN.test(line.trim()))) {
+      current = null;
+    } else if (current) {
+      current.push(line.trim());
+    }
   }
   
-  // Check for commits that don't trigger a bump but are included in changelog
-  if (NO_BUMP_TYPES.includes(parsedCommit.type)) {
-    return null;
+  return notes.map(note => note.filter(Boolean).join(' '));
+}
+
+/**
+ * Pull the issues a commit closes out of its Closes/Fixes/Resolves footers
+ * @param {String} message - Full commit message
+ * @returns {Array<Number>} - Issue numbers, in footer order without repeats
+ */
+function extractIssueReferences(message) {
+  const issues = [];
+  
+  // "Fixes #12" in the header is just part of the subject
+  for (const line of message.split('\n').slice(1)) {
+    const match = line.trim().match(ISSUE_FOOTER);
+    if (!match) continue;
+    
+    for (const ref of match[1].split(',')) {
+      const number = Number(ref.trim().substring(1));
+      if (!issues.includes(number)) {
+        issues.push(number);
+      }
+    }
   }
   
-  // Default to no bump if the type doesn't match any category
-  return null;
+  return issues;
 }
 
 /**
  * Determine if a commit should be excluded from the changelog
  * @param {Object} parsedCommit - Parsed commit object from conventional-commits-parser
+ * @param {Object} [commitTypes] - Table from getCommitTypes (default: the built-in types)
  * @returns {boolean} - True if the commit should be excluded
  */
-function isExcludedFromChangelog(parsedCommit) {
-  // Exclude commits with types in the excluded list
-  if (EXCLUDED_TYPES.includes(parsedCommit.type)) {
+function isExcludedFromChangelog(parsedCommit, commitTypes = getCommitTypes()) {
+  // Exclude hidden types (chore, unless the config says it counts)
+  const entry = Object.prototype.hasOwnProperty.call(commitTypes, parsedCommit.type) ? commitTypes[parsedCommit.type] : null;
+  if (entry && entry.hidden) {
     return true;
   }
   
@@ -170,66 +458,68 @@ function isExcludedFromChangelog(parsedCommit) {
 /**
  * Determine the type of version bump based on analyzed commits
  * @param {Array} commits - Array of parsed and analyzed commits
- * @param {Object} octokit - GitHub API client
- * @param {Object} context - GitHub context
+ * @param {Object} provider - VCS provider
  * @param {Object} config - Release Boss configuration
+ * @param {Object} options - Options
+ * @param {Array} options.tags - Tags already listed for this run (listed from the provider when not given)
  * @returns {Object} - Bump type and new version information
  */
-async function determineVersionBump(commits, octokit, context, config) {
-  const { owner, repo } = context.repo;
+async function determineVersionBump(commits, provider, config, { tags: listedTags } = {}) {
   let currentVersion = '0.0.0';
+  let releasedVersions = [];
+  let hasStableRelease = false;
   
-  console.log('Determining current version from repository tags...');
+  logger.info('Determining current version from repository tags...');
   try {
-    // First try to get tags in descending order
-    const { data: tags } = await octokit.rest.repos.listTags({
-      owner,
-      repo,
-      per_page: 10
-    });
+    // First try to get tags in descending order - the run usually listed them already
+    const tags = listedTags || await provider.listTags();
     
     if (tags && tags.length > 0) {
       // Filter tags that match semantic version format with optional 'v' prefix
-      const versionTags = tags.filter(tag => {
-        const version = tag.name.replace(/^v/, '');
-        return semver.valid(version);
-      });
+      // (and the package namespace, for monorepo packages)
+      const versionTags = tags.filter(tag => parseVersionFromTag(tag.name, config));
       
       if (versionTags.length > 0) {
         // Sort by semver (descending)
         versionTags.sort((a, b) => {
-          const versionA = a.name.replace(/^v/, '');
-          const versionB = b.name.replace(/^v/, '');
+          const versionA = parseVersionFromTag(a.name, config);
+          const versionB = parseVersionFromTag(b.name, config);
           return semver.rcompare(versionA, versionB);
         });
         
-        // Use the highest version
-        currentVersion = versionTags[0].name.replace(/^v/, '');
-        console.log(`Found ${versionTags.length} version tags. Latest tag: ${versionTags[0].name}`);
-        console.log(`Using version: ${currentVersion}`);
+        // Use the highest stable version - prereleases never count as the current version
+        releasedVersions = versionTags.map(tag => parseVersionFromTag(tag.name, config));
+        const stableTag = versionTags.find(tag => !semver.prerelease(parseVersionFromTag(tag.name, config)));
+        logger.info(`Found ${versionTags.length} version tags. Latest tag: ${versionTags[0].name}`);
+        if (stableTag) {
+          currentVersion = parseVersionFromTag(stableTag.name, config);
+          hasStableRelease = true;
+          logger.info(`Using version: ${currentVersion}`);
+        } else {
+          logger.info('Only prerelease tags so far - this will be the first release');
+        }
       } else {
-        console.log(`Found ${tags.length} tags, but none match semver format. Starting from 0.0.0`);
+        logger.info(`Found ${tags.length} tags, but none match the release tag format - this will be the first release`);
       }
     } else {
       // If no tags, fall back to releases
-      console.log('No tags found, checking releases...');
-      const latestRelease = await octokit.rest.repos.getLatestRelease({
-        owner,
-        repo
-      });
+      logger.info('No tags found, checking releases...');
+      const latestReleaseTag = await provider.getLatestReleaseTag();
+      const latestReleaseVersion = latestReleaseTag ? parseVersionFromTag(latestReleaseTag, config) : null;
       
-      if (latestRelease.data.tag_name) {
+      if (latestReleaseVersion) {
         // Remove 'v' prefix if it exists
-        currentVersion = latestRelease.data.tag_name.replace(/^v/, '');
-        console.log(`Latest release version from GitHub Releases: ${currentVersion}`);
+        currentVersion = latestReleaseVersion;
+        hasStableRelease = true;
+        logger.info(`Latest release version from ${provider.name} releases: ${currentVersion}`);
       } else {
-        console.log('No releases found either, starting from 0.0.0');
+        logger.info('No releases found either - this will be the first release');
       }
     }
   } catch (error) {
     // No releases or tags found, starting from 0.0.0
-    console.log(`Error fetching version information: ${error.message}`);
-    console.log('Starting from 0.0.0');
+    logger.info(`Error fetching version information: ${error.message}`);
+    logger.info('Treating this as the first release');
   }
   
   // Calculate the highest impact bump from all commits
@@ -248,18 +538,37 @@ async function determineVersionBump(commits, octokit, context, config) {
     bumpType = 'patch';
   }
   
-  console.log(`Determined bump type: ${bumpType || 'none'}`);
+  logger.info(`Determined bump type: ${bumpType || 'none'}`);
   
   // Override with explicit releaseAs from config if provided
   if (config.releaseAs && ['major', 'minor', 'patch'].includes(config.releaseAs)) {
-    console.log(`Overriding bump type with config releaseAs: ${config.releaseAs}`);
+    logger.info(`Overriding bump type with config releaseAs: ${config.releaseAs}`);
     bumpType = config.releaseAs;
   }
   
-  // No version bump needed
+  // Prerelease runs report the latest prerelease as the current version
+  const stableVersion = currentVersion;
+  if (config.prereleaseChannel && releasedVersions.length > 0) {
+    currentVersion = releasedVersions[0];
+  }
+  
+  // No version bump needed - chore/docs/style-only changes (or nothing at all) since the last release
   if (!bumpType) {
+    const types = [...new Set(commits.map(commit => commit.parsed && commit.parsed.type).filter(Boolean))];
+    const noBumpScopes = [...new Set(commits
+      .filter(commit => commit.parsed && hasScope(commit.parsed, config.noBumpScopes) && getBumpTypeForCommit(commit.parsed, getCommitTypes(config)))
+      .map(commit => commit.parsed.scope))];
+    let reason = types.length > 0
+      ? `Only ${types.join(', ')} commits since ${currentVersion} - none of them count toward a release (releaseTypes: ${getReleaseTypes(getCommitTypes(config)).join(', ')})`
+      : `No releasable commits since ${currentVersion}`;
+    if (noBumpScopes.length > 0) {
+      reason = `The only releasable commits since ${currentVersion} are scoped ${noBumpScopes.join(', ')}, which noBumpScopes keeps out of the version`;
+    }
+    logger.info(`${reason} - nothing to release 💅`);
+    
     return { 
       bumpType: null, 
+      reason,
       newVersion: currentVersion, 
       currentVersion,
       major: semver.major(currentVersion),
@@ -268,26 +577,55 @@ async function determineVersionBump(commits, octokit, context, config) {
     };
   }
   
+  // The very first release starts at firstVersion, whatever the commits say
+  if (!hasStableRelease) {
+    const firstVersion = getFirstVersion(config);
+    const newVersion = config.prereleaseChannel
+      ? getNextPrereleaseVersion(stableVersion, bumpType, config.prereleaseChannel, releasedVersions, firstVersion)
+      : firstVersion;
+    logger.info(`First release! Starting at ${newVersion} 🎉`);
+    
+    return {
+      bumpType,
+      newVersion,
+      currentVersion,
+      major: semver.major(newVersion),
+      minor: semver.minor(newVersion),
+      patch: semver.patch(newVersion)
+    };
+  }
+  
   // Calculate new version based on bump type and pre-1.0 rules
   let newVersion = currentVersion;
   let newBumpType = bumpType;
   
   // Apply pre-1.0 version bump rules
-  if (semver.lt(currentVersion, '1.0.0')) {
+  if (semver.lt(stableVersion, '1.0.0')) {
     if (bumpType === 'major') {
       // For pre-1.0, a breaking change results in a minor bump
-      console.log('Pre-1.0 rule: Converting major bump to minor');
+      logger.info('Pre-1.0 rule: Converting major bump to minor');
       newBumpType = 'minor';
     } else if (bumpType === 'minor') {
       // For pre-1.0, a feature results in a patch bump
-      console.log('Pre-1.0 rule: Converting minor bump to patch');
+      logger.info('Pre-1.0 rule: Converting minor bump to patch');
       newBumpType = 'patch';
     }
   }
   
   // Increment version
-  newVersion = semver.inc(currentVersion, newBumpType);
-  console.log(`New version will be: ${newVersion}`);
+  if (config.prereleaseChannel) {
+    newVersion = getNextPrereleaseVersion(stableVersion, newBumpType, config.prereleaseChannel, releasedVersions);
+    logger.info(`Prerelease channel ${config.prereleaseChannel}: building on ${stableVersion}`);
+  } else {
+    newVersion = semver.inc(stableVersion, newBumpType);
+  }
+  
+  // A maintenance branch can't release past its version line - that's what the main line is for
+  if (config.maintenanceRange && !semver.satisfies(newVersion, config.maintenanceRange)) {
+    throw new Error(`A ${newBumpType} bump would make ${config.releaseBranch} release ${newVersion}, which isn't on its ${config.maintenanceRange} line - ` +
+      'land that change on the main line instead, or set releaseAs to a smaller bump');
+  }
+  logger.info(`New version will be: ${newVersion}`);
   
   return { 
     bumpType, 
@@ -299,41 +637,50 @@ async function determineVersionBump(commits, octokit, context, config) {
   };
 }
 
+/**
+ * Get the version for the first release
+ * @param {Object} config - Release Boss configuration
+ * @returns {String} - firstVersion without its 'v' prefix (default 0.1.0)
+ */
+function getFirstVersion(config) {
+  return (config.firstVersion || '0.1.0').replace(/^v/, '');
+}
+
 /**
  * Find new commits since the last update
- * @param {Object} octokit - GitHub API client
- * @param {Object} context - GitHub context
+ * @param {Object} provider - VCS provider
  * @param {String} lastCommitSha - SHA of the last processed commit
  * @param {String} headRef - Reference to compare against (branch or commit)
  * @returns {Array} - Array of new commits
  */
-async function findNewCommitsSince(octokit, context, lastCommitSha, headRef) {
-  const { owner, repo } = context.repo;
-  
-  console.log(`Finding new commits since ${lastCommitSha.substring(0, 7)}...`);
+async function findNewCommitsSince(provider, lastCommitSha, headRef) {
+  logger.info(`Finding new commits since ${lastCommitSha.substring(0, 7)}...`);
   
   // Get commits between lastCommitSha and headRef
-  const compareResponse = await octokit.rest.repos.compareCommits({
-    owner,
-    repo,
-    base: lastCommitSha,
-    head: headRef
-  });
+  const commits = await provider.compareCommits(lastCommitSha, headRef);
   
-  if (!compareResponse.data.commits || compareResponse.data.commits.length === 0) {
-    console.log('No new commits found');
+  if (!commits || commits.length === 0) {
+    logger.info('No new commits found');
     return [];
   }
   
-  console.log(`Found ${compareResponse.data.commits.length} new commits since ${lastCommitSha.substring(0, 7)} 💅`);
-  return compareResponse.data.commits;
+  logger.info(`Found ${commits.length} new commits since ${lastCommitSha.substring(0, 7)} 💅`);
+  return commits;
 }
 
 module.exports = {
   analyzeCommits,
   determineVersionBump,
   getBumpTypeForCommit, // Exported for testing
+  getReleaseTypes,
+  getCommitTypes,
+  BUMP_LEVELS,
   isExcludedFromChangelog, // Exported for testing
+  hasScope,
+  extractBreakingChanges, // Exported for testing
+  extractIssueReferences,
+  ISSUE_FOOTER,
+  splitSquashCommit, // Exported for testing
   commitsToChangelogEntries, // Exported for changelog table generation
   findNewCommitsSince // Exported for PR updates
 };
@@ -0,0 +1,56 @@
+const { logger } = require('../utils/logger');
+const semver = require('semver');
+const { extractChangelogSection } = require('../github/changelogTable');
+const { renderMessageTemplate, buildMessageFields } = require('../utils/messageTemplate');
+const { getReleaseTagName } = require('../utils/tags');
+
+/**
+ * Build the release title from the configured template
+ * @param {String} version - Version being released
+ * @param {Object} config - Release Boss configuration
+ * @returns {String} - Release title (the tag name when releaseTitle isn't set)
+ */
+function buildReleaseTitle(version, config) {
+  return config.releaseTitle
+    ? renderMessageTemplate(config.releaseTitle, buildMessageFields(version, config))
+    : getReleaseTagName(version, config);
+}
+
+/**
+ * Publish the Releases page entry for a freshly tagged version
+ * The notes are the version's section of the changelog file as it was merged, so the release
+ * says exactly what the release PR did. A release that's already there (a re-run, or one made
+ * by hand) is updated instead.
+ * @param {Object} provider - VCS provider
+ * @param {Object} config - Release Boss configuration (package-scoped for monorepo packages)
+ * @param {String} version - Version that was tagged
+ * @param {Object} options - Options
+ * @param {String} options.ref - Commit or branch to read the changelog from (the merge commit)
+ * @returns {Promise<Object>} - { url, updated }
+ */
+async function publishRelease(provider, config, version, { ref }) {
+  const tagName = getReleaseTagName(version, config);
+
+  let body = null;
+  if (config.changelogPath) {
+    body = extractChangelogSection(await provider.getFileContent(config.changelogPath, ref), version);
+  }
+  if (body === null) {
+    logger.warn(`No ${version} section in ${config.changelogPath || 'the changelog (changelogPath is off)'} - publishing the ${tagName} release without notes`);
+    body = '';
+  }
+
+  const release = await provider.createRelease(tagName, {
+    title: buildReleaseTitle(version, config),
+    body,
+    prerelease: semver.prerelease(version) !== null
+  });
+
+  logger.info(`${release.updated ? 'Updated' : 'Published'} the ${tagName} release${release.url ? `: ${release.url}` : ''} 📰`);
+  return release;
+}
+
+module.exports = {
+  buildReleaseTitle,
+  publishRelease
+};
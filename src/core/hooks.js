@@ -0,0 +1,223 @@
+const { spawn, execFile } = require('child_process');
+const crypto = require('crypto');
+const fs = require('fs').promises;
+const path = require('path');
+const { logger } = require('../utils/logger');
+
+/**
+ * Points in the release hooks can run at
+ * preBump: the version is decided, before any files are touched
+ * postBump: after the version files are updated - files hooks change go into the bump commit
+ * prePR: right before the release PR is created or updated
+ * postRelease: after the release is tagged
+ */
+const HOOK_STAGES = ['preBump', 'postBump', 'prePR', 'postRelease'];
+
+/**
+ * Normalise a hook entry
+ * Entries are either a command or { command, continueOnError }.
+ * @param {String|Object} hook - Hook entry
+ * @returns {Object} - { command, continueOnError }
+ */
+function normalizeHook(hook) {
+  return typeof hook === 'string'
+    ? { command: hook, continueOnError: false }
+    : { command: hook.command, continueOnError: hook.continueOnError === true };
+}
+
+/**
+ * Build the environment variables hooks get on top of the process environment
+ * @param {Object} release - What's being released
+ * @param {String} release.version - Version being released
+ * @param {String} release.previousVersion - Version we're leaving behind
+ * @param {String} release.bumpType - major, minor or patch
+ * @param {String} release.tag - Release tag name
+ * @param {String} release.commitSha - Tagged commit (postRelease only)
+ * @param {Object} config - Release Boss configuration
+ * @returns {Object}
+ */
+function buildHookEnv(release, config) {
+  const env = {
+    RELEASE_VERSION: release.version || '',
+    PREVIOUS_VERSION: release.previousVersion || '',
+    RELEASE_BUMP_TYPE: release.bumpType || '',
+    RELEASE_TAG: release.tag || '',
+    CHANGELOG_PATH: config.changelogPath || '',
+    RELEASE_PACKAGE: config.packageName || ''
+  };
+  if (release.commitSha) {
+    env.RELEASE_COMMIT_SHA = release.commitSha;
+  }
+  return env;
+}
+
+/**
+ * Run a shell command and capture its output
+ * @param {String} command - Command to run
+ * @param {Object} env - Environment variables to add
+ * @param {String} cwd - Working directory
+ * @returns {Promise<Object>} - { code, stdout, stderr }
+ */
+function runCommand(command, env, cwd) {
+  return new Promise((resolve, reject) => {
+    const child = spawn(command, { shell: true, cwd, env: { ...process.env, ...env } });
+    let stdout = '';
+    let stderr = '';
+    child.stdout.on('data', chunk => { stdout += chunk; });
+    child.stderr.on('data', chunk => { stderr += chunk; });
+    child.on('error', reject);
+    child.on('close', code => resolve({ code, stdout, stderr }));
+  });
+}
+
+/**
+ * Log a hook's captured output, one indented line at a time
+ * @param {String} label - stdout or stderr
+ * @param {String} output - Captured output
+ */
+function logOutput(label, output) {
+  const lines = output.replace(/\n$/, '').split('\n').filter(line => line.length > 0);
+  if (lines.length > 0) {
+    logger.info(`  ${label}:`);
+    lines.forEach(line => logger.info(`    ${line}`));
+  }
+}
+
+/**
+ * Run the hooks configured for a stage, in order 🪝
+ *
+ * A hook that exits non-zero fails the release unless it has continueOnError.
+ * In dry-run mode the hooks are only logged - they can do anything, so we don't run them.
+ * @param {String} stage - One of HOOK_STAGES
+ * @param {Object} config - Release Boss configuration
+ * @param {Object} release - What's being released (see buildHookEnv)
+ * @param {Object} options - Options
+ * @param {Boolean} options.dryRun - Log the hooks instead of running them
+ * @param {String} options.cwd - Working directory (default: current directory)
+ * @param {Function} options.run - Command runner (for tests)
+ * @returns {Promise<Array>} - [{ command, code }] for the hooks that ran
+ */
+async function runHooks(stage, config, release, { dryRun = false, cwd = process.cwd(), run = runCommand } = {}) {
+  const hooks = ((config.hooks && config.hooks[stage]) || []).map(normalizeHook);
+  const results = [];
+  if (hooks.length === 0) {
+    return results;
+  }
+
+  const env = buildHookEnv(release, config);
+  for (const hook of hooks) {
+    if (dryRun) {
+      logger.info(`🔍 [dry-run] Would run ${stage} hook: ${hook.command}`);
+      continue;
+    }
+
+    logger.info(`🪝 Running ${stage} hook: ${hook.command}`);
+    let outcome;
+    try {
+      outcome = await run(hook.command, env, cwd);
+    } catch (error) {
+      outcome = { code: null, stdout: '', stderr: error.message };
+    }
+
+    logOutput('stdout', outcome.stdout || '');
+    logOutput('stderr', outcome.stderr || '');
+    results.push({ command: hook.command, code: outcome.code });
+
+    if (outcome.code !== 0) {
+      const message = `${stage} hook "${hook.command}" ${outcome.code === null ? 'could not be started' : `exited with code ${outcome.code}`}`;
+      if (!hook.continueOnError) {
+        throw new Error(message);
+      }
+      logger.warn(`${message} - carrying on since it has continueOnError 💅`);
+    } else {
+      logger.info(`✨ ${stage} hook finished: ${hook.command}`);
+    }
+  }
+
+  return results;
+}
+
+/**
+ * Run git in a directory
+ * @param {Array<String>} args - git arguments
+ * @param {String} cwd - Working directory
+ * @returns {Promise<String>} - stdout
+ */
+function runGit(args, cwd) {
+  return new Promise((resolve, reject) => {
+    execFile('git', args, { cwd, maxBuffer: 64 * 1024 * 1024 }, (error, stdout, stderr) => {
+      if (error) {
+        reject(new Error(`git ${args[0]} failed: ${(stderr || error.message).trim()}`));
+      } else {
+        resolve(stdout);
+      }
+    });
+  });
+}
+
+/**
+ * Fingerprint every changed or untracked file in the working tree
+ * Taken before the postBump hooks so we can tell which files they touched. git status lists
+ * paths from the top of the repo, whichever subdirectory the run started in, so that's where
+ * they're resolved from.
+ * @param {Object} options - Options
+ * @param {String} options.cwd - Checkout, or a directory in it, to look at (default: current directory)
+ * @param {Function} options.git - git runner (for tests)
+ * @returns {Promise<Map>} - Absolute path → content hash (null when deleted)
+ */
+async function snapshotWorkingTree({ cwd = process.cwd(), git = runGit } = {}) {
+  const root = path.resolve(cwd, (await git(['rev-parse', '--show-cdup'], cwd)).trim());
+  const output = await git(['status', '--porcelain', '-z', '--untracked-files=all'], cwd);
+  const entries = output.split('\0');
+  const snapshot = new Map();
+
+  for (let i = 0; i < entries.length; i++) {
+    const entry = entries[i];
+    if (!entry) {
+      continue;
+    }
+    // Renames are followed by their original path, which we don't need
+    if (entry[0] === 'R' || entry[0] === 'C') {
+      i++;
+    }
+
+    const file = path.join(root, entry.substring(3));
+    try {
+      const content = await fs.readFile(file);
+      snapshot.set(file, crypto.createHash('sha1').update(content).digest('hex'));
+    } catch {
+      snapshot.set(file, null);
+    }
+  }
+
+  return snapshot;
+}
+
+/**
+ * Find the files that changed since a snapshot
+ * @param {Map} before - Snapshot from snapshotWorkingTree
+ * @param {Object} options - Same options as snapshotWorkingTree
+ * @returns {Promise<Array<String>>} - Absolute paths of files that were written
+ */
+async function findChangedFiles(before, options = {}) {
+  const after = await snapshotWorkingTree(options);
+  const changed = [];
+
+  for (const [file, hash] of after) {
+    // Deleted files can't go into the bump commit, so they're left for the hook's author to sort out
+    if (hash !== null && before.get(file) !== hash) {
+      changed.push(file);
+    }
+  }
+
+  return changed;
+}
+
+module.exports = {
+  HOOK_STAGES,
+  normalizeHook,
+  buildHookEnv,
+  runHooks,
+  snapshotWorkingTree,
+  findChangedFiles
+};
@@ -0,0 +1,98 @@
+const semver = require('semver');
+const { parseVersionFromTag } = require('../utils/tags');
+
+/**
+ * Maintenance branches 🛠️
+ *
+ * Each entry in `maintenance` maps a branch to a version line (a semver
+ * range like 1.x). Runs on that branch only look at the line's tags, so the
+ * next version builds on the line's highest release instead of the newest tag
+ * in the repo - v1.4.2 from a 1.x branch while main is already on v2. Like a
+ * prerelease channel, the staging branch is cut from the maintenance branch,
+ * the release PR goes back into it and the tag lands there when it's merged.
+ * A bump that would leave the line (a breaking change on 1.x) is an error.
+ */
+
+/**
+ * Work out the version line a branch name stands for
+ * @param {String} branch - Branch name like 1.x, v1.x, 1.4.x or 1.x.x
+ * @returns {String|null} - Semver range like 1.x or 1.4.x, or null when the name isn't a version line
+ */
+function getBranchVersionRange(branch) {
+  const match = (branch || '').match(/^v?(\d+)(?:\.(\d+))?(?:\.x)+$/);
+  if (!match) {
+    return null;
+  }
+  return match[2] !== undefined ? `${match[1]}.${match[2]}.x` : `${match[1]}.x`;
+}
+
+/**
+ * Find the maintenance entry configured for a branch
+ * @param {Object} config - Release Boss configuration
+ * @param {String} branch - Branch the run was triggered for
+ * @returns {Object|null} - Maintenance entry ({ branch, range }, range filled in from the branch name) or null
+ */
+function findMaintenanceBranch(config, branch) {
+  if (!branch || !Array.isArray(config.maintenance)) {
+    return null;
+  }
+
+  const entry = config.maintenance.find(candidate => candidate.branch === branch);
+  return entry ? { branch: entry.branch, range: entry.range || getBranchVersionRange(entry.branch) } : null;
+}
+
+/**
+ * Find the maintenance line a version belongs to
+ * @param {Object} config - Release Boss configuration
+ * @param {String} version - Released version
+ * @returns {Object|null} - Maintenance entry ({ branch, range }) or null when the version is on the main line
+ */
+function findMaintenanceLine(config, version) {
+  if (!Array.isArray(config.maintenance)) {
+    return null;
+  }
+
+  return config.maintenance
+    .map(entry => findMaintenanceBranch(config, entry.branch))
+    .find(entry => semver.satisfies(version, entry.range)) || null;
+}
+
+/**
+ * Build the config used to release a maintenance branch
+ * @param {Object} config - Release Boss configuration
+ * @param {Object} entry - Maintenance entry (from findMaintenanceBranch)
+ * @returns {Object} - Config releasing from and into the maintenance branch, on its version line
+ */
+function getMaintenanceConfig(config, entry) {
+  return {
+    ...config,
+    maintenanceRange: entry.range,
+    mergeBranch: entry.branch,
+    releaseBranch: entry.branch
+  };
+}
+
+/**
+ * Keep the tags whose version is on a maintenance line
+ * @param {Array} tags - Tags from the provider
+ * @param {Object} config - Release Boss configuration (maintenance-scoped)
+ * @returns {Array} - The tags in config.maintenanceRange, or every tag outside a maintenance run
+ */
+function filterVersionLineTags(tags, config) {
+  if (!config.maintenanceRange) {
+    return tags;
+  }
+
+  return (tags || []).filter(tag => {
+    const version = parseVersionFromTag(tag.name, config);
+    return version && semver.satisfies(version, config.maintenanceRange);
+  });
+}
+
+module.exports = {
+  getBranchVersionRange,
+  findMaintenanceBranch,
+  findMaintenanceLine,
+  getMaintenanceConfig,
+  filterVersionLineTags
+};
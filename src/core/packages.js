@@ -0,0 +1,160 @@
+const path = require('path');
+const { mapWithConcurrency } = require('../utils/pool');
+const { logger } = require('../utils/logger');
+
+/**
+ * Monorepo package support 📦
+ *
+ * Each entry in `packages` is versioned on its own: it gets its own commits,
+ * version bump, changelog, release PR (from `<stagingBranch>-<name>-v<version>`)
+ * and tags (`<name>/v<version>`). Everything else is the normal single-repo flow
+ * running with a package-specific config.
+ */
+
+/**
+ * Normalise a package path so it can be compared against changed file paths
+ * @param {String} packagePath - Path from config
+ * @returns {String} - Path without leading './' or trailing '/'
+ */
+function normalizePackagePath(packagePath) {
+  return path.posix.normalize(packagePath.replace(/\\/g, '/')).replace(/^\.\//, '').replace(/\/+$/, '');
+}
+
+/**
+ * Resolve the configured packages with their defaults filled in
+ * @param {Object} config - Release Boss configuration
+ * @returns {Array} - Packages with name, path, commitScope, file lists and changelogPath
+ */
+function resolvePackages(config) {
+  if (!Array.isArray(config.packages) || config.packages.length === 0) {
+    return [];
+  }
+
+  return config.packages.map(pkg => {
+    const pkgPath = normalizePackagePath(pkg.path);
+    const name = pkg.name || path.posix.basename(pkgPath);
+    const inPackage = file => path.posix.join(pkgPath, file);
+
+    return {
+      name,
+      path: pkgPath,
+      commitScope: pkg.commitScope || name,
+      versionFiles: (pkg.versionFiles || []).map(entry => (typeof entry === 'string' ? inPackage(entry) : { ...entry, file: inPackage(entry.file) })),
+      templateFiles: (pkg.templateFiles || []).map(inPackage),
+      updateFiles: (pkg.updateFiles || []).map(fileConfig => ({ ...fileConfig, file: inPackage(fileConfig.file) })),
+      changelogPath: inPackage(pkg.changelogPath || 'CHANGELOG.md')
+    };
+  });
+}
+
+/**
+ * Build the config used to release a single package
+ * @param {Object} config - Release Boss configuration
+ * @param {Object} pkg - Resolved package (from resolvePackages)
+ * @returns {Object} - Config scoped to the package
+ */
+function getPackageConfig(config, pkg) {
+  const title = /\{package\}|\{\{\s*\.Package\s*\}\}/.test(config.pullRequestTitle)
+    ? config.pullRequestTitle
+    : `${config.pullRequestTitle} ({package})`;
+
+  return {
+    ...config,
+    packages: [],
+    packageName: pkg.name,
+    versionFiles: pkg.versionFiles,
+    templateFiles: pkg.templateFiles,
+    updateFiles: pkg.updateFiles,
+    changelogPath: pkg.changelogPath,
+    stagingBranch: `${config.stagingBranch}-${pkg.name}`,
+    tagNamespace: `${pkg.name}/`,
+    pullRequestTitle: title.replace(/\{package\}/g, pkg.name)
+  };
+}
+
+/**
+ * Find the package a staging branch belongs to
+ * @param {String} branch - Staging branch name (e.g. staging-api-v1.2.0)
+ * @param {Array} packages - Resolved packages
+ * @param {Object} config - Release Boss configuration
+ * @returns {Object|null} - Matching package
+ */
+function findPackageForBranch(branch, packages, config) {
+  // Longest name first so "api-v2" wins over "api" for staging-api-v2-v1.0.0
+  const candidates = [...packages].sort((a, b) => b.name.length - a.name.length);
+  return candidates.find(pkg => new RegExp(`^${config.stagingBranch}-${pkg.name}-v?[0-9]`).test(branch)) || null;
+}
+
+/**
+ * Check whether a file lives inside a package
+ * @param {String} file - File path relative to the repository root
+ * @param {Object} pkg - Resolved package
+ * @returns {Boolean}
+ */
+function isInPackage(file, pkg) {
+  return pkg.path === '.' || pkg.path === '' || file === pkg.path || file.startsWith(`${pkg.path}/`);
+}
+
+/**
+ * Work out which packages each commit belongs to
+ *
+ * An explicit scope matching a package's commitScope wins. Commits without a
+ * matching scope belong to every package that owns one of their changed files,
+ * so a commit that only touches files under one package path implicitly scopes
+ * to that package.
+ * @param {Array} commits - Analyzed commits (from analyzeCommits)
+ * @param {Array} packages - Resolved packages
+ * @param {Object} provider - VCS provider (used to list changed files)
+ * @param {Object} options - Options
+ * @param {Number} options.concurrency - Commits to list files for at once (default: 8)
+ * @returns {Map} - Package name → commits
+ */
+async function assignCommitsToPackages(commits, packages, provider, { concurrency } = {}) {
+  const assigned = new Map(packages.map(pkg => [pkg.name, []]));
+  const scopedPackages = commits.map(commit =>
+    (commit.parsed.scope ? packages.filter(pkg => pkg.commitScope === commit.parsed.scope) : []));
+
+  // Only commits without a package scope need their files, and those can be fetched side by side
+  // Commits split out of one squash share a hash, so each hash is only fetched once
+  const fetches = new Map();
+  const files = await mapWithConcurrency(commits, concurrency, async (commit, index) => {
+    if (scopedPackages[index].length > 0) {
+      return null;
+    }
+    if (!commit.files && !fetches.has(commit.hash)) {
+      fetches.set(commit.hash, provider.getCommitFiles(commit.hash));
+    }
+    try {
+      commit.files = commit.files || await fetches.get(commit.hash);
+      return commit.files;
+    } catch (error) {
+      logger.info(`Couldn't list files for commit ${commit.hash.substring(0, 7)}: ${error.message}`);
+      return null;
+    }
+  });
+
+  commits.forEach((commit, index) => {
+    let owners = scopedPackages[index];
+    if (owners.length === 0 && files[index]) {
+      owners = packages.filter(pkg => files[index].some(file => isInPackage(file, pkg)));
+    }
+
+    if (owners.length === 0) {
+      logger.info(`Commit ${commit.hash.substring(0, 7)} doesn't belong to any package - skipping it 🤷‍♀️`);
+    }
+
+    for (const pkg of owners) {
+      assigned.get(pkg.name).push(commit);
+    }
+  });
+
+  return assigned;
+}
+
+module.exports = {
+  resolvePackages,
+  getPackageConfig,
+  findPackageForBranch,
+  assignCommitsToPackages,
+  isInPackage
+};
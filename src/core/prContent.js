@@ -0,0 +1,118 @@
+const path = require('path');
+
+const { updatePRDescriptionWithChangelog, renderChangelogSection } = require('../github/changelogTable');
+const { renderMessageTemplate, buildMessageFields } = require('../utils/messageTemplate');
+const { getReleaseTagName } = require('../utils/tags');
+const { logger } = require('../utils/logger');
+
+/**
+ * Default template for the version bump commit
+ */
+const DEFAULT_RELEASE_COMMIT_MESSAGE = 'chore: update files for release {{.Version}}';
+
+/**
+ * What the release PR body shows of the changelog
+ * preview is the section the changelog file gets, table is the commit table
+ */
+const PULL_REQUEST_CHANGELOG_FORMATS = ['preview', 'table'];
+
+/**
+ * Build the release PR title from the configured template
+ * @param {String} version - Version being released
+ * @param {Object} config - Release Boss configuration
+ * @returns {String} - PR title
+ */
+function buildPRTitle(version, config) {
+  return renderMessageTemplate(config.pullRequestTitle, buildMessageFields(version, config));
+}
+
+/**
+ * Build the version bump commit message from the configured template
+ * @param {String} version - Version being released
+ * @param {Object} config - Release Boss configuration
+ * @returns {String} - Commit message
+ */
+function buildCommitMessage(version, config) {
+  return renderMessageTemplate(config.releaseCommitMessage || DEFAULT_RELEASE_COMMIT_MESSAGE, buildMessageFields(version, config));
+}
+
+/**
+ * Build the "Unreleased → vX.Y.Z" preview of the changelog section the release PR adds
+ * The notes come from the same renderer as the changelog file, so the two can't drift apart.
+ * @param {String} changelog - Changelog content for the release
+ * @param {Object} config - Release Boss configuration (compareUrl links the changes, when set)
+ * @param {String} version - Version being released
+ * @returns {String} - Markdown for the PR body
+ */
+function buildChangelogPreview(changelog, config, version) {
+  const section = renderChangelogSection(changelog, version);
+  const notes = section.substring(section.indexOf('\n') + 1).trim();
+
+  let preview = `## Unreleased → ${getReleaseTagName(version, config)}\n\n`;
+  if (config.compareUrl) {
+    const since = config.previousVersion ? ` since ${getReleaseTagName(config.previousVersion, config)}` : '';
+    preview += `[Compare the changes${since}](${config.compareUrl})\n\n`;
+  }
+  return `${preview}${notes}\n\n`;
+}
+
+/**
+ * Build the release PR body with the changelog preview (or table) and updated files list
+ * Shared by every provider so GitHub PRs and GitLab MRs look equally fabulous 💅
+ * @param {String} changelog - Changelog content for the release
+ * @param {Object} config - Release Boss configuration
+ * @param {Array} updatedFiles - List of files that were updated with version info
+ * @param {String} version - Version being released, for the header and footer templates
+ * @returns {String} - PR body
+ */
+function buildPRBody(changelog, config, updatedFiles = [], version = '') {
+  const fields = buildMessageFields(version, config);
+  
+  const initialBody = renderMessageTemplate(`${config.pullRequestHeader || 'Release PR'}`, fields);
+
+  // The preview is the changelog section as it'll be committed - the table is the older commit table
+  let body = config.pullRequestChangelog === 'table'
+    ? updatePRDescriptionWithChangelog(initialBody, changelog, config)
+    : `${initialBody}\n\n${buildChangelogPreview(changelog, config, version)}`;
+
+  // Add a cute intro line
+  body += `Time to freshen up our codebase with a fabulous new release! 💅✨\n\n`;
+
+  // Add list of updated files with cute styling
+  if (updatedFiles && updatedFiles.length > 0) {
+    body += `## 📦 Updated Files 📦\n\n`;
+    body += `These files got a gorgeous makeover:\n\n`;
+    for (const file of updatedFiles) {
+      body += `- \`${path.relative(process.cwd(), file)}\` 💖\n`;
+    }
+    body += '\n';
+  }
+
+  // A custom footer replaces ours entirely
+  if (config.pullRequestFooter) {
+    return body + renderMessageTemplate(config.pullRequestFooter, fields);
+  }
+
+  // Get our current version dynamically
+  let versionInfo;
+  try {
+    versionInfo = require('../version');
+  } catch (e) {
+    logger.info(`Oops! Couldn't find version info, using default version instead 💁‍♀️`);
+    versionInfo = { VERSION_WITH_V: 'v1.0.0' };
+  }
+
+  // Add a fabulous footer with dynamic version
+  body += `---\n\n*This PR was auto-generated by the fabulous Release Boss ${versionInfo.VERSION_WITH_V}* 👑`;
+
+  return body;
+}
+
+module.exports = {
+  buildPRTitle,
+  buildPRBody,
+  buildChangelogPreview,
+  buildCommitMessage,
+  DEFAULT_RELEASE_COMMIT_MESSAGE,
+  PULL_REQUEST_CHANGELOG_FORMATS
+};
@@ -0,0 +1,102 @@
+const semver = require('semver');
+const { parseVersionFromTag } = require('../utils/tags');
+
+/**
+ * Prerelease channels 🧪
+ *
+ * Each entry in `prerelease` maps a branch to a channel name. Runs on that
+ * branch release `<next>-<channel>.<n>` instead of a stable version: the
+ * staging branch is cut from the channel branch, the release PR goes back into
+ * it and the prerelease tag lands there when the PR is merged. The counter goes
+ * up while the base version stays the same and starts again at 1 when the base
+ * bumps or the channel changes (beta → rc).
+ */
+
+/**
+ * Find the prerelease channel configured for a branch
+ * @param {Object} config - Release Boss configuration
+ * @param {String} branch - Branch the run was triggered for
+ * @returns {Object|null} - Channel entry ({ channel, branch }) or null
+ */
+function findPrereleaseChannel(config, branch) {
+  if (!branch || !Array.isArray(config.prerelease)) {
+    return null;
+  }
+
+  return config.prerelease.find(entry => entry.branch === branch) || null;
+}
+
+/**
+ * Build the config used to release a prerelease channel
+ * @param {Object} config - Release Boss configuration
+ * @param {Object} channel - Channel entry (from findPrereleaseChannel)
+ * @returns {Object} - Config releasing from and into the channel branch
+ */
+function getPrereleaseConfig(config, channel) {
+  return {
+    ...config,
+    prereleaseChannel: channel.channel,
+    stableReleaseBranch: config.releaseBranch,
+    mergeBranch: channel.branch,
+    releaseBranch: channel.branch
+  };
+}
+
+/**
+ * Find the tag to compare against for a prerelease run
+ * This is the highest release tag (stable or prerelease), so only commits since
+ * the last release on any channel count towards the next one.
+ * @param {Array} tags - Tags from the provider
+ * @param {Object} config - Release Boss configuration
+ * @returns {String|null} - Tag name, or null if nothing has been released yet
+ */
+function findLatestReleaseTag(tags, config) {
+  const versionTags = (tags || []).filter(tag => parseVersionFromTag(tag.name, config));
+  if (versionTags.length === 0) {
+    return null;
+  }
+
+  versionTags.sort((a, b) => semver.rcompare(parseVersionFromTag(a.name, config), parseVersionFromTag(b.name, config)));
+  return versionTags[0].name;
+}
+
+/**
+ * Calculate the next prerelease version
+ * @param {String} stableVersion - Latest stable version
+ * @param {String} bumpType - Bump type after the pre-1.0 rules ('major', 'minor' or 'patch')
+ * @param {String} channel - Prerelease channel (alpha, beta, rc, ...)
+ * @param {Array<String>} versions - Every released version, stable and prerelease
+ * @param {String} firstVersion - Base to use instead of bumping, when there's no stable release yet (optional)
+ * @returns {String} - Next version like 1.3.0-beta.2
+ */
+function getNextPrereleaseVersion(stableVersion, bumpType, channel, versions, firstVersion = null) {
+  let base = firstVersion || semver.inc(stableVersion, bumpType);
+
+  // An earlier prerelease may already be aiming higher (e.g. beta.1 was a minor and this is only a fix)
+  const pending = versions
+    .filter(version => semver.prerelease(version) && semver.gt(version, stableVersion))
+    .map(version => `${semver.major(version)}.${semver.minor(version)}.${semver.patch(version)}`);
+  for (const pendingBase of pending) {
+    if (semver.gt(pendingBase, base)) {
+      base = pendingBase;
+    }
+  }
+
+  const counters = versions
+    .map(version => semver.prerelease(version))
+    .filter((prerelease, index) => prerelease &&
+      prerelease[0] === channel &&
+      typeof prerelease[1] === 'number' &&
+      semver.coerce(versions[index]).version === base)
+    .map(prerelease => prerelease[1]);
+
+  const counter = counters.length > 0 ? Math.max(...counters) + 1 : 1;
+  return `${base}-${channel}.${counter}`;
+}
+
+module.exports = {
+  findPrereleaseChannel,
+  getPrereleaseConfig,
+  findLatestReleaseTag,
+  getNextPrereleaseVersion
+};
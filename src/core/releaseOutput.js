@@ -0,0 +1,61 @@
+/**
+ * Version of the JSON output schema
+ * Bump it whenever a field is renamed, removed or changes meaning - adding fields doesn't need a bump.
+ */
+const OUTPUT_SCHEMA_VERSION = 1;
+
+/**
+ * Describe a release result (or one package's share of it)
+ * @param {Object} result - ReleaseResult
+ * @returns {Object}
+ */
+function describeRelease(result) {
+  return {
+    runType: result.runType,
+    reason: result.reason,
+    dryRun: result.dryRun,
+    bumpType: result.bumpType,
+    previousVersion: result.previousVersion,
+    version: result.nextVersion,
+    tag: result.releaseTag,
+    tags: result.tags,
+    commitSha: result.releaseCommitSha,
+    pr: result.prNumber || result.prStatus
+      ? { number: result.prNumber, url: result.prUrl, status: result.prStatus }
+      : null,
+    updatedFiles: result.filesChanged,
+    changelog: result.changelog,
+    package: result.packageName ? { name: result.packageName, path: result.packagePath } : null
+  };
+}
+
+/**
+ * Build the machine-readable output for a run
+ * @param {Object} result - ReleaseResult from ReleaseBoss.run()
+ * @returns {Object} - { schemaVersion, ...release, packages }
+ */
+function formatReleaseOutput(result) {
+  return {
+    schemaVersion: OUTPUT_SCHEMA_VERSION,
+    ...describeRelease(result),
+    packages: result.packages.map(describeRelease)
+  };
+}
+
+/**
+ * Build the machine-readable output for a failed run
+ * @param {Error} error - What went wrong
+ * @returns {Object} - { schemaVersion, error: { message } }
+ */
+function formatErrorOutput(error) {
+  return {
+    schemaVersion: OUTPUT_SCHEMA_VERSION,
+    error: { message: error.message }
+  };
+}
+
+module.exports = {
+  OUTPUT_SCHEMA_VERSION,
+  formatReleaseOutput,
+  formatErrorOutput
+};
@@ -0,0 +1,159 @@
+const { logger } = require('../utils/logger');
+const semver = require('semver');
+const { buildCommitMessage, buildPRTitle } = require('./prContent');
+const { getReleaseTagName, getAdditionalTagNames, parseVersionFromTag } = require('../utils/tags');
+const { runGit } = require('../providers/localGitProvider');
+const { findMaintenanceLine, getMaintenanceConfig } = require('./maintenance');
+
+/**
+ * Work out the version a rollback is for
+ * @param {String} input - Version or tag name (1.2.0, v1.2.0, ...)
+ * @param {Object} config - Release Boss configuration
+ * @returns {String} - Version
+ * @throws {Error} - If it's neither
+ */
+function resolveRollbackVersion(input, config) {
+  const version = parseVersionFromTag(input, config) || semver.valid(input);
+  if (!version) {
+    throw new Error(`"${input}" isn't a version or a release tag - pass something like 1.2.0`);
+  }
+  return version;
+}
+
+/**
+ * Find the commit that bumped the version for a release
+ * That's the commit named after the release commit message, or the release PR's title when
+ * the PR was squash merged. The newest match wins, in case an earlier attempt was merged too.
+ * @param {Object} provider - VCS provider
+ * @param {Object} config - Release Boss configuration
+ * @param {String} version - Released version
+ * @param {Array} tags - Normalised tags
+ * @returns {Promise<Object|null>} - Normalised commit, or null if none matches
+ */
+async function findBumpCommit(provider, config, version, tags) {
+  const tagName = getReleaseTagName(version, config);
+
+  // Only look back as far as the release before this one
+  const previous = tags
+    .map(tag => ({ name: tag.name, version: parseVersionFromTag(tag.name, config) }))
+    .filter(tag => tag.version && semver.lt(tag.version, version))
+    .sort((a, b) => semver.rcompare(a.version, b.version))[0];
+  const commits = previous ? await provider.compareCommits(previous.name, tagName) : await provider.listCommits(tagName);
+
+  const subjects = [buildCommitMessage(version, config), buildPRTitle(version, config)].map(message => message.split('\n')[0].trim());
+  const matches = commits.filter(commit => subjects.includes((commit.message || '').split('\n')[0].trim()));
+  return matches.length > 0 ? matches[matches.length - 1] : null;
+}
+
+/**
+ * Delete a tag from the local checkout, if it has one
+ * @param {String} tagName - Tag name
+ * @param {Object} options - { cwd, git, dryRun }
+ * @returns {Promise<Boolean>} - Whether the tag was there (and, outside a dry run, deleted)
+ */
+async function deleteLocalTag(tagName, { cwd, git, dryRun }) {
+  try {
+    await git(['rev-parse', '--quiet', '--verify', `refs/tags/${tagName}`], cwd);
+  } catch {
+    // Not a checkout, or the tag was never fetched - nothing to clean up
+    return false;
+  }
+
+  if (dryRun) {
+    logger.info(`🔍 [dry-run] Would delete local tag ${tagName}`);
+  } else {
+    await git(['tag', '-d', tagName], cwd);
+  }
+  return true;
+}
+
+/**
+ * Undo a release - delete its release notes and tag, and optionally revert its version bump
+ * The release and remote tag go first, then the local tag, then the bump commit is reverted on
+ * the release branch with a new commit. Alias tags (latest, v1, ...) are left alone, since the
+ * previous release's tags can't be put back - they're only warned about.
+ * @param {Object} provider - VCS provider (dry-run wrapped for a dry run)
+ * @param {Object} config - Release Boss configuration
+ * @param {String} input - Version or tag name to roll back
+ * @param {Object} options - Rollback options
+ * @param {Boolean} options.force - Roll back even though a newer release exists
+ * @param {Boolean} options.revert - Revert the bump commit on the release branch too
+ * @param {Boolean} options.dryRun - Log the local tag deletion instead of making it (the provider handles the rest)
+ * @param {String} options.cwd - Checkout whose local tag is deleted (default: current directory)
+ * @param {Function} options.git - git runner (for tests)
+ * @returns {Promise<Object>} - { version, tag, releaseDeleted, tagDeleted, localTagDeleted, revertSha }
+ */
+async function rollbackRelease(provider, config, input, { force = false, revert = false, dryRun = false, cwd = process.cwd(), git = runGit } = {}) {
+  if (config.packages && config.packages.length > 0) {
+    throw new Error('rollback doesn\'t support monorepo packages yet - delete the package\'s tag and release by hand');
+  }
+
+  const version = resolveRollbackVersion(input, config);
+  const tagName = getReleaseTagName(version, config);
+  const tags = await provider.listTags();
+
+  const tag = tags.find(candidate => candidate.name === tagName);
+  if (!tag) {
+    throw new Error(`There's no ${tagName} tag - nothing to roll back`);
+  }
+
+  // A maintenance release is only the latest on its own line - main being further along doesn't count,
+  // and its bump commit is on the maintenance branch
+  const line = findMaintenanceLine(config, version);
+  const releaseConfig = line ? getMaintenanceConfig(config, line) : config;
+  const newer = tags
+    .map(candidate => ({ name: candidate.name, version: parseVersionFromTag(candidate.name, config) }))
+    .filter(candidate => candidate.version && semver.gt(candidate.version, version))
+    .filter(candidate => !line || semver.satisfies(candidate.version, line.range))
+    .map(candidate => candidate.name);
+  if (newer.length > 0) {
+    if (!force) {
+      throw new Error(`${newer.join(', ')} ${newer.length === 1 ? 'was' : 'were'} released after ${tagName} - pass --force to roll it back anyway`);
+    }
+    logger.warn(`Rolling back ${tagName} even though ${newer.join(', ')} came after it 😬`);
+  }
+
+  // Look for the bump commit while the tag is still there to search from
+  let bumpCommit = null;
+  if (revert) {
+    bumpCommit = await findBumpCommit(provider, config, version, tags);
+    if (!bumpCommit) {
+      throw new Error(`Couldn't find the version bump commit for ${tagName} - nothing was rolled back`);
+    }
+  }
+
+  // The dry-run provider says what it would do, so only real steps are reported here
+  const report = dryRun ? () => {} : message => logger.info(message);
+
+  const releaseDeleted = await provider.deleteRelease(tagName);
+  report(releaseDeleted ? `Deleted the ${tagName} release 🗑️` : `${tagName} has no release to delete`);
+
+  const tagDeleted = await provider.deleteTag(tagName);
+  report(tagDeleted ? `Deleted tag ${tagName} 🗑️` : `Tag ${tagName} was already gone`);
+
+  const localTagDeleted = await deleteLocalTag(tagName, { cwd, git, dryRun });
+  if (localTagDeleted) {
+    report(`Deleted local tag ${tagName} 🗑️`);
+  }
+
+  let revertSha = null;
+  if (bumpCommit) {
+    const message = `chore: roll back release ${version}\n\nThis reverts commit ${bumpCommit.sha}.`;
+    ({ sha: revertSha } = await provider.revertCommit(bumpCommit.sha, { branch: releaseConfig.releaseBranch, message }));
+    report(`Reverted the version bump (${bumpCommit.sha.substring(0, 7)}) on ${releaseConfig.releaseBranch} with ${revertSha ? revertSha.substring(0, 7) : 'a new commit'} ↩️`);
+  }
+
+  const aliases = getAdditionalTagNames(version, releaseConfig)
+    .filter(alias => tags.some(candidate => candidate.name === alias && candidate.sha === tag.sha));
+  for (const alias of aliases) {
+    logger.warn(`Tag ${alias} still points at ${tag.sha.substring(0, 7)} - move it back to the previous release by hand`);
+  }
+
+  logger.info(`💅 Rolled back ${tagName}${dryRun ? ' [dry-run]' : ''}`);
+  return { version, tag: tagName, releaseDeleted, tagDeleted, localTagDeleted, revertSha };
+}
+
+module.exports = {
+  rollbackRelease,
+  findBumpCommit
+};
@@ -0,0 +1,85 @@
+const { parseDuration } = require('../utils/date');
+const { logger } = require('../utils/logger');
+
+/**
+ * Default age after which a staging branch without a PR counts as abandoned
+ */
+const DEFAULT_STALE_BRANCH_AGE = '7d';
+
+/**
+ * Build the pattern staging branches are named with
+ * Covers regular (staging-v1.2.0), package (staging-api-v1.2.0) and prerelease branches,
+ * so unrelated branches that just happen to start with the same word are never touched.
+ * @param {Object} config - Release Boss configuration
+ * @returns {RegExp}
+ */
+function getStagingBranchPattern(config) {
+  const prefix = config.stagingBranch.replace(/[.*+?^${}()|[\]\\]/g, '\\$&');
+  return new RegExp(`^${prefix}-(?:.+-)?v?\\d+\\.\\d+\\.\\d+`);
+}
+
+/**
+ * Delete staging branches left behind by runs that never got to clean up 🧹
+ *
+ * A staging branch is deleted when every PR from it is closed or merged, or when
+ * it never got a PR and its last commit is older than staleBranchAge. A branch
+ * with an open PR is always kept. Only branches without a PR need a date, so a
+ * provider that doesn't list one (GitHub) is asked for just those, one at a time.
+ * @param {Object} provider - VCS provider
+ * @param {Object} config - Release Boss configuration
+ * @param {Date} now - Current time (for tests)
+ * @returns {Promise<Array<String>>} - Branches that were deleted
+ */
+async function cleanupStaleStagingBranches(provider, config, now = new Date()) {
+  if (config.deleteStagingBranch === false) {
+    logger.info('Branch deletion is disabled in config (deleteStagingBranch: false) - skipping the stale branch sweep');
+    return [];
+  }
+
+  const maxAge = config.staleBranchAge === null ? null : parseDuration(config.staleBranchAge || DEFAULT_STALE_BRANCH_AGE);
+  const pattern = getStagingBranchPattern(config);
+  const branches = (await provider.listBranches(`${config.stagingBranch}-`)).filter(branch => pattern.test(branch.name));
+  const deleted = [];
+
+  for (const branch of branches) {
+    const prs = await provider.listBranchPRs(branch.name);
+    let reason = null;
+
+    // Never touch a release that's still in review
+    if (prs.some(pr => pr.state === 'open')) {
+      continue;
+    }
+
+    if (prs.length > 0) {
+      const numbers = prs.map(pr => `#${pr.number}`).join(', ');
+      const state = prs.every(pr => pr.state === 'merged') ? 'merged' : 'closed';
+      reason = prs.length === 1 ? `its PR ${numbers} is ${state}` : `its PRs ${numbers} are all ${state}`;
+    } else if (maxAge !== null) {
+      const date = branch.date || (branch.sha ? (await provider.getCommit(branch.sha)).date : null);
+      if (date && now - new Date(date) > maxAge) {
+        const days = Math.floor((now - new Date(date)) / (24 * 60 * 60 * 1000));
+        reason = `it has no PR and hasn't changed in ${days} day${days === 1 ? '' : 's'}`;
+      }
+    }
+
+    if (!reason) {
+      continue;
+    }
+
+    logger.info(`🧹 Deleting stale staging branch ${branch.name} - ${reason}`);
+    if (await provider.deleteBranch(branch.name)) {
+      deleted.push(branch.name);
+    }
+  }
+
+  if (deleted.length === 0) {
+    logger.info(`No stale staging branches to clean up (checked ${branches.length}) ✨`);
+  }
+  return deleted;
+}
+
+module.exports = {
+  cleanupStaleStagingBranches,
+  getStagingBranchPattern,
+  DEFAULT_STALE_BRANCH_AGE
+};
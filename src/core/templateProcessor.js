@@ -1,68 +1,428 @@
 const fs = require('fs').promises;
 const path = require('path');
+const semver = require('semver');
+const { diffLines } = require('../utils/diff');
+const { formatDate } = require('../utils/date');
+const { logger } = require('../utils/logger');
 
-const { Octokit } = require('@octokit/rest');
-const github = require('@actions/github');
+/**
+ * Marker prefixes recognised when markerPrefix isn't configured
+ * %%release-manager: is the pre-rename spelling, kept so older version files keep working.
+ */
+const DEFAULT_MARKER_PREFIXES = ['%%release-boss:', '%%release-manager:'];
+
+/**
+ * Placeholders buildTemplateVariables can fill in
+ */
+const TEMPLATE_PLACEHOLDERS = ['version', 'major', 'minor', 'patch', 'prerelease', 'date', 'sha', 'shortSha'];
+
+/**
+ * Find the first version template marker on a line
+ * @param {String} line - Line to search
+ * @param {Array<String>} prefixes - Marker prefixes to look for
+ * @returns {Object|null} - { index, length } of the marker prefix, or null
+ */
+function findMarker(line, prefixes) {
+  let marker = null;
+  for (const prefix of prefixes) {
+    const index = line.indexOf(prefix);
+    if (index !== -1 && (!marker || index < marker.index)) {
+      marker = { index, length: prefix.length };
+    }
+  }
+  return marker;
+}
+
+/**
+ * Take the indentation every non-blank line shares off all of them
+ * Nesting inside a block template survives, whatever the block itself is indented by.
+ * @param {Array<String>} lines - Template lines
+ * @returns {Array<String>} - Lines without the common indentation (and trailing whitespace)
+ */
+function dedentLines(lines) {
+  const indents = lines.filter(line => line.trim()).map(line => line.match(/^[ \t]*/)[0]);
+  const common = indents.length === 0 ? '' : indents.reduce((shared, indent) => {
+    let length = 0;
+    while (length < shared.length && length < indent.length && shared[length] === indent[length]) {
+      length++;
+    }
+    return shared.substring(0, length);
+  });
+  return lines.map(line => (line.startsWith(common) ? line.substring(common.length) : line).trimEnd());
+}
+
+/**
+ * Get the marker prefixes to look for
+ * @param {String} markerPrefix - Configured prefix (optional)
+ * @returns {Array<String>}
+ */
+function getMarkerPrefixes(markerPrefix) {
+  return markerPrefix ? [markerPrefix] : DEFAULT_MARKER_PREFIXES;
+}
+
+/**
+ * Render the version template markers in a file's content
+ * @param {String} content - Current file content
+ * @param {String} file - File path (for log messages)
+ * @param {Object} variables - Template variables from buildTemplateVariables
+ * @param {Array<String>} markerPrefixes - Marker prefixes to look for
+ * @returns {String} - Updated content
+ */
+function applyVersionMarkers(content, file, variables, markerPrefixes) {
+  logger.info(`Looking for version template markers...`);
+  logger.debug(`File content preview (first 200 chars):\n${content.substring(0, 200)}...`);
+  
+  const lines = content.split('\n');
+  const outputLines = [];
+  
+  // Process line by line
+  let i = 0;
+  let foundTemplates = 0;
+  while (i < lines.length) {
+    const line = lines[i];
+    const marker = findMarker(line, markerPrefixes);
+    
+    // If this is not a template line, just add it and continue
+    if (!marker) {
+      outputLines.push(line);
+      i++;
+      continue;
+    }
+    
+    // We found a template start marker - the lines it renders get the marker line's indentation,
+    // so a block inside a function or struct literal stays formatted (gofmt and friends)
+    foundTemplates++;
+    const indent = line.match(/^[ \t]*/)[0];
+    logger.info(`Found template marker #${foundTemplates} at line ${i + 1}:\n    ${line}`);
+    
+    // First, add the template line itself to preserve it
+    outputLines.push(line);
+    
+    // Determine if it's a single-line or multi-line template
+    const startMarkerEnd = marker.index + marker.length;
+    const templateEndInSameLine = line.indexOf('%%', startMarkerEnd);
+    
+    let templateContent;
+    let endLineIndex = i;
+    
+    if (templateEndInSameLine !== -1) {
+      // Single-line template
+      templateContent = line.substring(startMarkerEnd, templateEndInSameLine).trim();
+    } else {
+      // Multi-line template - the body keeps its own nesting, relative to its least indented line
+      const firstLine = line.substring(startMarkerEnd).trim();
+      const templateLines = [];
+      
+      let j = i + 1;
+      let foundEndMarker = false;
+      
+      // Look for the end marker on subsequent lines
+      while (j < lines.length) {
+        const currentLine = lines[j];
+        
+        // Check if the current line contains the end marker
+        const endMarkerIndex = currentLine.indexOf('%%');
+        if (endMarkerIndex !== -1) {
+          // We found the end marker
+          templateLines.push(currentLine.substring(0, endMarkerIndex));
+          endLineIndex = j;
+          foundEndMarker = true;
+          
+          // Add all template lines to preserve them
+          for (let k = i + 1; k <= j; k++) {
+            outputLines.push(lines[k]);
+          }
+          
+          break;
+        } else {
+          templateLines.push(currentLine);
+        }
+        
+        j++;
+      }
+      
+      if (!foundEndMarker) {
+        logger.warn(`No template end marker found starting at line ${i + 1} in ${file}`);
+        i++;
+        continue;
+      }
+      
+      // Only blank lines are trimmed off the ends - trim() would take the first line's nesting too
+      templateContent = [firstLine, ...dedentLines(templateLines)].join('\n').replace(/^\n+|\n+$/g, '');
+    }
+    
+    // Jump to the line after the template
+    i = endLineIndex + 1;
+    
+    // Render the template content
+    const renderedTemplate = renderTemplate(templateContent, variables);
+    const renderedLines = renderedTemplate.split('\n');
+    
+    // Count the lines in the current implementation (that will be replaced)
+    // Find how many lines to skip (the implementation we're replacing)
+    let implementationEndLine = i;
+    const implementationLines = [];
+    
+    while (implementationEndLine < lines.length) {
+      // Stop if we find another template marker
+      if (findMarker(lines[implementationEndLine], markerPrefixes)) {
+        break;
+      }
+      
+      implementationLines.push(lines[implementationEndLine]);
+      implementationEndLine++;
+      
+      // If we've found enough lines to replace, stop
+      if (implementationLines.length >= renderedLines.length) {
+        break;
+      }
+    }
+    
+    // Skip the lines we're replacing (don't add them to the output)
+    i += Math.min(renderedLines.length, implementationLines.length);
+    
+    // Add the rendered template (blank lines stay blank rather than picking up trailing whitespace)
+    for (const renderedLine of renderedLines) {
+      outputLines.push(renderedLine ? `${indent}${renderedLine}` : renderedLine);
+    }
+  }
+  
+  return outputLines.join('\n');
+}
+
+/**
+ * How version files can be updated
+ * marker: render the %%release-boss: templates inside the file (the default)
+ * json: set a dotted key, like .version, in a JSON file
+ * plain: the whole file is the version
+ */
+const VERSION_FILE_STRATEGIES = ['marker', 'json', 'plain'];
+
+/**
+ * Normalise a versionFiles entry
+ * Entries are either a path (marker strategy) or { file, strategy, key }.
+ * @param {String|Object} entry - versionFiles entry
+ * @returns {Object} - { file, strategy, key }
+ */
+function normalizeVersionFile(entry) {
+  if (typeof entry === 'string') {
+    return { file: entry, strategy: 'marker', key: null };
+  }
+
+  const strategy = entry.strategy || 'marker';
+  return {
+    ...entry,
+    strategy,
+    key: strategy === 'json' ? (entry.key || '.version') : null
+  };
+}
+
+/**
+ * Set a dotted key in a JSON document, keeping its indentation and trailing newline
+ * @param {String} content - Current file content
+ * @param {String} file - File path (for error messages)
+ * @param {String} key - Dotted key, e.g. .version or .tool.version
+ * @param {String} version - New version
+ * @returns {String} - Updated content
+ */
+function applyJsonVersion(content, file, key, version) {
+  let data;
+  try {
+    data = JSON.parse(content);
+  } catch (error) {
+    throw new Error(`${file} is not valid JSON: ${error.message}`);
+  }
+
+  const segments = key.replace(/^\./, '').split('.');
+  const last = segments.pop();
+  let target = data;
+  for (const segment of segments) {
+    target = target !== null && typeof target === 'object' ? target[segment] : undefined;
+  }
+  if (target === null || typeof target !== 'object' || !(last in target)) {
+    throw new Error(`${file} has no ${key} key to update`);
+  }
+
+  logger.info(`Setting ${key} in ${file}: ${target[last]} → ${version}`);
+  target[last] = version;
+
+  const indent = (content.match(/^[ \t]+(?=")/m) || ['  '])[0];
+  return JSON.stringify(data, null, indent) + (content.endsWith('\n') ? '\n' : '');
+}
+
+/**
+ * Replace a plain version file's contents with the version, keeping a trailing newline
+ * @param {String} content - Current file content
+ * @param {String} version - New version
+ * @returns {String} - Updated content
+ */
+function applyPlainVersion(content, version) {
+  return version + (content.endsWith('\n') || content.length === 0 ? '\n' : '');
+}
+
+/**
+ * What to do with merge conflict markers found in a version or update file
+ * abort: fail the release, naming the file (the default)
+ * ours: keep the side above ======= (the <<<<<<< side)
+ * theirs: keep the side below ======= (the >>>>>>> side)
+ */
+const CONFLICT_STRATEGIES = ['abort', 'ours', 'theirs'];
+
+// A lone ======= is a perfectly good setext heading, so only the opening and closing markers count
+const CONFLICT_START = /^<{7}(?: |$)/;
+const CONFLICT_BASE = /^\|{7}(?: |$)/;
+const CONFLICT_SEPARATOR = /^={7}$/;
+const CONFLICT_END = /^>{7}(?: |$)/;
+
+/**
+ * Find the first merge conflict marker in some content
+ * @param {String} content - File content
+ * @returns {Number} - 1-based line number of the marker, or 0 when there are none
+ */
+function findConflictMarker(content) {
+  const index = content.split('\n').findIndex(line => CONFLICT_START.test(line) || CONFLICT_END.test(line));
+  return index + 1;
+}
+
+/**
+ * Resolve the merge conflict blocks in a file with a conflictStrategy
+ * The diff3 base section (||||||| ...) is dropped with either side.
+ * @param {String} content - File content
+ * @param {String} file - File path (for error messages)
+ * @param {String} strategy - abort, ours or theirs
+ * @param {String} source - Where the content came from (for error messages)
+ * @returns {String} - Content without conflict blocks
+ */
+function resolveConflicts(content, file, strategy = 'abort', source = 'local') {
+  const firstMarker = findConflictMarker(content);
+  if (!firstMarker) {
+    return content;
+  }
+
+  if (strategy === 'abort') {
+    throw new Error(`${file} has merge conflict markers (line ${firstMarker}, read from ${source}) - resolve the conflict and re-run, or set conflictStrategy to ours or theirs`);
+  }
+
+  const output = [];
+  let side = null;
+  let start = 0;
+  content.split('\n').forEach((line, index) => {
+    if (side === null) {
+      if (CONFLICT_START.test(line)) {
+        side = 'ours';
+        start = index + 1;
+      } else if (CONFLICT_END.test(line)) {
+        throw new Error(`${file} has a >>>>>>> on line ${index + 1} without a <<<<<<< before it - resolve the conflict by hand`);
+      } else {
+        output.push(line);
+      }
+    } else if (CONFLICT_BASE.test(line) && side === 'ours') {
+      side = 'base';
+    } else if (CONFLICT_SEPARATOR.test(line) && side !== 'theirs') {
+      side = 'theirs';
+    } else if (CONFLICT_END.test(line) && side === 'theirs') {
+      side = null;
+    } else if (CONFLICT_START.test(line) || CONFLICT_END.test(line)) {
+      throw new Error(`${file} has a conflict starting on line ${start} that doesn't close properly - resolve the conflict by hand`);
+    } else if (side === strategy) {
+      output.push(line);
+    }
+  });
+
+  if (side !== null) {
+    throw new Error(`${file} has a conflict starting on line ${start} that doesn't close properly - resolve the conflict by hand`);
+  }
+
+  logger.warn(`⚠️ ${file} had merge conflict markers - kept the ${strategy === 'ours' ? '<<<<<<<' : '>>>>>>>'} side (conflictStrategy: ${strategy})`);
+  return output.join('\n');
+}
+
+/**
+ * Make sure an updated file has no conflict markers left before it gets written
+ * @param {String} content - Updated file content
+ * @param {String} file - File path (for error messages)
+ */
+function assertNoConflictMarkers(content, file) {
+  const line = findConflictMarker(content);
+  if (line) {
+    throw new Error(`${file} would be written with merge conflict markers (line ${line}) - resolve the conflict and re-run`);
+  }
+}
+
+/**
+ * Update a version file's content with its configured strategy
+ * @param {Object} entry - Normalised versionFiles entry
+ * @param {String} content - Current file content
+ * @param {Object} variables - Template variables from buildTemplateVariables
+ * @param {Array<String>} markerPrefixes - Marker prefixes to look for
+ * @returns {String} - Updated content
+ */
+function applyVersionStrategy(entry, content, variables, markerPrefixes) {
+  switch (entry.strategy) {
+    case 'json':
+      return applyJsonVersion(content, entry.file, entry.key, variables.version);
+    case 'plain':
+      return applyPlainVersion(content, variables.version);
+    case 'marker':
+      return applyVersionMarkers(content, entry.file, variables, markerPrefixes);
+    default:
+      throw new Error(`Unknown version file strategy "${entry.strategy}" for ${entry.file}`);
+  }
+}
 
 /**
- * Process files with inline version templates
- * @param {Array} files - List of files to process
+ * Process version files
+ * @param {Array} files - versionFiles entries: paths, or { file, strategy, key }
  * @param {String} version - New version to inject
  * @param {Object} options - Additional options
  * @param {String} options.releaseBranch - Release branch to check for existing content
+ * @param {Object} options.provider - VCS provider used to read the release branch (optional)
+ * @param {Boolean} options.dryRun - Log the diff instead of writing files
+ * @param {String} options.sha - Commit SHA for the {{sha}} and {{shortSha}} placeholders
+ * @param {String} options.dateFormat - Format for the {{date}} placeholder (default ISO-8601)
+ * @param {String} options.markerPrefix - Template marker prefix (default %%release-boss: or %%release-manager:)
+ * @param {String} options.conflictStrategy - What to do with merge conflict markers: abort (default), ours or theirs
  * @returns {Array} - List of processed files
  */
 async function processVersionFiles(files, version, options = {}) {
-  const [major, minor, patch] = version.split('.');
+  const variables = buildTemplateVariables(version, options);
+  const markerPrefixes = getMarkerPrefixes(options.markerPrefix);
   const processedFiles = [];
   
-  console.log(`Starting version file processing for ${files.length} files with version ${version}`);
-  console.log(`Parsed version parts: major=${major}, minor=${minor}, patch=${patch}`);
+  logger.info(`Starting version file processing for ${files.length} files with version ${version}`);
+  logger.info(`Parsed version parts: major=${variables.major}, minor=${variables.minor}, patch=${variables.patch}`);
   
-  // Set up GitHub client if we're in a GitHub Action
-  let octokit = null;
-  let context = null;
-  let isGitHubAction = false;
-  
-  try {
-    const token = process.env.GITHUB_TOKEN || process.env.INPUT_TOKEN;
-    if (token) {
-      octokit = github.getOctokit(token);
-      context = github.context;
-      isGitHubAction = true;
-      console.log(`✨ GitHub API client initialized - we'll try to avoid conflicts by checking the release branch first 💅`);
-    }
-  } catch (e) {
-    console.log(`Not running in GitHub Actions or token not available: ${e.message}`);
+  // Use the VCS provider (when we have one) to read from the release branch first
+  const provider = options.provider || null;
+  if (provider) {
+    logger.info(`✨ ${provider.name} provider available - we'll try to avoid conflicts by checking the release branch first 💅`);
   }
   
   // Determine which branches to check for existing content
   const releaseBranch = options.releaseBranch || 'release';
   
-  for (const file of files) {
-    console.log(`\nProcessing version file: ${file}`);
+  for (const entry of files.map(normalizeVersionFile)) {
+    const { file } = entry;
+    logger.info(`\nProcessing version file: ${file} (${entry.strategy})`);
     try {
       // Try to get content from GitHub API first to avoid conflicts
       let content = null;
       let contentSource = 'local';
       
-      if (isGitHubAction) {
+      if (provider) {
         try {
-          console.log(`Trying to fetch ${file} from ${releaseBranch} branch first to avoid conflicts...`);
-          const { owner, repo } = context.repo;
-          const { data } = await octokit.rest.repos.getContent({
-            owner,
-            repo,
-            path: file,
-            ref: releaseBranch
-          });
+          logger.info(`Trying to fetch ${file} from ${releaseBranch} branch first to avoid conflicts...`);
+          content = await provider.getFileContent(file, releaseBranch);
           
-          content = Buffer.from(data.content, 'base64').toString('utf8');
-          contentSource = `${releaseBranch} branch`;
-          console.log(`✨ Successfully retrieved ${file} from ${releaseBranch} branch to avoid conflicts!`);
+          if (content !== null) {
+            contentSource = `${releaseBranch} branch`;
+            logger.info(`✨ Successfully retrieved ${file} from ${releaseBranch} branch to avoid conflicts!`);
+          } else {
+            logger.info(`${file} doesn't exist in ${releaseBranch} branch, will use local file instead...`);
+          }
         } catch (error) {
-          console.log(`Couldn't get ${file} from ${releaseBranch} branch: ${error.message}`);
-          console.log(`Will use local file instead...`);
+          logger.info(`Couldn't get ${file} from ${releaseBranch} branch: ${error.message}`);
+          logger.info(`Will use local file instead...`);
         }
       }
       
@@ -71,162 +431,58 @@ async function processVersionFiles(files, version, options = {}) {
         // Verify file exists locally
         try {
           await fs.access(file);
-          console.log(`Local file exists!`);
+          logger.info(`Local file exists!`);
         } catch (err) {
-          console.error(`CRITICAL ERROR: File does not exist or cannot be accessed: ${file}`);
-          console.error(`Error details: ${err.message}`); 
+          logger.error(`CRITICAL ERROR: File does not exist or cannot be accessed: ${file}`);
+          logger.debug(`Error details: ${err.message}`); 
           continue; // Skip to next file
         }
         
         content = await fs.readFile(file, 'utf8');
-        console.log(`File read successfully from local filesystem (${content.length} bytes)`);
+        logger.debug(`File read successfully from local filesystem (${content.length} bytes)`);
       } else {
-        console.log(`Using content from ${contentSource} to avoid conflicts 💅`);
+        logger.info(`Using content from ${contentSource} to avoid conflicts 💅`);
       }
       
-      console.log(`Looking for version template markers...`);
-      console.log(`File content preview (first 200 chars):\n${content.substring(0, 200)}...`);
+      content = resolveConflicts(content, file, options.conflictStrategy, contentSource);
+      const updatedContent = applyVersionStrategy(entry, content, variables, markerPrefixes);
+      assertNoConflictMarkers(updatedContent, file);
       
-      const lines = content.split('\n');
-      const outputLines = [];
-      
-      // Process line by line
-      let i = 0;
-      let foundTemplates = 0;
-      while (i < lines.length) {
-        const line = lines[i];
-        const templateStartIndex = line.indexOf('%%release-boss:');
-        
-        // If this is not a template line, just add it and continue
-        if (templateStartIndex === -1) {
-          outputLines.push(line);
-          i++;
-          continue;
-        }
-        
-        // We found a template start marker
-        foundTemplates++;
-        console.log(`Found template marker #${foundTemplates} at line ${i + 1}:\n    ${line}`);
-        
-        // First, add the template line itself to preserve it
-        outputLines.push(line);
-        
-        // Determine if it's a single-line or multi-line template
-        const startMarkerEnd = templateStartIndex + '%%release-boss:'.length;
-        const templateEndInSameLine = line.indexOf('%%', startMarkerEnd);
-        
-        let templateContent;
-        let endLineIndex = i;
-        
-        if (templateEndInSameLine !== -1) {
-          // Single-line template
-          templateContent = line.substring(startMarkerEnd, templateEndInSameLine).trim();
-        } else {
-          // Multi-line template
-          const templateLines = [];
-          templateLines.push(line.substring(startMarkerEnd).trim());
-          
-          let j = i + 1;
-          let foundEndMarker = false;
-          
-          // Look for the end marker on subsequent lines
-          while (j < lines.length) {
-            const currentLine = lines[j];
-            
-            // Check if the current line contains the end marker
-            const endMarkerIndex = currentLine.indexOf('%%');
-            if (endMarkerIndex !== -1) {
-              // We found the end marker
-              templateLines.push(currentLine.substring(0, endMarkerIndex).trim());
-              endLineIndex = j;
-              foundEndMarker = true;
-              
-              // Add all template lines to preserve them
-              for (let k = i + 1; k <= j; k++) {
-                outputLines.push(lines[k]);
-              }
-              
-              break;
-            } else {
-              templateLines.push(currentLine.trim());
-            }
-            
-            j++;
-          }
-          
-          if (!foundEndMarker) {
-            console.warn(`No template end marker found starting at line ${i + 1} in ${file}`);
-            i++;
-            continue;
-          }
-          
-          templateContent = templateLines.join('\n').trim();
-        }
-        
-        // Jump to the line after the template
-        i = endLineIndex + 1;
-        
-        // Render the template content
-        const renderedTemplate = renderTemplate(templateContent, version, major, minor, patch);
-        const renderedLines = renderedTemplate.split('\n');
-        
-        // Count the lines in the current implementation (that will be replaced)
-        // Find how many lines to skip (the implementation we're replacing)
-        let implementationEndLine = i;
-        const implementationLines = [];
-        
-        while (implementationEndLine < lines.length) {
-          // Stop if we find another template marker
-          if (lines[implementationEndLine].includes('%%release-boss:')) {
-            break;
-          }
-          
-          implementationLines.push(lines[implementationEndLine]);
-          implementationEndLine++;
-          
-          // If we've found enough lines to replace, stop
-          if (implementationLines.length >= renderedLines.length) {
-            break;
-          }
-        }
-        
-        // Skip the lines we're replacing (don't add them to the output)
-        i += Math.min(renderedLines.length, implementationLines.length);
-        
-        // Add the rendered template
-        for (const renderedLine of renderedLines) {
-          outputLines.push(renderedLine);
-        }
+      // In dry-run mode we just show what would change and move on
+      if (options.dryRun) {
+        logDryRunDiff(file, content, updatedContent);
+        processedFiles.push(path.resolve(file));
+        continue;
       }
       
       // Write the updated content back to the file
-      console.log(`\nSaving updated file content to ${file}...`);
-      console.log(`Final file stats: ${outputLines.length} lines, ${outputLines.join('\n').length} bytes`);
+      logger.info(`\nSaving updated file content to ${file}...`);
+      logger.debug(`Final file stats: ${updatedContent.split('\n').length} lines, ${updatedContent.length} bytes`);
       
       try {
-        await fs.writeFile(file, outputLines.join('\n'), 'utf8');
-        console.log(`SUCCESS! Updated version references in ${file}`);
+        await fs.writeFile(file, updatedContent, 'utf8');
+        logger.info(`SUCCESS! Updated version references in ${file}`);
         
         // Verify the file was written correctly
         const verifyContent = await fs.readFile(file, 'utf8');
-        console.log(`Verification: File size after save is ${verifyContent.length} bytes`);
+        logger.debug(`Verification: File size after save is ${verifyContent.length} bytes`);
         
         // Get the absolute path for reporting
         const absPath = path.resolve(file);
-        console.log(`Absolute path for tracking: ${absPath}`);
+        logger.info(`Absolute path for tracking: ${absPath}`);
         
         processedFiles.push(absPath);
-        console.log(`Added to processed files list: ${absPath}`);
-        console.log(`Current processed files list: ${processedFiles.join(', ')}`);
+        logger.info(`Added to processed files list: ${absPath}`);
+        logger.info(`Current processed files list: ${processedFiles.join(', ')}`);
       } catch (saveError) {
-        console.error(`CRITICAL ERROR saving file ${file}: ${saveError.message}`);
-        console.error(`File system error details: ${JSON.stringify(saveError)}`);
+        logger.error(`CRITICAL ERROR saving file ${file}: ${saveError.message}`);
+        logger.debug(`File system error details: ${JSON.stringify(saveError)}`);
         throw saveError;
       }
       
     } catch (error) {
-      console.error(`Error processing version file ${file}: ${error.message}`);
-      console.error(`Stack trace: ${error.stack}`);
+      logger.error(`Error processing version file ${file}: ${error.message}`);
+      logger.debug(`Stack trace: ${error.stack}`);
       throw error;
     }
   }
@@ -238,31 +494,36 @@ async function processVersionFiles(files, version, options = {}) {
  * Process template files
  * @param {Array} files - List of template files to process
  * @param {String} version - New version to inject
+ * @param {Object} options - Additional options
+ * @param {Boolean} options.dryRun - Log the diff instead of writing files
+ * @param {String} options.sha - Commit SHA for the {{sha}} and {{shortSha}} placeholders
+ * @param {String} options.dateFormat - Format for the {{date}} placeholder (default ISO-8601)
+ * @param {String} options.conflictStrategy - What to do with merge conflict markers: abort (default), ours or theirs
  * @returns {Array} - List of generated output files
  */
-async function processTemplateFiles(files, version) {
-  const [major, minor, patch] = version.split('.');
+async function processTemplateFiles(files, version, options = {}) {
+  const variables = buildTemplateVariables(version, options);
   const generatedFiles = [];
   
-  console.log(`\nStarting template file processing for ${files.length} files with version ${version}`);
+  logger.info(`\nStarting template file processing for ${files.length} files with version ${version}`);
   
   for (const templateFile of files) {
-    console.log(`\nProcessing template file: ${templateFile}`);
+    logger.info(`\nProcessing template file: ${templateFile}`);
     try {
       // Verify file exists
       try {
         await fs.access(templateFile);
-        console.log(`Template file exists! Time to transform you, honey!`);
+        logger.info(`Template file exists! Time to transform you, honey!`);
       } catch (err) {
-        console.error(`CRITICAL ERROR: Template file does not exist or cannot be accessed: ${templateFile}`);
-        console.error(`Error details: ${err.message}`);
+        logger.error(`CRITICAL ERROR: Template file does not exist or cannot be accessed: ${templateFile}`);
+        logger.debug(`Error details: ${err.message}`);
         continue; // Skip to next file
       }
       
-      console.log(`Reading template content...`);
-      const content = await fs.readFile(templateFile, 'utf8');
-      console.log(`Template read successfully (${content.length} bytes)`);
-      console.log(`Template preview:\n${content.substring(0, 200)}...`);
+      logger.info(`Reading template content...`);
+      const content = resolveConflicts(await fs.readFile(templateFile, 'utf8'), templateFile, options.conflictStrategy);
+      logger.debug(`Template read successfully (${content.length} bytes)`);
+      logger.debug(`Template preview:\n${content.substring(0, 200)}...`);
       
       // Determine output file name based on the template file name
       let outputFile = templateFile;
@@ -270,55 +531,62 @@ async function processTemplateFiles(files, version) {
       if (templateFile.includes('.tpl')) {
         // Remove '.tpl' from the filename
         outputFile = templateFile.replace(/\.tpl/g, '');
-        console.log(`Template has .tpl extension - output file will be: ${outputFile}`);
+        logger.info(`Template has .tpl extension - output file will be: ${outputFile}`);
       } else {
         // Add '.new' to the filename
         const parsedPath = path.parse(templateFile);
         outputFile = path.join(parsedPath.dir, `${parsedPath.name}.new${parsedPath.ext}`);
-        console.log(`Template doesn't have .tpl extension - output file will be: ${outputFile}`);
+        logger.info(`Template doesn't have .tpl extension - output file will be: ${outputFile}`);
       }
       
       // Get absolute paths for proper tracking
       const absTemplateFile = path.resolve(templateFile);
       const absOutputFile = path.resolve(outputFile);
       
-      console.log(`Processing template file: ${absTemplateFile} -> ${absOutputFile}`);
+      logger.info(`Processing template file: ${absTemplateFile} -> ${absOutputFile}`);
       
       // Render the entire file content by replacing template markers
-      console.log(`Rendering template with version ${version}...`);
-      const renderedContent = renderTemplate(
-        content,
-        version,
-        major,
-        minor,
-        patch
-      );
-      console.log(`Template rendering complete! (${renderedContent.length} bytes)`);
-      console.log(`Rendered content preview:\n${renderedContent.substring(0, 200)}...`);
+      logger.info(`Rendering template with version ${version}...`);
+      const renderedContent = renderTemplate(content, variables);
+      logger.debug(`Template rendering complete! (${renderedContent.length} bytes)`);
+      logger.debug(`Rendered content preview:\n${renderedContent.substring(0, 200)}...`);
+      
+      // In dry-run mode we compare against the current output file instead of writing it
+      if (options.dryRun) {
+        let existingOutput = '';
+        try {
+          existingOutput = await fs.readFile(outputFile, 'utf8');
+        } catch (err) {
+          logger.info(`Output file ${outputFile} doesn't exist yet - it would be created`);
+        }
+        logDryRunDiff(outputFile, existingOutput, renderedContent);
+        generatedFiles.push(absOutputFile);
+        continue;
+      }
       
       // Write to output file
-      console.log(`Saving rendered content to ${absOutputFile}...`);
+      logger.info(`Saving rendered content to ${absOutputFile}...`);
       try {
         await fs.writeFile(outputFile, renderedContent, 'utf8');
-        console.log(`SUCCESS! Processed template file: ${templateFile} -> ${outputFile}`);
+        logger.info(`SUCCESS! Processed template file: ${templateFile} -> ${outputFile}`);
         
         // Verify the file was written correctly
         const verifyContent = await fs.readFile(outputFile, 'utf8');
-        console.log(`Verification: Output file size is ${verifyContent.length} bytes`);
+        logger.debug(`Verification: Output file size is ${verifyContent.length} bytes`);
         
         // Add to the list of generated files (using absolute path)
         generatedFiles.push(absOutputFile);
-        console.log(`Added to generated files list: ${absOutputFile}`);
-        console.log(`Current generated files list: ${generatedFiles.join(', ')}`);
+        logger.info(`Added to generated files list: ${absOutputFile}`);
+        logger.info(`Current generated files list: ${generatedFiles.join(', ')}`);
       } catch (saveError) {
-        console.error(`CRITICAL ERROR saving output file ${outputFile}: ${saveError.message}`);
-        console.error(`File system error details: ${JSON.stringify(saveError)}`);
+        logger.error(`CRITICAL ERROR saving output file ${outputFile}: ${saveError.message}`);
+        logger.debug(`File system error details: ${JSON.stringify(saveError)}`);
         throw saveError;
       }
       
     } catch (error) {
-      console.error(`Error processing template file ${templateFile}: ${error.message}`);
-      console.error(`Stack trace: ${error.stack}`);
+      logger.error(`Error processing template file ${templateFile}: ${error.message}`);
+      logger.debug(`Stack trace: ${error.stack}`);
       throw error;
     }
   }
@@ -326,21 +594,65 @@ async function processTemplateFiles(files, version) {
   return generatedFiles;
 }
 
+/**
+ * Build the values available to {{placeholder}} templates
+ * @param {String} version - Full version string (x.y.z or x.y.z-pre)
+ * @param {Object} options - Template options
+ * @param {String} options.sha - Commit SHA being released (optional)
+ * @param {String} options.dateFormat - Format for {{date}} (default ISO-8601)
+ * @param {Date} options.date - Date to use for {{date}} (defaults to now)
+ * @returns {Object} - Placeholder name → value
+ */
+function buildTemplateVariables(version, options = {}) {
+  const parsed = semver.parse(version);
+  const [major, minor, patch] = parsed
+    ? [parsed.major, parsed.minor, parsed.patch].map(String)
+    : version.split('.');
+
+  const variables = {
+    version,
+    major,
+    minor,
+    patch,
+    prerelease: parsed ? parsed.prerelease.join('.') : '',
+    date: formatDate(options.date || new Date(), options.dateFormat)
+  };
+
+  // Only offer the SHA placeholders when we actually know the SHA
+  if (options.sha) {
+    variables.sha = options.sha;
+    variables.shortSha = options.sha.substring(0, 7);
+  }
+
+  return variables;
+}
+
 /**
  * Render a template with version values
+ * Unknown placeholders are left exactly as they are.
  * @param {String} template - Template content
- * @param {String} version - Full version string (x.y.z)
- * @param {String} major - Major version
- * @param {String} minor - Minor version
- * @param {String} patch - Patch version
+ * @param {Object} variables - Placeholder values from buildTemplateVariables
  * @returns {String} - Rendered template
  */
-function renderTemplate(template, version, major, minor, patch) {
-  return template
-    .replace(/\{\{version\}\}/g, version)
-    .replace(/\{\{major\}\}/g, major)
-    .replace(/\{\{minor\}\}/g, minor)
-    .replace(/\{\{patch\}\}/g, patch);
+function renderTemplate(template, variables) {
+  return template.replace(/\{\{(\w+)\}\}/g, (placeholder, name) =>
+    Object.prototype.hasOwnProperty.call(variables, name) ? variables[name] : placeholder
+  );
+}
+
+/**
+ * Log the change a dry run would have written to a file
+ * @param {String} file - File that would be written
+ * @param {String} before - Current content
+ * @param {String} after - Content that would be written
+ */
+function logDryRunDiff(file, before, after) {
+  const diff = diffLines(before, after, file);
+  if (diff) {
+    logger.info(`🔍 [dry-run] Would write ${file}:\n${diff}`);
+  } else {
+    logger.info(`🔍 [dry-run] ${file} would be unchanged`);
+  }
 }
 
 /**
@@ -349,35 +661,29 @@ function renderTemplate(template, version, major, minor, patch) {
  * @param {String} version - New version to inject
  * @param {Object} options - Additional options
  * @param {String} options.releaseBranch - Release branch to check for existing content
+ * @param {Object} options.provider - VCS provider used to read the release branch (optional)
+ * @param {Boolean} options.dryRun - Log the diff instead of writing files
+ * @param {String} options.sha - Commit SHA for the {{sha}} and {{shortSha}} placeholders
+ * @param {String} options.dateFormat - Format for the {{date}} placeholder (default ISO-8601)
+ * @param {String} options.conflictStrategy - What to do with merge conflict markers: abort (default), ours or theirs
  * @returns {Array} - List of processed files
  */
 async function processUpdateFiles(files, version, options = {}) {
   if (!files || !Array.isArray(files) || files.length === 0) {
-    console.log('No update files to process');
+    logger.info('No update files to process');
     return [];
   }
 
-  const [major, minor, patch] = version.split('.');
+  const variables = buildTemplateVariables(version, options);
   const processedFiles = [];
   
-  console.log(`Starting update file processing for ${files.length} files with version ${version}`);
-  console.log(`Parsed version parts: major=${major}, minor=${minor}, patch=${patch}`);
-  
-  // Set up GitHub client if we're in a GitHub Action
-  let octokit = null;
-  let context = null;
-  let isGitHubAction = false;
+  logger.info(`Starting update file processing for ${files.length} files with version ${version}`);
+  logger.info(`Parsed version parts: major=${variables.major}, minor=${variables.minor}, patch=${variables.patch}`);
   
-  try {
-    const token = process.env.GITHUB_TOKEN || process.env.INPUT_TOKEN;
-    if (token) {
-      octokit = github.getOctokit(token);
-      context = github.context;
-      isGitHubAction = true;
-      console.log(`✨ GitHub API client initialized - we'll try to avoid conflicts by checking the release branch first 💅`);
-    }
-  } catch (e) {
-    console.log(`Not running in GitHub Actions or token not available: ${e.message}`);
+  // Use the VCS provider (when we have one) to read from the release branch first
+  const provider = options.provider || null;
+  if (provider) {
+    logger.info(`✨ ${provider.name} provider available - we'll try to avoid conflicts by checking the release branch first 💅`);
   }
   
   // Determine which branches to check for existing content
@@ -385,35 +691,29 @@ async function processUpdateFiles(files, version, options = {}) {
   
   for (const fileConfig of files) {
     if (!fileConfig.file || !fileConfig.findLine || !fileConfig.replaceLine) {
-      console.log(`Skipping invalid update file config: ${JSON.stringify(fileConfig)}`);
+      logger.info(`Skipping invalid update file config: ${JSON.stringify(fileConfig)}`);
       continue;
     }
     
     const filePath = fileConfig.file;
-    console.log(`\nProcessing update file: ${filePath}`);
+    logger.info(`\nProcessing update file: ${filePath}`);
     
     try {
       // Try to get content from GitHub API first to avoid conflicts
       let content = null;
       let contentSource = 'local';
       
-      if (isGitHubAction) {
+      if (provider) {
         try {
-          console.log(`Trying to fetch ${filePath} from ${releaseBranch} branch first to avoid conflicts...`);
-          const { data: fileData } = await octokit.rest.repos.getContent({
-            owner: context.repo.owner,
-            repo: context.repo.repo,
-            path: filePath,
-            ref: releaseBranch
-          });
+          logger.info(`Trying to fetch ${filePath} from ${releaseBranch} branch first to avoid conflicts...`);
+          content = await provider.getFileContent(filePath, releaseBranch);
           
-          if (fileData && fileData.content) {
-            content = Buffer.from(fileData.content, 'base64').toString('utf8');
-            contentSource = 'github';
-            console.log(`Successfully fetched content from GitHub API (${releaseBranch} branch) 💅`);
+          if (content !== null) {
+            contentSource = provider.name;
+            logger.info(`Successfully fetched content from ${provider.name} API (${releaseBranch} branch) 💅`);
           }
         } catch (error) {
-          console.log(`Couldn't fetch from GitHub API: ${error.message}. Will try local file instead.`);
+          logger.info(`Couldn't fetch from ${provider.name} API: ${error.message}. Will try local file instead.`);
         }
       }
       
@@ -422,10 +722,10 @@ async function processUpdateFiles(files, version, options = {}) {
         try {
           content = await fs.readFile(filePath, 'utf8');
           contentSource = 'local';
-          console.log(`Read file content from local filesystem`);
+          logger.info(`Read file content from local filesystem`);
         } catch (error) {
           if (error.code === 'ENOENT') {
-            console.log(`File doesn't exist locally, will create it`);
+            logger.info(`File doesn't exist locally, will create it`);
             content = '';
           } else {
             throw error;
@@ -433,15 +733,17 @@ async function processUpdateFiles(files, version, options = {}) {
         }
       }
       
+      content = resolveConflicts(content, filePath, options.conflictStrategy, contentSource);
+      
       // Process the file content by searching for the line and replacing it
       const findLine = fileConfig.findLine;
       let replaceLine = fileConfig.replaceLine;
       
       // Render the replacement line with version variables
-      replaceLine = renderTemplate(replaceLine, version, major, minor, patch);
+      replaceLine = renderTemplate(replaceLine, variables);
       
-      console.log(`Searching for line: ${findLine}`);
-      console.log(`Replacing with: ${replaceLine}`);
+      logger.info(`Searching for line: ${findLine}`);
+      logger.info(`Replacing with: ${replaceLine}`);
       
       // Split content into lines, find and replace the target line
       const lines = content.split('\n');
@@ -449,7 +751,7 @@ async function processUpdateFiles(files, version, options = {}) {
       
       for (let i = 0; i < lines.length; i++) {
         if (lines[i].includes(findLine)) {
-          console.log(`Found match on line ${i+1}: ${lines[i]}`);
+          logger.info(`Found match on line ${i+1}: ${lines[i]}`);
           lines[i] = replaceLine;
           found = true;
           break;
@@ -457,20 +759,27 @@ async function processUpdateFiles(files, version, options = {}) {
       }
       
       if (!found) {
-        console.log(`Warning: Could not find line containing '${findLine}' in ${filePath}`);
+        logger.warn(`Warning: Could not find line containing '${findLine}' in ${filePath}`);
         continue;
       }
       
       // Join the lines back together
       const updatedContent = lines.join('\n');
+      assertNoConflictMarkers(updatedContent, filePath);
+      
+      if (options.dryRun) {
+        logDryRunDiff(filePath, content, updatedContent);
+        processedFiles.push(filePath);
+        continue;
+      }
       
       // Write the updated content back to the file
       await fs.writeFile(filePath, updatedContent, 'utf8');
-      console.log(`Updated ${filePath} with new version information 💅`);
+      logger.info(`Updated ${filePath} with new version information 💅`);
       
       processedFiles.push(filePath);
     } catch (error) {
-      console.error(`Error processing update file ${filePath}: ${error.message}`);
+      logger.error(`Error processing update file ${filePath}: ${error.message}`);
       throw error;
     }
   }
@@ -480,6 +789,16 @@ async function processUpdateFiles(files, version, options = {}) {
 
 module.exports = {
   processVersionFiles,
+  normalizeVersionFile,
   processTemplateFiles,
-  processUpdateFiles
+  processUpdateFiles,
+  buildTemplateVariables,
+  renderTemplate,
+  getMarkerPrefixes,
+  findMarker,
+  resolveConflicts,
+  DEFAULT_MARKER_PREFIXES,
+  TEMPLATE_PLACEHOLDERS,
+  VERSION_FILE_STRATEGIES,
+  CONFLICT_STRATEGIES
 };
@@ -0,0 +1,243 @@
+const fs = require('fs').promises;
+const { getConfig, findConfigFile, validateConfig } = require('../utils/config');
+const { resolvePackages } = require('./packages');
+const { getMarkerPrefixes, findMarker, normalizeVersionFile, TEMPLATE_PLACEHOLDERS } = require('./templateProcessor');
+
+/**
+ * A problem found while validating
+ * @typedef {Object} ValidationProblem
+ * @property {String} file - File the problem is in
+ * @property {Number|null} line - 1-based line number, when we know it
+ * @property {String} message - What's wrong
+ */
+
+/**
+ * Check the config and every file it references without releasing anything
+ * Every problem is collected so one run shows the whole list.
+ * @param {String} configFilePath - Config file to check (optional, found like getConfig does)
+ * @returns {Promise<Array<ValidationProblem>>} - Problems found (empty when everything is fine)
+ */
+async function validateProject(configFilePath) {
+  const configFile = configFilePath || await findConfigFile();
+  const problems = [];
+
+  try {
+    await fs.access(configFile);
+  } catch {
+    problems.push({ file: configFile, line: null, message: 'config file not found' });
+    return problems;
+  }
+
+  let config;
+  try {
+    config = await getConfig(configFile);
+  } catch (error) {
+    const mark = error.cause && error.cause.mark;
+    const message = mark ? `invalid YAML: ${error.cause.reason}` : error.message;
+    problems.push({ file: configFile, line: mark ? mark.line + 1 : null, message });
+    return problems;
+  }
+
+  try {
+    validateConfig(config);
+  } catch (error) {
+    problems.push({ file: configFile, line: null, message: error.message });
+  }
+
+  // Only check the files when the lists are shaped well enough to walk
+  const markerPrefixes = getMarkerPrefixes(typeof config.markerPrefix === 'string' ? config.markerPrefix : null);
+  const fileSets = [{ label: '', ...config }];
+  try {
+    for (const pkg of resolvePackages(config)) {
+      fileSets.push({ label: ` (package ${pkg.name})`, ...pkg });
+    }
+  } catch (error) {
+    // Bad package entries were already reported by validateConfig
+  }
+
+  for (const fileSet of fileSets) {
+    for (const entry of asArray(fileSet.versionFiles)) {
+      if (!entry || (typeof entry !== 'string' && !entry.file)) {
+        continue;
+      }
+
+      const { file, strategy, key } = normalizeVersionFile(entry);
+      const content = await readReferencedFile(file, `versionFiles${fileSet.label}`, configFile, problems);
+      if (content === null) {
+        continue;
+      }
+
+      if (strategy === 'json') {
+        problems.push(...checkJsonVersionFile(file, content, key));
+      } else if (strategy === 'marker') {
+        problems.push(...checkVersionFile(file, content, markerPrefixes));
+      }
+    }
+
+    for (const file of asArray(fileSet.templateFiles)) {
+      const content = await readReferencedFile(file, `templateFiles${fileSet.label}`, configFile, problems);
+      if (content !== null) {
+        problems.push(...checkPlaceholders(file, content, 1));
+      }
+    }
+
+    for (const fileConfig of asArray(fileSet.updateFiles)) {
+      if (!fileConfig || !fileConfig.file || !fileConfig.findLine || !fileConfig.replaceLine) {
+        problems.push({ file: configFile, line: null, message: `updateFiles${fileSet.label} entries need file, findLine and replaceLine: ${JSON.stringify(fileConfig)}` });
+        continue;
+      }
+
+      const content = await readReferencedFile(fileConfig.file, `updateFiles${fileSet.label}`, configFile, problems);
+      if (content === null) {
+        continue;
+      }
+
+      const lineIndex = content.split('\n').findIndex(line => line.includes(fileConfig.findLine));
+      if (lineIndex === -1) {
+        problems.push({ file: fileConfig.file, line: null, message: `no line contains findLine "${fileConfig.findLine}"` });
+      }
+
+      for (const problem of checkPlaceholders(configFile, fileConfig.replaceLine, null)) {
+        problems.push({ ...problem, message: `replaceLine for ${fileConfig.file}: ${problem.message}` });
+      }
+    }
+  }
+
+  return problems;
+}
+
+/**
+ * Treat a missing or malformed file list as empty (validateConfig reports the malformed ones)
+ * @param {*} list - Configured file list
+ * @returns {Array}
+ */
+function asArray(list) {
+  return Array.isArray(list) ? list : [];
+}
+
+/**
+ * Read a file the config points at, recording a problem when it isn't there
+ * @param {String} file - File to read
+ * @param {String} setting - Config setting that references it, for the message
+ * @param {String} configFile - Config file the setting lives in
+ * @param {Array<ValidationProblem>} problems - Problems found so far
+ * @returns {Promise<String|null>} - File content, or null when it can't be read
+ */
+async function readReferencedFile(file, setting, configFile, problems) {
+  try {
+    return await fs.readFile(file, 'utf8');
+  } catch (error) {
+    const reason = error.code === 'ENOENT' ? 'does not exist' : `can't be read (${error.message})`;
+    problems.push({ file: configFile, line: null, message: `${setting} references ${file}, which ${reason}` });
+    return null;
+  }
+}
+
+/**
+ * Check a version file's template markers are closed and only use known placeholders
+ * @param {String} file - File being checked
+ * @param {String} content - File content
+ * @param {Array<String>} markerPrefixes - Marker prefixes to look for
+ * @returns {Array<ValidationProblem>}
+ */
+function checkVersionFile(file, content, markerPrefixes) {
+  const lines = content.split('\n');
+  const problems = [];
+
+  for (let i = 0; i < lines.length; i++) {
+    const marker = findMarker(lines[i], markerPrefixes);
+    if (!marker) {
+      continue;
+    }
+
+    const startMarkerEnd = marker.index + marker.length;
+    const endInSameLine = lines[i].indexOf('%%', startMarkerEnd);
+    if (endInSameLine !== -1) {
+      problems.push(...checkPlaceholders(file, lines[i].substring(startMarkerEnd, endInSameLine), i + 1));
+      continue;
+    }
+
+    // Multi-line template - it ends at the next %%, which mustn't be another opening marker
+    let end = i + 1;
+    while (end < lines.length && !lines[end].includes('%%')) {
+      end++;
+    }
+
+    if (end === lines.length || findMarker(lines[end], markerPrefixes)) {
+      problems.push({ file, line: i + 1, message: 'template marker is never closed with %%' });
+      continue;
+    }
+
+    const template = [lines[i].substring(startMarkerEnd), ...lines.slice(i + 1, end), lines[end].substring(0, lines[end].indexOf('%%'))].join('\n');
+    problems.push(...checkPlaceholders(file, template, i + 1));
+    i = end;
+  }
+
+  return problems;
+}
+
+/**
+ * Check a json-strategy version file parses and has the key we'll update
+ * @param {String} file - File being checked
+ * @param {String} content - File content
+ * @param {String} key - Dotted key, e.g. .version
+ * @returns {Array<ValidationProblem>}
+ */
+function checkJsonVersionFile(file, content, key) {
+  let data;
+  try {
+    data = JSON.parse(content);
+  } catch (error) {
+    return [{ file, line: null, message: `invalid JSON: ${error.message}` }];
+  }
+
+  const segments = key.replace(/^\./, '').split('.');
+  const last = segments.pop();
+  const target = segments.reduce((value, segment) => (value !== null && typeof value === 'object' ? value[segment] : undefined), data);
+  if (target === null || typeof target !== 'object' || !(last in target)) {
+    return [{ file, line: null, message: `no ${key} key to update` }];
+  }
+
+  return [];
+}
+
+/**
+ * Find {{placeholders}} the template engine can't fill in
+ * @param {String} file - File being checked
+ * @param {String} text - Template text
+ * @param {Number|null} firstLine - Line number the text starts on (null when unknown)
+ * @returns {Array<ValidationProblem>}
+ */
+function checkPlaceholders(file, text, firstLine) {
+  const problems = [];
+
+  text.split('\n').forEach((line, offset) => {
+    for (const match of line.matchAll(/\{\{(\w+)\}\}/g)) {
+      if (!TEMPLATE_PLACEHOLDERS.includes(match[1])) {
+        problems.push({
+          file,
+          line: firstLine === null ? null : firstLine + offset,
+          message: `unknown placeholder {{${match[1]}}} (available: ${TEMPLATE_PLACEHOLDERS.join(', ')})`
+        });
+      }
+    }
+  });
+
+  return problems;
+}
+
+/**
+ * Format a problem like compilers do: file:line: message
+ * @param {ValidationProblem} problem
+ * @returns {String}
+ */
+function formatProblem(problem) {
+  return `${problem.file}${problem.line ? `:${problem.line}` : ''}: ${problem.message}`;
+}
+
+module.exports = {
+  validateProject,
+  checkVersionFile,
+  checkPlaceholders,
+  formatProblem
+};
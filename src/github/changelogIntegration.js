@@ -10,6 +10,7 @@ const {
   updatePRDescriptionWithChangelog,
   generateFileChangelog
 } = require('./changelogTable');
+const { logger } = require('../utils/logger');
 
 /**
  * Create a new PR with a changelog table
@@ -41,7 +42,7 @@ async function createPRWithChangelog(octokit, context, newVersion, commits, conf
     base: config.releaseBranch
   });
   
-  console.log(`Created PR #${newPR.number} with changelog table! 💁‍♀️`);
+  logger.info(`Created PR #${newPR.number} with changelog table! 💁‍♀️`);
   
   return {
     prNumber: newPR.number,
@@ -85,7 +86,7 @@ async function updatePRWithChangelog(octokit, context, prNumber, newVersion, com
     body: updatedBody
   });
   
-  console.log(`Updated PR #${prNumber} with new changelog entries! 💅`);
+  logger.info(`Updated PR #${prNumber} with new changelog entries! 💅`);
   
   return {
     prNumber,
@@ -105,7 +106,7 @@ function generateChangelogFile(commits, newVersion, baseContent = '', config) {
   if (!config.changelogPath) return null;
   
   // Generate changelog content
-  const changelogContent = generateFileChangelog(commits, newVersion, baseContent);
+  const changelogContent = generateFileChangelog(commits, newVersion, baseContent, { header: config.changelogHeader });
   
   return {
     path: config.changelogPath,
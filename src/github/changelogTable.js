@@ -5,6 +5,7 @@
 
 // Import the conventional-commits-parser for robust parsing
 const conventionalCommitsParser = require('conventional-commits-parser');
+const { logger } = require('../utils/logger');
 
 /**
  * Generate a changelog table from commits
@@ -15,7 +16,7 @@ const conventionalCommitsParser = require('conventional-commits-parser');
 function generateChangelogTable(commits, config = {}) {
   // Ensure commits is an array
   if (!Array.isArray(commits)) {
-    console.log('Warning: commits is not an array in generateChangelogTable! 💅 Type:', typeof commits);
+    logger.warn(`Warning: commits is not an array in generateChangelogTable! 💅 Type: ${typeof commits}`);
     commits = [];
   }
   
@@ -31,7 +32,7 @@ function generateChangelogTable(commits, config = {}) {
   const rows = commits.map(entry => {
     // Skip invalid entries
     if (!entry || typeof entry !== 'object') {
-      console.log('Warning: Invalid entry in entries, skipping 💅', entry);
+      logger.warn('Warning: Invalid entry in entries, skipping 💅', { entry });
       return null;
     }
     
@@ -56,7 +57,7 @@ function generateChangelogTable(commits, config = {}) {
 function commitsToChangelogEntries(commits) {
   // Ensure commits is an array
   if (!Array.isArray(commits)) {
-    console.log('Warning: commits is not an array! 💅 Type:', typeof commits);
+    logger.warn(`Warning: commits is not an array! 💅 Type: ${typeof commits}`);
     return [];
   }
   
@@ -75,7 +76,7 @@ function commitsToChangelogEntries(commits) {
     
     // Check if commit has message property
     if (!commit.message && !commit.subject) {
-      console.log('Warning: commit object has no message or subject property! 💅', commit);
+      logger.warn('Warning: commit object has no message or subject property! 💅', { commit });
       return {
         type: commit.type || 'unknown',
         scope: commit.scope || '',
@@ -99,14 +100,14 @@ function commitsToChangelogEntries(commits) {
         const parsed = conventionalCommitsParser.sync(message, {
           headerPattern: /^(\w*)(?:\(([\w\$\.\-\*\s]*)\))?\: (.*)$/,
           headerCorrespondence: ['type', 'scope', 'subject'],
-          noteKeywords: ['BREAKING CHANGE', 'BREAKING-CHANGE'],
+          noteKeywords: ['BREAKING CHANGE', 'BREAKING-CHANGE', 'BREAKING CHANGES', 'BREAKING-CHANGES'],
           revertPattern: /^revert:\s([\s\S]*?)/,
           revertCorrespondence: ['header'],
           issuePrefixes: ['#']
         });
         
         if (parsed && parsed.type) {
-          console.log(`Parsed commit message: type=${parsed.type}, subject=${parsed.subject || ''} 💅`);
+          logger.info(`Parsed commit message: type=${parsed.type}, subject=${parsed.subject || ''} 💅`);
           return {
             type: parsed.type,
             scope: parsed.scope || '',
@@ -117,7 +118,7 @@ function commitsToChangelogEntries(commits) {
           };
         }
       } catch (error) {
-        console.log(`Error parsing commit message: ${error.message} 💁‍♀️`);
+        logger.info(`Error parsing commit message: ${error.message} 💁‍♀️`);
       }
     }
     
@@ -141,7 +142,7 @@ function commitsToChangelogEntries(commits) {
 function extractChangelogTable(description, config = {}) {
   // Check if description is valid
   if (!description || typeof description !== 'string') {
-    console.log('Warning: PR description is not a valid string! 💅 Type:', typeof description);
+    logger.warn(`Warning: PR description is not a valid string! 💅 Type: ${typeof description}`);
     return null;
   }
   
@@ -154,7 +155,7 @@ function extractChangelogTable(description, config = {}) {
     const match = description.match(tableRegex);
     return match ? match[1].trim() : null;
   } catch (error) {
-    console.log(`Error extracting changelog table: ${error.message} 💅`);
+    logger.info(`Error extracting changelog table: ${error.message} 💅`);
     return null;
   }
 }
@@ -166,14 +167,14 @@ function extractChangelogTable(description, config = {}) {
  */
 function parseChangelogTable(tableContent) {
   if (!tableContent || typeof tableContent !== 'string') {
-    console.log('Warning: tableContent is not a valid string! 💅 Type:', typeof tableContent);
+    logger.warn(`Warning: tableContent is not a valid string! 💅 Type: ${typeof tableContent}`);
     return [];
   }
   
   // Split table into lines and remove header and separator rows
   const lines = tableContent.split('\n').filter(line => line.trim());
   if (lines.length < 3) {
-    console.log('Warning: table has fewer than 3 lines, skipping parsing 💁‍♀️');
+    logger.warn('Warning: table has fewer than 3 lines, skipping parsing 💁‍♀️');
     return []; // Need at least header, separator, and one entry
   }
   
@@ -181,14 +182,14 @@ function parseChangelogTable(tableContent) {
   
   return dataRows.map(row => {
     if (typeof row !== 'string') {
-      console.log('Warning: row is not a string! 💁‍♀️ Type:', typeof row);
+      logger.warn(`Warning: row is not a string! 💁‍♀️ Type: ${typeof row}`);
       return null;
     }
     
     // Parse table row: | type | scope | description | PR | commit | author |
     const cells = row.split('|').map(cell => cell.trim()).filter(cell => cell);
     if (cells.length < 6) {
-      console.log('Warning: row has fewer than 6 cells, skipping 💅');
+      logger.warn('Warning: row has fewer than 6 cells, skipping 💅');
       return null; // Invalid row
     }
     
@@ -212,12 +213,12 @@ function parseChangelogTable(tableContent) {
 function mergeChangelogEntries(existingEntries, newEntries) {
   // Ensure both parameters are arrays
   if (!Array.isArray(existingEntries)) {
-    console.log('Warning: existingEntries is not an array! 💅 Type:', typeof existingEntries);
+    logger.warn(`Warning: existingEntries is not an array! 💅 Type: ${typeof existingEntries}`);
     existingEntries = [];
   }
   
   if (!Array.isArray(newEntries)) {
-    console.log('Warning: newEntries is not an array! 👱‍♀️ Type:', typeof newEntries);
+    logger.warn(`Warning: newEntries is not an array! 👱‍♀️ Type: ${typeof newEntries}`);
     newEntries = [];
   }
   
@@ -227,7 +228,7 @@ function mergeChangelogEntries(existingEntries, newEntries) {
   
   existingEntries.forEach(entry => {
     if (!entry || typeof entry !== 'object') {
-      console.log('Warning: Invalid entry in existingEntries, skipping 💅', entry);
+      logger.warn('Warning: Invalid entry in existingEntries, skipping 💅', { entry });
       return;
     }
     
@@ -239,7 +240,7 @@ function mergeChangelogEntries(existingEntries, newEntries) {
   // Add new entries, overwriting if they already exist
   newEntries.forEach(entry => {
     if (!entry || typeof entry !== 'object') {
-      console.log('Warning: Invalid entry in newEntries, skipping 👱‍♀️', entry);
+      logger.warn('Warning: Invalid entry in newEntries, skipping 👱‍♀️', { entry });
       return;
     }
     
@@ -253,7 +254,7 @@ function mergeChangelogEntries(existingEntries, newEntries) {
   
   // If we couldn't extract any valid entries, add a fallback entry
   if (result.length === 0) {
-    console.log('No valid entries in mergeChangelogEntries, adding fallback entry 💅');
+    logger.info('No valid entries in mergeChangelogEntries, adding fallback entry 💅');
     result.push({
       type: 'chore',
       scope: 'release',
@@ -278,7 +279,7 @@ function mergeChangelogEntries(existingEntries, newEntries) {
 function parseChangelogString(changelog) {
   // Ensure changelog is a string
   if (typeof changelog !== 'string') {
-    console.log(`Warning: changelog is not a string! 💅 Type: ${typeof changelog}`);
+    logger.warn(`Warning: changelog is not a string! 💅 Type: ${typeof changelog}`);
     changelog = String(changelog || '');
   }
   
@@ -289,10 +290,10 @@ function parseChangelogString(changelog) {
     if (changelog.trim().startsWith('[')) {
       try {
         parsedCommits = JSON.parse(changelog);
-        console.log(`Successfully parsed changelog string into an array with ${parsedCommits.length} items 💁‍♀️`);
+        logger.debug(`Successfully parsed changelog string into an array with ${parsedCommits.length} items 💁‍♀️`);
         return parsedCommits;
       } catch (jsonError) {
-        console.log(`Not a valid JSON string: ${jsonError.message} 💅`);
+        logger.info(`Not a valid JSON string: ${jsonError.message} 💅`);
         // Continue with other parsing methods
       }
     }
@@ -302,7 +303,7 @@ function parseChangelogString(changelog) {
     
     // Log the first line for debugging
     if (lines.length > 0) {
-      console.log(`First line of changelog: "${lines[0]}" 💁‍♀️`);
+      logger.info(`First line of changelog: "${lines[0]}" 💁‍♀️`);
     }
     
     // Process each line
@@ -334,7 +335,7 @@ function parseChangelogString(changelog) {
         const parsed = conventionalCommitsParser.sync(commitMessage, {
           headerPattern: /^(\w*)(?:\(([\w\$\.\-\*\s]*)\))?\: (.*)$/,
           headerCorrespondence: ['type', 'scope', 'subject'],
-          noteKeywords: ['BREAKING CHANGE', 'BREAKING-CHANGE'],
+          noteKeywords: ['BREAKING CHANGE', 'BREAKING-CHANGE', 'BREAKING CHANGES', 'BREAKING-CHANGES'],
           revertPattern: /^revert:\s([\s\S]*?)/,
           revertCorrespondence: ['header'],
           issuePrefixes: ['#']
@@ -345,7 +346,7 @@ function parseChangelogString(changelog) {
         const prRef = prMatch ? `#${prMatch[1]}` : '';
         
         if (parsed && parsed.type) {
-          console.log(`Successfully parsed commit: type=${parsed.type}, subject=${parsed.subject || ''} 💁‍♀️`);
+          logger.info(`Successfully parsed commit: type=${parsed.type}, subject=${parsed.subject || ''} 💁‍♀️`);
           
           parsedCommits.push({
             type: parsed.type,
@@ -358,7 +359,7 @@ function parseChangelogString(changelog) {
           });
         } else {
           // If parsing fails, try to guess the type from the message
-          console.log(`Failed to parse as conventional commit, using fallback: "${commitMessage}" 💅`);
+          logger.info(`Failed to parse as conventional commit, using fallback: "${commitMessage}" 💅`);
           
           let type = 'chore';
           if (commitMessage.includes('fix') || commitMessage.includes('bug')) type = 'fix';
@@ -376,13 +377,13 @@ function parseChangelogString(changelog) {
           });
         }
       } catch (parseError) {
-        console.log(`Error parsing line "${line}": ${parseError.message} 💁‍♀️`);
+        logger.info(`Error parsing line "${line}": ${parseError.message} 💁‍♀️`);
       }
     });
     
     // Add fallback entry if no commits were parsed
     if (parsedCommits.length === 0) {
-      console.log('No commits parsed, adding fallback entry 💅');
+      logger.info('No commits parsed, adding fallback entry 💅');
       parsedCommits.push({
         type: 'chore',
         scope: 'release',
@@ -395,7 +396,7 @@ function parseChangelogString(changelog) {
     
     return parsedCommits;
   } catch (error) {
-    console.log(`Error processing changelog: ${error.message} 💁‍♀️`);
+    logger.info(`Error processing changelog: ${error.message} 💁‍♀️`);
     
     // Return fallback entry
     return [{
@@ -425,7 +426,7 @@ function updatePRDescriptionWithChangelog(description, changelog, config = {}) {
   if (existingTable !== null && typeof existingTable === 'string') {
     existingEntries = parseChangelogTable(existingTable);
   } else {
-    console.log('No existing table found or table is not a string, starting with empty entries 💅');
+    logger.info('No existing table found or table is not a string, starting with empty entries 💅');
   }
   
   // Parse changelog string into commit objects
@@ -460,25 +461,68 @@ function updatePRDescriptionWithChangelog(description, changelog, config = {}) {
   }
 }
 
+/**
+ * Default preamble for a brand new changelog file
+ */
+const DEFAULT_CHANGELOG_HEADER = '# Changelog\n';
+
+/**
+ * Matches a release heading like "## 1.2.3 (2024-01-01)" or "## [1.2.3](url) - 2024-01-01"
+ */
+const RELEASE_HEADING = /^## \[?v?(\d+\.\d+\.\d+[^\]\s(]*)/gm;
+
 /**
  * Generate file-based changelog content from changelog string
- * @param {String} changelog - Changelog content for the release
+ * @param {String|Array} changelog - Changelog markdown from generateChangelog (or commits to list)
  * @param {String} newVersion - New version to be released
  * @param {String} baseContent - Existing changelog content (optional)
+ * @param {Object} options - Additional options
+ * @param {String} options.header - Preamble for a new changelog file (default "# Changelog")
  * @returns {String} - Generated changelog content
  */
-function generateFileChangelog(changelog, newVersion, baseContent = '') {
-  // Parse changelog string into commit objects
-  const parsedCommits = parseChangelogString(changelog);
+function generateFileChangelog(changelog, newVersion, baseContent = '', options = {}) {
+  const section = renderChangelogSection(changelog, newVersion);
+  return insertChangelogSection(baseContent, section, newVersion, options.header);
+}
+
+/**
+ * Render one release's section exactly as it goes into the changelog file
+ * A changelog string from generateChangelog goes in as it is - sections, breaking notes, links,
+ * issue refs and author credits included - with only its version heading rewritten. The release
+ * PR body shows this same output, so what reviewers see is what lands.
+ * @param {String|Array} changelog - Changelog markdown for the release, or commits to list
+ * @param {String} newVersion - Version the section is for
+ * @param {Date} date - Release date, when the changelog's heading doesn't have one (default today)
+ * @returns {String} - Markdown starting with the "## version (date)" heading
+ */
+function renderChangelogSection(changelog, newVersion, date = new Date()) {
+  if (Array.isArray(changelog)) {
+    return renderCommitListSection(changelog, newVersion, date);
+  }
   
-  // Convert parsed commits to changelog entries
-  const entries = commitsToChangelogEntries(parsedCommits);
+  const lines = String(changelog || '').replace(/^\n+/, '').split('\n');
+  const heading = lines[0].startsWith('## ') ? lines.shift() : '';
+  const notes = lines.join('\n').trim();
   
-  // Format entries as markdown list items
-  const markdownContent = entries.map(entry => {
+  // Keep the heading's compare link and date - only the version in it is ours to set
+  const link = heading.match(/^## \[[^\]]*\]\(([^)]*)\)/);
+  const day = (heading.match(/\((\d{4}-\d{2}-\d{2})\)\s*$/) || [])[1] || date.toISOString().split('T')[0];
+  const version = link ? `[${newVersion}](${link[1]})` : newVersion;
+  return `## ${version} (${day})\n\n${notes}\n`;
+}
+
+/**
+ * Render a release section as a flat list, for callers that only have commits and no changelog markdown
+ * @param {Array} commits - Analyzed commits (or changelog entries)
+ * @param {String} newVersion - Version the section is for
+ * @param {Date} date - Release date
+ * @returns {String} - Markdown starting with the "## version (date)" heading
+ */
+function renderCommitListSection(commits, newVersion, date) {
+  const markdownContent = commitsToChangelogEntries(commits).map(entry => {
     // Check if entry is valid
     if (!entry || typeof entry !== 'object') {
-      console.log('Warning: Invalid entry in generateFileChangelog, skipping 💅', entry);
+      logger.warn('Warning: Invalid entry in generateFileChangelog, skipping 💅', { entry });
       return null;
     }
     
@@ -494,21 +538,73 @@ function generateFileChangelog(changelog, newVersion, baseContent = '') {
   .filter(item => item !== null) // Remove any null entries
   .join('\n');
   
-  // Get today's date in YYYY-MM-DD format
-  const today = new Date().toISOString().split('T')[0];
+  // Date in YYYY-MM-DD format
+  const day = date.toISOString().split('T')[0];
+  return `## ${newVersion} (${day})\n\n${markdownContent}\n`;
+}
+
+/**
+ * Put a release section into an existing changelog
+ * The section goes above the newest release (or replaces the section for the same version),
+ * and everything else in the file is kept byte-for-byte.
+ * @param {String} baseContent - Existing changelog content (empty for a new file)
+ * @param {String} section - Markdown for the release, starting with its "## version" heading
+ * @param {String} version - Version the section is for
+ * @param {String} header - Preamble for a new changelog file (optional)
+ * @returns {String} - Updated changelog content
+ */
+function insertChangelogSection(baseContent, section, version, header = DEFAULT_CHANGELOG_HEADER) {
+  // Brand new changelog - start it with the preamble
+  if (!baseContent || !baseContent.trim()) {
+    const preamble = header || DEFAULT_CHANGELOG_HEADER;
+    return `${preamble.replace(/\n*$/, '\n')}\n${section}`;
+  }
   
-  // If we have existing content, add the new section at the top
-  if (baseContent && baseContent.includes('# Changelog')) {
-    const changelogStart = baseContent.indexOf('# Changelog');
-    const afterHeader = baseContent.indexOf('\n\n', changelogStart) + 2;
-    
-    return baseContent.substring(0, afterHeader) +
-      `## ${newVersion} (${today})\n\n${markdownContent}\n\n` +
-      baseContent.substring(afterHeader);
-  } else {
-    // Create a new changelog
-    return `# Changelog\n\n## ${newVersion} (${today})\n\n${markdownContent}\n`;
+  const headings = [...baseContent.matchAll(RELEASE_HEADING)].map(match => ({
+    index: match.index,
+    version: match[1]
+  }));
+  const target = version.replace(/^v/, '');
+  const existing = headings.findIndex(heading => heading.version === target);
+  
+  // Re-releasing a version - swap its section out rather than adding a duplicate
+  if (existing !== -1) {
+    logger.info(`Changelog already has a section for ${version} - replacing it 💅`);
+    const start = headings[existing].index;
+    const next = headings[existing + 1];
+    return next
+      ? baseContent.substring(0, start) + `${section}\n` + baseContent.substring(next.index)
+      : baseContent.substring(0, start) + section;
+  }
+  
+  // New release goes right above the newest one
+  if (headings.length > 0) {
+    const first = headings[0].index;
+    return baseContent.substring(0, first) + `${section}\n` + baseContent.substring(first);
   }
+  
+  // Only a preamble so far - the first release goes underneath it
+  const separator = baseContent.endsWith('\n\n') ? '' : baseContent.endsWith('\n') ? '\n' : '\n\n';
+  return `${baseContent}${separator}${section}`;
+}
+
+/**
+ * Pull one release's section out of a changelog file
+ * @param {String} content - Changelog file content
+ * @param {String} version - Version to find
+ * @returns {String|null} - The section's notes without its "## version" heading, or null when there's no section for it
+ */
+function extractChangelogSection(content, version) {
+  const headings = [...(content || '').matchAll(RELEASE_HEADING)];
+  const target = version.replace(/^v/, '');
+  const index = headings.findIndex(heading => heading[1] === target);
+  if (index === -1) {
+    return null;
+  }
+
+  const start = content.indexOf('\n', headings[index].index);
+  const end = headings[index + 1] ? headings[index + 1].index : content.length;
+  return start === -1 || start > end ? '' : content.substring(start + 1, end).trim();
 }
 
 module.exports = {
@@ -519,5 +615,9 @@ module.exports = {
   mergeChangelogEntries,
   updatePRDescriptionWithChangelog,
   generateFileChangelog,
-  parseChangelogString
+  renderChangelogSection,
+  insertChangelogSection,
+  extractChangelogSection,
+  parseChangelogString,
+  DEFAULT_CHANGELOG_HEADER
 };
@@ -12,6 +12,7 @@ const {
   mergeChangelogEntries,
   updatePRDescriptionWithChangelog
 } = require('./changelogTable');
+const { logger } = require('../utils/logger');
 
 /**
  * Example of creating a new PR with a changelog table
@@ -45,7 +46,7 @@ async function createPRWithChangelogTable(octokit, context, newVersion, commits,
     base: config.releaseBranch
   });
   
-  console.log(`Created PR #${newPR.number} with changelog table! 💁‍♀️`);
+  logger.info(`Created PR #${newPR.number} with changelog table! 💁‍♀️`);
   
   return {
     prNumber: newPR.number,
@@ -88,7 +89,7 @@ async function updatePRWithChangelogTable(octokit, context, newVersion, commits,
     body: updatedBody
   });
   
-  console.log(`Updated PR #${prNumber} with new changelog entries! 💅`);
+  logger.info(`Updated PR #${prNumber} with new changelog entries! 💅`);
   
   return {
     prNumber,
@@ -9,32 +9,35 @@
  * If so, we'll find the PR that created this merge and extract all its juicy details! 💅
  */
 
+const { logger } = require('../utils/logger');
+
 /**
  * Detect if a push event is actually a merged release PR
  * @param {Object} octokit - GitHub API client
  * @param {Object} context - GitHub context (has repo, payload info)
  * @param {Object} config - Release Boss config
+ * @param {String} branch - Branch the run is for, when context.ref doesn't say (optional)
  * @returns {Promise<Object>} PR info if detected, null otherwise
  */
-async function detectReleasePR(octokit, context, config) {
+async function detectReleasePR(octokit, context, config, branch = null) {
   const { owner, repo } = context.repo;
   
-  console.log(`👌 STEALTH PR DETECTION ACTIVATED - Looking for sneaky PR merges disguised as pushes! 👩‍🕵️‍♀️`);
+  logger.info(`👌 STEALTH PR DETECTION ACTIVATED - Looking for sneaky PR merges disguised as pushes! 👩‍🕵️‍♀️`);
   
   // Only check push events
   if (context.eventName !== 'push') {
-    console.log('Not a push event, skipping stealth PR detection 💅');
+    logger.info('Not a push event, skipping stealth PR detection 💅');
     return null;
   }
   
-  // Are we on the release branch?
-  const currentBranch = context.ref.replace('refs/heads/', '');
+  // Are we on the release branch? (a detached checkout can say which branch it's for)
+  const currentBranch = branch || (context.ref || '').replace('refs/heads/', '');
   if (currentBranch !== config.releaseBranch) {
-    console.log(`Push is to ${currentBranch}, not the release branch ${config.releaseBranch} - not a release PR! 🤷‍♀️`);
+    logger.info(`Push is to ${currentBranch}, not the release branch ${config.releaseBranch} - not a release PR! 🤷‍♀️`);
     return null;
   }
   
-  console.log(`Detected push to release branch ${config.releaseBranch} - investigating if this is a merged PR... 🔍`);
+  logger.info(`Detected push to release branch ${config.releaseBranch} - investigating if this is a merged PR... 🔍`);
   
   // Get the commit info
   try {
@@ -47,7 +50,7 @@ async function detectReleasePR(octokit, context, config) {
     
     // Is it a merge commit? (has more than one parent)
     if (!commit.parents || commit.parents.length <= 1) {
-      console.log(`Commit ${commitSha.substring(0, 7)} is not a merge commit (only has ${commit.parents?.length || 0} parents) 🙄`);
+      logger.info(`Commit ${commitSha.substring(0, 7)} is not a merge commit (only has ${commit.parents?.length || 0} parents) 🙄`);
       return null;
     }
     
@@ -58,7 +61,7 @@ async function detectReleasePR(octokit, context, config) {
     
     if (!prNumber) {
       // Try to find this PR by looking at recent closed PRs to the release branch
-      console.log(`No PR number found in commit message, trying to find it from recent PRs... 👀`);
+      logger.info(`No PR number found in commit message, trying to find it from recent PRs... 👀`);
       const { data: recentPRs } = await octokit.rest.pulls.list({
         owner,
         repo,
@@ -73,13 +76,13 @@ async function detectReleasePR(octokit, context, config) {
       for (const pr of recentPRs) {
         if (pr.merge_commit_sha === commitSha) {
           prNumber = pr.number;
-          console.log(`Found matching PR #${prNumber} with merge commit ${commitSha.substring(0, 7)} 💅`);
+          logger.info(`Found matching PR #${prNumber} with merge commit ${commitSha.substring(0, 7)} 💅`);
           break;
         }
       }
       
       if (!prNumber) {
-        console.log(`Couldn't find a matching PR for commit ${commitSha.substring(0, 7)} - not a release PR! 😢`);
+        logger.info(`Couldn't find a matching PR for commit ${commitSha.substring(0, 7)} - not a release PR! 😢`);
         return null;
       }
     }
@@ -94,20 +97,20 @@ async function detectReleasePR(octokit, context, config) {
     // Check if the head branch starts with our staging prefix
     const headBranch = pullRequest.head.ref;
     if (!headBranch.startsWith(`${config.stagingBranch}-`)) {
-      console.log(`PR #${prNumber} head branch "${headBranch}" doesn't match staging pattern "${config.stagingBranch}-*" 🤔`);
+      logger.info(`PR #${prNumber} head branch "${headBranch}" doesn't match staging pattern "${config.stagingBranch}-*" 🤔`);
       return null;
     }
     
-    console.log(`Detected stealth release PR #${prNumber} from ${headBranch} to ${config.releaseBranch} - yasss queen! 💁‍♀️`);
+    logger.info(`Detected stealth release PR #${prNumber} from ${headBranch} to ${config.releaseBranch} - yasss queen! 💁‍♀️`);
     
     // Extract the version from the branch name (similar to extractVersionFromStagingBranch)
     const versionMatch = headBranch.match(new RegExp(`^${config.stagingBranch}-v?([0-9]+\\.[0-9]+\\.[0-9]+.*?)$`));
     const version = versionMatch ? versionMatch[1] : null;
     
     if (version) {
-      console.log(`Extracted version ${version} from staging branch ${headBranch} 💅`);
+      logger.info(`Extracted version ${version} from staging branch ${headBranch} 💅`);
     } else {
-      console.log(`Couldn't extract version from branch ${headBranch} 😱`);
+      logger.info(`Couldn't extract version from branch ${headBranch} 😱`);
     }
     
     // Return all the PR info we need
@@ -121,7 +124,7 @@ async function detectReleasePR(octokit, context, config) {
       mergeCommitSha: commitSha
     };
   } catch (error) {
-    console.error(`Error detecting release PR: ${error.message}`);
+    logger.error(`Error detecting release PR: ${error.message}`);
     return null;
   }
 }
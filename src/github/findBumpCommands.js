@@ -4,34 +4,29 @@
  * So we'll check for comments like "/bump major" or "/bump minor" and slay those version numbers!
  */
 
+const { logger } = require('../utils/logger');
+
 /**
  * Finds any bump commands in the PR's comments
- * @param {Object} octokit - GitHub API client 
- * @param {Object} context - GitHub context
+ * @param {Object} provider - VCS provider
  * @param {Number} prNumber - PR number to check
  * @returns {Promise<Object>} Bump command details if found
  */
-async function findBumpCommandsInPR(octokit, context, prNumber) {
-  const { owner, repo } = context.repo;
-  
+async function findBumpCommandsInPR(provider, prNumber) {
   if (!prNumber) {
-    console.log('No PR number provided, skipping bump command check 🤷‍♀️');
+    logger.info('No PR number provided, skipping bump command check 🤷‍♀️');
     return { hasBumpCommand: false };
   }
   
-  console.log(`👌 BUMP COMMAND CHECK RUNNING FOR PR #${prNumber} - I'm on the hunt for those sassy commands! 💃`);
+  logger.info(`👌 BUMP COMMAND CHECK RUNNING FOR PR #${prNumber} - I'm on the hunt for those sassy commands! 💃`);
   
-  console.log(`💋 Checking comments on PR #${prNumber} for bump commands...`);
+  logger.info(`💋 Checking comments on PR #${prNumber} for bump commands...`);
   
   try {
     // Get all comments on the PR
-    const { data: comments } = await octokit.rest.issues.listComments({
-      owner,
-      repo,
-      issue_number: prNumber,
-    });
+    const comments = await provider.listPRComments(prNumber);
     
-    console.log(`Found ${comments.length} comments on PR #${prNumber}`);
+    logger.info(`Found ${comments.length} comments on PR #${prNumber}`);
     
     // Look for bump commands
     for (const comment of comments) {
@@ -42,22 +37,22 @@ async function findBumpCommandsInPR(octokit, context, prNumber) {
       
       if (bumpCommandMatch) {
         const bumpType = bumpCommandMatch[1].toLowerCase();
-        console.log(`💃 Found bump command in comment by ${comment.user.login}: /bump ${bumpType}`);
+        logger.info(`💃 Found bump command in comment by ${comment.author}: /bump ${bumpType}`);
         
         return {
           hasBumpCommand: true,
           bumpType: bumpType,
-          commenter: comment.user.login,
-          commentUrl: comment.html_url,
+          commenter: comment.author,
+          commentUrl: comment.url,
           commentId: comment.id
         };
       }
     }
     
-    console.log('No bump commands found in PR comments 🤷‍♀️');
+    logger.info('No bump commands found in PR comments 🤷‍♀️');
     return { hasBumpCommand: false };
   } catch (error) {
-    console.error(`Error checking PR comments for bump commands: ${error.message}`);
+    logger.error(`Error checking PR comments for bump commands: ${error.message}`);
     return { hasBumpCommand: false, error: error.message };
   }
 }
@@ -74,12 +69,12 @@ function applyBumpCommand(currentVersion, bumpType) {
   
   // If it's already at the requested bump level, don't change anything
   if (bumpType === 'minor' && patch === 0) {
-    console.log(`Version ${currentVersion} is already at a minor version bump (patch is 0), no change needed 💅`);
+    logger.info(`Version ${currentVersion} is already at a minor version bump (patch is 0), no change needed 💅`);
     return currentVersion;
   }
   
   if (bumpType === 'major' && minor === 0 && patch === 0) {
-    console.log(`Version ${currentVersion} is already at a major version bump (minor and patch are 0), no change needed 💅`);
+    logger.info(`Version ${currentVersion} is already at a major version bump (minor and patch are 0), no change needed 💅`);
     return currentVersion;
   }
   
@@ -6,6 +6,11 @@ const {
   updatePRDescriptionWithChangelog,
   generateFileChangelog
 } = require('./changelogTable');
+const { buildPRTitle, buildPRBody, buildCommitMessage } = require('../core/prContent');
+const { getReleaseTagName, getAdditionalTagNames } = require('../utils/tags');
+const { getSigner } = require('../utils/signing');
+const { normalizeVersionFile } = require('../core/templateProcessor');
+const { logger } = require('../utils/logger');
 
 /**
  * Create or update a pull request for a new release
@@ -13,29 +18,32 @@ const {
  * @param {Object} context - GitHub context
  * @param {String} newVersion - New version to be released
  * @param {String} changelog - Changelog content for the release
- * @param {Object} config - Release Boss configuration
+ * @param {Object} config - Release Boss configuration (config.releasePR is the open release PR to update, if any)
  * @param {Array} [updatedFiles] - List of files that were updated with version info
  */
 async function createOrUpdatePR(octokit, context, newVersion, changelog, config, updatedFiles = []) {
   const { owner, repo } = context.repo;
-  console.log(`Creating/updating PR for version ${newVersion}...`);
+  logger.info(`Creating/updating PR for version ${newVersion}...`);
   
   // The changelog parameter is expected to be a string
   // No type conversion needed as it should already be a string from generateChangelog
   if (typeof changelog !== 'string') {
-    console.log(`Warning: changelog parameter is not a string! 💅 Type: ${typeof changelog}`);
+    logger.warn(`Warning: changelog parameter is not a string! 💅 Type: ${typeof changelog}`);
     // Convert to string if it's not already
     changelog = String(changelog || '');
   }
   
+  // Load the signing key up front so a bad key fails before we touch any branches
+  const signer = config.signCommits ? await getSigner(config) : null;
+  
   // Check if we're in a PR context and need to update an existing PR
   if (context.payload.pull_request) {
     return await updateExistingPR(octokit, context, newVersion, changelog, config, updatedFiles);
   }
   
   // Create a new staging branch and PR
-  // Format: stagingBranch-vX.Y.Z
-  const stagingBranch = `${config.stagingBranch}-v${newVersion}`;
+  // Format: stagingBranch-vX.Y.Z - or the branch of the release PR that's already open, which we update in place
+  const stagingBranch = config.releasePR ? config.releasePR.headBranch : `${config.stagingBranch}-v${newVersion}`;
   let stagingBranchExists = false;
   
   // Step 1: Check if staging branch exists
@@ -45,11 +53,11 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
       repo,
       ref: `heads/${stagingBranch}`
     });
-    console.log(`Staging branch ${stagingBranch} already exists`); 
+    logger.info(`Staging branch ${stagingBranch} already exists`); 
     stagingBranchExists = true;
   } catch (error) {
     if (error.status === 404) {
-      console.log(`Staging branch ${stagingBranch} does not exist - will create it`);
+      logger.info(`Staging branch ${stagingBranch} does not exist - will create it`);
       stagingBranchExists = false;
     } else {
       throw error; // Re-throw unexpected errors
@@ -57,7 +65,7 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
   }
   
   // Step 2: Get current commit SHA of the merge branch to use as base
-  console.log(`Getting current state of ${config.mergeBranch} branch...`);
+  logger.info(`Getting current state of ${config.mergeBranch} branch...`);
   const { data: mergeBranchData } = await octokit.rest.repos.getBranch({
     owner,
     repo,
@@ -65,24 +73,41 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
   });
   
   const mergeBranchSha = mergeBranchData.commit.sha;
-  console.log(`Current HEAD of ${config.mergeBranch} is ${mergeBranchSha.substring(0, 7)}`);
+  logger.info(`Current HEAD of ${config.mergeBranch} is ${mergeBranchSha.substring(0, 7)}`);
   
   // Get current commit SHA of the release branch
-  console.log(`Getting current state of ${config.releaseBranch} branch...`);
-  const { data: releaseBranchData } = await octokit.rest.repos.getBranch({
-    owner,
-    repo,
-    branch: config.releaseBranch
-  });
+  logger.info(`Getting current state of ${config.releaseBranch} branch...`);
+  let releaseBranchSha;
+  try {
+    const { data: releaseBranchData } = await octokit.rest.repos.getBranch({
+      owner,
+      repo,
+      branch: config.releaseBranch
+    });
+    releaseBranchSha = releaseBranchData.commit.sha;
+  } catch (error) {
+    if (error.status !== 404) {
+      throw error;
+    }
+    
+    // Brand new repo - the release branch starts where the merge branch is today
+    logger.info(`Release branch ${config.releaseBranch} doesn't exist yet - creating it from ${config.mergeBranch} for the first release 🎉`);
+    await octokit.rest.git.createRef({
+      owner,
+      repo,
+      ref: `refs/heads/${config.releaseBranch}`,
+      sha: mergeBranchSha
+    });
+    releaseBranchSha = mergeBranchSha;
+  }
   
-  const releaseBranchSha = releaseBranchData.commit.sha;
-  console.log(`Current HEAD of ${config.releaseBranch} is ${releaseBranchSha.substring(0, 7)}`);
+  logger.info(`Current HEAD of ${config.releaseBranch} is ${releaseBranchSha.substring(0, 7)}`);
   
   // Step 3: Create or reset staging branch
   if (!stagingBranchExists) {
     // For new branches, create from release branch (not merge branch)
     // This follows the traditional release branch workflow
-    console.log(`Creating staging branch ${stagingBranch} from ${config.releaseBranch}...`);
+    logger.info(`Creating staging branch ${stagingBranch} from ${config.releaseBranch}...`);
     await octokit.rest.git.createRef({
       owner,
       repo,
@@ -90,12 +115,12 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
       sha: releaseBranchSha  // Use release branch SHA as base
     });
     
-    console.log(`Created staging branch: ${stagingBranch} from ${config.releaseBranch} 💅`);
+    logger.info(`Created staging branch: ${stagingBranch} from ${config.releaseBranch} 💅`);
     
     // Now merge the main branch into the staging branch
-    console.log(`Merging ${config.mergeBranch} into new staging branch...`);
+    logger.info(`Merging ${config.mergeBranch} into new staging branch...`);
     try {
-      const { data: mergeCommit } = await octokit.rest.repos.merge({
+      const { status: mergeStatus, data: mergeCommit } = await octokit.rest.repos.merge({
         owner,
         repo,
         base: stagingBranch,           // The staging branch we just created
@@ -103,15 +128,20 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
         commit_message: `chore: merge ${config.mergeBranch} into ${stagingBranch} for release ${newVersion}`
       });
       
-      console.log(`Successfully merged ${config.mergeBranch} into ${stagingBranch} with commit ${mergeCommit.sha.substring(0, 7)} 💃`);
+      if (mergeStatus === 204) {
+        // Nothing to merge - e.g. prerelease channels, where the staging branch is cut from the merge branch itself
+        logger.info(`${stagingBranch} already has everything from ${config.mergeBranch} - nothing to merge 💅`);
+      } else {
+        logger.info(`Successfully merged ${config.mergeBranch} into ${stagingBranch} with commit ${mergeCommit.sha.substring(0, 7)} 💃`);
+      }
     } catch (error) {
       // If there's a merge conflict, let's handle it gracefully
       if (error.message.includes('Merge conflict')) {
-        console.log(`Merge conflict detected when merging ${config.mergeBranch} into ${stagingBranch}. Let's resolve it! 💪`);
+        logger.info(`Merge conflict detected when merging ${config.mergeBranch} into ${stagingBranch}. Let's resolve it! 💪`);
         
         // We'll handle this by cherry-picking changes from main that don't conflict with version/changelog files
         // First, get the list of files that have changed in main since the release branch diverged
-        console.log(`Getting list of files changed in ${config.mergeBranch} since ${config.releaseBranch} diverged...`);
+        logger.info(`Getting list of files changed in ${config.mergeBranch} since ${config.releaseBranch} diverged...`);
         
         try {
           // First, let's get the list of version and changelog files that we want to preserve from release branch
@@ -124,10 +154,10 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
           
           // Add version files if configured
           if (config.versionFiles && Array.isArray(config.versionFiles)) {
-            preserveFiles.push(...config.versionFiles);
+            preserveFiles.push(...config.versionFiles.map(entry => normalizeVersionFile(entry).file));
           }
           
-          console.log(`Files to preserve from ${config.releaseBranch}: ${preserveFiles.join(', ')}`);
+          logger.info(`Files to preserve from ${config.releaseBranch}: ${preserveFiles.join(', ')}`);
           
           // Reset the staging branch to match release branch exactly
           await octokit.rest.git.updateRef({
@@ -138,10 +168,10 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
             force: true
           });
           
-          console.log(`Reset ${stagingBranch} to match ${config.releaseBranch} exactly`);
+          logger.info(`Reset ${stagingBranch} to match ${config.releaseBranch} exactly`);
           
           // Collect all files from release branch that need to be preserved
-          console.log(`Collecting files from ${config.releaseBranch} to preserve in ${stagingBranch}...`);
+          logger.info(`Collecting files from ${config.releaseBranch} to preserve in ${stagingBranch}...`);
           const filesToPreserve = [];
           
           for (const filePath of preserveFiles) {
@@ -161,19 +191,19 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
                   path: filePath,
                   content: content
                 });
-                console.log(`Added ${filePath} from ${config.releaseBranch} to preservation batch`);
+                logger.info(`Added ${filePath} from ${config.releaseBranch} to preservation batch`);
               } else {
-                console.log(`File ${filePath} exists but has no content in ${config.releaseBranch}, skipping...`);
+                logger.info(`File ${filePath} exists but has no content in ${config.releaseBranch}, skipping...`);
               }
             } catch (fileError) {
               // Handle various error cases gracefully
               if (fileError.status === 404) {
-                console.log(`File ${filePath} doesn't exist in ${config.releaseBranch}, skipping...`);
+                logger.info(`File ${filePath} doesn't exist in ${config.releaseBranch}, skipping...`);
               } else if (fileError.message.includes('Not Found')) {
-                console.log(`File ${filePath} not found in ${config.releaseBranch}, skipping... 💁‍♀️`);
+                logger.info(`File ${filePath} not found in ${config.releaseBranch}, skipping... 💁‍♀️`);
                 // Continue with the process even if we can't get this file
               } else {
-                console.warn(`Warning: Could not preserve ${filePath} from ${config.releaseBranch}: ${fileError.message}`);
+                logger.warn(`Warning: Could not preserve ${filePath} from ${config.releaseBranch}: ${fileError.message}`);
               }
             }
           }
@@ -186,12 +216,13 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
                 context,
                 filesToPreserve,
                 `chore: preserve files from ${config.releaseBranch} for release ${newVersion} 💅`,
-                stagingBranch
+                stagingBranch,
+                signer
               );
-              console.log(`Preserved ${filesToPreserve.length} files from ${config.releaseBranch} in a single fabulous commit! 💁‍♀️`);
+              logger.info(`Preserved ${filesToPreserve.length} files from ${config.releaseBranch} in a single fabulous commit! 💁‍♀️`);
             } catch (commitError) {
-              console.warn(`Warning: Could not commit preserved files from ${config.releaseBranch}: ${commitError.message}`);
-              console.log(`But don't worry, we'll continue with the process anyway! 💁‍♀️`);
+              logger.warn(`Warning: Could not commit preserved files from ${config.releaseBranch}: ${commitError.message}`);
+              logger.info(`But don't worry, we'll continue with the process anyway! 💁‍♀️`);
               // Continue with the process even if we can't commit the preserved files
             }
           }
@@ -209,7 +240,7 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
           });
           
           // Collect all files from merge branch that need to be copied
-          console.log(`Collecting files from ${config.mergeBranch} to update in ${stagingBranch}...`);
+          logger.info(`Collecting files from ${config.mergeBranch} to update in ${stagingBranch}...`);
           const filesToUpdate = [];
           const batchSize = 50; // Process files in batches to avoid overwhelming the API
           let currentBatch = [];
@@ -233,7 +264,7 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
                     path: file.path,
                     content: content
                   });
-                  console.log(`Added ${file.path} from ${config.mergeBranch} to update batch`);
+                  logger.info(`Added ${file.path} from ${config.mergeBranch} to update batch`);
                   
                   // If we've reached our batch size, commit this batch
                   if (currentBatch.length >= batchSize) {
@@ -243,16 +274,17 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
                       context,
                       currentBatch,
                       `chore: update files from ${config.mergeBranch} (batch ${batchCount}) for release ${newVersion} 💅`,
-                      stagingBranch
+                      stagingBranch,
+                      signer
                     );
-                    console.log(`Updated ${currentBatch.length} files from ${config.mergeBranch} in batch ${batchCount} 💁‍♀️`);
+                    logger.info(`Updated ${currentBatch.length} files from ${config.mergeBranch} in batch ${batchCount} 💁‍♀️`);
                     filesToUpdate.push(...currentBatch);
                     currentBatch = [];
                   }
                 }
               } catch (fileError) {
                 // If we can't get the file content, that's okay - just skip it
-                console.warn(`Warning: Could not update ${file.path} from ${config.mergeBranch}: ${fileError.message}`);
+                logger.warn(`Warning: Could not update ${file.path} from ${config.mergeBranch}: ${fileError.message}`);
               }
             }
           }
@@ -265,29 +297,30 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
               context,
               currentBatch,
               `chore: update files from ${config.mergeBranch} (batch ${batchCount}) for release ${newVersion} 💅`,
-              stagingBranch
+              stagingBranch,
+              signer
             );
-            console.log(`Updated ${currentBatch.length} files from ${config.mergeBranch} in final batch ${batchCount} 💁‍♀️`);
+            logger.info(`Updated ${currentBatch.length} files from ${config.mergeBranch} in final batch ${batchCount} 💁‍♀️`);
             filesToUpdate.push(...currentBatch);
           }
           
-          console.log(`Total files updated from ${config.mergeBranch}: ${filesToUpdate.length}`);
+          logger.info(`Total files updated from ${config.mergeBranch}: ${filesToUpdate.length}`);
           
-          console.log(`Successfully resolved merge conflicts between ${config.releaseBranch} and ${config.mergeBranch} 🎉`);
+          logger.info(`Successfully resolved merge conflicts between ${config.releaseBranch} and ${config.mergeBranch} 🎉`);
         } catch (resolveError) {
-          console.error(`Error resolving merge conflicts: ${resolveError.message}`);
+          logger.error(`Error resolving merge conflicts: ${resolveError.message}`);
           throw new Error(`Failed to resolve merge conflicts: ${resolveError.message}`);
         }
       } else {
         // If it's not a merge conflict, rethrow the error
-        console.error(`Error merging ${config.mergeBranch} into ${stagingBranch}: ${error.message}`);
+        logger.error(`Error merging ${config.mergeBranch} into ${stagingBranch}: ${error.message}`);
         throw error;
       }
     }
   } else {
     // For existing branches, we'll reset them to the release branch and start fresh
     // This is simpler and more consistent than trying to update them in place
-    console.log(`Existing staging branch ${stagingBranch} found - resetting to ${config.releaseBranch} and starting fresh 💅`);
+    logger.info(`Existing staging branch ${stagingBranch} found - resetting to ${config.releaseBranch} and starting fresh 💅`);
     
     try {
       // Force update the staging branch ref to match release branch
@@ -299,12 +332,12 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
         force: true
       });
       
-      console.log(`Reset ${stagingBranch} to match ${config.releaseBranch} exactly`);
+      logger.info(`Reset ${stagingBranch} to match ${config.releaseBranch} exactly`);
       
       // Now merge the main branch into the staging branch
-      console.log(`Merging ${config.mergeBranch} into reset staging branch...`);
+      logger.info(`Merging ${config.mergeBranch} into reset staging branch...`);
       try {
-        const { data: mergeCommit } = await octokit.rest.repos.merge({
+        const { status: mergeStatus, data: mergeCommit } = await octokit.rest.repos.merge({
           owner,
           repo,
           base: stagingBranch,           // The staging branch we just reset
@@ -312,15 +345,20 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
           commit_message: `chore: merge ${config.mergeBranch} into ${stagingBranch} for release ${newVersion}`
         });
         
-        console.log(`Successfully merged ${config.mergeBranch} into ${stagingBranch} with commit ${mergeCommit.sha.substring(0, 7)} 💃`);
+        if (mergeStatus === 204) {
+          // Nothing to merge - e.g. prerelease channels, where the staging branch is cut from the merge branch itself
+          logger.info(`${stagingBranch} already has everything from ${config.mergeBranch} - nothing to merge 💅`);
+        } else {
+          logger.info(`Successfully merged ${config.mergeBranch} into ${stagingBranch} with commit ${mergeCommit.sha.substring(0, 7)} 💃`);
+        }
       } catch (error) {
         // If there's a merge conflict, let's handle it gracefully
         if (error.message.includes('Merge conflict')) {
-          console.log(`Merge conflict detected when merging ${config.mergeBranch} into ${stagingBranch}. Let's resolve it! 💪`);
+          logger.info(`Merge conflict detected when merging ${config.mergeBranch} into ${stagingBranch}. Let's resolve it! 💪`);
           
           // We'll handle this by cherry-picking changes from main that don't conflict with version/changelog files
           // First, get the list of files that have changed in main since the release branch diverged
-          console.log(`Getting list of files changed in ${config.mergeBranch} since ${config.releaseBranch} diverged...`);
+          logger.info(`Getting list of files changed in ${config.mergeBranch} since ${config.releaseBranch} diverged...`);
           
           try {
             // First, let's get the list of version and changelog files that we want to preserve from release branch
@@ -333,7 +371,7 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
             
             // Add version files if configured
             if (config.versionFiles && Array.isArray(config.versionFiles)) {
-              preserveFiles.push(...config.versionFiles);
+              preserveFiles.push(...config.versionFiles.map(entry => normalizeVersionFile(entry).file));
             }
             
             // Add update files if configured (just the file paths)
@@ -345,7 +383,7 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
               }
             }
             
-            console.log(`Files to preserve from ${config.releaseBranch}: ${preserveFiles.join(', ')}`);
+            logger.info(`Files to preserve from ${config.releaseBranch}: ${preserveFiles.join(', ')}`);
             
             // Now, try to cherry-pick changes from main branch for files that aren't in preserveFiles
             // We'll do this by getting the content of each file in main and committing it to staging
@@ -384,7 +422,7 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
                   }
                 } catch (fileError) {
                   // If we can't get the file content, that's okay - just skip it
-                  console.warn(`Warning: Could not update ${file.path} from ${config.mergeBranch}: ${fileError.message}`);
+                  logger.warn(`Warning: Could not update ${file.path} from ${config.mergeBranch}: ${fileError.message}`);
                 }
               }
             }
@@ -396,27 +434,28 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
                 context,
                 filesToUpdate,
                 `chore: update files from ${config.mergeBranch} for release ${newVersion}`,
-                stagingBranch
+                stagingBranch,
+                signer
               );
-              console.log(`Updated ${filesToUpdate.length} files from ${config.mergeBranch} in a single commit 💅`);
+              logger.info(`Updated ${filesToUpdate.length} files from ${config.mergeBranch} in a single commit 💅`);
             }
             
-            console.log(`Successfully resolved merge conflicts between ${config.releaseBranch} and ${config.mergeBranch} 🎉`);
+            logger.info(`Successfully resolved merge conflicts between ${config.releaseBranch} and ${config.mergeBranch} 🎉`);
           } catch (resolveError) {
             // Don't fail the entire process if we can't resolve merge conflicts
-            console.warn(`Warning: Error resolving merge conflicts: ${resolveError.message}`);
-            console.log(`But don't worry, we'll still try to create a PR with what we have! 💁‍♀️`);
+            logger.warn(`Warning: Error resolving merge conflicts: ${resolveError.message}`);
+            logger.info(`But don't worry, we'll still try to create a PR with what we have! 💁‍♀️`);
             
             // If we have at least updated the version file, we can still proceed
             if (updatedFiles && updatedFiles.length > 0) {
-              console.log(`We have ${updatedFiles.length} updated files, so we can still create a PR!`);
+              logger.info(`We have ${updatedFiles.length} updated files, so we can still create a PR!`);
             } else {
               // If we don't have any updated files, we need to at least update the version file
               try {
                 // Try to get the version file from the release branch
                 if (config.versionFiles && config.versionFiles.length > 0) {
-                  const versionFile = config.versionFiles[0];
-                  console.log(`Attempting to get version file ${versionFile} from ${config.releaseBranch}...`);
+                  const versionFile = normalizeVersionFile(config.versionFiles[0]).file;
+                  logger.info(`Attempting to get version file ${versionFile} from ${config.releaseBranch}...`);
                   
                   try {
                     const { data: fileContent } = await octokit.rest.repos.getContent({
@@ -437,14 +476,14 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
                         content: updatedContent
                       });
                       
-                      console.log(`Added updated version file ${versionFile} to the PR`);
+                      logger.info(`Added updated version file ${versionFile} to the PR`);
                     }
                   } catch (versionError) {
-                    console.warn(`Could not get version file: ${versionError.message}`);
+                    logger.warn(`Could not get version file: ${versionError.message}`);
                   }
                 }
               } catch (fallbackError) {
-                console.warn(`Failed to create fallback version update: ${fallbackError.message}`);
+                logger.warn(`Failed to create fallback version update: ${fallbackError.message}`);
                 // At this point, we've tried everything we can
                 throw new Error(`Failed to resolve merge conflicts and couldn't create a fallback: ${resolveError.message}`);
               }
@@ -452,7 +491,7 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
           }
         } else {
           // If it's not a merge conflict, rethrow the error
-          console.error(`Error merging ${config.mergeBranch} into ${stagingBranch}: ${error.message}`);
+          logger.error(`Error merging ${config.mergeBranch} into ${stagingBranch}: ${error.message}`);
           throw error;
         }
       }
@@ -468,7 +507,7 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
         });
         
         if (comparison.files.length === 0 || comparison.total_commits === 0) {
-          console.log(`No differences detected between ${stagingBranch} and ${config.releaseBranch}, adding dummy commit...`);
+          logger.info(`No differences detected between ${stagingBranch} and ${config.releaseBranch}, adding dummy commit...`);
           
           // Create a dummy file or modification to prevent PR from being closed
           // Get current timestamp to ensure uniqueness
@@ -480,16 +519,17 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
             context,
             [{ path: '.release-timestamp', content: dummyContent }],
             `chore: maintain PR state for release ${newVersion} 💅`,
-            stagingBranch
+            stagingBranch,
+            signer
           );
             
-          console.log(`Added dummy commit to ${stagingBranch} to prevent PR from being auto-closed 💅`);
+          logger.info(`Added dummy commit to ${stagingBranch} to prevent PR from being auto-closed 💅`);
         }
       } catch (compareError) {
-        console.warn(`Warning: Could not compare branches: ${compareError.message}`);
+        logger.warn(`Warning: Could not compare branches: ${compareError.message}`);
       }
     } catch (error) {
-      console.error(`Error updating staging branch: ${error.message}`);
+      logger.error(`Error updating staging branch: ${error.message}`);
       throw error;
     }
   }
@@ -500,7 +540,7 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
   
   if (config.changelogPath) {
     changelogPath = config.changelogPath;
-    console.log(`Preparing changelog for ${newVersion} (will be committed with other files)...`);
+    logger.info(`Preparing changelog for ${newVersion} (will be committed with other files)...`);
     
     // Get current content of the changelog from the first available source
     let baseContent = '';
@@ -526,11 +566,11 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
       sourceBranches.push(stagingBranch);
     }
     
-    console.log(`Will try to fetch changelog content from branches in this order: ${sourceBranches.join(', ')}`);
+    logger.info(`Will try to fetch changelog content from branches in this order: ${sourceBranches.join(', ')}`);
     
     for (const sourceRef of sourceBranches) {
       try {
-        console.log(`Attempting to get changelog from ${sourceRef} branch...`);
+        logger.info(`Attempting to get changelog from ${sourceRef} branch...`);
         const { data } = await octokit.rest.repos.getContent({
           owner,
           repo,
@@ -541,79 +581,79 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
         // Decode content from base64
         baseContent = Buffer.from(data.content, 'base64').toString('utf8');
         sourceUsed = sourceRef;
-        console.log(`✨ Successfully retrieved base changelog from ${sourceRef} branch!`);
+        logger.info(`✨ Successfully retrieved base changelog from ${sourceRef} branch!`);
         break; // We found content, no need to check other branches
       } catch (error) {
         if (error.status === 404) {
-          console.log(`Changelog file doesn't exist in ${sourceRef} branch, trying next source...`);
+          logger.info(`Changelog file doesn't exist in ${sourceRef} branch, trying next source...`);
         } else {
-          console.log(`Error getting changelog from ${sourceRef}, trying next source: ${error.message}`);
+          logger.info(`Error getting changelog from ${sourceRef}, trying next source: ${error.message}`);
         }
       }
     }
     
     if (!sourceUsed) {
-      console.log(`Couldn't find changelog in any branch, will start fresh 💁‍♀️`);
+      logger.info(`Couldn't find changelog in any branch, will start fresh 💁‍♀️`);
     } else {
-      console.log(`Using changelog content from ${sourceUsed} as base to avoid conflicts 💅`);
+      logger.info(`Using changelog content from ${sourceUsed} as base to avoid conflicts 💅`);
     }
     
     // Generate changelog content using our new function
     // Pass the changelog string directly to generateFileChangelog
     // The function will handle parsing if needed
-    changelogContent = generateFileChangelog(changelog, newVersion, baseContent);
+    changelogContent = generateFileChangelog(changelog, newVersion, baseContent, { header: config.changelogHeader });
     
-    console.log(`Prepared changelog content for ${newVersion} 📝`);
+    logger.info(`Prepared changelog content for ${newVersion} 📝`);
   }
   
   // Step 5: Commit updated version files to staging branch
-  console.log(`\nChecking updatedFiles array for committing to staging branch...`);
-  console.log(`Current working directory: ${process.cwd()}`);
+  logger.info(`\nChecking updatedFiles array for committing to staging branch...`);
+  logger.info(`Current working directory: ${process.cwd()}`);
   
   if (!updatedFiles) {
-    console.warn(`WARNING: updatedFiles is undefined or null! This is probably a bug.`);
+    logger.warn(`WARNING: updatedFiles is undefined or null! This is probably a bug.`);
   } else if (updatedFiles.length === 0) {
-    console.warn(`WARNING: updatedFiles array is empty! No files to commit.`);
+    logger.warn(`WARNING: updatedFiles array is empty! No files to commit.`);
   } else {
-    console.log(`Found ${updatedFiles.length} updated files to commit to ${stagingBranch}:`);
+    logger.info(`Found ${updatedFiles.length} updated files to commit to ${stagingBranch}:`);
     updatedFiles.forEach((file, index) => {
-      console.log(`  ${index + 1}. ${file} (${path.isAbsolute(file) ? 'absolute' : 'relative'} path)`);
+      logger.info(`  ${index + 1}. ${file} (${path.isAbsolute(file) ? 'absolute' : 'relative'} path)`);
       
       // Check if file actually exists
       try {
         const stats = fs.statSync(file);
-        console.log(`     File exists! Size: ${stats.size} bytes, Modified: ${stats.mtime}`);
+        logger.info(`     File exists! Size: ${stats.size} bytes, Modified: ${stats.mtime}`);
       } catch (err) {
-        console.error(`     File does not exist or cannot be accessed: ${err.message}`);
+        logger.error(`     File does not exist or cannot be accessed: ${err.message}`);
       }
     });
     
-    console.log(`Starting to commit files to ${stagingBranch}...`);
+    logger.info(`Starting to commit files to ${stagingBranch}...`);
     
     // Collect all files to commit in a single batch
     const filesToCommit = [];
     
     for (const file of updatedFiles) {
       try {
-        console.log(`\nProcessing file for commit: ${file}`);
+        logger.info(`\nProcessing file for commit: ${file}`);
         
         // Read the file content
-        console.log(`  Reading file content...`);
+        logger.debug(`  Reading file content...`);
         const fileContent = await fs.readFile(file, 'utf8');
-        console.log(`  File read successfully (${fileContent.length} bytes)`);
-        console.log(`  File content preview (first 100 chars):\n    "${fileContent.substring(0, 100)}..."`);
+        logger.debug(`  File read successfully (${fileContent.length} bytes)`);
+        logger.debug(`  File content preview (first 100 chars):\n    "${fileContent.substring(0, 100)}..."`);
         
         // Calculate relative path
         const filePathInRepo = path.relative(process.cwd(), file);
-        console.log(`  Converted to repo-relative path: ${filePathInRepo}`);
+        logger.info(`  Converted to repo-relative path: ${filePathInRepo}`);
         
         if (filePathInRepo === '') {
-          console.error(`  ERROR: Relative path is empty! This file would overwrite the repo root.`);
+          logger.error(`  ERROR: Relative path is empty! This file would overwrite the repo root.`);
           continue;
         }
         
         if (filePathInRepo.startsWith('..')) {
-          console.error(`  ERROR: File is outside the repo directory: ${filePathInRepo}`);
+          logger.error(`  ERROR: File is outside the repo directory: ${filePathInRepo}`);
           continue;
         }
         
@@ -622,10 +662,10 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
           path: filePathInRepo,
           content: fileContent
         });
-        console.log(`  Added ${filePathInRepo} to batch commit`);
+        logger.info(`  Added ${filePathInRepo} to batch commit`);
       } catch (error) {
-        console.error(`  Failed to process file ${file} for commit: ${error.message}`);
-        console.error(`  Error stack: ${error.stack}`);
+        logger.error(`  Failed to process file ${file} for commit: ${error.message}`);
+        logger.debug(`  Error stack: ${error.stack}`);
       }
     }
     
@@ -635,7 +675,7 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
         path: changelogPath,
         content: changelogContent
       });
-      console.log(`Added changelog to batch commit: ${changelogPath}`);
+      logger.info(`Added changelog to batch commit: ${changelogPath}`);
     }
     
     // Commit all files in a single batch
@@ -645,24 +685,25 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
           octokit,
           context,
           filesToCommit,
-          `chore: update files for release ${newVersion}`,
-          stagingBranch
+          buildCommitMessage(newVersion, config),
+          stagingBranch,
+          signer
         );
-        console.log(`Successfully committed ${filesToCommit.length} files in a single fabulous commit! 💁‍♀️`);
+        logger.info(`Successfully committed ${filesToCommit.length} files in a single fabulous commit! 💁‍♀️`);
       } catch (commitError) {
-        console.error(`Error during batch commit operation: ${commitError.message}`);
+        logger.error(`Error during batch commit operation: ${commitError.message}`);
         if (commitError.response) {
-          console.error(`API Response: ${JSON.stringify(commitError.response.data)}`);
+          logger.debug(`API Response: ${JSON.stringify(commitError.response.data)}`);
         }
         throw commitError;
       }
     } else {
-      console.log(`No files to commit`);
+      logger.info(`No files to commit`);
     }
   }
   
   // Step 6: Check if PR already exists
-  console.log(`Checking for existing PRs from ${stagingBranch} to ${config.releaseBranch}...`);
+  logger.info(`Checking for existing PRs from ${stagingBranch} to ${config.releaseBranch}...`);
   let existingPR = null;
   
   const { data: openPRs } = await octokit.rest.pulls.list({
@@ -675,46 +716,16 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
   
   if (openPRs.length > 0) {
     existingPR = openPRs[0];
-    console.log(`Found existing PR #${existingPR.number}`);
+    logger.info(`Found existing PR #${existingPR.number}`);
   }
   
   // Step 7: Build PR title and body
-  const title = config.pullRequestTitle.replace('{version}', newVersion);
-  // Create PR body with changelog table
-  const initialBody = `${config.pullRequestHeader || 'Release PR'}`;
-  
-  // Use the updatePRDescriptionWithChangelog function to add the changelog table
-  let body = updatePRDescriptionWithChangelog(initialBody, changelog, config);
-  
-  // Add a cute intro line
-  body += `Time to freshen up our codebase with a fabulous new release! 💅✨\n\n`;
-  
-  // Add list of updated files with cute styling
-  if (updatedFiles && updatedFiles.length > 0) {
-    body += `## 📦 Updated Files 📦\n\n`;
-    body += `These files got a gorgeous makeover:\n\n`;
-    for (const file of updatedFiles) {
-      body += `- \`${path.relative(process.cwd(), file)}\` 💖\n`;
-    }
-    body += '\n';
-  }
-  
-  // Get our current version dynamically
-  let versionInfo;
-  try {
-    versionInfo = require('../version');
-  } catch (e) {
-    console.log(`Oops! Couldn't find version info, using default version instead 💁‍♀️`);
-    versionInfo = { VERSION_WITH_V: 'v1.0.0' };
-  }
-  
-  // Add a fabulous footer with dynamic version
-  body += `---\n\n*This PR was auto-generated by the fabulous Release Boss ${versionInfo.VERSION_WITH_V}* 👑`;
-  
-  
+  const title = buildPRTitle(newVersion, config);
+  const body = buildPRBody(changelog, config, updatedFiles, newVersion);
+
   // Step 8: Create or update PR
   if (existingPR) {
-    console.log(`Updating existing PR #${existingPR.number}...`);
+    logger.info(`Updating existing PR #${existingPR.number}...`);
     await octokit.rest.pulls.update({
       owner,
       repo,
@@ -723,14 +734,14 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
       body
     });
     
-    console.log(`Updated PR #${existingPR.number}: ${title}`);
+    logger.info(`Updated PR #${existingPR.number}: ${title}`);
     return {
       prNumber: existingPR.number,
       prUrl: existingPR.html_url,
       prStatus: existingPR.state
     };
   } else {
-    console.log(`Creating new PR from ${stagingBranch} to ${config.releaseBranch}...`);
+    logger.info(`Creating new PR from ${stagingBranch} to ${config.releaseBranch}...`);
     const { data: newPR } = await octokit.rest.pulls.create({
       owner,
       repo,
@@ -742,7 +753,7 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
     
     // Add release label if it exists
     try {
-      console.log(`Adding 'release' label to PR #${newPR.number}...`);
+      logger.info(`Adding 'release' label to PR #${newPR.number}...`);
       await octokit.rest.issues.addLabels({
         owner,
         repo,
@@ -751,10 +762,10 @@ async function createOrUpdatePR(octokit, context, newVersion, changelog, config,
       });
     } catch (error) {
       // Label may not exist, just continue
-      console.log('Could not add release label to PR (label may not exist)');
+      logger.info('Could not add release label to PR (label may not exist)');
     }
     
-    console.log(`Created PR #${newPR.number}: ${title}`);
+    logger.info(`Created PR #${newPR.number}: ${title}`);
     return {
       prNumber: newPR.number,
       prUrl: newPR.html_url,
@@ -776,25 +787,26 @@ async function updateExistingPR(octokit, context, newVersion, changelog, config,
   const { owner, repo } = context.repo;
   const prNumber = context.payload.pull_request.number;
   
-  console.log(`Updating existing PR #${prNumber} with new version ${newVersion}...`);
+  logger.info(`Updating existing PR #${prNumber} with new version ${newVersion}...`);
   
   // The changelog parameter is expected to be a string
   // No type conversion needed as it should already be a string from generateChangelog
   if (typeof changelog !== 'string') {
-    console.log(`Warning: changelog parameter is not a string in updateExistingPR! 💅 Type: ${typeof changelog}`);
+    logger.warn(`Warning: changelog parameter is not a string in updateExistingPR! 💅 Type: ${typeof changelog}`);
     // Convert to string if it's not already
     changelog = String(changelog || '');
   }
   
   // Get the PR branch
   const prBranch = context.payload.pull_request.head.ref;
+  const signer = config.signCommits ? await getSigner(config) : null;
   
   // Collect all files to commit in a single batch
   const filesToCommit = [];
   
   // Prepare changelog content if needed
   if (config.changelogPath) {
-    console.log(`Preparing changelog for ${newVersion} in existing PR...`);
+    logger.info(`Preparing changelog for ${newVersion} in existing PR...`);
     
     // Get current content of the changelog from the first available source
     let baseContent = '';
@@ -804,11 +816,11 @@ async function updateExistingPR(octokit, context, newVersion, changelog, config,
     // ALWAYS try to get content from the release branch first to avoid conflicts
     const sourceBranches = [config.releaseBranch, 'release', prBranch];
     
-    console.log(`Will try to fetch changelog content from branches in this order: ${sourceBranches.join(', ')}`);
+    logger.info(`Will try to fetch changelog content from branches in this order: ${sourceBranches.join(', ')}`);
     
     for (const sourceRef of sourceBranches) {
       try {
-        console.log(`Attempting to get changelog from ${sourceRef} branch...`);
+        logger.info(`Attempting to get changelog from ${sourceRef} branch...`);
         const { data } = await octokit.rest.repos.getContent({
           owner,
           repo,
@@ -819,13 +831,13 @@ async function updateExistingPR(octokit, context, newVersion, changelog, config,
         // Decode content from base64
         baseContent = Buffer.from(data.content, 'base64').toString('utf8');
         sourceUsed = sourceRef;
-        console.log(`✨ Successfully retrieved base changelog from ${sourceRef} branch!`);
+        logger.info(`✨ Successfully retrieved base changelog from ${sourceRef} branch!`);
         break; // We found content, no need to check other branches
       } catch (error) {
         if (error.status === 404) {
-          console.log(`Changelog file doesn't exist in ${sourceRef} branch, trying next source...`);
+          logger.info(`Changelog file doesn't exist in ${sourceRef} branch, trying next source...`);
         } else {
-          console.log(`Error getting changelog from ${sourceRef}, trying next source: ${error.message}`);
+          logger.info(`Error getting changelog from ${sourceRef}, trying next source: ${error.message}`);
         }
       }
     }
@@ -833,19 +845,19 @@ async function updateExistingPR(octokit, context, newVersion, changelog, config,
     // Generate changelog content using our new function
     // Pass the changelog string directly to generateFileChangelog
     // The function will handle parsing if needed
-    let changelogContent = generateFileChangelog(changelog, newVersion, baseContent);
+    let changelogContent = generateFileChangelog(changelog, newVersion, baseContent, { header: config.changelogHeader });
     
     // Add changelog to files to commit
     filesToCommit.push({
       path: config.changelogPath,
       content: changelogContent
     });
-    console.log(`Added changelog to batch commit: ${config.changelogPath}`);
+    logger.info(`Added changelog to batch commit: ${config.changelogPath}`);
   }
   
   // Add version files to the batch
   if (updatedFiles && updatedFiles.length > 0) {
-    console.log(`Processing ${updatedFiles.length} updated version files for batch commit...`);
+    logger.info(`Processing ${updatedFiles.length} updated version files for batch commit...`);
     
     for (const file of updatedFiles) {
       try {
@@ -853,7 +865,7 @@ async function updateExistingPR(octokit, context, newVersion, changelog, config,
         const filePathInRepo = path.relative(process.cwd(), file);
         
         if (filePathInRepo === '' || filePathInRepo.startsWith('..')) {
-          console.error(`Invalid file path: ${filePathInRepo}`);
+          logger.error(`Invalid file path: ${filePathInRepo}`);
           continue;
         }
         
@@ -861,9 +873,9 @@ async function updateExistingPR(octokit, context, newVersion, changelog, config,
           path: filePathInRepo,
           content: fileContent
         });
-        console.log(`Added ${filePathInRepo} to batch commit`);
+        logger.info(`Added ${filePathInRepo} to batch commit`);
       } catch (error) {
-        console.error(`Error processing file ${file} for commit: ${error.message}`);
+        logger.error(`Error processing file ${file} for commit: ${error.message}`);
       }
     }
   }
@@ -875,41 +887,45 @@ async function updateExistingPR(octokit, context, newVersion, changelog, config,
         octokit,
         context,
         filesToCommit,
-        `chore: update files for release ${newVersion}`,
-        prBranch
+        buildCommitMessage(newVersion, config),
+        prBranch,
+        signer
       );
-      console.log(`Successfully committed ${filesToCommit.length} files in a single fabulous commit! 💁‍♀️`);
+      logger.info(`Successfully committed ${filesToCommit.length} files in a single fabulous commit! 💁‍♀️`);
     } catch (commitError) {
-      console.error(`Error during batch commit operation: ${commitError.message}`);
+      logger.error(`Error during batch commit operation: ${commitError.message}`);
       throw commitError;
     }
   } else {
-    console.log(`No files to commit in the PR`);
+    logger.info(`No files to commit in the PR`);
   }
   
   // Update the PR title and body
-  const title = config.pullRequestTitle.replace('{version}', newVersion);
+  const title = buildPRTitle(newVersion, config);
   
-  // Get existing PR body
-  const { data: pr } = await octokit.rest.pulls.get({
-    owner,
-    repo,
-    pull_number: prNumber
-  });
-  
-  // Update the PR body with the new changelog entries
-  let body = pr.body || '';
-  
-  // Use the updatePRDescriptionWithChangelog function to update the changelog table
-  body = updatePRDescriptionWithChangelog(body, changelog, config);
-  
-  // Add updated list of files
-  if (updatedFiles && updatedFiles.length > 0) {
-    body += `## Updated Files\n\n`;
-    for (const file of updatedFiles) {
-      body += `- ${path.relative(process.cwd(), file)}\n`;
+  let body;
+  if (config.pullRequestChangelog === 'table') {
+    // Get existing PR body
+    const { data: pr } = await octokit.rest.pulls.get({
+      owner,
+      repo,
+      pull_number: prNumber
+    });
+    
+    // Use the updatePRDescriptionWithChangelog function to merge the new entries into the changelog table
+    body = updatePRDescriptionWithChangelog(pr.body || '', changelog, config);
+    
+    // Add updated list of files
+    if (updatedFiles && updatedFiles.length > 0) {
+      body += `## Updated Files\n\n`;
+      for (const file of updatedFiles) {
+        body += `- ${path.relative(process.cwd(), file)}\n`;
+      }
+      body += '\n';
     }
-    body += '\n';
+  } else {
+    // The preview is rebuilt from scratch, so it always matches the changelog just committed
+    body = buildPRBody(changelog, config, updatedFiles, newVersion);
   }
   
   // Update the PR
@@ -921,7 +937,7 @@ async function updateExistingPR(octokit, context, newVersion, changelog, config,
     body
   });
   
-  console.log(`Updated PR #${prNumber} with new version ${newVersion}`);
+  logger.info(`Updated PR #${prNumber} with new version ${newVersion}`);
   return {
     prNumber,
     prUrl: pr.data.html_url,
@@ -943,7 +959,7 @@ async function updateExistingPR(octokit, context, newVersion, changelog, config,
  */
 async function commitFileToStaging(octokit, context, filePath, fileContent, message, branch) {
   const { owner, repo } = context.repo;
-  console.log(`Committing file ${filePath} to branch ${branch}...`);
+  logger.info(`Committing file ${filePath} to branch ${branch}...`);
   
   let currentSha = null;
   
@@ -958,13 +974,13 @@ async function commitFileToStaging(octokit, context, filePath, fileContent, mess
     
     if (existingFile) {
       currentSha = existingFile.sha;
-      console.log(`File ${filePath} already exists in branch ${branch}, will update it`);
+      logger.info(`File ${filePath} already exists in branch ${branch}, will update it`);
     }
   } catch (error) {
     if (error.status === 404) {
-      console.log(`File ${filePath} doesn't exist in branch ${branch} yet, will create it`);
+      logger.info(`File ${filePath} doesn't exist in branch ${branch} yet, will create it`);
     } else {
-      console.error(`Error checking file existence:`, error);
+      logger.error(`Error checking file existence: ${error.message}`);
       throw error;
     }
   }
@@ -981,9 +997,9 @@ async function commitFileToStaging(octokit, context, filePath, fileContent, mess
       sha: currentSha
     });
     
-    console.log(`Successfully committed ${filePath} to ${branch}`);
+    logger.info(`Successfully committed ${filePath} to ${branch}`);
   } catch (error) {
-    console.error(`Error committing file:`, error);
+    logger.error(`Error committing file: ${error.message}`);
     throw error;
   }
 }
@@ -994,46 +1010,33 @@ async function commitFileToStaging(octokit, context, filePath, fileContent, mess
  * @param {Object} context - GitHub context
  * @param {String} version - Version to tag
  * @param {Object} config - Release Boss configuration
+ * @param {Object} signer - GPG signer for signTags (optional, loaded from signingKey by default)
+ * @param {Object} options - Tagging options
+ * @param {String} options.sha - Commit to tag (default: the head of the release branch)
+ * @param {Boolean} options.annotate - Create an annotated tag object even when the tag isn't signed
  * @returns {Object} - Object containing the commit SHA and a list of all tags created
  */
-async function tagRelease(octokit, context, version, config) {
+async function tagRelease(octokit, context, version, config, signer = undefined, options = {}) {
   const { owner, repo } = context.repo;
   
-  // Determine if version tags should be prefixed with 'v'
-  const useVPrefix = config.versionTagPrefix !== false; // Default to true if not specified
-  const prefix = useVPrefix ? 'v' : '';
+  // Primary version tag (with 'v' and package namespace as configured)
+  const tagName = getReleaseTagName(version, config);
   
-  // Primary version tag
-  const tagName = `${prefix}${version}`;
-  
-  // Parse version components for additional tags
-  const versionParts = version.split('.');
-  const major = versionParts[0];
-  const minor = versionParts.length > 1 ? versionParts[1] : '0';
+  // Load the signing key before anything else so a bad key fails cleanly
+  if (signer === undefined) {
+    signer = config.signTags ? await getSigner(config) : null;
+  }
   
-  // Setup additional tags if configured
-  const additionalTags = [];
+  // Setup additional tags if configured (latest, major, major.minor)
+  const additionalTags = getAdditionalTagNames(version, config);
   const createdTags = [];
   
   // Add the primary tag to our created tags list
   createdTags.push(tagName);
   
-  // Setup additional tag configurations
-  if (config.tagLatest !== false) { // Default to true if not specified
-    additionalTags.push('latest');
-  }
-  
-  if (config.tagMajor === true) {
-    additionalTags.push(`${prefix}${major}`);
-  }
-  
-  if (config.tagMinor === true) {
-    additionalTags.push(`${prefix}${major}.${minor}`);
-  }
-  
-  console.log(`Creating tag ${tagName} for release...`);
+  logger.info(`Creating tag ${tagName} for release...`);
   if (additionalTags.length > 0) {
-    console.log(`Will also create/update additional tags: ${additionalTags.join(', ')}`);
+    logger.info(`Will also create/update additional tags: ${additionalTags.join(', ')}`);
   }
   
   // Check if primary tag already exists
@@ -1045,37 +1048,70 @@ async function tagRelease(octokit, context, version, config) {
       ref: `tags/${tagName}`
     });
     
-    console.log(`Tag ${tagName} already exists at commit ${existingTag.object.sha.substring(0, 7)}, skipping tag creation`);
     commitSha = existingTag.object.sha;
+    
+    // Annotated tags point at a tag object - the release commit is behind it
+    if (existingTag.object.type === 'tag') {
+      const { data: tagObject } = await octokit.rest.git.getTag({
+        owner,
+        repo,
+        tag_sha: existingTag.object.sha
+      });
+      commitSha = tagObject.object.sha;
+    }
+    
+    logger.info(`Tag ${tagName} already exists at commit ${commitSha.substring(0, 7)}, skipping tag creation`);
   } catch (error) {
     if (error.status !== 404) {
-      console.error(`Error checking for existing tag:`, error);
+      logger.error(`Error checking for existing tag: ${error.message}`);
       throw error;
     }
     // Tag doesn't exist, proceed with creation
-    console.log(`Tag ${tagName} does not exist yet, will create it`);
+    logger.info(`Tag ${tagName} does not exist yet, will create it`);
     
-    // Get SHA of the current commit on the release branch
+    // Tag the merge commit when we know it - the release branch may have moved on since
     try {
-      const { data: releaseRef } = await octokit.rest.git.getRef({
-        owner,
-        repo,
-        ref: `heads/${config.releaseBranch}`
-      });
+      if (options.sha) {
+        commitSha = options.sha;
+      } else {
+        const { data: releaseRef } = await octokit.rest.git.getRef({
+          owner,
+          repo,
+          ref: `heads/${config.releaseBranch}`
+        });
+        commitSha = releaseRef.object.sha;
+      }
+      logger.info(`Creating tag ${tagName} at commit ${commitSha.substring(0, 7)}...`);
       
-      commitSha = releaseRef.object.sha;
-      console.log(`Creating tag ${tagName} at commit ${commitSha.substring(0, 7)}...`);
+      // Signed (or annotated) releases get a tag object; otherwise a lightweight tag will do
+      let tagSha = commitSha;
+      if (signer || options.annotate) {
+        const message = `Release ${tagName}`;
+        const fields = signer ? await signer.signTag({ tag: tagName, object: commitSha, message }) : { message };
+        const { data: tagObject } = await octokit.rest.git.createTag({
+          owner,
+          repo,
+          tag: tagName,
+          object: commitSha,
+          type: 'commit',
+          ...fields
+        });
+        tagSha = tagObject.sha;
+        logger.info(signer
+          ? `🔏 Created signed tag object ${tagSha.substring(0, 7)} with ${signer.key.fingerprint}`
+          : `Created annotated tag object ${tagSha.substring(0, 7)}`);
+      }
       
       await octokit.rest.git.createRef({
         owner,
         repo,
         ref: `refs/tags/${tagName}`,
-        sha: commitSha
+        sha: tagSha
       });
       
-      console.log(`Successfully created tag: ${tagName} at ${commitSha.substring(0, 7)}`);
+      logger.info(`Successfully created tag: ${tagName} at ${commitSha.substring(0, 7)}`);
     } catch (error) {
-      console.error(`Error creating primary tag:`, error);
+      logger.error(`Error creating primary tag: ${error.message}`);
       throw error;
     }
   }
@@ -1092,7 +1128,7 @@ async function tagRelease(octokit, context, version, config) {
             ref: `tags/${tag}`
           });
           
-          console.log(`Tag ${tag} already exists, updating it to point to the new commit 💅`);
+          logger.info(`Tag ${tag} already exists, updating it to point to the new commit 💅`);
           
           // Update existing tag to point to the new commit
           await octokit.rest.git.updateRef({
@@ -1103,7 +1139,7 @@ async function tagRelease(octokit, context, version, config) {
             force: true // Force update if it points to a different commit
           });
           
-          console.log(`Updated existing tag ${tag} to point to commit ${commitSha.substring(0, 7)}`);
+          logger.info(`Updated existing tag ${tag} to point to commit ${commitSha.substring(0, 7)}`);
           createdTags.push(tag);
         } catch (error) {
           if (error.status === 404) {
@@ -1115,14 +1151,14 @@ async function tagRelease(octokit, context, version, config) {
               sha: commitSha
             });
             
-            console.log(`Created additional tag ${tag} pointing to commit ${commitSha.substring(0, 7)}`);
+            logger.info(`Created additional tag ${tag} pointing to commit ${commitSha.substring(0, 7)}`);
             createdTags.push(tag);
           } else {
             throw error;
           }
         }
       } catch (error) {
-        console.error(`Error managing additional tag ${tag}:`, error);
+        logger.error(`Error managing additional tag ${tag}: ${error.message}`);
         // Continue with other tags instead of failing completely
       }
     }
@@ -1134,6 +1170,31 @@ async function tagRelease(octokit, context, version, config) {
   };
 }
 
+/**
+ * Create a commit object, signing it when we have a signer
+ * @param {Object} octokit - GitHub API client
+ * @param {Object} context - GitHub context
+ * @param {Object} commit - { message, tree, parents }
+ * @param {Object} signer - GPG signer (optional)
+ * @returns {Object} - Created commit
+ */
+async function createCommit(octokit, context, commit, signer = null) {
+  const { owner, repo } = context.repo;
+  const signed = signer ? await signer.signCommit(commit) : {};
+  
+  const { data } = await octokit.rest.git.createCommit({
+    owner,
+    repo,
+    ...commit,
+    ...signed
+  });
+  
+  if (signer) {
+    logger.info(`🔏 Signed commit ${data.sha.substring(0, 7)} with ${signer.key.fingerprint}`);
+  }
+  return data;
+}
+
 /**
  * Commit multiple files to a branch in a single commit
  * @param {Object} octokit - GitHub API client
@@ -1141,15 +1202,16 @@ async function tagRelease(octokit, context, version, config) {
  * @param {Array} files - Array of {path, content} objects
  * @param {String} message - Commit message
  * @param {String} branch - Branch to commit to
+ * @param {Object} signer - GPG signer for the commit (optional)
  */
-async function commitMultipleFilesToStaging(octokit, context, files, message, branch) {
+async function commitMultipleFilesToStaging(octokit, context, files, message, branch, signer = null) {
   if (!files || !Array.isArray(files) || files.length === 0) {
-    console.log('No files to commit');
+    logger.info('No files to commit');
     return;
   }
 
   const { owner, repo } = context.repo;
-  console.log(`Committing ${files.length} files to branch ${branch} in a single commit...`);
+  logger.info(`Committing ${files.length} files to branch ${branch} in a single commit...`);
   
   // Get the current commit SHA to use as the base
   const { data: refData } = await octokit.rest.git.getRef({
@@ -1195,13 +1257,11 @@ async function commitMultipleFilesToStaging(octokit, context, files, message, br
   });
   
   // Create a commit with the new tree
-  const { data: newCommit } = await octokit.rest.git.createCommit({
-    owner,
-    repo,
+  const newCommit = await createCommit(octokit, context, {
     message,
     tree: newTree.sha,
     parents: [baseTreeSha]
-  });
+  }, signer);
   
   // Try to update the branch reference to point to the new commit
   try {
@@ -1212,8 +1272,8 @@ async function commitMultipleFilesToStaging(octokit, context, files, message, br
       sha: newCommit.sha
     });
     
-    console.log(`Successfully committed ${files.length} files to ${branch} in a single commit! 💅`);
-    console.log(`Files: ${files.map(f => f.path).join(', ')}`);
+    logger.info(`Successfully committed ${files.length} files to ${branch} in a single commit! 💅`);
+    logger.info(`Files: ${files.map(f => f.path).join(', ')}`);
   } catch (error) {
     // Check if this is a "not a fast forward" error
     if (error.message.includes('Update is not a fast forward') || 
@@ -1221,7 +1281,7 @@ async function commitMultipleFilesToStaging(octokit, context, files, message, br
          error.response.data.message && 
          error.response.data.message.includes('Update is not a fast forward'))) {
       
-      console.log(`💁‍♀️ Oh honey, we got a "not a fast forward" error! Let me fix that for you...`);
+      logger.info(`💁‍♀️ Oh honey, we got a "not a fast forward" error! Let me fix that for you...`);
       
       // Get the latest state of the branch
       try {
@@ -1233,16 +1293,14 @@ async function commitMultipleFilesToStaging(octokit, context, files, message, br
         });
         
         const latestSha = latestRefData.object.sha;
-        console.log(`Current branch HEAD is at ${latestSha.substring(0, 7)}`);
+        logger.info(`Current branch HEAD is at ${latestSha.substring(0, 7)}`);
         
         // Create a new commit with the latest branch as parent
-        const { data: mergeCommit } = await octokit.rest.git.createCommit({
-          owner,
-          repo,
+        const mergeCommit = await createCommit(octokit, context, {
           message: `${message} (resolved fast-forward issue)`,
           tree: newTree.sha,
           parents: [latestSha] // Use the latest SHA as parent
-        });
+        }, signer);
         
         // Force update the branch reference
         await octokit.rest.git.updateRef({
@@ -1253,15 +1311,15 @@ async function commitMultipleFilesToStaging(octokit, context, files, message, br
           force: true // Force the update to resolve the fast-forward issue
         });
         
-        console.log(`Successfully committed ${files.length} files to ${branch} with force update! 💅`);
-        console.log(`Files: ${files.map(f => f.path).join(', ')}`);
+        logger.info(`Successfully committed ${files.length} files to ${branch} with force update! 💅`);
+        logger.info(`Files: ${files.map(f => f.path).join(', ')}`);
       } catch (retryError) {
-        console.error(`Failed to resolve fast-forward issue: ${retryError.message}`);
+        logger.error(`Failed to resolve fast-forward issue: ${retryError.message}`);
         throw new Error(`Failed to commit changes after resolving fast-forward issue: ${retryError.message}`);
       }
     } else {
       // If it's not a fast-forward error, rethrow
-      console.error(`Error updating branch reference: ${error.message}`);
+      logger.error(`Error updating branch reference: ${error.message}`);
       throw error;
     }
   }
@@ -1276,7 +1334,7 @@ async function commitMultipleFilesToStaging(octokit, context, files, message, br
  */
 async function deleteBranch(octokit, context, branch) {
   const { owner, repo } = context.repo;
-  console.log(`Attempting to delete branch ${branch} - cleaning up after ourselves like the fabulous queen we are! 💁‍♀️`);
+  logger.info(`Attempting to delete branch ${branch} - cleaning up after ourselves like the fabulous queen we are! 💁‍♀️`);
   
   try {
     // First check if the branch exists
@@ -1289,7 +1347,7 @@ async function deleteBranch(octokit, context, branch) {
     } catch (error) {
       // If branch doesn't exist, that's fine - just return
       if (error.status === 404) {
-        console.log(`Branch ${branch} doesn't exist, no need to delete it 💅`);
+        logger.info(`Branch ${branch} doesn't exist, no need to delete it 💅`);
         return false;
       }
       throw error; // Re-throw other errors
@@ -1302,10 +1360,10 @@ async function deleteBranch(octokit, context, branch) {
       ref: `heads/${branch}`
     });
     
-    console.log(`Successfully deleted branch ${branch} - keeping things tidy! ✨`);
+    logger.info(`Successfully deleted branch ${branch} - keeping things tidy! ✨`);
     return true;
   } catch (error) {
-    console.error(`Error deleting branch ${branch}: ${error.message}`);
+    logger.error(`Error deleting branch ${branch}: ${error.message}`);
     return false;
   }
 }
@@ -0,0 +1,209 @@
+const path = require('path');
+
+const { Provider } = require('./provider');
+const { buildPRTitle } = require('../core/prContent');
+const { getReleaseTagName, getAdditionalTagNames } = require('../utils/tags');
+const { logger } = require('../utils/logger');
+
+/**
+ * Dry-run wrapper around a real provider 🔍
+ *
+ * Every read goes straight through to the wrapped provider so versions and
+ * changelogs come out exactly as they would for real, but anything that would
+ * change the remote (branches, commits, PRs, tags) is only logged.
+ */
+class DryRunProvider extends Provider {
+  /**
+   * @param {Provider} provider - Provider to wrap
+   */
+  constructor(provider) {
+    super({ name: provider.name });
+    this.provider = provider;
+  }
+
+  get repoUrl() {
+    return this.provider.repoUrl;
+  }
+
+  get headSha() {
+    return this.provider.headSha;
+  }
+
+  get branch() {
+    return this.provider.branch;
+  }
+
+  compareUrl(from, to) {
+    return this.provider.compareUrl(from, to);
+  }
+
+  pullRequestUrl(number) {
+    return this.provider.pullRequestUrl(number);
+  }
+
+  issueUrl(number) {
+    return this.provider.issueUrl(number);
+  }
+
+  async checkConnection() {
+    if (this.provider.checkConnection) {
+      await this.provider.checkConnection();
+    }
+  }
+
+  async compareCommits(base, head) {
+    return this.provider.compareCommits(base, head);
+  }
+
+  async getCommit(sha) {
+    return this.provider.getCommit(sha);
+  }
+
+  async getCommitFiles(sha) {
+    return this.provider.getCommitFiles(sha);
+  }
+
+  async listTags() {
+    return this.provider.listTags();
+  }
+
+  async listCommits(head) {
+    return this.provider.listCommits(head);
+  }
+
+  async branchExists(branch) {
+    // Providers that can't tell get the benefit of the doubt, like checkConnection
+    return this.provider.branchExists ? this.provider.branchExists(branch) : true;
+  }
+
+  async listBranches(prefix) {
+    return this.provider.listBranches(prefix);
+  }
+
+  async listBranchPRs(branch) {
+    return this.provider.listBranchPRs(branch);
+  }
+
+  async resolveUsername(email) {
+    return this.provider.resolveUsername(email);
+  }
+
+  async getLatestReleaseTag() {
+    return this.provider.getLatestReleaseTag();
+  }
+
+  async getFileContent(filePath, ref) {
+    return this.provider.getFileContent(filePath, ref);
+  }
+
+  async findOpenReleasePR(config, version) {
+    return this.provider.findOpenReleasePR(config, version);
+  }
+
+  async detectMergedReleasePR(config, options) {
+    return this.provider.detectMergedReleasePR(config, options);
+  }
+
+  async getPR(number) {
+    return this.provider.getPR(number);
+  }
+
+  async listPRComments(number) {
+    return this.provider.listPRComments(number);
+  }
+
+  async getPRMergeStatus(number) {
+    return this.provider.getPRMergeStatus(number);
+  }
+
+  async createReleasePR(version, changelog, config, updatedFiles = []) {
+    const stagingBranch = config.releasePR ? config.releasePR.headBranch : `${config.stagingBranch}-v${version}`;
+    const title = buildPRTitle(version, config);
+
+    log(config.releasePR
+      ? `Would refresh staging branch ${stagingBranch} with the changes from ${config.mergeBranch}`
+      : `Would create staging branch ${stagingBranch} with the changes from ${config.mergeBranch}`);
+
+    const files = updatedFiles.map(file => path.relative(process.cwd(), file));
+    if (config.changelogPath) {
+      files.push(config.changelogPath);
+    }
+    if (files.length > 0) {
+      log(`Would commit ${files.length} files to ${stagingBranch}${config.signCommits ? ' (GPG-signed)' : ''}:`);
+      files.forEach(file => log(`  - ${file}`));
+    }
+
+    let existing = config.releasePR || null;
+    if (config.releasePR === undefined) {
+      try {
+        existing = await this.provider.findOpenReleasePR(config);
+      } catch (error) {
+        log(`Couldn't look up open release PRs: ${error.message}`);
+      }
+    }
+
+    if (existing) {
+      log(`Would update PR #${existing.number} (${existing.url}) to "${title}"`);
+      return { prNumber: existing.number, prUrl: existing.url, prStatus: 'dry-run' };
+    }
+
+    log(`Would open a PR from ${stagingBranch} to ${config.releaseBranch}: "${title}"`);
+    return { prNumber: null, prUrl: null, prStatus: 'dry-run' };
+  }
+
+  async updatePR(number, fields) {
+    log(`Would update PR #${number}${fields.title ? ` to "${fields.title}"` : ''}`);
+    return this.provider.getPR(number);
+  }
+
+  async mergePR(number, options = {}) {
+    log(`Would merge PR #${number} using the ${options.method || 'merge'} method`);
+    return { merged: false, sha: null };
+  }
+
+  async createTag(version, config, options = {}) {
+    const tags = [getReleaseTagName(version, config), ...getAdditionalTagNames(version, config)];
+    const target = options.sha ? `commit ${options.sha.substring(0, 7)}` : `the head of ${config.releaseBranch}`;
+    const kind = config.signTags ? ' as a GPG-signed annotated tag' : options.annotate ? ' as an annotated tag' : '';
+
+    log(`Would tag ${target} with: ${tags.join(', ')}${kind ? ` (${tags[0]}${kind})` : ''}`);
+    return { sha: options.sha || 'dry-run', tags };
+  }
+
+  async createRelease(tagName, release) {
+    log(`Would publish the ${release.prerelease ? 'prerelease' : 'release'} "${release.title}" for ${tagName} (or update the one already there), with ${release.body.split('\n').length} lines of notes`);
+    return { url: null, updated: false };
+  }
+
+  async deleteRelease(tagName) {
+    log(`Would delete the ${tagName} release, if there is one`);
+    return true;
+  }
+
+  async deleteTag(tagName) {
+    log(`Would delete tag ${tagName}`);
+    return true;
+  }
+
+  async revertCommit(sha, { branch, message }) {
+    log(`Would revert commit ${sha.substring(0, 7)} on ${branch}: "${message.split('\n')[0]}"`);
+    return { sha: null };
+  }
+
+  async deleteBranch(branch) {
+    log(`Would delete branch ${branch}`);
+    return true;
+  }
+}
+
+/**
+ * Log a dry-run action
+ * @param {String} message - What would have happened
+ */
+function log(message) {
+  logger.info(`🔍 [dry-run] ${message}`);
+}
+
+module.exports = {
+  DryRunProvider
+};
@@ -0,0 +1,604 @@
+const crypto = require('crypto');
+const fs = require('fs').promises;
+const path = require('path');
+
+const { Provider } = require('./provider');
+const { generateFileChangelog } = require('../github/changelogTable');
+const { buildPRTitle, buildPRBody, buildCommitMessage } = require('../core/prContent');
+const { getReleaseTagName, getAdditionalTagNames } = require('../utils/tags');
+const { logger } = require('../utils/logger');
+
+/**
+ * Error shaped like the platforms' API errors, so callers can check error.status
+ * @param {String} message - Error message
+ * @param {Number} status - HTTP-style status code
+ * @returns {Error}
+ */
+function apiError(message, status) {
+  return Object.assign(new Error(message), { status });
+}
+
+/**
+ * In-memory implementation of the Provider interface, for tests 🧪
+ *
+ * Holds a tiny repository - commits, branches, tags, PRs and releases - so the
+ * whole workflow can run against a fixture instead of a real platform. Seed it
+ * with addCommits, createBranch and addTag, run release-boss against it, then
+ * assert on branches, tags, pullRequests, releases and events.
+ *
+ * Links are GitHub-style. Every release PR commit lands in memory - nothing
+ * on disk changes except what release-boss itself writes (version files).
+ *
+ * @example
+ * const { ReleaseBoss, FakeProvider } = require('release-boss');
+ * const provider = new FakeProvider({ branch: 'main' });
+ * provider.addCommits('main', ['feat: shiny new thing', 'fix: small thing']);
+ * const result = await new ReleaseBoss({}, { provider, context: { payload: {} } }).run();
+ * provider.pullRequests[0]; // { number: result.prNumber, state: 'open', headBranch: 'staging-v0.1.0', ... }
+ */
+class FakeProvider extends Provider {
+  /**
+   * @param {Object} options - Provider options
+   * @param {String} options.repoUrl - Web URL used for links (default https://github.com/owner/repo)
+   * @param {String} options.branch - Branch the run is for (default: none, like a detached HEAD)
+   * @param {String} options.sha - Commit the run is for (default: the head of options.branch)
+   * @param {Object} options.usernames - Platform usernames by author email, for resolveUsername
+   * @param {String} options.date - Date of the first commit - each later one is a minute newer
+   *   (default 2024-01-01T00:00:00Z, so shas and dates are the same every run)
+   */
+  constructor({ repoUrl = 'https://github.com/owner/repo', branch = null, sha = null, usernames = {}, date = '2024-01-01T00:00:00Z' } = {}) {
+    super({ name: 'fake' });
+    this.url = repoUrl.replace(/\/+$/, '');
+    this.ref = branch;
+    this.sha = sha;
+    this.usernames = usernames;
+    this.clock = Date.parse(date);
+
+    // Commit records by sha: { sha, message, parents, authorName, authorEmail, username, date, changes, files, order }
+    this.commits = new Map();
+    // Branch and tag name -> sha
+    this.branches = new Map();
+    this.tags = new Map();
+    // Normalised PRs, in the order they were opened
+    this.pullRequests = [];
+    // PR number -> normalised comments
+    this.comments = new Map();
+    // Tag name -> { tagName, title, body, prerelease }
+    this.releases = new Map();
+    // Everything that changed the fake, in order: { type, ... }
+    this.events = [];
+  }
+
+  get repoUrl() {
+    return this.url;
+  }
+
+  get headSha() {
+    return this.sha || (this.ref ? this.branches.get(this.ref) || null : null);
+  }
+
+  get branch() {
+    return this.ref;
+  }
+
+  /**
+   * Switch the run to a branch, like a new pipeline for its head (e.g. the release branch after a merge)
+   * @param {String} branch - Branch name
+   */
+  checkout(branch) {
+    if (!this.branches.has(branch)) {
+      throw apiError(`Branch ${branch} doesn't exist in the fake repo`, 404);
+    }
+    this.ref = branch;
+    this.sha = null;
+  }
+
+  /**
+   * Add commits on top of a branch (a branch that doesn't exist yet starts from nothing)
+   * @param {String} branch - Branch name
+   * @param {Array<String|Object>} commits - Messages, or { message, author, email, username, files } where
+   *   files maps paths to their new content (null deletes the file)
+   * @returns {Array} - The new commits, normalised, oldest first
+   */
+  addCommits(branch, commits) {
+    return commits.map(commit => {
+      const fields = typeof commit === 'string' ? { message: commit } : commit;
+      const parent = this.branches.get(branch);
+      const sha = this.writeCommit(parent ? [parent] : [], fields);
+      this.branches.set(branch, sha);
+      return this.normalizeCommit(this.commits.get(sha));
+    });
+  }
+
+  /**
+   * Create a branch
+   * @param {String} name - Branch name
+   * @param {String} from - Branch, tag or sha to start it at
+   * @returns {String} - The sha the branch points at
+   */
+  createBranch(name, from) {
+    const sha = this.requireRef(from);
+    this.branches.set(name, sha);
+    this.events.push({ type: 'createBranch', branch: name, sha });
+    return sha;
+  }
+
+  /**
+   * Tag a commit (an existing tag is moved)
+   * @param {String} name - Tag name
+   * @param {String} ref - Branch, tag or sha to tag
+   * @returns {String} - The sha that was tagged
+   */
+  addTag(name, ref) {
+    const sha = this.requireRef(ref);
+    this.tags.set(name, sha);
+    this.events.push({ type: 'createTag', tag: name, sha });
+    return sha;
+  }
+
+  /**
+   * Leave a comment on a PR
+   * @param {Number} number - PR number
+   * @param {String} body - Comment text
+   * @param {String} author - Commenter's username
+   * @returns {Object} - Normalised comment
+   */
+  addComment(number, body, author = null) {
+    this.findPR(number);
+    const comments = this.comments.get(number) || [];
+    const id = comments.length + 1;
+    const comment = { id, body, author, url: `${this.pullRequestUrl(number)}#issuecomment-${id}` };
+    this.comments.set(number, [...comments, comment]);
+    return comment;
+  }
+
+  /**
+   * Store a commit and return its sha
+   * @param {Array<String>} parents - Parent shas (first parent first)
+   * @param {Object} fields - { message, author, email, username, files }
+   * @returns {String}
+   */
+  writeCommit(parents, { message, author = 'Release Boss', email = 'release-boss@example.com', username = null, files = {} }) {
+    const base = parents.length > 0 ? this.commits.get(parents[0]).files : new Map();
+    const tree = new Map(base);
+    for (const [filePath, content] of Object.entries(files)) {
+      if (content === null) {
+        tree.delete(filePath);
+      } else {
+        tree.set(filePath, content);
+      }
+    }
+
+    const order = this.commits.size;
+    const date = new Date(this.clock + order * 60 * 1000).toISOString();
+    // Same fixture, same shas - tests can hard-code them if they like
+    const sha = crypto.createHash('sha1').update(`${parents.join(' ')}\n${order}\n${message}`).digest('hex');
+
+    this.commits.set(sha, {
+      sha,
+      message,
+      parents,
+      authorName: author,
+      authorEmail: email,
+      username,
+      date,
+      changes: { ...files },
+      files: tree,
+      order
+    });
+    return sha;
+  }
+
+  /**
+   * Normalise a commit record into the provider commit shape
+   * @param {Object} commit - Commit record
+   * @returns {Object} - Normalised commit
+   */
+  normalizeCommit(commit) {
+    return {
+      sha: commit.sha,
+      message: commit.message,
+      author: commit.username || commit.authorName,
+      authorName: commit.authorName,
+      authorEmail: commit.authorEmail,
+      username: commit.username,
+      date: commit.date,
+      url: `${this.repoUrl}/commit/${commit.sha}`
+    };
+  }
+
+  /**
+   * Turn a branch, tag, HEAD or (abbreviated) sha into a full sha
+   * @param {String} ref - Reference
+   * @returns {String|null} - The sha, or null when nothing matches
+   */
+  async resolveRef(ref) {
+    return this.findSha(ref);
+  }
+
+  /**
+   * Synchronous resolveRef
+   * @param {String} ref - Reference
+   * @returns {String|null}
+   */
+  findSha(ref) {
+    if (!ref) {
+      return null;
+    }
+    if (ref === 'HEAD') {
+      return this.headSha;
+    }
+    if (this.branches.has(ref)) {
+      return this.branches.get(ref);
+    }
+    if (this.tags.has(ref)) {
+      return this.tags.get(ref);
+    }
+    if (ref.length >= 7) {
+      const matches = [...this.commits.keys()].filter(sha => sha.startsWith(ref));
+      return matches.length === 1 ? matches[0] : null;
+    }
+    return null;
+  }
+
+  /**
+   * resolveRef that fails like a platform would for an unknown ref
+   * @param {String} ref - Reference
+   * @returns {String}
+   */
+  requireRef(ref) {
+    const sha = this.findSha(ref);
+    if (!sha) {
+      throw apiError(`No commit found for ref ${ref}`, 404);
+    }
+    return sha;
+  }
+
+  /**
+   * Every commit reachable from a sha, oldest first
+   * @param {String} sha - Starting commit
+   * @returns {Array} - Commit records
+   */
+  history(sha) {
+    const seen = new Set();
+    const pending = [sha];
+    while (pending.length > 0) {
+      const next = pending.pop();
+      if (!seen.has(next)) {
+        seen.add(next);
+        pending.push(...this.commits.get(next).parents);
+      }
+    }
+    // Parents are always written before their children, so write order is a topological order
+    return [...seen].map(id => this.commits.get(id)).sort((a, b) => a.order - b.order);
+  }
+
+  async compareCommits(base, head) {
+    const reachable = new Set(this.history(this.requireRef(base)).map(commit => commit.sha));
+    return this.history(this.requireRef(head))
+      .filter(commit => !reachable.has(commit.sha))
+      .map(commit => this.normalizeCommit(commit));
+  }
+
+  async listCommits(head) {
+    return this.history(this.requireRef(head)).map(commit => this.normalizeCommit(commit));
+  }
+
+  async getCommit(sha) {
+    return this.normalizeCommit(this.commits.get(this.requireRef(sha)));
+  }
+
+  async getCommitFiles(sha) {
+    const commit = this.commits.get(this.requireRef(sha));
+    return Object.keys(commit.changes);
+  }
+
+  async listTags() {
+    // Newest first, like the platforms list them
+    return [...this.tags.entries()].reverse().map(([name, sha]) => ({ name, sha }));
+  }
+
+  async getLatestReleaseTag() {
+    const releases = [...this.releases.keys()];
+    return releases.length > 0 ? releases[releases.length - 1] : null;
+  }
+
+  async resolveUsername(email) {
+    return this.usernames[email] || null;
+  }
+
+  async getFileContent(filePath, ref) {
+    const sha = this.findSha(ref);
+    if (!sha) {
+      return null;
+    }
+    const content = this.commits.get(sha).files.get(filePath);
+    return content === undefined ? null : content;
+  }
+
+  async branchExists(branch) {
+    return this.branches.has(branch);
+  }
+
+  /**
+   * Look up a PR, failing like a platform would when there's no such PR
+   * @param {Number} number - PR number
+   * @returns {Object} - The stored PR
+   */
+  findPR(number) {
+    const pr = this.pullRequests.find(candidate => candidate.number === Number(number));
+    if (!pr) {
+      throw apiError(`PR #${number} doesn't exist in the fake repo`, 404);
+    }
+    return pr;
+  }
+
+  async findOpenReleasePR(config, version) {
+    const pr = this.pullRequests.find(candidate =>
+      candidate.state === 'open' &&
+      candidate.baseBranch === config.releaseBranch &&
+      (version ? candidate.headBranch === `${config.stagingBranch}-v${version}` : candidate.headBranch.startsWith(`${config.stagingBranch}-`))
+    );
+    return pr ? { ...pr } : null;
+  }
+
+  async detectMergedReleasePR(config, options = {}) {
+    const sha = this.headSha;
+    if (!sha || (options.branch || this.ref) !== config.releaseBranch) {
+      logger.info(`Not a run on the release branch ${config.releaseBranch} - no merged PR to detect 🤷‍♀️`);
+      return null;
+    }
+
+    const pr = this.pullRequests.find(candidate =>
+      candidate.state === 'merged' &&
+      candidate.mergeCommitSha === sha &&
+      candidate.baseBranch === config.releaseBranch &&
+      candidate.headBranch.startsWith(`${config.stagingBranch}-`)
+    );
+
+    if (!pr) {
+      return null;
+    }
+
+    const versionMatch = pr.headBranch.match(new RegExp(`^${config.stagingBranch}-v?([0-9]+\\.[0-9]+\\.[0-9]+.*?)$`));
+    return {
+      number: pr.number,
+      title: pr.title,
+      body: pr.body,
+      headBranch: pr.headBranch,
+      version: versionMatch ? versionMatch[1] : null,
+      merged: true,
+      mergeCommitSha: sha
+    };
+  }
+
+  async createReleasePR(version, changelog, config, updatedFiles = []) {
+    // A PR that's already open keeps its branch, even when the version has moved on
+    const stagingBranch = config.releasePR ? config.releasePR.headBranch : `${config.stagingBranch}-v${version}`;
+
+    // Like GitLab, the staging branch is cut fresh from the merge branch every run
+    this.createBranch(stagingBranch, config.mergeBranch);
+    logger.info(`Created staging branch ${stagingBranch} from ${config.mergeBranch} 🧪`);
+
+    if (!this.branches.has(config.releaseBranch)) {
+      logger.info(`Release branch ${config.releaseBranch} doesn't exist yet - creating it from ${config.mergeBranch} for the first release 🎉`);
+      this.createBranch(config.releaseBranch, config.mergeBranch);
+    }
+
+    const files = {};
+
+    for (const file of updatedFiles) {
+      const filePathInRepo = path.relative(process.cwd(), file);
+      if (filePathInRepo === '' || filePathInRepo.startsWith('..')) {
+        logger.error(`Invalid file path: ${filePathInRepo}`);
+        continue;
+      }
+      files[filePathInRepo] = await fs.readFile(file, 'utf8');
+    }
+
+    if (config.changelogPath) {
+      const baseContent = await this.getFileContent(config.changelogPath, config.releaseBranch) || '';
+      files[config.changelogPath] = generateFileChangelog(changelog, version, baseContent, { header: config.changelogHeader });
+    }
+
+    if (Object.keys(files).length > 0) {
+      const sha = this.writeCommit([this.branches.get(stagingBranch)], { message: buildCommitMessage(version, config), files });
+      this.branches.set(stagingBranch, sha);
+      this.events.push({ type: 'commit', branch: stagingBranch, sha, files: Object.keys(files) });
+      logger.info(`Committed ${Object.keys(files).length} files to ${stagingBranch} in a single fabulous commit! 💁‍♀️`);
+    }
+
+    const title = buildPRTitle(version, config);
+    const body = buildPRBody(changelog, config, updatedFiles, version);
+    const existing = config.releasePR || await this.findOpenReleasePR(config, version);
+
+    if (existing) {
+      const updated = await this.updatePR(existing.number, { title, body });
+      logger.info(`Updated PR #${updated.number}: ${title}`);
+      return { prNumber: updated.number, prUrl: updated.url, prStatus: updated.state };
+    }
+
+    const number = this.pullRequests.length + 1;
+    const pr = {
+      number,
+      url: this.pullRequestUrl(number),
+      state: 'open',
+      title,
+      body,
+      headBranch: stagingBranch,
+      baseBranch: config.releaseBranch,
+      mergeCommitSha: null
+    };
+    this.pullRequests.push(pr);
+    this.events.push({ type: 'createPR', number, headBranch: stagingBranch, baseBranch: config.releaseBranch });
+
+    logger.info(`Created PR #${number}: ${title}`);
+    return { prNumber: number, prUrl: pr.url, prStatus: pr.state };
+  }
+
+  async updatePR(number, fields) {
+    const pr = this.findPR(number);
+    if (fields.title !== undefined) pr.title = fields.title;
+    if (fields.body !== undefined) pr.body = fields.body;
+    this.events.push({ type: 'updatePR', number: pr.number });
+    return { ...pr };
+  }
+
+  async getPR(number) {
+    return { ...this.findPR(number) };
+  }
+
+  async listPRComments(number) {
+    this.findPR(number);
+    return [...(this.comments.get(Number(number)) || [])];
+  }
+
+  async getPRMergeStatus(number) {
+    const pr = this.findPR(number);
+    const sha = this.branches.get(pr.headBranch) || null;
+
+    if (pr.state !== 'open') {
+      return { state: 'blocked', reason: `it is ${pr.state}`, sha };
+    }
+    if (!sha) {
+      return { state: 'blocked', reason: `its branch ${pr.headBranch} is gone`, sha };
+    }
+    // There are no checks in the fake repo, so an open PR can always be merged
+    return { state: 'ready', reason: null, sha };
+  }
+
+  async mergePR(number, options = {}) {
+    const method = options.method || 'merge';
+    const pr = this.findPR(number);
+    if (pr.state !== 'open') {
+      throw apiError(`PR #${pr.number} is ${pr.state}, not open`, 405);
+    }
+
+    const head = this.requireRef(pr.headBranch);
+    if (options.sha && options.sha !== head) {
+      throw apiError(`Head branch was modified - PR #${pr.number} is at ${head.substring(0, 7)}, not ${options.sha.substring(0, 7)}`, 409);
+    }
+
+    const base = this.requireRef(pr.baseBranch);
+    const changes = {};
+    for (const commit of await this.compareCommits(base, head)) {
+      Object.assign(changes, this.commits.get(commit.sha).changes);
+    }
+
+    let sha;
+    if (method === 'rebase') {
+      // Each commit is replayed on top of the base, so the last one is what landed
+      sha = base;
+      for (const commit of await this.compareCommits(base, head)) {
+        const record = this.commits.get(commit.sha);
+        sha = this.writeCommit([sha], { message: record.message, author: record.authorName, email: record.authorEmail, username: record.username, files: record.changes });
+      }
+    } else {
+      const parents = method === 'squash' ? [base] : [base, head];
+      const message = method === 'squash' ? `${pr.title} (#${pr.number})` : `Merge pull request #${pr.number} from ${pr.headBranch}`;
+      sha = this.writeCommit(parents, { message, files: changes });
+    }
+
+    this.branches.set(pr.baseBranch, sha);
+    pr.state = 'merged';
+    pr.mergeCommitSha = sha;
+    this.events.push({ type: 'mergePR', number: pr.number, method, sha });
+    return { merged: true, sha };
+  }
+
+  async createTag(version, config, options = {}) {
+    const tagName = getReleaseTagName(version, config);
+    const sha = options.sha || this.requireRef(config.releaseBranch);
+    const createdTags = [];
+
+    if (this.tags.has(tagName)) {
+      logger.info(`Tag ${tagName} already exists, skipping tag creation`);
+    } else {
+      this.addTag(tagName, sha);
+      logger.info(`Successfully created tag: ${tagName} at ${sha.substring(0, 7)}`);
+    }
+    createdTags.push(tagName);
+
+    for (const tag of getAdditionalTagNames(version, config)) {
+      this.addTag(tag, sha);
+      logger.info(`Pointed tag ${tag} at commit ${sha.substring(0, 7)}`);
+      createdTags.push(tag);
+    }
+
+    return { sha, tags: createdTags };
+  }
+
+  async createRelease(tagName, { title, body, prerelease }) {
+    if (!this.tags.has(tagName)) {
+      throw apiError(`Tag ${tagName} doesn't exist in the fake repo`, 422);
+    }
+
+    const updated = this.releases.has(tagName);
+    this.releases.set(tagName, { tagName, title, body, prerelease: prerelease === true });
+    this.events.push({ type: updated ? 'updateRelease' : 'createRelease', tag: tagName });
+    return { url: `${this.repoUrl}/releases/tag/${tagName}`, updated };
+  }
+
+  async deleteRelease(tagName) {
+    if (!this.releases.delete(tagName)) {
+      return false;
+    }
+    this.events.push({ type: 'deleteRelease', tag: tagName });
+    return true;
+  }
+
+  async deleteTag(tagName) {
+    if (!this.tags.delete(tagName)) {
+      return false;
+    }
+    this.events.push({ type: 'deleteTag', tag: tagName });
+    return true;
+  }
+
+  async revertCommit(sha, { branch, message }) {
+    const commit = this.commits.get(this.requireRef(sha));
+    if (commit.parents.length !== 1) {
+      throw new Error(`Commit ${commit.sha.substring(0, 7)} has ${commit.parents.length} parents - only single-parent commits can be reverted`);
+    }
+
+    const headSha = this.requireRef(branch);
+    const head = this.commits.get(headSha).files;
+    const parent = this.commits.get(commit.parents[0]).files;
+    const files = {};
+    for (const filePath of Object.keys(commit.changes)) {
+      if (head.get(filePath) !== commit.files.get(filePath)) {
+        throw new Error(`${filePath} changed on ${branch} since commit ${commit.sha.substring(0, 7)} - revert it by hand`);
+      }
+      files[filePath] = parent.has(filePath) ? parent.get(filePath) : null;
+    }
+
+    const revert = this.writeCommit([headSha], { message, files });
+    this.branches.set(branch, revert);
+    this.events.push({ type: 'commit', branch, sha: revert, files: Object.keys(files) });
+    return { sha: revert };
+  }
+
+  async listBranches(prefix) {
+    return [...this.branches.entries()]
+      .filter(([name]) => name.startsWith(prefix))
+      .map(([name, sha]) => ({ name, sha, date: this.commits.get(sha).date }));
+  }
+
+  async listBranchPRs(branch) {
+    return this.pullRequests.filter(pr => pr.headBranch === branch).map(pr => ({ ...pr }));
+  }
+
+  async deleteBranch(branch) {
+    if (!this.branches.delete(branch)) {
+      logger.info(`Branch ${branch} doesn't exist, no need to delete it 💅`);
+      return false;
+    }
+    this.events.push({ type: 'deleteBranch', branch });
+    logger.info(`Successfully deleted branch ${branch} - keeping things tidy! ✨`);
+    return true;
+  }
+}
+
+module.exports = {
+  FakeProvider
+};
@@ -0,0 +1,634 @@
+const fs = require('fs').promises;
+const path = require('path');
+const semver = require('semver');
+
+const { Provider } = require('./provider');
+const { requestJson } = require('../utils/http');
+const { withRetry } = require('../utils/retry');
+const { generateFileChangelog } = require('../github/changelogTable');
+const { buildPRTitle, buildPRBody, buildCommitMessage } = require('../core/prContent');
+const { getReleaseTagName, getAdditionalTagNames } = require('../utils/tags');
+const { logger } = require('../utils/logger');
+
+/**
+ * Page size for list calls - Gitea quietly caps limit at its MAX_RESPONSE_ITEMS (50 by default)
+ */
+const PAGE_SIZE = 50;
+
+/**
+ * Combined commit status states that mean the checks failed
+ */
+const FAILED_STATUSES = ['failure', 'error'];
+
+/**
+ * Normalise a Gitea pull request into the provider PR shape
+ * @param {Object} pr - Gitea pull request payload
+ * @returns {Object} - Normalised PR
+ */
+function normalizePR(pr) {
+  return {
+    number: pr.number,
+    url: pr.html_url,
+    state: pr.merged ? 'merged' : pr.state,
+    title: pr.title,
+    body: pr.body || '',
+    headBranch: pr.head ? pr.head.ref : null,
+    baseBranch: pr.base ? pr.base.ref : null,
+    mergeCommitSha: pr.merge_commit_sha || null
+  };
+}
+
+/**
+ * Gitea (and Forgejo) implementation of the Provider interface, for self-hosted teams 🍵
+ * The API is GitHub-shaped, but pages are capped server-side and tags and releases are separate.
+ */
+class GiteaProvider extends Provider {
+  /**
+   * @param {Object} options - Provider options
+   * @param {String} options.token - Gitea access token
+   * @param {String} options.repository - Repository path (e.g. "owner/repo")
+   * @param {String} options.baseUrl - Gitea instance URL (e.g. https://gitea.example.com)
+   * @param {String} options.sha - Commit SHA of the current run (optional)
+   * @param {String} options.ref - Branch of the current run (optional)
+   * @param {Function} options.request - HTTP request function (defaults to requestJson)
+   * @param {Object} options.retry - Retry policy for every API request (optional)
+   */
+  constructor({ token, repository, baseUrl, sha, ref, request, retry }) {
+    super({ name: 'gitea' });
+
+    if (!repository) {
+      throw new Error('Gitea provider needs a repository path - set "repository" in config or run inside Gitea Actions 🍵');
+    }
+    if (!baseUrl) {
+      throw new Error('Gitea provider needs the instance URL - set "baseUrl" in config (e.g. https://gitea.example.com) 🍵');
+    }
+
+    this.token = token;
+    this.repository = repository;
+    this.baseUrl = baseUrl.replace(/\/+$/, '');
+    this.sha = sha || null;
+    this.ref = ref || null;
+    this.request = request || requestJson;
+    this.retry = retry || null;
+  }
+
+  get repoUrl() {
+    return `${this.baseUrl}/${this.repository}`;
+  }
+
+  get headSha() {
+    return this.sha;
+  }
+
+  get branch() {
+    return this.ref;
+  }
+
+  pullRequestUrl(number) {
+    return `${this.repoUrl}/pulls/${number}`;
+  }
+
+  async checkConnection() {
+    try {
+      await this.send('GET', `${this.baseUrl}/api/v1/version`);
+    } catch (error) {
+      if (error.status === 404) {
+        throw new Error(`${this.baseUrl}/api/v1/version doesn't exist, so that's not a Gitea instance - baseUrl is the address you open in a browser, like https://gitea.example.com`);
+      }
+      if (!error.status) {
+        throw new Error(`Couldn't reach Gitea at ${this.baseUrl}: ${error.message} - check baseUrl`);
+      }
+      // 401s and friends mean the server is there - the token problem gets its own error later
+    }
+  }
+
+  /**
+   * Send one request to the Gitea API, through the retry policy
+   * @param {String} method - HTTP method
+   * @param {String} url - Fully qualified URL
+   * @param {Object} options - { body }
+   * @returns {Promise<Object>} - { status, headers, data }
+   */
+  async send(method, url, options = {}) {
+    const send = () => this.request(method, url, {
+      headers: this.token ? { Authorization: `token ${this.token}` } : {},
+      body: options.body,
+      timeout: this.retry ? this.retry.timeout : undefined
+    });
+
+    return this.retry ? withRetry(send, this.retry, `${method} ${new URL(url).pathname}`) : send();
+  }
+
+  /**
+   * Build the URL of an API path for this repository
+   * @param {String} apiPath - Path relative to /repos/:owner/:repo
+   * @param {Object} query - Query parameters (undefined and null values are left out)
+   * @returns {String}
+   */
+  url(apiPath, query) {
+    const params = Object.entries(query || {})
+      .filter(([, value]) => value !== undefined && value !== null)
+      .map(([key, value]) => `${encodeURIComponent(key)}=${encodeURIComponent(value)}`)
+      .join('&');
+    return `${this.baseUrl}/api/v1/repos/${this.repository}${apiPath}${params ? `?${params}` : ''}`;
+  }
+
+  /**
+   * Call the Gitea REST API for this repository
+   * @param {String} method - HTTP method
+   * @param {String} apiPath - Path relative to /repos/:owner/:repo
+   * @param {Object} options - { body, query }
+   * @returns {Promise<*>} - Response data
+   */
+  async api(method, apiPath, options = {}) {
+    const { data } = await this.send(method, this.url(apiPath, options.query), { body: options.body });
+    return data;
+  }
+
+  /**
+   * Fetch every page of a list call
+   * Gitea caps limit at the server's MAX_RESPONSE_ITEMS without saying so, so a short page doesn't
+   * mean the end - the X-Total-Count header does. Servers that leave it out stop at the first empty page.
+   * @param {String} apiPath - Path relative to /repos/:owner/:repo
+   * @param {Object} query - Query parameters besides page and limit
+   * @returns {Promise<Array>} - Every item, in the order the API returned them
+   */
+  async paginate(apiPath, query = {}) {
+    const items = [];
+
+    for (let page = 1; ; page++) {
+      const { headers, data } = await this.send('GET', this.url(apiPath, { ...query, page, limit: PAGE_SIZE }));
+      const pageItems = Array.isArray(data) ? data : [];
+      items.push(...pageItems);
+
+      const total = Number(headers && headers['x-total-count']);
+      if (pageItems.length === 0 || (total > 0 && items.length >= total)) {
+        break;
+      }
+    }
+
+    return items;
+  }
+
+  /**
+   * Normalise a Gitea commit into the provider commit shape
+   * @param {Object} commit - Gitea commit payload
+   * @returns {Object} - Normalised commit
+   */
+  normalizeCommit(commit) {
+    const author = (commit.commit && commit.commit.author) || {};
+    return {
+      sha: commit.sha,
+      message: commit.commit ? commit.commit.message : '',
+      author: (commit.author && commit.author.login) || author.name || null,
+      authorName: author.name || null,
+      authorEmail: author.email || null,
+      username: commit.author ? commit.author.login || null : null,
+      date: author.date || commit.created,
+      url: commit.html_url || `${this.repoUrl}/commit/${commit.sha}`
+    };
+  }
+
+  async compareCommits(base, head) {
+    const data = await this.api('GET', `/compare/${encodeURIComponent(base)}...${encodeURIComponent(head)}`);
+
+    // Compare lists commits like git log does - newest first
+    return (data.commits || []).reverse().map(commit => this.normalizeCommit(commit));
+  }
+
+  async listCommits(head) {
+    // Skipping stats, verification and files keeps each page cheap for the server
+    const commits = await this.paginate('/commits', { sha: head, stat: false, verification: false, files: false });
+    return commits.reverse().map(commit => this.normalizeCommit(commit));
+  }
+
+  async getCommit(sha) {
+    return this.normalizeCommit(await this.api('GET', `/git/commits/${sha}`, { query: { stat: false, verification: false, files: false } }));
+  }
+
+  async getCommitFiles(sha) {
+    const commit = await this.api('GET', `/git/commits/${sha}`);
+    return (commit.files || []).map(file => file.filename);
+  }
+
+  async listTags() {
+    const tags = await this.paginate('/tags');
+    return tags.map(tag => ({
+      name: tag.name,
+      sha: tag.commit ? tag.commit.sha : null
+    }));
+  }
+
+  async getLatestReleaseTag() {
+    const releases = await this.api('GET', '/releases', { query: { draft: false, 'pre-release': false, limit: 1 } });
+    return releases && releases.length > 0 ? releases[0].tag_name : null;
+  }
+
+  /**
+   * Read a file through the contents API
+   * @param {String} filePath - Path in the repository
+   * @param {String} ref - Branch, tag or SHA
+   * @returns {Promise<Object|null>} - { content, sha } - sha is the blob SHA updates need - or null if it doesn't exist
+   */
+  async getContents(filePath, ref) {
+    try {
+      const file = await this.api('GET', `/contents/${filePath.split('/').map(encodeURIComponent).join('/')}`, { query: { ref } });
+      return { content: Buffer.from(file.content || '', 'base64').toString('utf8'), sha: file.sha };
+    } catch (error) {
+      if (error.status === 404) {
+        return null;
+      }
+      throw error;
+    }
+  }
+
+  async getFileContent(filePath, ref) {
+    const file = await this.getContents(filePath, ref);
+    return file ? file.content : null;
+  }
+
+  /**
+   * Get a branch, or null if it doesn't exist
+   * @param {String} branch - Branch name
+   * @returns {Promise<Object|null>} - Gitea branch payload
+   */
+  async getBranch(branch) {
+    try {
+      return await this.api('GET', `/branches/${encodeURIComponent(branch)}`);
+    } catch (error) {
+      if (error.status === 404) {
+        return null;
+      }
+      throw error;
+    }
+  }
+
+  /**
+   * List pull requests, every page of them
+   * Gitea can't filter the list by head branch, so callers filter what comes back.
+   * @param {String} state - 'open', 'closed' or 'all'
+   * @returns {Promise<Array>} - Gitea pull request payloads
+   */
+  async listPulls(state) {
+    return this.paginate('/pulls', { state });
+  }
+
+  async findOpenReleasePR(config, version) {
+    const pulls = await this.listPulls('open');
+    const releasePR = pulls.find(pr =>
+      pr.base.ref === config.releaseBranch &&
+      (version ? pr.head.ref === `${config.stagingBranch}-v${version}` : pr.head.ref.startsWith(`${config.stagingBranch}-`))
+    );
+    return releasePR ? normalizePR(releasePR) : null;
+  }
+
+  async detectMergedReleasePR(config, options = {}) {
+    if (!this.sha || (options.branch || this.ref) !== config.releaseBranch) {
+      logger.info(`Not a run on the release branch ${config.releaseBranch} - no merged PR to detect 🤷‍♀️`);
+      return null;
+    }
+
+    let pr;
+    try {
+      pr = await this.api('GET', `/commits/${this.sha}/pull`);
+    } catch (error) {
+      if (error.status === 404) {
+        return null;
+      }
+      throw error;
+    }
+
+    if (!pr.merged || pr.base.ref !== config.releaseBranch || !pr.head.ref.startsWith(`${config.stagingBranch}-`)) {
+      return null;
+    }
+
+    const versionMatch = pr.head.ref.match(new RegExp(`^${config.stagingBranch}-v?([0-9]+\\.[0-9]+\\.[0-9]+.*?)$`));
+    return {
+      number: pr.number,
+      title: pr.title,
+      body: pr.body,
+      headBranch: pr.head.ref,
+      version: versionMatch ? versionMatch[1] : null,
+      merged: true,
+      mergeCommitSha: pr.merge_commit_sha || this.sha
+    };
+  }
+
+  async createReleasePR(version, changelog, config, updatedFiles = []) {
+    // A PR that's already open keeps its branch, even when the version has moved on
+    const stagingBranch = config.releasePR ? config.releasePR.headBranch : `${config.stagingBranch}-v${version}`;
+
+    // Like GitLab, there's no branch-to-branch merge API, so the staging branch is cut fresh from the merge branch
+    if (await this.getBranch(stagingBranch)) {
+      logger.info(`Staging branch ${stagingBranch} already exists - recreating it from ${config.mergeBranch}`);
+      await this.api('DELETE', `/branches/${encodeURIComponent(stagingBranch)}`);
+    }
+
+    await this.api('POST', '/branches', { body: { new_branch_name: stagingBranch, old_branch_name: config.mergeBranch } });
+    logger.info(`Created staging branch ${stagingBranch} from ${config.mergeBranch} 🍵`);
+
+    // Brand new repo - the PR needs a release branch to target
+    if (!await this.getBranch(config.releaseBranch)) {
+      logger.info(`Release branch ${config.releaseBranch} doesn't exist yet - creating it from ${config.mergeBranch} for the first release 🎉`);
+      await this.api('POST', '/branches', { body: { new_branch_name: config.releaseBranch, old_branch_name: config.mergeBranch } });
+    }
+
+    const filesToCommit = [];
+
+    for (const file of updatedFiles) {
+      const filePathInRepo = path.relative(process.cwd(), file);
+      if (filePathInRepo === '' || filePathInRepo.startsWith('..')) {
+        logger.error(`Invalid file path: ${filePathInRepo}`);
+        continue;
+      }
+      filesToCommit.push({ path: filePathInRepo, content: await fs.readFile(file, 'utf8') });
+    }
+
+    if (config.changelogPath) {
+      const baseContent = await this.getFileContent(config.changelogPath, config.releaseBranch) || '';
+      filesToCommit.push({
+        path: config.changelogPath,
+        content: generateFileChangelog(changelog, version, baseContent, { header: config.changelogHeader })
+      });
+    }
+
+    if (filesToCommit.length > 0) {
+      // Updating a file needs the SHA of the blob being replaced
+      const files = [];
+      for (const file of filesToCommit) {
+        const existing = await this.getContents(file.path, stagingBranch);
+        files.push({
+          operation: existing ? 'update' : 'create',
+          path: file.path,
+          content: Buffer.from(file.content, 'utf8').toString('base64'),
+          ...(existing ? { sha: existing.sha } : {})
+        });
+      }
+
+      await this.api('POST', '/contents', {
+        body: { branch: stagingBranch, message: buildCommitMessage(version, config), files }
+      });
+      logger.info(`Committed ${files.length} files to ${stagingBranch} in a single fabulous commit! 💁‍♀️`);
+    }
+
+    const title = buildPRTitle(version, config);
+    const body = buildPRBody(changelog, config, updatedFiles, version);
+    const existing = config.releasePR || await this.findOpenReleasePR(config, version);
+
+    if (existing) {
+      const updated = await this.updatePR(existing.number, { title, body });
+      logger.info(`Updated PR #${updated.number}: ${title}`);
+      return { prNumber: updated.number, prUrl: updated.url, prStatus: updated.state };
+    }
+
+    // Gitea labels PRs by label ID rather than name, so the release PR goes without one
+    const pr = await this.api('POST', '/pulls', {
+      body: { head: stagingBranch, base: config.releaseBranch, title, body }
+    });
+
+    logger.info(`Created PR #${pr.number}: ${title}`);
+    const created = normalizePR(pr);
+    return { prNumber: created.number, prUrl: created.url, prStatus: created.state };
+  }
+
+  async updatePR(number, fields) {
+    const body = {};
+    if (fields.title !== undefined) body.title = fields.title;
+    if (fields.body !== undefined) body.body = fields.body;
+
+    const pr = await this.api('PATCH', `/pulls/${number}`, { body });
+    return normalizePR(pr);
+  }
+
+  async getPR(number) {
+    const pr = await this.api('GET', `/pulls/${number}`);
+    return normalizePR(pr);
+  }
+
+  async listPRComments(number) {
+    const comments = await this.api('GET', `/issues/${number}/comments`);
+    return (comments || []).map(comment => ({
+      id: comment.id,
+      body: comment.body || '',
+      author: comment.user ? comment.user.login : null,
+      url: comment.html_url
+    }));
+  }
+
+  async getPRMergeStatus(number) {
+    const pr = await this.api('GET', `/pulls/${number}`);
+    const sha = pr.head.sha;
+
+    if (pr.merged || pr.state !== 'open') {
+      return { state: 'blocked', reason: `it is ${pr.merged ? 'merged' : pr.state}`, sha };
+    }
+    if (!pr.mergeable) {
+      return { state: 'blocked', reason: 'it has conflicts', sha };
+    }
+
+    // Gitea doesn't say which checks are required, so every status on the head counts
+    const status = await this.api('GET', `/commits/${sha}/status`);
+    if (status.state === 'pending') {
+      return { state: 'pending', reason: 'waiting on the checks', sha };
+    }
+    if (FAILED_STATUSES.includes(status.state)) {
+      const failed = (status.statuses || []).filter(check => FAILED_STATUSES.includes(check.status)).map(check => check.context);
+      return { state: 'blocked', reason: `checks failed: ${failed.join(', ') || status.state}`, sha };
+    }
+    return { state: 'ready', reason: null, sha };
+  }
+
+  async mergePR(number, options = {}) {
+    try {
+      // Gitea answers an empty 200, so the merge commit comes from the PR afterwards
+      await this.api('POST', `/pulls/${number}/merge`, {
+        body: { Do: options.method || 'merge', ...(options.sha ? { head_commit_id: options.sha } : {}) }
+      });
+    } catch (error) {
+      // 405 is "not mergeable", 409 is "the head moved since the checks ran"
+      if (error.status === 405 || error.status === 409) {
+        logger.info(`PR #${number} couldn't be merged: ${error.message}`);
+        return { merged: false, sha: null };
+      }
+      throw error;
+    }
+
+    const merged = await this.getPR(number);
+    return { merged: merged.state === 'merged', sha: merged.mergeCommitSha };
+  }
+
+  async createTag(version, config, options = {}) {
+    const tagName = getReleaseTagName(version, config);
+
+    let sha = options.sha;
+    if (!sha) {
+      const branch = await this.api('GET', `/branches/${encodeURIComponent(config.releaseBranch)}`);
+      sha = branch.commit.id;
+    }
+    const createdTags = [];
+
+    const existing = (await this.listTags()).find(tag => tag.name === tagName);
+    if (existing) {
+      logger.info(`Tag ${tagName} already exists, skipping tag creation`);
+    } else {
+      // Creating a release makes a lightweight tag - an annotated one has to exist before the release does
+      if (options.annotate) {
+        await this.api('POST', '/tags', { body: { tag_name: tagName, target: sha, message: `Release ${tagName}` } });
+      }
+      await this.api('POST', '/releases', {
+        body: {
+          tag_name: tagName,
+          target_commitish: sha,
+          name: tagName,
+          prerelease: semver.prerelease(version) !== null
+        }
+      });
+      logger.info(`Successfully created tag and release: ${tagName} at ${sha.substring(0, 7)}`);
+    }
+    createdTags.push(tagName);
+
+    const additionalTags = getAdditionalTagNames(version, config);
+
+    // Gitea tags can't be moved, so alias tags are deleted and recreated
+    for (const tag of additionalTags) {
+      try {
+        try {
+          await this.api('DELETE', `/tags/${encodeURIComponent(tag)}`);
+        } catch (error) {
+          if (error.status !== 404) throw error;
+        }
+        await this.api('POST', '/tags', { body: { tag_name: tag, target: sha } });
+        logger.info(`Pointed tag ${tag} at commit ${sha.substring(0, 7)}`);
+        createdTags.push(tag);
+      } catch (error) {
+        logger.error(`Error managing additional tag ${tag}: ${error.message}`);
+      }
+    }
+
+    return { sha, tags: createdTags };
+  }
+
+  async createRelease(tagName, { title, body, prerelease }) {
+    let existing = null;
+    try {
+      existing = await this.api('GET', `/releases/tags/${encodeURIComponent(tagName)}`);
+    } catch (error) {
+      if (error.status !== 404) {
+        throw error;
+      }
+    }
+
+    // createTag already made a bare release, so this is usually an update
+    if (existing) {
+      const release = await this.api('PATCH', `/releases/${existing.id}`, { body: { name: title, body, prerelease } });
+      return { url: release.html_url, updated: true };
+    }
+
+    const release = await this.api('POST', '/releases', { body: { tag_name: tagName, name: title, body, prerelease } });
+    return { url: release.html_url, updated: false };
+  }
+
+  async branchExists(branch) {
+    return await this.getBranch(branch) !== null;
+  }
+
+  async deleteRelease(tagName) {
+    let release;
+    try {
+      release = await this.api('GET', `/releases/tags/${encodeURIComponent(tagName)}`);
+    } catch (error) {
+      if (error.status === 404) {
+        return false;
+      }
+      throw error;
+    }
+
+    await this.api('DELETE', `/releases/${release.id}`);
+    return true;
+  }
+
+  async deleteTag(tagName) {
+    try {
+      await this.api('DELETE', `/tags/${encodeURIComponent(tagName)}`);
+      return true;
+    } catch (error) {
+      if (error.status === 404) {
+        return false;
+      }
+      throw error;
+    }
+  }
+
+  async revertCommit(sha, { branch, message }) {
+    const commit = await this.api('GET', `/git/commits/${sha}`);
+    if (!commit.parents || commit.parents.length !== 1) {
+      throw new Error(`Commit ${sha.substring(0, 7)} has ${(commit.parents || []).length} parents - only a regular commit can be reverted`);
+    }
+    const parentSha = commit.parents[0].sha;
+
+    // There's no revert endpoint, so each file the commit touched goes back to its parent's content -
+    // which is only a revert while the branch still has the commit's version of it
+    const files = [];
+    const changed = [];
+    for (const file of commit.files || []) {
+      for (const filePath of [file.filename, file.previous_filename].filter(Boolean)) {
+        const [previous, committed, current] = await Promise.all([parentSha, sha, branch].map(ref => this.getContents(filePath, ref)));
+        if ((current && current.sha) !== (committed && committed.sha)) {
+          changed.push(filePath);
+        } else if (!previous) {
+          files.push({ operation: 'delete', path: filePath, sha: current.sha });
+        } else {
+          files.push({
+            operation: current ? 'update' : 'create',
+            path: filePath,
+            content: Buffer.from(previous.content, 'utf8').toString('base64'),
+            ...(current ? { sha: current.sha } : {})
+          });
+        }
+      }
+    }
+    if (changed.length > 0) {
+      throw new Error(`${changed.join(', ')} changed on ${branch} since commit ${sha.substring(0, 7)} - revert it by hand`);
+    }
+
+    const result = await this.api('POST', '/contents', { body: { branch, message, files } });
+    return { sha: result.commit ? result.commit.sha : null };
+  }
+
+  async listBranches(prefix) {
+    const branches = await this.paginate('/branches');
+
+    return branches
+      .filter(branch => branch.name.startsWith(prefix))
+      .map(branch => ({
+        name: branch.name,
+        sha: branch.commit ? branch.commit.id : null,
+        date: branch.commit ? branch.commit.timestamp : null
+      }));
+  }
+
+  async listBranchPRs(branch) {
+    const pulls = await this.listPulls('all');
+    return pulls.filter(pr => pr.head.ref === branch).map(normalizePR);
+  }
+
+  async deleteBranch(branch) {
+    try {
+      await this.api('DELETE', `/branches/${encodeURIComponent(branch)}`);
+      logger.info(`Successfully deleted branch ${branch} - keeping things tidy! ✨`);
+      return true;
+    } catch (error) {
+      if (error.status === 404) {
+        logger.info(`Branch ${branch} doesn't exist, no need to delete it 💅`);
+      } else {
+        logger.error(`Error deleting branch ${branch}: ${error.message}`);
+      }
+      return false;
+    }
+  }
+}
+
+module.exports = {
+  GiteaProvider,
+  normalizePR
+};
@@ -0,0 +1,626 @@
+const { Provider } = require('./provider');
+const { detectReleasePR } = require('../github/detectReleasePR');
+const { createOrUpdatePR, tagRelease, deleteBranch } = require('../github/prManager');
+const { withRetry } = require('../utils/retry');
+
+/**
+ * Check run conclusions that count as a failed check
+ */
+const FAILED_CONCLUSIONS = ['failure', 'timed_out', 'cancelled', 'action_required', 'startup_failure'];
+
+/**
+ * Items per page for list endpoints - the most GitHub hands out
+ */
+const PAGE_SIZE = 100;
+
+/**
+ * github.com's API, uploads and web hosts
+ */
+const GITHUB_DOTCOM = {
+  apiUrl: 'https://api.github.com',
+  uploadUrl: 'https://uploads.github.com',
+  webUrl: 'https://github.com'
+};
+
+/**
+ * Work out the API, upload and web URLs for github.com or a GitHub Enterprise Server
+ *
+ * githubApiUrl wins, then the GITHUB_API_URL the runner sets on GHES. GHES serves
+ * its API from /api/v3 and uploads from /api/uploads on the web host, so the other
+ * URLs follow from the API URL unless they're set too.
+ * @param {Object} config - Release Boss configuration
+ * @param {Object} env - Environment variables (default: process.env)
+ * @returns {Object} - { apiUrl, uploadUrl, webUrl } without trailing slashes
+ */
+function getGitHubUrls(config, env = process.env) {
+  const trim = url => url.replace(/\/+$/, '');
+  const apiUrl = trim(config.githubApiUrl || env.GITHUB_API_URL || GITHUB_DOTCOM.apiUrl);
+  if (apiUrl === GITHUB_DOTCOM.apiUrl) {
+    return { ...GITHUB_DOTCOM, uploadUrl: trim(config.githubUploadUrl || GITHUB_DOTCOM.uploadUrl) };
+  }
+
+  const api = new URL(apiUrl);
+  const enterprisePath = api.pathname.match(/^(.*)\/api\/v3$/);
+  let webUrl;
+  if (enterprisePath) {
+    webUrl = `${api.origin}${enterprisePath[1]}`;
+  } else if (!config.githubApiUrl && env.GITHUB_SERVER_URL) {
+    webUrl = trim(env.GITHUB_SERVER_URL);
+  } else {
+    // GHE.com style hosts serve the API from an api. subdomain
+    webUrl = `${api.protocol}//${api.host.replace(/^api\./, '')}`;
+  }
+
+  return {
+    apiUrl,
+    uploadUrl: trim(config.githubUploadUrl || (enterprisePath ? `${webUrl}/api/uploads` : `${api.protocol}//${api.host.replace(/^api\./, 'uploads.')}`)),
+    webUrl
+  };
+}
+
+/**
+ * Read every page of a list endpoint
+ * A page shorter than PAGE_SIZE is the last one.
+ * @param {Function} fetchPage - Fetches one page: (page) => Promise<Array> of its items
+ * @returns {Promise<Array>} - Every item, in the order the API returned them
+ */
+async function paginate(fetchPage) {
+  const items = [];
+
+  for (let page = 1; ; page++) {
+    const pageItems = (await fetchPage(page)) || [];
+    items.push(...pageItems);
+    if (pageItems.length < PAGE_SIZE) {
+      break;
+    }
+  }
+
+  return items;
+}
+
+/**
+ * Normalise a GitHub commit into the provider commit shape
+ * @param {Object} commit - GitHub commit payload
+ * @returns {Object} - Normalised commit
+ */
+function normalizeCommit(commit) {
+  return {
+    sha: commit.sha,
+    message: commit.commit.message,
+    author: commit.author ? commit.author.login : null,
+    authorName: commit.commit.author.name || null,
+    authorEmail: commit.commit.author.email || null,
+    username: commit.author ? commit.author.login : null,
+    date: commit.commit.author.date,
+    url: commit.html_url
+  };
+}
+
+/**
+ * Normalise a GitHub pull request into the provider PR shape
+ * @param {Object} pr - GitHub pull request payload
+ * @returns {Object} - Normalised PR
+ */
+function normalizePR(pr) {
+  return {
+    number: pr.number,
+    url: pr.html_url,
+    state: pr.merged_at ? 'merged' : pr.state,
+    title: pr.title,
+    body: pr.body || '',
+    headBranch: pr.head ? pr.head.ref : null,
+    baseBranch: pr.base ? pr.base.ref : null,
+    mergeCommitSha: pr.merge_commit_sha || null
+  };
+}
+
+/**
+ * GitHub implementation of the Provider interface - our original queen 👑
+ */
+class GitHubProvider extends Provider {
+  /**
+   * @param {Object} octokit - GitHub API client
+   * @param {Object} context - GitHub context
+   * @param {Object} options - Provider options
+   * @param {Object} options.retry - Retry policy for every API request (optional)
+   * @param {Object} options.urls - API, upload and web URLs from getGitHubUrls (default: github.com)
+   */
+  constructor(octokit, context, { retry, urls = GITHUB_DOTCOM } = {}) {
+    super({ name: 'github' });
+    this.octokit = octokit;
+    this.context = context;
+    this.urls = urls;
+
+    // Every request - including the ones prManager makes with this client - goes through the retry policy
+    if (retry && octokit.hook) {
+      octokit.hook.wrap('request', (request, options) =>
+        withRetry(() => request(options), retry, `${options.method} ${options.url}`));
+    }
+  }
+
+  get repoUrl() {
+    const { owner, repo } = this.context.repo;
+    return `${this.urls.webUrl}/${owner}/${repo}`;
+  }
+
+  async checkConnection() {
+    // github.com is always there - it's self-hosted instances that get mistyped
+    if (this.urls.apiUrl === GITHUB_DOTCOM.apiUrl) {
+      return;
+    }
+
+    try {
+      await this.octokit.request('GET /meta');
+    } catch (error) {
+      if (error.status === 404) {
+        throw new Error(`${this.urls.apiUrl}/meta doesn't exist, so that's not a GitHub API URL - on GitHub Enterprise Server it's usually https://<your-host>/api/v3`);
+      }
+      if (!error.status) {
+        throw new Error(`Couldn't reach the GitHub API at ${this.urls.apiUrl}: ${error.message} - check githubApiUrl`);
+      }
+      // 401s and friends mean the server is there - the token problem gets its own error later
+    }
+  }
+
+  get headSha() {
+    return this.context.sha || null;
+  }
+
+  get branch() {
+    const pullRequest = this.context.payload && this.context.payload.pull_request;
+    if (pullRequest) {
+      return pullRequest.base.ref;
+    }
+    const ref = this.context.ref || '';
+    return ref.startsWith('refs/heads/') ? ref.substring('refs/heads/'.length) : null;
+  }
+
+  async compareCommits(base, head) {
+    const { owner, repo } = this.context.repo;
+    // Without paging the compare API stops at 250 commits and doesn't say so
+    const commits = await paginate(async page => {
+      const { data } = await this.octokit.rest.repos.compareCommits({
+        owner,
+        repo,
+        base,
+        head,
+        per_page: PAGE_SIZE,
+        page
+      });
+      return data.commits;
+    });
+
+    return commits.map(normalizeCommit);
+  }
+
+  async getCommit(sha) {
+    const { owner, repo } = this.context.repo;
+    const { data } = await this.octokit.rest.repos.getCommit({
+      owner,
+      repo,
+      ref: sha
+    });
+    return normalizeCommit(data);
+  }
+
+  async getCommitFiles(sha) {
+    const { owner, repo } = this.context.repo;
+    // Big commits list their files over several pages - ignorePaths and packages need all of them
+    const files = await paginate(async page => {
+      const { data } = await this.octokit.rest.repos.getCommit({
+        owner,
+        repo,
+        ref: sha,
+        per_page: PAGE_SIZE,
+        page
+      });
+      return data.files;
+    });
+    return files.map(file => file.filename);
+  }
+
+  async listTags() {
+    const { owner, repo } = this.context.repo;
+    // Every page - an older version line's tags are often well behind the newest hundred
+    const tags = await paginate(async page => {
+      const { data } = await this.octokit.rest.repos.listTags({ owner, repo, per_page: PAGE_SIZE, page });
+      return data;
+    });
+
+    return tags.map(tag => ({
+      name: tag.name,
+      sha: tag.commit ? tag.commit.sha : null
+    }));
+  }
+
+  async getLatestReleaseTag() {
+    const { owner, repo } = this.context.repo;
+    const { data } = await this.octokit.rest.repos.getLatestRelease({
+      owner,
+      repo
+    });
+    return data.tag_name || null;
+  }
+
+  async resolveUsername(email) {
+    // No API call needed for noreply addresses (users.noreply.github.com, or your GHES host)
+    const noreply = email.match(/^(?:\d+\+)?([^@]+)@users\.noreply\.[^@]+$/i);
+    if (noreply) {
+      return noreply[1];
+    }
+
+    if (!this.usernames) {
+      this.usernames = new Map();
+    }
+    if (!this.usernames.has(email)) {
+      // Only public emails are searchable - anyone else gets credited by name
+      this.usernames.set(email, this.octokit.rest.search.users({ q: `${email} in:email`, per_page: 1 })
+        .then(({ data }) => (data.total_count === 1 ? data.items[0].login : null))
+        .catch(() => null));
+    }
+    return this.usernames.get(email);
+  }
+
+  async getFileContent(filePath, ref) {
+    const { owner, repo } = this.context.repo;
+    try {
+      const { data } = await this.octokit.rest.repos.getContent({
+        owner,
+        repo,
+        path: filePath,
+        ref
+      });
+      return data && data.content ? Buffer.from(data.content, 'base64').toString('utf8') : null;
+    } catch (error) {
+      if (error.status === 404) {
+        return null;
+      }
+      throw error;
+    }
+  }
+
+  async findOpenReleasePR(config, version) {
+    const { owner, repo } = this.context.repo;
+    const params = {
+      owner,
+      repo,
+      state: 'open',
+      base: config.releaseBranch
+    };
+
+    if (version) {
+      params.head = `${owner}:${config.stagingBranch}-v${version}`;
+    }
+
+    const { data: openPRs } = await this.octokit.rest.pulls.list(params);
+    const releasePR = openPRs.find(pr => pr.head && pr.head.ref.startsWith(`${config.stagingBranch}-`));
+    return releasePR ? normalizePR(releasePR) : null;
+  }
+
+  async detectMergedReleasePR(config, options = {}) {
+    return detectReleasePR(this.octokit, this.context, config, options.branch);
+  }
+
+  async createReleasePR(version, changelog, config, updatedFiles) {
+    return createOrUpdatePR(this.octokit, this.context, version, changelog, config, updatedFiles);
+  }
+
+  async updatePR(number, fields) {
+    const { owner, repo } = this.context.repo;
+    const { data: pr } = await this.octokit.rest.pulls.update({
+      owner,
+      repo,
+      pull_number: number,
+      ...fields
+    });
+    return normalizePR(pr);
+  }
+
+  async getPR(number) {
+    const { owner, repo } = this.context.repo;
+    const { data: pr } = await this.octokit.rest.pulls.get({
+      owner,
+      repo,
+      pull_number: number
+    });
+    return normalizePR(pr);
+  }
+
+  async listCommits(head) {
+    const { owner, repo } = this.context.repo;
+    const commits = await paginate(async page => {
+      const { data } = await this.octokit.rest.repos.listCommits({
+        owner,
+        repo,
+        sha: head,
+        per_page: PAGE_SIZE,
+        page
+      });
+      return data;
+    });
+
+    // The API lists newest first - compareCommits gives oldest first, so match it
+    return commits.reverse().map(normalizeCommit);
+  }
+
+  async listPRComments(number) {
+    const { owner, repo } = this.context.repo;
+    const { data: comments } = await this.octokit.rest.issues.listComments({
+      owner,
+      repo,
+      issue_number: number
+    });
+
+    return comments.map(comment => ({
+      id: comment.id,
+      body: comment.body || '',
+      author: comment.user ? comment.user.login : null,
+      url: comment.html_url
+    }));
+  }
+
+  async getPRMergeStatus(number) {
+    const { owner, repo } = this.context.repo;
+    const { data: pr } = await this.octokit.rest.pulls.get({
+      owner,
+      repo,
+      pull_number: number
+    });
+    const sha = pr.head.sha;
+
+    if (pr.state !== 'open') {
+      return { state: 'blocked', reason: pr.merged_at ? 'it is already merged' : 'it was closed', sha };
+    }
+
+    // "unstable" only means checks that aren't required are failing - branch protection lets it merge
+    switch (pr.mergeable_state) {
+      case 'clean':
+      case 'unstable':
+      case 'has_hooks':
+        return { state: 'ready', reason: null, sha };
+      case 'dirty':
+        return { state: 'blocked', reason: 'it has merge conflicts', sha };
+      case 'behind':
+        return { state: 'blocked', reason: `it is behind ${pr.base.ref}`, sha };
+      case 'draft':
+        return { state: 'blocked', reason: 'it is a draft', sha };
+      case 'blocked':
+        return { ...await this.getChecksStatus(sha), sha };
+      default:
+        // GitHub works mergeability out in the background - "unknown" means ask again
+        return { state: 'pending', reason: 'GitHub is still working out whether it can be merged', sha };
+    }
+  }
+
+  /**
+   * Work out why branch protection is holding a PR back from its head commit's checks
+   * @param {String} sha - PR head commit
+   * @returns {Promise<Object>} - { state, reason }
+   */
+  async getChecksStatus(sha) {
+    const { owner, repo } = this.context.repo;
+    const { data: { check_runs: runs } } = await this.octokit.rest.checks.listForRef({
+      owner,
+      repo,
+      ref: sha,
+      per_page: 100
+    });
+    const { data: { statuses } } = await this.octokit.rest.repos.getCombinedStatusForRef({
+      owner,
+      repo,
+      ref: sha
+    });
+
+    // Anything still running might be the required check, so wait for it before calling it failed
+    const pending = [
+      ...runs.filter(run => run.status !== 'completed').map(run => run.name),
+      ...statuses.filter(status => status.state === 'pending').map(status => status.context)
+    ];
+    if (pending.length > 0) {
+      return { state: 'pending', reason: `waiting on ${pending.join(', ')}` };
+    }
+
+    const failed = [
+      ...runs.filter(run => FAILED_CONCLUSIONS.includes(run.conclusion)).map(run => run.name),
+      ...statuses.filter(status => status.state === 'failure' || status.state === 'error').map(status => status.context)
+    ];
+    if (failed.length > 0) {
+      return { state: 'blocked', reason: `checks failed: ${failed.join(', ')}` };
+    }
+
+    // Required checks that haven't started yet (or a required review) look just like this
+    return { state: 'pending', reason: 'branch protection is waiting on checks or reviews' };
+  }
+
+  async mergePR(number, options = {}) {
+    const { owner, repo } = this.context.repo;
+    const { data } = await this.octokit.rest.pulls.merge({
+      owner,
+      repo,
+      pull_number: number,
+      merge_method: options.method || 'merge',
+      ...(options.sha ? { sha: options.sha } : {})
+    });
+    return { merged: data.merged, sha: data.sha };
+  }
+
+  async createTag(version, config, options = {}) {
+    return tagRelease(this.octokit, this.context, version, config, undefined, options);
+  }
+
+  async createRelease(tagName, { title, body, prerelease }) {
+    const { owner, repo } = this.context.repo;
+
+    let existing = null;
+    try {
+      ({ data: existing } = await this.octokit.rest.repos.getReleaseByTag({ owner, repo, tag: tagName }));
+    } catch (error) {
+      if (error.status !== 404) {
+        throw error;
+      }
+    }
+
+    if (existing) {
+      const { data } = await this.octokit.rest.repos.updateRelease({
+        owner,
+        repo,
+        release_id: existing.id,
+        name: title,
+        body,
+        prerelease
+      });
+      return { url: data.html_url, updated: true };
+    }
+
+    const { data } = await this.octokit.rest.repos.createRelease({
+      owner,
+      repo,
+      tag_name: tagName,
+      name: title,
+      body,
+      prerelease
+    });
+    return { url: data.html_url, updated: false };
+  }
+
+  async deleteRelease(tagName) {
+    const { owner, repo } = this.context.repo;
+
+    let release;
+    try {
+      ({ data: release } = await this.octokit.rest.repos.getReleaseByTag({ owner, repo, tag: tagName }));
+    } catch (error) {
+      if (error.status === 404) {
+        return false;
+      }
+      throw error;
+    }
+
+    await this.octokit.rest.repos.deleteRelease({ owner, repo, release_id: release.id });
+    return true;
+  }
+
+  async deleteTag(tagName) {
+    const { owner, repo } = this.context.repo;
+    try {
+      await this.octokit.rest.git.deleteRef({ owner, repo, ref: `tags/${tagName}` });
+      return true;
+    } catch (error) {
+      // A ref that isn't there is a 422 ("Reference does not exist"), not a 404
+      if (error.status === 404 || error.status === 422) {
+        return false;
+      }
+      throw error;
+    }
+  }
+
+  async revertCommit(sha, { branch, message }) {
+    const { owner, repo } = this.context.repo;
+    const { data: commit } = await this.octokit.rest.repos.getCommit({ owner, repo, ref: sha });
+    if (!commit.parents || commit.parents.length !== 1) {
+      throw new Error(`Commit ${sha.substring(0, 7)} has ${(commit.parents || []).length} parents - only a regular commit can be reverted`);
+    }
+
+    const { data: ref } = await this.octokit.rest.git.getRef({ owner, repo, ref: `heads/${branch}` });
+    const headSha = ref.object.sha;
+    const [before, after, head] = await Promise.all([commit.parents[0].sha, sha, headSha].map(commitSha => this.readTree(commitSha)));
+
+    // There's no revert endpoint, so each file the commit touched goes back to its parent's blob -
+    // which is only a revert while the branch still has the commit's version of it
+    const tree = [];
+    const changed = [];
+    for (const file of commit.files || []) {
+      for (const filePath of [file.filename, file.previous_filename].filter(Boolean)) {
+        const current = head.entries.get(filePath);
+        const committed = after.entries.get(filePath);
+        if ((current && current.sha) !== (committed && committed.sha)) {
+          changed.push(filePath);
+          continue;
+        }
+        const previous = before.entries.get(filePath);
+        tree.push(previous
+          ? { path: filePath, mode: previous.mode, type: 'blob', sha: previous.sha }
+          : { path: filePath, mode: '100644', type: 'blob', sha: null });
+      }
+    }
+    if (changed.length > 0) {
+      throw new Error(`${changed.join(', ')} changed on ${branch} since commit ${sha.substring(0, 7)} - revert it by hand`);
+    }
+
+    const { data: newTree } = await this.octokit.rest.git.createTree({ owner, repo, base_tree: head.treeSha, tree });
+    const { data: revert } = await this.octokit.rest.git.createCommit({ owner, repo, message, tree: newTree.sha, parents: [headSha] });
+    await this.octokit.rest.git.updateRef({ owner, repo, ref: `heads/${branch}`, sha: revert.sha });
+    return { sha: revert.sha };
+  }
+
+  /**
+   * Read every file of a commit's tree
+   * @param {String} commitSha - Commit SHA
+   * @returns {Promise<Object>} - { treeSha, entries } - entries maps each path to { mode, sha }
+   */
+  async readTree(commitSha) {
+    const { owner, repo } = this.context.repo;
+    const { data: commit } = await this.octokit.rest.git.getCommit({ owner, repo, commit_sha: commitSha });
+    const { data } = await this.octokit.rest.git.getTree({ owner, repo, tree_sha: commit.tree.sha, recursive: 'true' });
+
+    const entries = new Map();
+    for (const entry of data.tree || []) {
+      if (entry.type === 'blob') {
+        entries.set(entry.path, { mode: entry.mode, sha: entry.sha });
+      }
+    }
+    return { treeSha: commit.tree.sha, entries };
+  }
+
+  async branchExists(branch) {
+    const { owner, repo } = this.context.repo;
+    try {
+      await this.octokit.rest.repos.getBranch({ owner, repo, branch });
+      return true;
+    } catch (error) {
+      if (error.status === 404) {
+        return false;
+      }
+      throw error;
+    }
+  }
+
+  async listBranches(prefix) {
+    const { owner, repo } = this.context.repo;
+    const { data: refs } = await this.octokit.rest.git.listMatchingRefs({
+      owner,
+      repo,
+      ref: `heads/${prefix}`
+    });
+
+    // Refs don't carry dates - the stale branch sweep looks up the few it needs with getCommit
+    return (refs || []).map(ref => ({
+      name: ref.ref.replace(/^refs\/heads\//, ''),
+      sha: ref.object.sha,
+      date: null
+    }));
+  }
+
+  async listBranchPRs(branch) {
+    const { owner, repo } = this.context.repo;
+    const { data: prs } = await this.octokit.rest.pulls.list({
+      owner,
+      repo,
+      state: 'all',
+      head: `${owner}:${branch}`,
+      per_page: 100
+    });
+    return (prs || []).map(normalizePR);
+  }
+
+  async deleteBranch(branch) {
+    return deleteBranch(this.octokit, this.context, branch);
+  }
+}
+
+module.exports = {
+  GitHubProvider,
+  getGitHubUrls,
+  normalizeCommit,
+  normalizePR
+};
@@ -0,0 +1,552 @@
+const fs = require('fs').promises;
+const path = require('path');
+
+const { Provider } = require('./provider');
+const { requestJson } = require('../utils/http');
+const { withRetry } = require('../utils/retry');
+const { generateFileChangelog } = require('../github/changelogTable');
+const { buildPRTitle, buildPRBody, buildCommitMessage } = require('../core/prContent');
+const { getReleaseTagName, getAdditionalTagNames } = require('../utils/tags');
+const { logger } = require('../utils/logger');
+
+/**
+ * Items per page for list endpoints - the most GitLab hands out
+ */
+const PAGE_SIZE = 100;
+
+/**
+ * detailed_merge_status values that mean GitLab (or the pipeline) isn't done yet
+ */
+const PENDING_MERGE_STATUSES = ['checking', 'unchecked', 'preparing', 'ci_still_running', 'approvals_syncing'];
+
+/**
+ * Normalise a GitLab merge request into the provider PR shape
+ * @param {Object} mr - GitLab merge request payload
+ * @returns {Object} - Normalised PR
+ */
+function normalizeMR(mr) {
+  const states = { opened: 'open', closed: 'closed', merged: 'merged', locked: 'closed' };
+  return {
+    number: mr.iid,
+    url: mr.web_url,
+    state: states[mr.state] || mr.state,
+    title: mr.title,
+    body: mr.description || '',
+    headBranch: mr.source_branch,
+    baseBranch: mr.target_branch,
+    mergeCommitSha: mr.merge_commit_sha || mr.squash_commit_sha || null
+  };
+}
+
+/**
+ * GitLab implementation of the Provider interface, for gitlab.com and self-hosted instances 🦊
+ * Merge requests play the role of pull requests here.
+ */
+class GitLabProvider extends Provider {
+  /**
+   * @param {Object} options - Provider options
+   * @param {String} options.token - GitLab access token
+   * @param {String} options.project - Project path (e.g. "group/project")
+   * @param {String} options.baseUrl - GitLab instance URL (default https://gitlab.com)
+   * @param {String} options.sha - Commit SHA of the current pipeline (optional)
+   * @param {String} options.ref - Branch of the current pipeline (optional)
+   * @param {Function} options.request - HTTP request function (defaults to requestJson)
+   * @param {Object} options.retry - Retry policy for every API request (optional)
+   */
+  constructor({ token, project, baseUrl, sha, ref, request, retry }) {
+    super({ name: 'gitlab' });
+
+    if (!project) {
+      throw new Error('GitLab provider needs a project path - set "repository" in config or run inside GitLab CI 🦊');
+    }
+
+    this.token = token;
+    this.project = project;
+    this.baseUrl = (baseUrl || 'https://gitlab.com').replace(/\/+$/, '');
+    this.sha = sha || null;
+    this.ref = ref || null;
+    this.request = request || requestJson;
+    this.retry = retry || null;
+  }
+
+  get repoUrl() {
+    return `${this.baseUrl}/${this.project}`;
+  }
+
+  get headSha() {
+    return this.sha;
+  }
+
+  get branch() {
+    return this.ref;
+  }
+
+  compareUrl(from, to) {
+    return `${this.repoUrl}/-/compare/${from}...${to}`;
+  }
+
+  pullRequestUrl(number) {
+    return `${this.repoUrl}/-/merge_requests/${number}`;
+  }
+
+  issueUrl(number) {
+    return `${this.repoUrl}/-/issues/${number}`;
+  }
+
+  /**
+   * Call the GitLab REST API for this project
+   * @param {String} method - HTTP method
+   * @param {String} apiPath - Path relative to /projects/:id
+   * @param {Object} options - { body, query, raw }
+   * @returns {Promise<*>} - Response data
+   */
+  async api(method, apiPath, options = {}) {
+    const query = options.query
+      ? '?' + Object.entries(options.query)
+        .filter(([, value]) => value !== undefined && value !== null)
+        .map(([key, value]) => `${encodeURIComponent(key)}=${encodeURIComponent(value)}`)
+        .join('&')
+      : '';
+    const url = `${this.baseUrl}/api/v4/projects/${encodeURIComponent(this.project)}${apiPath}${query}`;
+
+    const send = () => this.request(method, url, {
+      headers: { 'PRIVATE-TOKEN': this.token },
+      body: options.body,
+      raw: options.raw,
+      timeout: this.retry ? this.retry.timeout : undefined
+    });
+
+    const { data } = this.retry ? await withRetry(send, this.retry, `${method} ${apiPath}`) : await send();
+    return data;
+  }
+
+  /**
+   * Read every page of a list endpoint
+   * A page shorter than PAGE_SIZE is the last one.
+   * @param {String} apiPath - Path relative to /projects/:id
+   * @param {Object} query - Query parameters besides page and per_page
+   * @returns {Promise<Array>} - Every item, in the order the API returned them
+   */
+  async paginate(apiPath, query = {}) {
+    const items = [];
+
+    for (let page = 1; ; page++) {
+      const data = await this.api('GET', apiPath, { query: { ...query, per_page: PAGE_SIZE, page } });
+      const pageItems = Array.isArray(data) ? data : [];
+      items.push(...pageItems);
+      if (pageItems.length < PAGE_SIZE) {
+        break;
+      }
+    }
+
+    return items;
+  }
+
+  /**
+   * Normalise a GitLab commit into the provider commit shape
+   * @param {Object} commit - GitLab commit payload
+   * @returns {Object} - Normalised commit
+   */
+  normalizeCommit(commit) {
+    return {
+      sha: commit.id,
+      message: commit.message,
+      author: commit.author_name || null,
+      authorName: commit.author_name || null,
+      authorEmail: commit.author_email || null,
+      username: null,
+      date: commit.authored_date || commit.created_at,
+      url: commit.web_url || `${this.repoUrl}/-/commit/${commit.id}`
+    };
+  }
+
+  async compareCommits(base, head) {
+    const data = await this.api('GET', '/repository/compare', { query: { from: base, to: head } });
+
+    return (data.commits || []).map(commit => this.normalizeCommit(commit));
+  }
+
+  async listCommits(head) {
+    const commits = await this.paginate('/repository/commits', { ref_name: head });
+    return commits.reverse().map(commit => this.normalizeCommit(commit));
+  }
+
+  async getCommit(sha) {
+    return this.normalizeCommit(await this.api('GET', `/repository/commits/${encodeURIComponent(sha)}`));
+  }
+
+  async getCommitFiles(sha) {
+    const diffs = await this.paginate(`/repository/commits/${sha}/diff`);
+    const files = new Set();
+    for (const diff of diffs) {
+      files.add(diff.new_path);
+      if (diff.old_path) files.add(diff.old_path);
+    }
+    return [...files];
+  }
+
+  async listTags() {
+    const tags = await this.paginate('/repository/tags', { order_by: 'updated' });
+    return tags.map(tag => ({
+      name: tag.name,
+      sha: tag.commit ? tag.commit.id : null
+    }));
+  }
+
+  async getLatestReleaseTag() {
+    const releases = await this.api('GET', '/releases', { query: { per_page: 1 } });
+    return releases && releases.length > 0 ? releases[0].tag_name : null;
+  }
+
+  async getFileContent(filePath, ref) {
+    try {
+      return await this.api('GET', `/repository/files/${encodeURIComponent(filePath)}/raw`, {
+        query: { ref },
+        raw: true
+      });
+    } catch (error) {
+      if (error.status === 404) {
+        return null;
+      }
+      throw error;
+    }
+  }
+
+  async branchExists(branch) {
+    try {
+      await this.api('GET', `/repository/branches/${encodeURIComponent(branch)}`);
+      return true;
+    } catch (error) {
+      if (error.status === 404) {
+        return false;
+      }
+      throw error;
+    }
+  }
+
+  async findOpenReleasePR(config, version) {
+    const mrs = await this.api('GET', '/merge_requests', {
+      query: {
+        state: 'opened',
+        target_branch: config.releaseBranch,
+        source_branch: version ? `${config.stagingBranch}-v${version}` : undefined
+      }
+    });
+
+    const releaseMR = (mrs || []).find(mr => mr.source_branch.startsWith(`${config.stagingBranch}-`));
+    return releaseMR ? normalizeMR(releaseMR) : null;
+  }
+
+  async detectMergedReleasePR(config, options = {}) {
+    if (!this.sha || (options.branch || this.ref) !== config.releaseBranch) {
+      logger.info(`Not a pipeline on the release branch ${config.releaseBranch} - no merged MR to detect 🤷‍♀️`);
+      return null;
+    }
+
+    const mrs = await this.api('GET', `/repository/commits/${this.sha}/merge_requests`);
+    const releaseMR = (mrs || []).find(mr =>
+      mr.state === 'merged' &&
+      mr.target_branch === config.releaseBranch &&
+      mr.source_branch.startsWith(`${config.stagingBranch}-`)
+    );
+
+    if (!releaseMR) {
+      return null;
+    }
+
+    const versionMatch = releaseMR.source_branch.match(new RegExp(`^${config.stagingBranch}-v?([0-9]+\\.[0-9]+\\.[0-9]+.*?)$`));
+    return {
+      number: releaseMR.iid,
+      title: releaseMR.title,
+      body: releaseMR.description,
+      headBranch: releaseMR.source_branch,
+      version: versionMatch ? versionMatch[1] : null,
+      merged: true,
+      mergeCommitSha: this.sha
+    };
+  }
+
+  async createReleasePR(version, changelog, config, updatedFiles = []) {
+    // An MR that's already open keeps its branch, even when the version has moved on
+    const stagingBranch = config.releasePR ? config.releasePR.headBranch : `${config.stagingBranch}-v${version}`;
+
+    // GitLab has no branch-to-branch merge API, so the staging branch is always
+    // cut fresh from the merge branch - the MR then carries everything new 💅
+    if (await this.branchExists(stagingBranch)) {
+      logger.info(`Staging branch ${stagingBranch} already exists - recreating it from ${config.mergeBranch}`);
+      await this.api('DELETE', `/repository/branches/${encodeURIComponent(stagingBranch)}`);
+    }
+
+    await this.api('POST', '/repository/branches', { query: { branch: stagingBranch, ref: config.mergeBranch } });
+    logger.info(`Created staging branch ${stagingBranch} from ${config.mergeBranch} 🦊`);
+
+    // Brand new repo - the MR needs a release branch to target
+    if (!await this.branchExists(config.releaseBranch)) {
+      logger.info(`Release branch ${config.releaseBranch} doesn't exist yet - creating it from ${config.mergeBranch} for the first release 🎉`);
+      await this.api('POST', '/repository/branches', { query: { branch: config.releaseBranch, ref: config.mergeBranch } });
+    }
+
+    const filesToCommit = [];
+
+    for (const file of updatedFiles) {
+      const filePathInRepo = path.relative(process.cwd(), file);
+      if (filePathInRepo === '' || filePathInRepo.startsWith('..')) {
+        logger.error(`Invalid file path: ${filePathInRepo}`);
+        continue;
+      }
+      filesToCommit.push({ path: filePathInRepo, content: await fs.readFile(file, 'utf8') });
+    }
+
+    if (config.changelogPath) {
+      const baseContent = await this.getFileContent(config.changelogPath, config.releaseBranch) || '';
+      filesToCommit.push({
+        path: config.changelogPath,
+        content: generateFileChangelog(changelog, version, baseContent, { header: config.changelogHeader })
+      });
+    }
+
+    if (filesToCommit.length > 0) {
+      const actions = [];
+      for (const file of filesToCommit) {
+        const existing = await this.getFileContent(file.path, stagingBranch);
+        actions.push({
+          action: existing === null ? 'create' : 'update',
+          file_path: file.path,
+          content: file.content
+        });
+      }
+
+      await this.api('POST', '/repository/commits', {
+        body: {
+          branch: stagingBranch,
+          commit_message: buildCommitMessage(version, config),
+          actions
+        }
+      });
+      logger.info(`Committed ${actions.length} files to ${stagingBranch} in a single fabulous commit! 💁‍♀️`);
+    }
+
+    const title = buildPRTitle(version, config);
+    const description = buildPRBody(changelog, config, updatedFiles, version);
+    const existing = config.releasePR || await this.findOpenReleasePR(config, version);
+
+    if (existing) {
+      const updated = await this.updatePR(existing.number, { title, body: description });
+      logger.info(`Updated MR !${updated.number}: ${title}`);
+      return { prNumber: updated.number, prUrl: updated.url, prStatus: updated.state };
+    }
+
+    const mr = await this.api('POST', '/merge_requests', {
+      body: {
+        source_branch: stagingBranch,
+        target_branch: config.releaseBranch,
+        title,
+        description,
+        labels: 'release',
+        remove_source_branch: config.deleteStagingBranch !== false
+      }
+    });
+
+    logger.info(`Created MR !${mr.iid}: ${title}`);
+    const created = normalizeMR(mr);
+    return { prNumber: created.number, prUrl: created.url, prStatus: created.state };
+  }
+
+  async updatePR(number, fields) {
+    const body = {};
+    if (fields.title !== undefined) body.title = fields.title;
+    if (fields.body !== undefined) body.description = fields.body;
+
+    const mr = await this.api('PUT', `/merge_requests/${number}`, { body });
+    return normalizeMR(mr);
+  }
+
+  async getPR(number) {
+    const mr = await this.api('GET', `/merge_requests/${number}`);
+    return normalizeMR(mr);
+  }
+
+  async listPRComments(number) {
+    const notes = await this.api('GET', `/merge_requests/${number}/notes`, { query: { sort: 'asc', per_page: 100 } });
+    return (notes || [])
+      .filter(note => !note.system)
+      .map(note => ({
+        id: note.id,
+        body: note.body || '',
+        author: note.author ? note.author.username : null,
+        url: `${this.pullRequestUrl(number)}#note_${note.id}`
+      }));
+  }
+
+  async getPRMergeStatus(number) {
+    const mr = await this.api('GET', `/merge_requests/${number}`);
+    const sha = mr.sha;
+
+    if (mr.state !== 'opened') {
+      return { state: 'blocked', reason: `it is ${mr.state}`, sha };
+    }
+
+    const status = mr.detailed_merge_status;
+    if (status === 'mergeable') {
+      return { state: 'ready', reason: null, sha };
+    }
+    if (PENDING_MERGE_STATUSES.includes(status)) {
+      return { state: 'pending', reason: `GitLab says ${status}`, sha };
+    }
+    // ci_must_pass covers both a pipeline that's still going and one that failed
+    if (status === 'ci_must_pass') {
+      const pipeline = mr.head_pipeline ? mr.head_pipeline.status : null;
+      return ['failed', 'canceled'].includes(pipeline)
+        ? { state: 'blocked', reason: `the pipeline ${pipeline}`, sha }
+        : { state: 'pending', reason: 'waiting on the pipeline', sha };
+    }
+    return { state: 'blocked', reason: `GitLab says ${status}`, sha };
+  }
+
+  async mergePR(number, options = {}) {
+    const method = options.method || 'merge';
+    if (method === 'rebase') {
+      await this.api('PUT', `/merge_requests/${number}/rebase`);
+    }
+
+    // After a rebase the head has moved on, so the checked sha doesn't apply
+    const mr = await this.api('PUT', `/merge_requests/${number}/merge`, {
+      body: { squash: method === 'squash', ...(options.sha && method !== 'rebase' ? { sha: options.sha } : {}) }
+    });
+    const merged = normalizeMR(mr);
+    // Fast-forward merges don't make a merge commit - the MR head is what landed
+    return { merged: merged.state === 'merged', sha: merged.mergeCommitSha || mr.sha || null };
+  }
+
+  async createTag(version, config, options = {}) {
+    const tagName = getReleaseTagName(version, config);
+
+    let sha = options.sha;
+    if (!sha) {
+      const branch = await this.api('GET', `/repository/branches/${encodeURIComponent(config.releaseBranch)}`);
+      sha = branch.commit.id;
+    }
+    const createdTags = [];
+
+    const existing = (await this.listTags()).find(tag => tag.name === tagName);
+    if (existing) {
+      logger.info(`Tag ${tagName} already exists, skipping tag creation`);
+    } else {
+      // A message is what makes GitLab create an annotated tag
+      const message = options.annotate ? { message: `Release ${tagName}` } : {};
+      await this.api('POST', '/repository/tags', { query: { tag_name: tagName, ref: sha, ...message } });
+      logger.info(`Successfully created tag: ${tagName} at ${sha.substring(0, 7)}`);
+    }
+    createdTags.push(tagName);
+
+    const additionalTags = getAdditionalTagNames(version, config);
+
+    // GitLab tags can't be moved, so alias tags are deleted and recreated
+    for (const tag of additionalTags) {
+      try {
+        try {
+          await this.api('DELETE', `/repository/tags/${encodeURIComponent(tag)}`);
+        } catch (error) {
+          if (error.status !== 404) throw error;
+        }
+        await this.api('POST', '/repository/tags', { query: { tag_name: tag, ref: sha } });
+        logger.info(`Pointed tag ${tag} at commit ${sha.substring(0, 7)}`);
+        createdTags.push(tag);
+      } catch (error) {
+        logger.error(`Error managing additional tag ${tag}: ${error.message}`);
+      }
+    }
+
+    return { sha, tags: createdTags };
+  }
+
+  async createRelease(tagName, { title, body }) {
+    // GitLab has no prerelease flag - a release is a release
+    const fields = { name: title, description: body };
+
+    try {
+      await this.api('GET', `/releases/${encodeURIComponent(tagName)}`);
+    } catch (error) {
+      if (error.status !== 404) {
+        throw error;
+      }
+      const release = await this.api('POST', '/releases', { body: { tag_name: tagName, ...fields } });
+      return { url: release._links ? release._links.self : `${this.repoUrl}/-/releases/${tagName}`, updated: false };
+    }
+
+    const release = await this.api('PUT', `/releases/${encodeURIComponent(tagName)}`, { body: fields });
+    return { url: release._links ? release._links.self : `${this.repoUrl}/-/releases/${tagName}`, updated: true };
+  }
+
+  async deleteRelease(tagName) {
+    try {
+      await this.api('DELETE', `/releases/${encodeURIComponent(tagName)}`);
+      return true;
+    } catch (error) {
+      if (error.status === 404) {
+        return false;
+      }
+      throw error;
+    }
+  }
+
+  async deleteTag(tagName) {
+    try {
+      await this.api('DELETE', `/repository/tags/${encodeURIComponent(tagName)}`);
+      return true;
+    } catch (error) {
+      if (error.status === 404) {
+        return false;
+      }
+      throw error;
+    }
+  }
+
+  async revertCommit(sha, { branch }) {
+    // GitLab reverts with a 3-way merge and writes its own "Revert ..." message - there's no way to pass one
+    const commit = await this.api('POST', `/repository/commits/${sha}/revert`, { body: { branch } });
+    return { sha: commit.id };
+  }
+
+  async listBranches(prefix) {
+    // A leading ^ makes GitLab's search a prefix match
+    const branches = await this.api('GET', '/repository/branches', {
+      query: { search: `^${prefix}`, per_page: 100 }
+    });
+
+    return (branches || [])
+      .filter(branch => branch.name.startsWith(prefix))
+      .map(branch => ({
+        name: branch.name,
+        sha: branch.commit ? branch.commit.id : null,
+        date: branch.commit ? branch.commit.committed_date : null
+      }));
+  }
+
+  async listBranchPRs(branch) {
+    const mrs = await this.api('GET', '/merge_requests', {
+      query: { state: 'all', source_branch: branch, per_page: 100 }
+    });
+    return (mrs || []).map(normalizeMR);
+  }
+
+  async deleteBranch(branch) {
+    try {
+      await this.api('DELETE', `/repository/branches/${encodeURIComponent(branch)}`);
+      logger.info(`Successfully deleted branch ${branch} - keeping things tidy! ✨`);
+      return true;
+    } catch (error) {
+      if (error.status === 404) {
+        logger.info(`Branch ${branch} doesn't exist, no need to delete it 💅`);
+      } else {
+        logger.error(`Error deleting branch ${branch}: ${error.message}`);
+      }
+      return false;
+    }
+  }
+}
+
+module.exports = {
+  GitLabProvider,
+  normalizeMR
+};
@@ -0,0 +1,72 @@
+const github = require('@actions/github');
+
+const { GitHubProvider, getGitHubUrls } = require('./githubProvider');
+const { GitLabProvider } = require('./gitlabProvider');
+const { GiteaProvider } = require('./giteaProvider');
+const { DryRunProvider } = require('./dryRunProvider');
+const { getRetryPolicy } = require('../utils/retry');
+
+/**
+ * Supported VCS platforms
+ */
+const PLATFORMS = ['github', 'gitlab', 'gitea'];
+
+/**
+ * Create the provider for the configured platform
+ * @param {Object} config - Release Boss configuration
+ * @param {Object} options - Provider options
+ * @param {String} options.token - API token for the platform
+ * @param {Boolean} options.dryRun - Wrap the provider so nothing on the remote changes
+ * @returns {Provider} - Provider instance
+ */
+function createProvider(config, { token, dryRun = false }) {
+  const provider = createPlatformProvider(config, token);
+  return dryRun ? new DryRunProvider(provider) : provider;
+}
+
+/**
+ * Create the real provider for the configured platform
+ * @param {Object} config - Release Boss configuration
+ * @param {String} token - API token for the platform
+ * @returns {Provider} - Provider instance
+ */
+function createPlatformProvider(config, token) {
+  const platform = config.platform || 'github';
+  const retry = getRetryPolicy(config);
+
+  switch (platform) {
+    case 'github': {
+      const urls = getGitHubUrls(config);
+      return new GitHubProvider(github.getOctokit(token, { baseUrl: urls.apiUrl }), github.context, { retry, urls });
+    }
+
+    case 'gitlab':
+      return new GitLabProvider({
+        token,
+        project: config.repository || process.env.CI_PROJECT_PATH,
+        baseUrl: config.baseUrl || process.env.CI_SERVER_URL,
+        sha: process.env.CI_COMMIT_SHA,
+        ref: process.env.CI_COMMIT_BRANCH,
+        retry
+      });
+
+    // Gitea Actions sets the GitHub-style variables, so those are the fallbacks here
+    case 'gitea':
+      return new GiteaProvider({
+        token,
+        repository: config.repository || process.env.GITHUB_REPOSITORY,
+        baseUrl: config.baseUrl || process.env.GITHUB_SERVER_URL,
+        sha: process.env.GITHUB_SHA,
+        ref: (process.env.GITHUB_REF || '').startsWith('refs/heads/') ? process.env.GITHUB_REF.substring('refs/heads/'.length) : null,
+        retry
+      });
+
+    default:
+      throw new Error(`Unsupported platform "${platform}" - pick one of: ${PLATFORMS.join(', ')}`);
+  }
+}
+
+module.exports = {
+  createProvider,
+  PLATFORMS
+};
@@ -0,0 +1,199 @@
+const { execFile } = require('child_process');
+
+const { Provider } = require('./provider');
+
+/**
+ * Field and record separators for git log output (commit bodies can hold anything else)
+ */
+const FIELD = '\x1f';
+const RECORD = '\x1e';
+
+/**
+ * Run git in a directory
+ * @param {Array<String>} args - git arguments
+ * @param {String} cwd - Working directory
+ * @returns {Promise<String>} - stdout
+ */
+function runGit(args, cwd) {
+  return new Promise((resolve, reject) => {
+    execFile('git', args, { cwd, maxBuffer: 64 * 1024 * 1024 }, (error, stdout, stderr) => {
+      if (error) {
+        reject(new Error(`git ${args[0]} failed: ${(stderr || error.message).trim()}`));
+      } else {
+        resolve(stdout);
+      }
+    });
+  });
+}
+
+/**
+ * Turn a git remote URL into the repository's web URL
+ * @param {String} remote - Remote URL (https, ssh or scp-style)
+ * @returns {String|null} - e.g. https://github.com/owner/repo
+ */
+function remoteToWebUrl(remote) {
+  const url = remote.trim().replace(/\.git$/, '');
+  const scp = url.match(/^[\w.-]+@([^:/]+):(.+)$/);
+  if (scp) {
+    return `https://${scp[1]}/${scp[2]}`;
+  }
+
+  const match = url.match(/^(?:https?|ssh|git):\/\/(?:[^@/]+@)?([^/:]+)(?::\d+)?\/(.+)$/);
+  return match ? `https://${match[1]}/${match[2]}` : null;
+}
+
+/**
+ * Read-only provider backed by the local git checkout 🏠
+ *
+ * Used by the command line, where there's no CI context or token - it only
+ * reads commits and tags, and never touches the remote.
+ */
+class LocalGitProvider extends Provider {
+  /**
+   * @param {Object} options - Provider options
+   * @param {String} options.platform - Platform the repo is hosted on, for link formats (github, gitlab, gitea)
+   * @param {String} options.repoUrl - Web URL of the repository
+   * @param {String} options.cwd - Checkout to read from (default: current directory)
+   * @param {Function} options.git - git runner (for tests)
+   */
+  constructor({ platform = 'github', repoUrl, cwd = process.cwd(), git = runGit }) {
+    super({ name: platform });
+    this.url = repoUrl;
+    this.cwd = cwd;
+    this.git = args => git(args, this.cwd);
+  }
+
+  get repoUrl() {
+    return this.url;
+  }
+
+  compareUrl(from, to) {
+    return this.name === 'gitlab'
+      ? `${this.repoUrl}/-/compare/${from}...${to}`
+      : super.compareUrl(from, to);
+  }
+
+  pullRequestUrl(number) {
+    if (this.name === 'gitlab') {
+      return `${this.repoUrl}/-/merge_requests/${number}`;
+    }
+    return this.name === 'gitea' ? `${this.repoUrl}/pulls/${number}` : super.pullRequestUrl(number);
+  }
+
+  issueUrl(number) {
+    return this.name === 'gitlab'
+      ? `${this.repoUrl}/-/issues/${number}`
+      : super.issueUrl(number);
+  }
+
+  /**
+   * Resolve a reference to a commit SHA
+   * @param {String} ref - Branch, tag or SHA
+   * @returns {Promise<String>}
+   */
+  async resolveRef(ref) {
+    return (await this.git(['rev-parse', '--verify', '--quiet', `${ref}^{commit}`])).trim();
+  }
+
+  async compareCommits(base, head) {
+    return this.log(`${base}..${head}`);
+  }
+
+  async listCommits(head) {
+    return this.log(head);
+  }
+
+  /**
+   * Read commits with git log
+   * @param {String} range - Revision range
+   * @returns {Promise<Array>} - Normalised commits, oldest first
+   */
+  async log(range) {
+    const output = await this.git(['log', '--reverse', `--format=%H${FIELD}%an${FIELD}%ae${FIELD}%aI${FIELD}%B${RECORD}`, range, '--']);
+
+    return output.split(RECORD)
+      .map(record => record.replace(/^\n/, ''))
+      .filter(Boolean)
+      .map(record => {
+        const [sha, author, email, date, body] = record.split(FIELD);
+        return {
+          sha,
+          message: body.trim(),
+          author,
+          authorName: author,
+          authorEmail: email,
+          username: null,
+          date,
+          url: this.name === 'gitlab' ? `${this.repoUrl}/-/commit/${sha}` : `${this.repoUrl}/commit/${sha}`
+        };
+      });
+  }
+
+  async getCommit(sha) {
+    // sha^! is the commit on its own, without its parents' history
+    const [commit] = await this.log(`${sha}^!`);
+    return commit;
+  }
+
+  async getCommitFiles(sha) {
+    const output = await this.git(['diff-tree', '--no-commit-id', '--name-only', '-r', '--root', sha]);
+    return output.split('\n').filter(Boolean);
+  }
+
+  async listTags() {
+    const output = await this.git(['for-each-ref', 'refs/tags', `--format=%(refname:short)${FIELD}%(objectname)${FIELD}%(*objectname)`]);
+
+    return output.split('\n')
+      .filter(Boolean)
+      .map(line => {
+        const [name, sha, peeled] = line.split(FIELD);
+        // Annotated tags point at a tag object - the commit is the peeled SHA
+        return { name, sha: peeled || sha };
+      });
+  }
+
+  async getFileContent(filePath, ref) {
+    try {
+      return await this.git(['show', `${ref}:${filePath}`]);
+    } catch {
+      return null;
+    }
+  }
+}
+
+/**
+ * Create a provider for the local checkout
+ * The web URL comes from baseUrl and repository when both are set, otherwise from the origin remote.
+ * @param {Object} config - Release Boss configuration
+ * @param {Object} options - Provider options
+ * @param {String} options.cwd - Checkout to read from (default: current directory)
+ * @param {Function} options.git - git runner (for tests)
+ * @returns {Promise<LocalGitProvider>}
+ */
+async function createLocalGitProvider(config, { cwd = process.cwd(), git = runGit } = {}) {
+  const platform = config.platform || 'github';
+  let repoUrl = config.baseUrl && config.repository
+    ? `${config.baseUrl.replace(/\/+$/, '')}/${config.repository}`
+    : null;
+
+  if (!repoUrl) {
+    try {
+      repoUrl = remoteToWebUrl(await git(['remote', 'get-url', 'origin'], cwd));
+    } catch {
+      repoUrl = null;
+    }
+  }
+
+  if (!repoUrl) {
+    throw new Error('Couldn\'t work out the repository URL - add an origin remote or set baseUrl and repository in the config');
+  }
+
+  return new LocalGitProvider({ platform, repoUrl, cwd, git });
+}
+
+module.exports = {
+  LocalGitProvider,
+  createLocalGitProvider,
+  remoteToWebUrl,
+  runGit
+};
@@ -0,0 +1,332 @@
+/**
+ * Provider interface for Release Boss 💅
+ *
+ * Every VCS platform (GitHub, GitLab, ...) implements this class so the
+ * changelog and version-bump logic never has to care where the repo lives.
+ * Only the VCS calls differ between providers - everything else is shared!
+ *
+ * Normalised shapes returned by providers:
+ * - Commit: { sha, message, author, authorName, authorEmail, username, date, url }
+ *   (username is the author's platform username, or null when the platform doesn't say)
+ * - Tag: { name, sha }
+ * - Branch: { name, sha, date } (date of the branch's last commit)
+ * - PR: { number, url, state, title, body, headBranch, baseBranch, mergeCommitSha }
+ * - Comment: { id, body, author, url }
+ *
+ * PR state is always one of 'open', 'closed' or 'merged'.
+ */
+class Provider {
+  /**
+   * @param {Object} options - Provider options
+   * @param {String} options.name - Platform name (github, gitlab)
+   */
+  constructor({ name }) {
+    this.name = name;
+  }
+
+  /**
+   * Web URL of the repository (used for changelog links)
+   * @returns {String}
+   */
+  get repoUrl() {
+    throw new Error(`${this.name} provider does not implement repoUrl`);
+  }
+
+  /**
+   * Commit SHA the current run was triggered for, if known
+   * @returns {String|null}
+   */
+  get headSha() {
+    return null;
+  }
+
+  /**
+   * Branch the current run was triggered for (the target branch for merged PRs), if known
+   * @returns {String|null}
+   */
+  get branch() {
+    return null;
+  }
+
+  /**
+   * Make sure the platform's API is where the config says it is
+   * Called once at startup so a mistyped URL fails with a helpful error instead of a confusing one later.
+   * @returns {Promise<void>}
+   * @throws {Error} - If the API can't be reached
+   */
+  async checkConnection() {}
+
+  /**
+   * Web URL comparing two tags
+   * @param {String} from - Base tag
+   * @param {String} to - Head tag
+   * @returns {String}
+   */
+  compareUrl(from, to) {
+    return `${this.repoUrl}/compare/${from}...${to}`;
+  }
+
+  /**
+   * Web URL of a pull/merge request
+   * @param {Number|String} number - PR number
+   * @returns {String}
+   */
+  pullRequestUrl(number) {
+    return `${this.repoUrl}/pull/${number}`;
+  }
+
+  /**
+   * Web URL of an issue
+   * @param {Number|String} number - Issue number
+   * @returns {String}
+   */
+  issueUrl(number) {
+    return `${this.repoUrl}/issues/${number}`;
+  }
+
+  /**
+   * List commits reachable from head but not from base
+   * @param {String} base - Base reference
+   * @param {String} head - Head reference
+   * @returns {Promise<Array>} - Normalised commits, oldest first
+   */
+  async compareCommits(base, head) {
+    throw new Error(`${this.name} provider does not implement compareCommits`);
+  }
+
+  /**
+   * List every commit reachable from head - the whole history, for a first release
+   * @param {String} head - Head reference
+   * @returns {Promise<Array>} - Normalised commits, oldest first
+   */
+  async listCommits(head) {
+    throw new Error(`${this.name} provider does not implement listCommits`);
+  }
+
+  /**
+   * Read a single commit
+   * @param {String} sha - Commit SHA (abbreviated is fine)
+   * @returns {Promise<Object>} - Normalised commit
+   * @throws {Error} - If there's no such commit in the repository
+   */
+  async getCommit(sha) {
+    throw new Error(`${this.name} provider does not implement getCommit`);
+  }
+
+  /**
+   * List the files changed by a single commit
+   * @param {String} sha - Commit SHA
+   * @returns {Promise<Array<String>>} - Paths relative to the repository root
+   */
+  async getCommitFiles(sha) {
+    throw new Error(`${this.name} provider does not implement getCommitFiles`);
+  }
+
+  /**
+   * List tags in the repository
+   * @returns {Promise<Array>} - Normalised tags
+   */
+  async listTags() {
+    throw new Error(`${this.name} provider does not implement listTags`);
+  }
+
+  /**
+   * Get the tag name of the latest published release, if the platform has releases
+   * @returns {Promise<String|null>}
+   */
+  async getLatestReleaseTag() {
+    return null;
+  }
+
+  /**
+   * Look up the platform username for a commit email address
+   * @param {String} email - Email address from a commit or trailer
+   * @returns {Promise<String|null>} - Username, or null if it can't be resolved
+   */
+  async resolveUsername(email) {
+    return null;
+  }
+
+  /**
+   * Read a file from the repository
+   * @param {String} filePath - Path in the repository
+   * @param {String} ref - Branch, tag or SHA
+   * @returns {Promise<String|null>} - File content, or null if it doesn't exist
+   */
+  async getFileContent(filePath, ref) {
+    throw new Error(`${this.name} provider does not implement getFileContent`);
+  }
+
+  /**
+   * Find the open release PR for a version (or any open release PR when no version is given)
+   * @param {Object} config - Release Boss configuration
+   * @param {String} [version] - Version being released
+   * @returns {Promise<Object|null>} - Normalised PR
+   */
+  async findOpenReleasePR(config, version) {
+    throw new Error(`${this.name} provider does not implement findOpenReleasePR`);
+  }
+
+  /**
+   * Detect whether the current run was triggered by merging a release PR
+   * @param {Object} config - Release Boss configuration
+   * @param {Object} options - { branch } - the branch the run is for, when it isn't the one the provider detects
+   * @returns {Promise<Object|null>} - { number, title, body, headBranch, version, merged, mergeCommitSha }
+   */
+  async detectMergedReleasePR(config, options) {
+    return null;
+  }
+
+  /**
+   * Create (or refresh) the staging branch and release PR for a version
+   * When config.releasePR is set, that open PR and its branch are updated in place - even if
+   * they were made for an earlier version - instead of opening a second one.
+   * @param {String} version - Version being released
+   * @param {String} changelog - Changelog content for the release
+   * @param {Object} config - Release Boss configuration
+   * @param {Array} updatedFiles - Local files updated with the new version
+   * @returns {Promise<Object>} - { prNumber, prUrl, prStatus }
+   */
+  async createReleasePR(version, changelog, config, updatedFiles) {
+    throw new Error(`${this.name} provider does not implement createReleasePR`);
+  }
+
+  /**
+   * Update a PR title/body
+   * @param {Number} number - PR number
+   * @param {Object} fields - { title, body }
+   * @returns {Promise<Object>} - Normalised PR
+   */
+  async updatePR(number, fields) {
+    throw new Error(`${this.name} provider does not implement updatePR`);
+  }
+
+  /**
+   * Get a PR by number
+   * @param {Number} number - PR number
+   * @returns {Promise<Object>} - Normalised PR
+   */
+  async getPR(number) {
+    throw new Error(`${this.name} provider does not implement getPR`);
+  }
+
+  /**
+   * List comments on a PR
+   * @param {Number} number - PR number
+   * @returns {Promise<Array>} - Normalised comments
+   */
+  async listPRComments(number) {
+    throw new Error(`${this.name} provider does not implement listPRComments`);
+  }
+
+  /**
+   * Check whether a PR can be merged yet (its required checks have passed, no conflicts, ...)
+   * @param {Number} number - PR number
+   * @returns {Promise<Object>} - { state: 'ready' | 'pending' | 'blocked', reason, sha } - sha is the PR head that was checked
+   */
+  async getPRMergeStatus(number) {
+    throw new Error(`${this.name} provider does not implement getPRMergeStatus`);
+  }
+
+  /**
+   * Merge a PR
+   * @param {Number} number - PR number
+   * @param {Object} options - { method: 'merge' | 'squash' | 'rebase', sha } - sha makes the merge fail if the head moved
+   * @returns {Promise<Object>} - { merged, sha } - sha is the commit the merge produced
+   */
+  async mergePR(number, options) {
+    throw new Error(`${this.name} provider does not implement mergePR`);
+  }
+
+  /**
+   * Create the release tag (and any configured alias tags)
+   * @param {String} version - Version to tag
+   * @param {Object} config - Release Boss configuration
+   * @param {Object} options - { sha, annotate } - the commit to tag (default: the head of the release branch)
+   *   and whether to create an annotated tag
+   * @returns {Promise<Object>} - { sha, tags }
+   */
+  async createTag(version, config, options) {
+    throw new Error(`${this.name} provider does not implement createTag`);
+  }
+
+  /**
+   * Publish a release for an existing tag, or update the one that's already there
+   * @param {String} tagName - Tag the release is for
+   * @param {Object} release - { title, body, prerelease }
+   * @returns {Promise<Object>} - { url, updated } - updated is true when the release already existed
+   */
+  async createRelease(tagName, release) {
+    throw new Error(`${this.name} provider does not implement createRelease`);
+  }
+
+  /**
+   * Delete the release published for a tag (the tag itself stays)
+   * @param {String} tagName - Tag the release is for
+   * @returns {Promise<Boolean>} - Whether a release was deleted (false when the tag had none)
+   */
+  async deleteRelease(tagName) {
+    throw new Error(`${this.name} provider does not implement deleteRelease`);
+  }
+
+  /**
+   * Delete a tag
+   * @param {String} tagName - Tag name
+   * @returns {Promise<Boolean>} - Whether the tag was deleted (false when it didn't exist)
+   */
+  async deleteTag(tagName) {
+    throw new Error(`${this.name} provider does not implement deleteTag`);
+  }
+
+  /**
+   * Undo a commit with a new commit on top of a branch
+   * @param {String} sha - Commit to revert
+   * @param {Object} options - { branch, message } - the branch to commit to and the revert commit's message
+   *   (GitLab writes its own message)
+   * @returns {Promise<Object>} - { sha } - the revert commit
+   */
+  async revertCommit(sha, options) {
+    throw new Error(`${this.name} provider does not implement revertCommit`);
+  }
+
+  /**
+   * Check whether a branch exists
+   * @param {String} branch - Branch name
+   * @returns {Promise<Boolean>}
+   */
+  async branchExists(branch) {
+    throw new Error(`${this.name} provider does not implement branchExists`);
+  }
+
+  /**
+   * List branches whose name starts with a prefix
+   * @param {String} prefix - Branch name prefix
+   * @returns {Promise<Array>} - Normalised branches ({ name, sha, date } - date is null when the
+   *   platform doesn't list it, so getCommit(sha) has to be asked)
+   */
+  async listBranches(prefix) {
+    throw new Error(`${this.name} provider does not implement listBranches`);
+  }
+
+  /**
+   * List every PR (open, closed or merged) opened from a branch
+   * @param {String} branch - Head branch name
+   * @returns {Promise<Array>} - Normalised PRs
+   */
+  async listBranchPRs(branch) {
+    throw new Error(`${this.name} provider does not implement listBranchPRs`);
+  }
+
+  /**
+   * Delete a branch
+   * @param {String} branch - Branch name
+   * @returns {Promise<Boolean>} - Whether the branch was deleted
+   */
+  async deleteBranch(branch) {
+    throw new Error(`${this.name} provider does not implement deleteBranch`);
+  }
+}
+
+module.exports = {
+  Provider
+};
@@ -0,0 +1,1087 @@
+const { logger, setLogContext, resetLogContext } = require('./utils/logger');
+const github = require('@actions/github');
+const semver = require('semver');
+const { getConfig, resolveConfig, DEFAULT_CONFIG } = require('./utils/config');
+const { createProvider } = require('./providers');
+const { DryRunProvider } = require('./providers/dryRunProvider');
+const { FakeProvider } = require('./providers/fakeProvider');
+const { findBumpCommandsInPR, applyBumpCommand } = require('./github/findBumpCommands');
+
+const { analyzeCommits, determineVersionBump } = require('./core/commitAnalyzer');
+const { cleanupStaleStagingBranches } = require('./core/stagingCleanup');
+const { mergeReleasePR } = require('./core/autoMerge');
+const { publishRelease } = require('./core/githubRelease');
+const { rollbackRelease } = require('./core/rollback');
+const { runHooks, snapshotWorkingTree, findChangedFiles } = require('./core/hooks');
+const { generateChangelog } = require('./core/changelogGenerator');
+const { processVersionFiles, processTemplateFiles, processUpdateFiles, normalizeVersionFile } = require('./core/templateProcessor');
+const { resolvePackages, getPackageConfig, findPackageForBranch, assignCommitsToPackages } = require('./core/packages');
+const { findPrereleaseChannel, getPrereleaseConfig, findLatestReleaseTag } = require('./core/prerelease');
+const { findMaintenanceBranch, getMaintenanceConfig, filterVersionLineTags } = require('./core/maintenance');
+const { getTagPrefix, getReleaseTagName } = require('./utils/tags');
+const { assertConfiguredBranch, assertBranchesExist } = require('./utils/branch');
+
+/**
+ * Helper function to extract version from staging branch name
+ * @param {String} branchName - The branch name to extract version from
+ * @param {String} stagingPrefix - The prefix for staging branches
+ * @returns {String|null} - Extracted version or null if not found
+ */
+function extractVersionFromStagingBranch(branchName, stagingPrefix) {
+  // Using a template literal for dynamic regex pattern
+  const pattern = `^${stagingPrefix}-v?([0-9]+.[0-9]+.[0-9]+.*?)$`;
+  const regex = new RegExp(pattern);
+  const match = branchName.match(regex);
+  return match ? match[1] : null;
+}
+
+/**
+ * @typedef {Object} ReleaseResult
+ * @property {String} runType - 'pr' (release PR prepared), 'release' (release tagged) or 'none' (nothing to release)
+ * @property {String|null} reason - Why there's nothing to release, on a 'none' run
+ * @property {Boolean} dryRun - Whether this was a dry run (nothing on the remote changed)
+ * @property {String|null} bumpType - 'major', 'minor', 'patch' or null
+ * @property {String} previousVersion - Version before this release
+ * @property {String} nextVersion - Version being released (same as previousVersion when nothing bumps)
+ * @property {String|null} changelog - Changelog markdown for the release PR
+ * @property {Array<String>} filesChanged - Version/template/update files that were (or would be) changed
+ * @property {Number|null} prNumber - Release PR number
+ * @property {String|null} prUrl - Release PR URL
+ * @property {String|null} prStatus - Release PR state ('open', 'closed', 'merged' or 'dry-run')
+ * @property {String|null} releaseTag - Primary tag for the release
+ * @property {Array<String>} tags - Every tag created (release tag plus aliases) on a release run
+ * @property {String|null} releaseCommitSha - Commit that was tagged on a release run
+ * @property {String|null} packageName - Monorepo package this result belongs to
+ * @property {String|null} packagePath - Directory of that package
+ * @property {Array<ReleaseResult>} packages - Per-package results on a monorepo PR run
+ */
+
+/**
+ * Release Boss as a library 💅
+ *
+ * Runs the same workflow as the GitHub Action, but takes a config object
+ * (build it in code or load it with getConfig) and hands back a ReleaseResult
+ * instead of setting action outputs.
+ *
+ * @example
+ * const { ReleaseBoss } = require('release-boss');
+ * const result = await new ReleaseBoss({ releaseBranch: 'release' }, { token, dryRun: true }).run();
+ * console.log(result.nextVersion, result.changelog);
+ */
+class ReleaseBoss {
+  /**
+   * @param {Object} config - Release Boss configuration; missing keys fall back to DEFAULT_CONFIG
+   * @param {Object} options - Run options
+   * @param {String} options.token - API token for the platform (not needed when passing a provider)
+   * @param {Boolean} options.dryRun - Log every change instead of making it (also enabled by config.dryRun)
+   * @param {Object} options.provider - VCS provider to use instead of creating one from config (still dry-run wrapped)
+   * @param {Object} options.context - GitHub-style event context (defaults to the Actions context)
+   * @param {String} options.branch - Branch the run is for, when the provider can't tell (e.g. a detached HEAD)
+   * @param {Array<String>} options.commits - Release exactly these commit SHAs instead of everything since the last release
+   */
+  constructor(config = {}, options = {}) {
+    this.config = resolveConfig(config);
+    this.dryRun = options.dryRun === true || this.config.dryRun === true;
+    this.context = options.context || github.context;
+    this.branch = options.branch || null;
+    this.commits = options.commits || null;
+    if (options.provider) {
+      this.provider = this.dryRun ? new DryRunProvider(options.provider) : options.provider;
+    } else {
+      this.provider = createProvider(this.config, { token: options.token, dryRun: this.dryRun });
+    }
+  }
+
+  /**
+   * Run the release workflow
+   * @returns {Promise<ReleaseResult>}
+   */
+  async run() {
+    return runWorkflow(this.config, {
+      provider: this.provider,
+      context: this.context,
+      dryRun: this.dryRun,
+      branch: this.branch,
+      commits: this.commits
+    });
+  }
+
+  /**
+   * Tag a merged release PR and run the postRelease hooks, without the rest of the workflow
+   * @param {Object} options - { pr } - the merged PR's number (default: the one merged into the current commit)
+   * @returns {Promise<ReleaseResult>}
+   */
+  async finalize({ pr } = {}) {
+    return finalizeWorkflow(this.config, {
+      provider: this.provider,
+      dryRun: this.dryRun,
+      prNumber: pr,
+      branch: this.branch
+    });
+  }
+
+  /**
+   * Undo a release - delete its tag and release, and optionally revert its version bump
+   * @param {String} version - Version (or tag name) to roll back
+   * @param {Object} options - { force, revert } - roll back even with a newer release out, and revert the bump commit
+   * @returns {Promise<Object>} - { version, tag, releaseDeleted, tagDeleted, localTagDeleted, revertSha }
+   */
+  async rollback(version, { force, revert } = {}) {
+    resetLogContext();
+    setLogContext({ version });
+    return rollbackRelease(this.provider, this.config, version, { force, revert, dryRun: this.dryRun });
+  }
+
+  /**
+   * Work out the next version without running the workflow
+   * @returns {Promise<Object>} - { bumpType, previousVersion, nextVersion, reason }
+   */
+  async nextVersion() {
+    return computeNextVersion(this.config, { provider: this.provider, branch: this.branch, commits: this.commits });
+  }
+}
+
+/**
+ * Run the whole release workflow - either tag a merged release PR or prepare the next one
+ * @param {Object} config - Validated Release Boss configuration
+ * @param {Object} options - { provider, context, dryRun, branch, commits } - branch overrides the one the provider
+ *   detects, and commits (SHAs) replaces the commits since the last release
+ * @returns {Promise<ReleaseResult>}
+ */
+async function runWorkflow(config, { provider, context, dryRun, branch, commits: shas }) {
+  resetLogContext();
+  
+  // Log the Release Boss version at startup
+  const { VERSION_WITH_V } = require('./version');
+  logger.info(`💅 Release Boss ${VERSION_WITH_V} is ready to slay! 💁‍♀️✨`);
+  
+  if (dryRun) {
+    logger.info('🔍 Dry-run mode is ON - we\'ll serve the whole show but nothing on the remote will change 💅');
+  }
+  
+  logger.info(`Using ${provider.name} provider for ${provider.repoUrl}`);
+  if (provider.checkConnection) {
+    await provider.checkConnection();
+  }
+  
+  // A branch the config doesn't know about is a mistake, not something to release from
+  const runBranch = getRunBranch(config, provider, branch);
+  setLogContext({ branch: runBranch });
+  
+  // Runs that crashed before cleaning up leave their staging branches behind 🧹
+  logger.startGroup('🧹 Stale Branch Sweep - Out with the old, darling! 💅');
+  try {
+    await cleanupStaleStagingBranches(provider, config);
+  } catch (error) {
+    logger.warn(`Couldn't clean up stale staging branches: ${error.message}`);
+  }
+  logger.endGroup();
+  
+  // Prerelease channel branches release from (and into) themselves 🧪
+  const prereleaseChannel = findPrereleaseChannel(config, runBranch);
+  if (prereleaseChannel) {
+    logger.info(`Branch ${prereleaseChannel.branch} is on the ${prereleaseChannel.channel} prerelease channel 🧪`);
+    config = getPrereleaseConfig(config, prereleaseChannel);
+  }
+  
+  // Maintenance branches release their own version line, from (and into) themselves 🛠️
+  const maintenanceBranch = findMaintenanceBranch(config, runBranch);
+  if (maintenanceBranch) {
+    logger.info(`Branch ${maintenanceBranch.branch} maintains the ${maintenanceBranch.range} line - only its tags count 🛠️`);
+    config = getMaintenanceConfig(config, maintenanceBranch);
+  }
+  
+  // Monorepo packages (empty for a regular single-version repo)
+  const packages = resolvePackages(config);
+  if (packages.length > 0) {
+    logger.info(`Monorepo mode with ${packages.length} packages: ${packages.map(pkg => pkg.name).join(', ')} 📦`);
+  }
+  
+  // Check if this is a PR merge or a regular push
+  logger.startGroup('✨ Run Type Detection - What are we serving today? ✨');
+  const isPRMerge = context.payload.pull_request && context.payload.action === 'closed' && context.payload.pull_request.merged;
+  
+  let mergedPR = null;
+  
+  if (isPRMerge) {
+    logger.info(`Detected PR merge event: PR #${context.payload.pull_request.number}`);
+    logger.info(`PR Title: ${context.payload.pull_request.title}`);
+    logger.info(`PR was merged: ${context.payload.pull_request.merged}`);
+    
+    // The head branch name is more reliable than the PR title, and the merge commit is what gets tagged
+    mergedPR = {
+      number: context.payload.pull_request.number,
+      title: context.payload.pull_request.title,
+      headBranch: context.payload.pull_request.head.ref,
+      mergeCommitSha: context.payload.pull_request.merge_commit_sha || null
+    };
+  } else {
+    logger.info('Regular push event detected, not a PR merge');
+    
+    // Check if this might be a "stealth" PR merge (push to release branch from a staging branch)
+    logger.startGroup('👀 Detective work - Checking for stealth PR merges 👀');
+    logger.info('This looks like a regular push, but let me see if it\'s actually a stealth PR merge...');
+    
+    // Try to detect if this push is actually a merged PR
+    mergedPR = await provider.detectMergedReleasePR(config, { branch: runBranch });
+    
+    if (mergedPR) {
+      logger.info(`OMG! I found a stealth PR merge! PR #${mergedPR.number} from ${mergedPR.headBranch} 💅`);
+      logger.info(`PR Title: ${mergedPR.title}`);
+    } else {
+      logger.info('No stealth PR merge detected, just a regular push 🤷‍♀️');
+    }
+    
+    logger.endGroup();
+  }
+  logger.endGroup();
+  
+  if (mergedPR) {
+    // Stealth merges keep their branch - there's no merge event telling us the PR is done with it
+    const release = await finalizeRelease(provider, config, { pr: mergedPR, packages, deleteBranch: isPRMerge, dryRun });
+    if (release) {
+      return release;
+    }
+  }
+  
+  // Analyze commits and determine version bump
+  logger.startGroup('🔍 Commit Analysis - Reading the room, hunty! 🙌');
+  let commits, tags;
+  try {
+    ({ config, tags, commits } = await fetchReleaseHistory(provider, config, packages, shas));
+    logger.info(`Found ${commits.length} commits to analyze - let's see what you've been working on, babe! 👁‍🗨️`);
+    
+    // Detailed commit information
+    if (commits.length > 0) {
+      logger.debug('Commit details:');
+      commits.forEach((commit, index) => {
+        logger.debug(`\nCommit #${index + 1}:`);
+        logger.debug(`  Hash: ${commit.hash}`);
+        logger.debug(`  Message: ${commit.message.split('\n')[0]}${commit.message.split('\n').length > 1 ? ' ...' : ''}`);
+        logger.debug(`  Type: ${commit.parsed.type || 'unknown'}`);
+        logger.debug(`  Scope: ${commit.parsed.scope || 'none'}`);
+        logger.debug(`  Breaking Changes: ${commit.breakingChanges.length > 0 ? commit.breakingChanges.join(' | ') : 'No'}`);
+        logger.debug(`  Bump Type: ${commit.bumpType || 'none'}`);
+        logger.debug(`  Excluded: ${commit.excluded ? 'Yes' : 'No'}`);
+      });
+    } else {
+      logger.info('No commits found to analyze');
+    }
+  } catch (error) {
+    logger.error(`Error analyzing commits: ${error.message}`);
+    throw error;
+  }
+  logger.endGroup();
+  
+  if (packages.length === 0) {
+    const release = await prepareRelease(provider, config, commits, { context, dryRun, tags });
+    return autoMergeRelease(provider, config, release, { packages, dryRun });
+  }
+  
+  // Monorepo mode - every package gets its own version, changelog, PR and tag 📦
+  logger.startGroup('📦 Package Assignment - Who wore it best? 💅');
+  const commitsByPackage = await assignCommitsToPackages(commits, packages, provider, { concurrency: config.fetchConcurrency });
+  for (const pkg of packages) {
+    logger.info(`  - ${pkg.name} (${pkg.path}): ${commitsByPackage.get(pkg.name).length} commits`);
+  }
+  logger.endGroup();
+  
+  const packageResults = [];
+  for (const pkg of packages) {
+    // Each package's records carry its own version and PR, not the previous package's
+    setLogContext({ package: pkg.name, version: null, pr: null });
+    logger.info(`\n📦 Preparing release for package ${pkg.name} 📦`);
+    const packageConfig = getPackageConfig(config, pkg);
+    const prepared = await prepareRelease(provider, packageConfig, commitsByPackage.get(pkg.name), { context, dryRun, tags });
+    const release = await autoMergeRelease(provider, config, prepared, { packages, dryRun });
+    release.packageName = pkg.name;
+    release.packagePath = pkg.path;
+    packageResults.push(release);
+  }
+  
+  setLogContext({ package: null, version: null, pr: null });
+  
+  const released = packageResults.filter(result => result.bumpType);
+  logger.info(`Release PR management complete for ${released.length} of ${packages.length} packages 💅`);
+  
+  return createResult({
+    runType: released.length > 0 ? 'pr' : 'none',
+    dryRun,
+    reason: released.length > 0 ? null : `None of the ${packages.length} packages have releasable commits`,
+    packages: packageResults
+  });
+}
+
+/**
+ * Merge a freshly prepared release PR and finish the release, when autoMerge is on
+ * @param {Object} provider - VCS provider
+ * @param {Object} config - Release Boss configuration (not package-scoped)
+ * @param {ReleaseResult} release - Result of prepareRelease
+ * @param {Object} options - { packages, dryRun }
+ * @returns {Promise<ReleaseResult>} - The release run's result once merged, otherwise the PR run's result
+ */
+async function autoMergeRelease(provider, config, release, { packages, dryRun }) {
+  if (!config.autoMerge || release.runType !== 'pr' || !release.prNumber || (!dryRun && release.prStatus !== 'open')) {
+    return release;
+  }
+  
+  logger.startGroup('🤖 Auto-Merge - Look ma, no hands! 💅');
+  let merge;
+  try {
+    merge = await mergeReleasePR(provider, config, release.prNumber, { dryRun });
+  } catch (error) {
+    logger.warn(`Couldn't auto-merge PR #${release.prNumber}: ${error.message}`);
+  }
+  logger.endGroup();
+  
+  if (!merge) {
+    return release;
+  }
+  
+  const pr = await provider.getPR(release.prNumber);
+  const result = await finalizeRelease(provider, config, { pr: { ...pr, mergeCommitSha: merge.sha }, packages, dryRun });
+  return result ? { ...result, changelog: release.changelog, filesChanged: release.filesChanged, prUrl: release.prUrl } : release;
+}
+
+/**
+ * Finish the release for an already merged PR - `release-boss finalize`
+ * For teams that merge by hand (or by merge queue) and trigger the tagging from a merge webhook.
+ * @param {Object} config - Validated Release Boss configuration
+ * @param {Object} options - { provider, dryRun, prNumber, branch } - without a prNumber, the merge commit
+ *   the run is on is looked up instead (like a stealth merge)
+ * @returns {Promise<ReleaseResult>}
+ */
+async function finalizeWorkflow(config, { provider, dryRun, prNumber, branch }) {
+  resetLogContext();
+  const runBranch = getRunBranch(config, provider, branch);
+  setLogContext({ branch: runBranch });
+  const prereleaseChannel = findPrereleaseChannel(config, runBranch);
+  if (prereleaseChannel) {
+    config = getPrereleaseConfig(config, prereleaseChannel);
+  }
+  const maintenanceBranch = findMaintenanceBranch(config, runBranch);
+  if (maintenanceBranch) {
+    config = getMaintenanceConfig(config, maintenanceBranch);
+  }
+  const packages = resolvePackages(config);
+  
+  let pr;
+  if (prNumber) {
+    pr = await provider.getPR(prNumber);
+    if (pr.state !== 'merged') {
+      throw new Error(`PR #${prNumber} is ${pr.state}, not merged - merge it before finalizing the release`);
+    }
+  } else {
+    pr = await provider.detectMergedReleasePR(config, { branch: runBranch });
+    if (!pr) {
+      throw new Error('No merged release PR found for this commit - pass the PR number to finalize');
+    }
+  }
+  
+  const result = await finalizeRelease(provider, config, { pr, packages, dryRun });
+  return result || createResult({
+    dryRun,
+    prNumber: pr.number,
+    reason: `PR #${pr.number} isn't a release PR (${pr.headBranch} isn't a ${config.stagingBranch}- branch) and has no bump command`
+  });
+}
+
+/**
+ * Finish the release for a merged PR - tag its merge commit and run the postRelease hooks
+ * This is the "after merge" half of the workflow: runWorkflow calls it for merge events and
+ * stealth merges, autoMerge calls it once it has merged the release PR, and `release-boss finalize`
+ * calls it on its own.
+ * @param {Object} provider - VCS provider
+ * @param {Object} config - Release Boss configuration (not package-scoped)
+ * @param {Object} options - Finalize options
+ * @param {Object} options.pr - Merged PR: { number, title, headBranch, mergeCommitSha }
+ * @param {Array} options.packages - Resolved monorepo packages (empty for a single-version repo)
+ * @param {Boolean} options.deleteBranch - Delete the PR's head branch (when deleteStagingBranch allows)
+ * @param {Boolean} options.dryRun - Log the tag instead of creating it
+ * @returns {Promise<ReleaseResult|null>} - null when the PR isn't a release PR and has no bump command
+ */
+async function finalizeRelease(provider, config, { pr, packages = [], deleteBranch = true, dryRun }) {
+  const headBranch = pr.headBranch;
+  logger.info(`PR was merged from branch: ${headBranch}`);
+  
+  if (deleteBranch) {
+    // Delete the staging branch after merge if configured to do so
+    logger.startGroup('💋 Branch Cleanup - Keeping things tidy! 💅');
+    if (config.deleteStagingBranch) {
+      try {
+        const branchDeleted = await provider.deleteBranch(headBranch);
+        if (branchDeleted) {
+          logger.info(`Successfully deleted branch ${headBranch} after merge - keeping our repo fabulous! ✨`);
+        } else {
+          logger.info(`Branch ${headBranch} was not deleted - it might have been deleted already or there was an issue.`);
+        }
+      } catch (error) {
+        logger.warn(`Failed to delete branch ${headBranch}: ${error.message}`);
+      }
+    } else {
+      logger.info(`Branch deletion is disabled in config (deleteStagingBranch: false) - keeping ${headBranch} around for posterity 💅`);
+    }
+    logger.endGroup();
+  }
+  
+  let isReleasePR = false;
+  let branchVersion = null;
+  let releasePackage = null;
+  
+  // Check if this is a staging branch merge (our release PR pattern)
+  if (headBranch.startsWith(`${config.stagingBranch}-`)) {
+    logger.info(`This PR is from a staging branch! That's our release pattern, honey! 💅`);
+    isReleasePR = true;
+    
+    // Extract version from staging branch name (monorepo branches carry the package name too)
+    releasePackage = findPackageForBranch(headBranch, packages, config);
+    const stagingPrefix = releasePackage ? `${config.stagingBranch}-${releasePackage.name}` : config.stagingBranch;
+    branchVersion = extractVersionFromStagingBranch(headBranch, stagingPrefix);
+    if (releasePackage) {
+      logger.info(`This release belongs to package ${releasePackage.name} 📦`);
+    }
+    
+    if (branchVersion) {
+      logger.info(`Extracted version ${branchVersion} from staging branch name ${headBranch} 💃`);
+    } else {
+      logger.info(`Couldn't extract version from branch name ${headBranch} - that's weird! 🤔`);
+    }
+  } else {
+    logger.info(`This PR is not from a staging branch, so it's not a release PR 🤷‍♀️`);
+  }
+  
+  // Check for bump commands in PR comments
+  logger.startGroup('💋 Checking for bump commands in PR comments 💋');
+  logger.info(`Searching for bump commands in PR #${pr.number} comments...`);
+  const bumpCommandResult = await findBumpCommandsInPR(provider, pr.number);
+  
+  if (bumpCommandResult.hasBumpCommand) {
+    logger.info(`💃 Found a /bump ${bumpCommandResult.bumpType} command from ${bumpCommandResult.commenter}! Time to level up! 💅`);
+  } else {
+    logger.info('No bump commands found in the PR comments 🤷‍♀️');
+  }
+  logger.endGroup();
+  
+  // Process the PR if it's a release PR (from a staging branch) OR if we have a bump command
+  // This is much simpler than trying to match PR titles! 💅
+  if (!isReleasePR && !bumpCommandResult.hasBumpCommand) {
+    return null;
+  }
+  
+  // This is a merged release PR - create a tag! 💅
+  logger.info('Detected merge of release PR - time to make it official! 💍');
+  
+  // Monorepo packages are tagged with their own namespace
+  const releaseConfig = releasePackage ? getPackageConfig(config, releasePackage) : config;
+  
+  // We already extracted the version from the branch name earlier
+  // No need to do it again! We're all about efficiency, honey! 💅
+  
+  // Just in case we don't have a branch version yet (unlikely), try to extract it from PR title
+  if (!branchVersion && pr.title) {
+    logger.info('No branch version found, trying to extract from PR title as a last resort...');
+    const titleVersion = extractVersionFromPRTitle(pr.title, releaseConfig.pullRequestTitle);
+    if (titleVersion) {
+      branchVersion = titleVersion;
+      logger.info(`Extracted version ${branchVersion} from PR title as a fallback 🤷‍♀️`);
+    }
+  }
+  
+  // A release PR that was moved to a newer version keeps its original branch name - the title has the version it moved to
+  if (branchVersion && pr.title) {
+    const titleVersion = extractVersionFromPRTitle(pr.title, releaseConfig.pullRequestTitle);
+    if (titleVersion && semver.valid(titleVersion) && semver.valid(branchVersion) && semver.gt(titleVersion, branchVersion)) {
+      logger.info(`The PR title says ${titleVersion} - the PR moved there after ${branchVersion}'s branch was cut, so ${titleVersion} it is 💅`);
+      branchVersion = titleVersion;
+    }
+  }
+  
+  // Make sure we have a version to work with
+  let version = branchVersion;
+  
+  if (!version) {
+    // If we still don't have a version and we have a bump command, start from 0.0.0
+    if (bumpCommandResult.hasBumpCommand) {
+      version = '0.0.0';
+      logger.info(`No version found in branch or title, but we have a bump command! Starting from ${version} 💅`);
+    } else {
+      throw new Error("Couldn't determine version from branch name or PR title - I'm totally confused! 😵");
+    }
+  } else {
+    logger.info(`Using version ${version} extracted from branch name 💁‍♀️`);
+  }
+  
+  // We've already checked for bump commands earlier, so let's use that result
+  // No need to make another API call, we're data-efficient like that! 💅
+  if (bumpCommandResult.hasBumpCommand) {
+    logger.info(`💋 Found that /bump ${bumpCommandResult.bumpType} command from ${bumpCommandResult.commenter || 'someone fabulous'}!`);
+    
+    // Apply the bump command to get our new fabulous version
+    const originalVersion = version;
+    version = applyBumpCommand(version, bumpCommandResult.bumpType);
+    
+    if (version !== originalVersion) {
+      logger.info(`💅 Applied ${bumpCommandResult.bumpType} bump to version: ${originalVersion} → ${version} - we're moving up in the world, honey!`);
+    } else {
+      logger.info(`Version ${version} is already fierce enough for a ${bumpCommandResult.bumpType} version - no change needed 🤷‍♀️`);
+    }
+  }
+  
+  setLogContext({ version, pr: pr.number, package: releasePackage ? releasePackage.name : null });
+  
+  const previousVersion = version; // For now, track the same version
+  
+  // Skip the regular commit analysis flow since we already know what version we want!
+  logger.info(`\n💅 Skipping regular commit analysis since we already have our version: ${version}`);
+  logger.info('No need to analyze commits between branches when we already know what we want, honey! 💁‍♀️');
+  
+  const result = createResult({
+    runType: 'release',
+    dryRun,
+    bumpType: bumpCommandResult.hasBumpCommand ? bumpCommandResult.bumpType : 'patch', // Default to patch if no bump command
+    previousVersion,
+    nextVersion: version,
+    prNumber: pr.number,
+    prStatus: 'merged',
+    releaseTag: getReleaseTagName(version, releaseConfig),
+    packageName: releasePackage ? releasePackage.name : null,
+    packagePath: releasePackage ? releasePackage.path : null
+  });
+  
+  logger.startGroup('🎉 Release Tagging - Crown that queen! 👑');
+  try {
+    // Get prefix based on configuration (default to 'v' if not specified)
+    const prefix = getTagPrefix(releaseConfig);
+    const releaseTag = getReleaseTagName(version, releaseConfig);
+    
+    // Log tagging strategy
+    logger.info(`Tagging strategy:`);
+    logger.info(`  Version Tag Prefix: ${prefix ? `"${prefix}"` : 'none'}`);
+    logger.info(`  Tag as 'latest': ${config.tagLatest !== false ? 'yes' : 'no'}`);
+    logger.info(`  Tag major version (${prefix}${version.split('.')[0]}): ${config.tagMajor === true ? 'yes' : 'no'}`);
+    logger.info(`  Tag major.minor version (${prefix}${version.split('.')[0]}.${version.split('.')[1]}): ${config.tagMinor === true ? 'yes' : 'no'}`);
+    
+    // Tag the commit the merge produced - not whatever the release branch points at by now
+    const annotate = releaseConfig.annotateTags === true || releaseConfig.autoMerge === true;
+    const taggingResult = await provider.createTag(version, releaseConfig, { sha: pr.mergeCommitSha || undefined, annotate });
+    const { sha: releaseCommitSha, tags: createdTags } = taggingResult;
+    
+    logger.info(`Tagged release ${releaseTag} at commit ${releaseCommitSha.substring(0, 7)}`);
+    
+    if (createdTags.length > 1) {
+      const additionalTags = createdTags.filter(tag => tag !== releaseTag);
+      logger.info(`Created/updated additional tags: ${additionalTags.join(', ')}`);
+    }
+    
+    result.releaseTag = releaseTag;
+    result.releaseCommitSha = releaseCommitSha;
+    result.tags = createdTags;
+  } catch (error) {
+    logger.error(`Error creating tag: ${error.message}`);
+    throw error;
+  }
+  logger.endGroup();
+  
+  if (releaseConfig.createGithubRelease) {
+    logger.startGroup('📰 Release Notes - Extra, extra, read all about it! 🗞️');
+    try {
+      // The changelog as it was merged - the release branch may have moved on since
+      await publishRelease(provider, releaseConfig, version, { ref: pr.mergeCommitSha || releaseConfig.releaseBranch });
+    } catch (error) {
+      logger.error(`Error publishing the release: ${error.message}`);
+      throw error;
+    }
+    logger.endGroup();
+  }
+  
+  logger.startGroup('🪝 Post-Release Hooks - Time to tell everyone! 📣');
+  await runHooks('postRelease', releaseConfig, {
+    version,
+    previousVersion,
+    bumpType: result.bumpType,
+    tag: result.releaseTag,
+    commitSha: result.releaseCommitSha
+  }, { dryRun });
+  logger.endGroup();
+  
+  return result;
+}
+
+/**
+ * Pick the branch a run is for and make sure the config knows about it
+ * @param {Object} config - Release Boss configuration
+ * @param {Object} provider - VCS provider (its branch is the fallback)
+ * @param {String} branch - Branch given explicitly (optional)
+ * @returns {String|null} - The branch, or null when there's no telling
+ * @throws {Error} - Listing the configured branches, when the branch isn't one of them
+ */
+function getRunBranch(config, provider, branch) {
+  const runBranch = branch || provider.branch;
+  if (!runBranch) {
+    logger.warn('Couldn\'t tell which branch this run is for (a detached HEAD?) - pass --branch, or set GITHUB_REF or CI_COMMIT_BRANCH, so prerelease channels and merged release PRs are recognised');
+    return null;
+  }
+  
+  const { rule } = assertConfiguredBranch(config, runBranch);
+  logger.info(`Running for branch ${runBranch} (${rule}) 🌿`);
+  return runBranch;
+}
+
+/**
+ * Fetch the tags and commits the next release is worked out from - once per run
+ * The version calculation and the changelog both read this, so neither asks the provider again:
+ * each analyzed commit already carries its parsed type and scope, breaking flag, author and
+ * (when ignorePaths or packages needed them) changed files.
+ * @param {Object} provider - VCS provider
+ * @param {Object} config - Release Boss configuration (prerelease- or maintenance-scoped on those branches)
+ * @param {Array} packages - Resolved monorepo packages (empty for a single-version repo)
+ * @param {Array<String>} shas - Commit SHAs to release instead of the range (optional)
+ * @returns {Promise<Object>} - { config, tags, commits } - config as resolveCommitRange left it
+ */
+async function fetchReleaseHistory(provider, config, packages, shas) {
+  // A maintenance branch's version line is all it gets to see
+  const tags = filterVersionLineTags(await provider.listTags(), config);
+  let baseRef;
+  ({ config, baseRef } = resolveCommitRange(config, packages, tags));
+  await assertBranchesExist(provider, config);
+  const commits = await analyzeCommits(provider, config, baseRef, null, { shas });
+  return { config, tags, commits };
+}
+
+/**
+ * Work out which commits the next release is made of
+ * @param {Object} config - Release Boss configuration (prerelease- or maintenance-scoped on those branches)
+ * @param {Array} packages - Resolved monorepo packages (empty for a single-version repo)
+ * @param {Array} tags - The repo's tags
+ * @returns {Object} - { config, baseRef } - config.firstRelease is set when there's no release tag yet,
+ *   and baseRef is undefined unless the range doesn't start at the release branch
+ */
+function resolveCommitRange(config, packages, tags) {
+  // No release tag yet (for any package) means this is the first release - there's nothing to diff against
+  const tagConfigs = packages.length > 0 ? packages.map(pkg => getPackageConfig(config, pkg)) : [config];
+  if (config.maintenanceRange && !findLatestReleaseTag(tags, config)) {
+    throw new Error(`There's no release on the ${config.maintenanceRange} line yet, so there's nothing for ${config.releaseBranch} to patch - tag one on ${config.releaseBranch} first`);
+  }
+  if (tagConfigs.every(tagConfig => !findLatestReleaseTag(tags, tagConfig))) {
+    logger.info(`No release tags yet - this is the first release, so the whole history counts 🎉`);
+    config = { ...config, firstRelease: true };
+  }
+  
+  // Prereleases only count commits since the last release on any channel
+  let baseRef;
+  if (config.prereleaseChannel && !config.firstRelease) {
+    baseRef = findLatestReleaseTag(tags, config) || config.stableReleaseBranch;
+    logger.info(`Prerelease run - comparing ${config.mergeBranch} against ${baseRef}`);
+  }
+  
+  // Maintenance branches release into themselves, so the range starts at the line's latest tag
+  if (config.maintenanceRange) {
+    baseRef = findLatestReleaseTag(tags, config);
+    logger.info(`Maintenance run for ${config.maintenanceRange} - comparing ${config.mergeBranch} against ${baseRef}`);
+  }
+  
+  return { config, baseRef };
+}
+
+/**
+ * Work out the next version without touching anything - not even in dry-run style
+ * Only tags and commits are read, so it's safe to run early in a pipeline (e.g. to tag a Docker image).
+ * @param {Object} config - Validated Release Boss configuration
+ * @param {Object} options - { provider, branch, commits } - branch overrides the one the provider detects,
+ *   and commits (SHAs) replaces the commits since the last release
+ * @returns {Promise<Object>} - { bumpType, previousVersion, nextVersion, reason } - nextVersion is the
+ *   current version (and reason says why) when there's nothing to release
+ * @throws {Error} - For monorepos, whose packages each have their own version
+ */
+async function computeNextVersion(config, { provider, branch, commits: shas }) {
+  // Only the prerelease channel and version line depend on the branch - printing a version from any branch is fine
+  const prereleaseChannel = findPrereleaseChannel(config, branch || provider.branch);
+  if (prereleaseChannel) {
+    config = getPrereleaseConfig(config, prereleaseChannel);
+  }
+  const maintenanceBranch = findMaintenanceBranch(config, branch || provider.branch);
+  if (maintenanceBranch) {
+    config = getMaintenanceConfig(config, maintenanceBranch);
+  }
+  
+  const packages = resolvePackages(config);
+  if (packages.length > 0) {
+    throw new Error(`This is a monorepo with ${packages.length} packages, each with its own version - there's no single next version to print`);
+  }
+  
+  let tags, commits;
+  ({ config, tags, commits } = await fetchReleaseHistory(provider, config, packages, shas));
+  const { bumpType, newVersion, currentVersion, reason } = await determineVersionBump(commits, provider, config, { tags });
+  
+  return {
+    bumpType,
+    previousVersion: currentVersion,
+    nextVersion: bumpType ? newVersion : currentVersion,
+    reason: bumpType ? null : reason
+  };
+}
+
+/**
+ * Work out the next version and prepare its release PR
+ * This is the whole PR side of the workflow for one repo (or one monorepo package).
+ * @param {Object} provider - VCS provider
+ * @param {Object} config - Release Boss configuration (package-scoped for monorepo packages)
+ * @param {Array} commits - Analyzed commits to release
+ * @param {Object} options - { context, dryRun, tags } - tags as fetchReleaseHistory listed them
+ * @returns {ReleaseResult}
+ */
+async function prepareRelease(provider, config, commits, { context, dryRun, tags }) {
+  logger.startGroup('💎 Version Bump Determination - Time to level up! 💪');
+  let bumpType, newVersion, currentVersion, reason;
+  try {
+    const result = await determineVersionBump(commits, provider, config, { tags });
+    ({ bumpType, newVersion, currentVersion, reason } = result);
+    
+    logger.info(`Current version: ${currentVersion} - that's so last season! 👠`); 
+    logger.info(`Bump type: ${bumpType || 'none'} ${bumpType ? bumpType === 'major' ? '- MAJOR glow-up incoming! 🙌👑' : bumpType === 'minor' ? '- Fresh new lewk! 💄' : '- Just a touch-up, darling 💅' : '- Keeping it subtle today, honey 🙄'}`);
+    
+    if (bumpType) {
+      setLogContext({ version: newVersion });
+      logger.info(`New version: ${newVersion} - looking FABULOUS, darling! ✨💃`);
+      logger.info(`Version components: Major=${result.major}, Minor=${result.minor}, Patch=${result.patch}`);
+      
+      if (currentVersion && semver.lt(currentVersion, '1.0.0')) {
+        logger.info('\nApplying pre-1.0 version bump rules:');
+        logger.info(`  - Major changes become minor bumps`);
+        logger.info(`  - Minor changes become patch bumps`);
+      }
+    } else {
+      logger.info('No version bump needed based on commits');
+    }
+  } catch (error) {
+    logger.error(`Error determining version bump: ${error.message}`);
+    throw error;
+  }
+  logger.endGroup();
+  
+  if (!bumpType) {
+    logger.info(`✨ No release needed: ${reason} - no PR today, darling 💅`);
+    return createResult({ runType: 'none', dryRun, previousVersion: currentVersion, nextVersion: currentVersion, reason });
+  }
+  
+  logger.info(`Determined version bump: ${currentVersion} → ${newVersion} (${bumpType})`);
+  
+  // Generate changelog
+  logger.startGroup('📝 Changelog Generation - Spilling the tea! 🍵✨');
+  let changelog;
+  try {
+    changelog = await generateChangelog(commits, newVersion, currentVersion, provider, config);
+    logger.info('Changelog generated successfully');
+    logger.info('\nPreview of changelog:');
+    logger.info('============================');
+    logger.info(changelog.length > 500 ? changelog.substring(0, 500) + '...' : changelog);
+    logger.info('============================');
+  } catch (error) {
+    logger.error(`Error generating changelog: ${error.message}`);
+    throw error;
+  }
+  logger.endGroup();
+  
+  const hookRelease = { version: newVersion, previousVersion: currentVersion, bumpType, tag: getReleaseTagName(newVersion, config) };
+  
+  logger.startGroup('🪝 Pre-Bump Hooks - Getting ready, darling! 💄');
+  await runHooks('preBump', config, hookRelease, { dryRun });
+  logger.endGroup();
+  
+  // Process version and template files
+  const updatedFiles = [];
+  const templateOptions = {
+    dryRun,
+    sha: provider.headSha,
+    dateFormat: config.dateFormat,
+    markerPrefix: config.markerPrefix,
+    conflictStrategy: config.conflictStrategy
+  };
+  
+  logger.startGroup('✨ Template Processing - Makeover time! 💅');
+  try {
+    if (config.versionFiles && config.versionFiles.length > 0) {
+      logger.info(`Processing ${config.versionFiles.length} version files:`);
+      config.versionFiles.map(normalizeVersionFile).forEach(({ file, strategy }) => logger.info(`  - ${file} (${strategy})`));
+      
+      // Pass the release branch info to avoid conflicts
+      const processedVersionFiles = await processVersionFiles(config.versionFiles, newVersion, {
+        ...templateOptions,
+        releaseBranch: config.releaseBranch,
+        provider
+      });
+      
+      logger.info(`\nSuccessfully processed ${processedVersionFiles.length} version files:`);
+      processedVersionFiles.forEach(file => logger.info(`  - ${file}`));
+      updatedFiles.push(...processedVersionFiles);
+    } else {
+      logger.info('No version files to process');
+    }
+    
+    if (config.templateFiles && config.templateFiles.length > 0) {
+      logger.info(`\nProcessing ${config.templateFiles.length} template files:`);
+      config.templateFiles.forEach(file => logger.info(`  - ${file}`));
+      
+      const generatedTemplateFiles = await processTemplateFiles(config.templateFiles, newVersion, templateOptions);
+      logger.info(`\nSuccessfully generated ${generatedTemplateFiles.length} output files:`);
+      generatedTemplateFiles.forEach(file => logger.info(`  - ${file}`));
+      updatedFiles.push(...generatedTemplateFiles);
+    } else {
+      logger.info('No template files to process');
+    }
+    
+    if (config.updateFiles && config.updateFiles.length > 0) {
+      logger.info(`\nProcessing ${config.updateFiles.length} update files:`);
+      config.updateFiles.forEach(file => logger.info(`  - ${file.file} (find: '${file.findLine.substring(0, 30)}${file.findLine.length > 30 ? '...' : ''}')`));
+      
+      // Pass the release branch info to avoid conflicts
+      const processedUpdateFiles = await processUpdateFiles(config.updateFiles, newVersion, {
+        ...templateOptions,
+        releaseBranch: config.releaseBranch,
+        provider
+      });
+      
+      logger.info(`\nSuccessfully processed ${processedUpdateFiles.length} update files:`);
+      processedUpdateFiles.forEach(file => logger.info(`  - ${file}`));
+      updatedFiles.push(...processedUpdateFiles);
+    } else {
+      logger.info('No update files to process');
+    }
+  } catch (error) {
+    logger.error(`Error processing templates: ${error.message}`);
+    throw error;
+  }
+  logger.endGroup();
+  
+  logger.info(`Processed ${updatedFiles.length} files with new version information`);
+  
+  // Whatever the postBump hooks write (lockfiles, generated code) goes into the bump commit too
+  logger.startGroup('🪝 Post-Bump Hooks - The finishing touches! 💅');
+  const hasPostBumpHooks = !dryRun && config.hooks && config.hooks.postBump && config.hooks.postBump.length > 0;
+  let snapshot = null;
+  if (hasPostBumpHooks) {
+    try {
+      snapshot = await snapshotWorkingTree();
+    } catch (error) {
+      logger.warn(`Couldn't read the working tree, so files changed by postBump hooks won't be committed: ${error.message}`);
+    }
+  }
+  await runHooks('postBump', config, hookRelease, { dryRun });
+  if (snapshot) {
+    const hookFiles = (await findChangedFiles(snapshot)).filter(file => !updatedFiles.includes(file));
+    if (hookFiles.length > 0) {
+      logger.info(`postBump hooks changed ${hookFiles.length} more files:`);
+      hookFiles.forEach(file => logger.info(`  - ${file}`));
+      updatedFiles.push(...hookFiles);
+    }
+  }
+  logger.endGroup();
+  
+  // Create or update PR
+  logger.startGroup('💋 Pull Request Management - Serving lewks! 💃');
+  let prNumber, prUrl, prStatus;
+  let stagingBranch = `${config.stagingBranch}-v${newVersion}`;
+  try {
+    logger.info(`Creating or updating PR for version ${newVersion} - time to strut our stuff! 👌👑`);
+    
+    if (updatedFiles.length > 0) {
+      logger.info('\nFiles to be committed to PR:');
+      updatedFiles.forEach(file => logger.info(`  - ${file}`));
+    } else {
+      logger.info('No files to commit to PR, only changelog will be updated');
+    }
+    
+    // Try to verify if there's an existing PR first to track status changes
+    let existingPrState = null;
+    if (context.payload.pull_request && context.payload.pull_request.number) {
+      // This is running in the context of a PR
+      const existingPrNumber = context.payload.pull_request.number;
+      try {
+        const pr = await provider.getPR(existingPrNumber);
+        existingPrState = pr.state;
+        logger.info(`Found existing PR #${existingPrNumber} in state: ${existingPrState}`);
+      } catch (e) {
+        logger.warn(`Couldn't get existing PR #${existingPrNumber} state: ${e.message}`);
+      }
+    }
+    
+    await runHooks('prePR', config, hookRelease, { dryRun });
+    
+    // Re-runs (and new commits) update the release PR that's already open instead of opening another one
+    let releasePR = null;
+    try {
+      releasePR = await provider.findOpenReleasePR(config);
+    } catch (error) {
+      logger.warn(`Couldn't look up open release PRs: ${error.message}`);
+    }
+    const openVersion = releasePR ? extractVersionFromStagingBranch(releasePR.headBranch, config.stagingBranch) : null;
+    if (!openVersion) {
+      releasePR = null;
+    } else if (openVersion === newVersion) {
+      logger.info(`Release PR #${releasePR.number} for ${newVersion} is already open - updating it in place 💅`);
+    } else {
+      logger.info(`Release PR #${releasePR.number} is open for ${openVersion}, but new commits make this ${newVersion} - moving it to the new version in place 💅`);
+    }
+    stagingBranch = releasePR ? releasePR.headBranch : stagingBranch;
+    
+    // The commit message and PR templates can mention the version we're leaving behind, and the
+    // PR body links what the staging branch changes since it
+    const compareUrl = provider.compareUrl(getReleaseTagName(currentVersion, config), stagingBranch);
+    const result = await provider.createReleasePR(newVersion, changelog, { ...config, previousVersion: currentVersion, compareUrl, releasePR }, updatedFiles);
+    ({ prNumber, prUrl, prStatus } = result);
+    setLogContext({ pr: prNumber });
+    
+    if (dryRun) {
+      logger.info(`🔍 Dry run complete - the next release would be v${newVersion} (${currentVersion} → ${newVersion})`);
+    } else if (prNumber) {
+      logger.info(`PR #${prNumber} status: ${prStatus || 'unknown'}`);
+      
+      // Verify PR is still open
+      try {
+        const pr = await provider.getPR(prNumber);
+        
+        if (pr.state === 'open') {
+          logger.info(`Successfully created/updated PR #${prNumber}: ${prUrl}`);
+          prStatus = 'open';
+        } else if (pr.state === 'closed') {
+          logger.warn(`PR #${prNumber} was closed unexpectedly - this may indicate staging branch has no differences from target`);
+          prStatus = 'closed';
+          
+          // Delete the staging branch since the PR was closed (if configured to do so)
+          logger.startGroup('💋 Branch Cleanup - Cleaning up after closed PR! 💅');
+          if (config.deleteStagingBranch) {
+            try {
+              const branchDeleted = await provider.deleteBranch(stagingBranch);
+              if (branchDeleted) {
+                logger.info(`Successfully deleted branch ${stagingBranch} after PR was closed - keeping our repo fabulous! ✨`);
+              } else {
+                logger.warn(`Staging branch ${stagingBranch} seems to be gone already - this may indicate other issues`);
+              }
+            } catch (error) {
+              logger.warn(`Failed to delete branch ${stagingBranch}: ${error.message}`);
+            }
+          } else {
+            logger.info(`Branch deletion is disabled in config (deleteStagingBranch: false) - keeping ${stagingBranch} around for posterity 💅`);
+          }
+          logger.endGroup();
+        }
+      } catch (e) {
+        logger.warn(`Couldn't verify PR #${prNumber} status: ${e.message}`);
+      }
+    } else {
+      logger.warn('No PR number returned from createOrUpdatePR function');
+    }
+    
+    // If PR went from open to closed, this is a significant event worth logging
+    if (existingPrState === 'open' && prStatus === 'closed') {
+      logger.warn(`PR state changed from 'open' to 'closed' during this run. This usually happens when there are no changes between branches.`);
+    }
+  } catch (error) {
+    logger.error(`Error creating/updating PR: ${error.message}`);
+    throw error;
+  }
+  logger.endGroup();
+  
+  logger.info(`Release PR management complete. PR #${prNumber} status: ${prStatus || 'unknown'}`);
+  
+  // Provide clearer messaging based on PR status
+  if (prStatus === 'closed') {
+    logger.warn(`⚠️ The PR was closed. This usually happens when there are no changes between the staging and release branches.`);
+    logger.warn(`   Verify if all expected changes are included and if the PR needs to be manually reopened.`);
+  } else if (prStatus === 'open') {
+    logger.info('✅ Release PR successfully created or updated');
+  }
+  
+  return createResult({
+    runType: 'pr',
+    dryRun,
+    bumpType,
+    previousVersion: currentVersion,
+    nextVersion: newVersion,
+    changelog,
+    filesChanged: updatedFiles,
+    prNumber: prNumber || null,
+    prUrl: prUrl || null,
+    prStatus: prStatus || null,
+    releaseTag: getReleaseTagName(newVersion, config)
+  });
+}
+
+/**
+ * Build a ReleaseResult with every field present
+ * @param {Object} fields - Fields to set
+ * @returns {ReleaseResult}
+ */
+function createResult(fields) {
+  return {
+    runType: 'none',
+    reason: null,
+    dryRun: false,
+    bumpType: null,
+    previousVersion: null,
+    nextVersion: null,
+    changelog: null,
+    filesChanged: [],
+    prNumber: null,
+    prUrl: null,
+    prStatus: null,
+    releaseTag: null,
+    tags: [],
+    releaseCommitSha: null,
+    packageName: null,
+    packagePath: null,
+    packages: [],
+    ...fields
+  };
+}
+
+/**
+ * Extracts version from PR title - now with extra fabulous support for emojis and fancy formatting! 💅
+ * @param {String} title - PR title 
+ * @param {String} template - PR title template with {version} placeholder
+ * @returns {String} Extracted version number or null if not found
+ */
+function extractVersionFromPRTitle(title, template) {
+  // First escape regex special characters in the template
+  const escapedTemplate = template.replace(/[.*+?^${}()|[\]\\]/g, '\\$&');
+  
+  // Replace the escaped {version} / {{.Version}} with a semver capture group - any other field can be anything
+  const pattern = escapedTemplate
+    .replace(/\\\{\\\{\s*\\\.Version\s*\\\}\\\}|\\\{version\\\}/, '([0-9]+\\.[0-9]+\\.[0-9]+(?:-[\\w.-]+)?)')
+    .replace(/\\\{\\\{\s*\\\.Version\s*\\\}\\\}|\\\{version\\\}/g, '[0-9]+\\.[0-9]+\\.[0-9]+(?:-[\\w.-]+)?')
+    .replace(/\\\{\\\{\s*\\\.\w+\s*\\\}\\\}/g, '.*?');
+  const regex = new RegExp(pattern);
+  
+  // Try to match and extract the version
+  const match = title.match(regex);
+  if (match && match[1]) {
+    return match[1].trim();
+  }
+  
+  // Super simple fallback - just in case all else fails
+  try {
+    logger.info(`Trying fallback method for version extraction - regex didn't match! 😱`);
+    const prefix = template.replace('{version}', '');
+    const extracted = title.substring(title.indexOf(prefix) + prefix.length).trim();
+    
+    // Quick sanity check to make sure it looks like a version
+    if (/^[0-9]+\.[0-9]+\.[0-9]+/.test(extracted)) {
+      return extracted;
+    } 
+    return null;
+  } catch (e) {
+    logger.info(`Oopsie! Couldn't extract version from PR title using any of our methods! 😱`);
+    return null;
+  }
+}
+
+module.exports = {
+  ReleaseBoss,
+  runWorkflow,
+  finalizeWorkflow,
+  getConfig,
+  resolveConfig,
+  DEFAULT_CONFIG,
+  createProvider,
+  FakeProvider
+};
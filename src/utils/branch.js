@@ -0,0 +1,94 @@
+const { logger } = require('./logger');
+
+/**
+ * Work out which branch a run is for, from what the command line and CI say
+ *
+ * A branch passed in explicitly (--branch) wins. Then the CI's own variables:
+ * GITHUB_REF when it's a branch ref (not a tag or a pull request merge ref) and
+ * GitLab's CI_COMMIT_BRANCH. When neither says, the caller falls back to the
+ * provider's guess - CI that checks out a bare commit (detached HEAD) leaves
+ * nothing else to go on.
+ * @param {Object} options - Where the branch can come from
+ * @param {String} options.branch - Branch given explicitly (optional)
+ * @param {Object} options.env - Environment variables (default: process.env)
+ * @returns {String|null} - Branch name, or null when nothing says
+ */
+function resolveBranch({ branch, env = process.env } = {}) {
+  if (branch) {
+    return branch.replace(/^refs\/heads\//, '');
+  }
+  if (env.GITHUB_REF && env.GITHUB_REF.startsWith('refs/heads/')) {
+    return env.GITHUB_REF.substring('refs/heads/'.length);
+  }
+  return env.CI_COMMIT_BRANCH || null;
+}
+
+/**
+ * List the branches release-boss is configured to run on, and what each one is for
+ * @param {Object} config - Release Boss configuration
+ * @returns {Array<Object>} - { branch, rule } entries, e.g. { branch: 'main', rule: 'mergeBranch' }
+ */
+function getConfiguredBranches(config) {
+  const branches = [
+    { branch: config.mergeBranch, rule: 'mergeBranch' },
+    { branch: config.releaseBranch, rule: 'releaseBranch' },
+    ...(Array.isArray(config.prerelease) ? config.prerelease : [])
+      .map(entry => ({ branch: entry.branch, rule: `prerelease ${entry.channel}` })),
+    ...(Array.isArray(config.maintenance) ? config.maintenance : [])
+      .map(entry => ({ branch: entry.branch, rule: 'maintenance' }))
+  ];
+  return branches.filter(entry => entry.branch);
+}
+
+/**
+ * Make sure a run's branch is one the config knows about
+ * @param {Object} config - Release Boss configuration
+ * @param {String} branch - Resolved branch (from resolveBranch)
+ * @returns {Object} - The matching { branch, rule } entry
+ * @throws {Error} - Listing the configured branches, when none of them match
+ */
+function assertConfiguredBranch(config, branch) {
+  const branches = getConfiguredBranches(config);
+  const match = branches.find(entry => entry.branch === branch);
+  if (!match) {
+    const configured = branches.map(entry => `${entry.branch} (${entry.rule})`).join(', ');
+    throw new Error(`Branch "${branch}" doesn't match any configured branch - release-boss runs on ${configured}. ` +
+      'On a detached checkout, pass --branch (or set GITHUB_REF or CI_COMMIT_BRANCH) to say which branch this is');
+  }
+  return match;
+}
+
+/**
+ * Make sure the branch commits are read from and the branch release PRs merge into both exist
+ * Staging branches are cut fresh by every run, so there's nothing to look up for those. A missing
+ * release branch is fine for the very first release - it's created from the merge branch then.
+ * @param {Object} provider - VCS provider (skipped when it can't look branches up)
+ * @param {Object} config - Release Boss configuration (firstRelease set by resolveCommitRange)
+ * @returns {Promise<void>}
+ * @throws {Error} - Naming the branch that's missing
+ */
+async function assertBranchesExist(provider, config) {
+  if (typeof provider.branchExists !== 'function') {
+    return;
+  }
+
+  if (!await provider.branchExists(config.mergeBranch)) {
+    throw new Error(`mergeBranch (sourceBranch) "${config.mergeBranch}" doesn't exist on ${provider.name} - that's where commits are read from, so check the name`);
+  }
+
+  // Prerelease channels release from and into the same branch
+  if (config.releaseBranch === config.mergeBranch || await provider.branchExists(config.releaseBranch)) {
+    return;
+  }
+  if (!config.firstRelease) {
+    throw new Error(`releaseBranch (targetBranch) "${config.releaseBranch}" doesn't exist on ${provider.name}, but there are releases already - check the name, or push the branch back`);
+  }
+  logger.info(`Release branch ${config.releaseBranch} doesn't exist yet - it'll be created from ${config.mergeBranch} for the first release 🎉`);
+}
+
+module.exports = {
+  resolveBranch,
+  getConfiguredBranches,
+  assertConfiguredBranch,
+  assertBranchesExist
+};
@@ -1,24 +1,142 @@
 const fs = require('fs').promises;
 const yaml = require('js-yaml');
+const semver = require('semver');
+const { PLATFORMS } = require('../providers');
+const { parseMessageTemplate, hasVersionField } = require('./messageTemplate');
+const { parseDuration } = require('./date');
+const { VERSION_FILE_STRATEGIES, CONFLICT_STRATEGIES } = require('../core/templateProcessor');
+const { HOOK_STAGES } = require('../core/hooks');
+const { BUMP_LEVELS } = require('../core/commitAnalyzer');
+const { MERGE_METHODS } = require('../core/autoMerge');
+const { PULL_REQUEST_CHANGELOG_FORMATS } = require('../core/prContent');
+const { getBranchVersionRange } = require('../core/maintenance');
+const { DEFAULT_SUBJECT_RULES } = require('../core/changelogGenerator');
+const { logger } = require('./logger');
+
+/**
+ * Release Boss configuration - the same keys as .release-boss.yml
+ * @typedef {Object} ReleaseBossConfig
+ * @property {String} platform - VCS platform: 'github', 'gitlab' or 'gitea'
+ * @property {String|null} baseUrl - Base URL for self-hosted instances
+ * @property {String|null} githubApiUrl - GitHub API URL (GitHub Enterprise Server: https://<host>/api/v3)
+ * @property {String|null} githubUploadUrl - GitHub uploads URL (default: worked out from githubApiUrl)
+ * @property {String|null} repository - Project path like "group/project"
+ * @property {Boolean} dryRun - Log every change instead of making it
+ * @property {Object} retry - API retry policy (maxAttempts, baseDelay, maxDelay, jitter, timeout)
+ * @property {String} mergeBranch - Branch feature work is merged into, and commits are read from (sourceBranch in the config file works too)
+ * @property {String} stagingBranch - Prefix for release staging branches
+ * @property {String} releaseBranch - Branch release PRs merge into and releases are tagged on (or targetBranch)
+ * @property {Boolean} deleteStagingBranch - Delete the staging branch once its PR is done
+ * @property {String|null} staleBranchAge - Age ("7d", "12h") after which a staging branch without a PR is deleted
+ * @property {Boolean} autoMerge - Merge the release PR once its required checks pass, then tag the merge commit
+ * @property {String} mergeMethod - How autoMerge merges: 'merge', 'squash' or 'rebase'
+ * @property {String} autoMergeTimeout - How long ("30m", "1h") autoMerge waits for the release PR's checks
+ * @property {String} pullRequestTitle - Release PR title template, with {{.Version}} (or {version}) in it
+ * @property {String} pullRequestHeader - Template for the text shown above the changelog in the release PR
+ * @property {String|null} pullRequestFooter - Template replacing the footer of the release PR
+ * @property {String} pullRequestChangelog - What the release PR shows of the changelog: 'preview' or 'table'
+ * @property {String} releaseCommitMessage - Template for the version bump commit message
+ * @property {Array<String|Object>} versionFiles - Version files: paths with in-file templates, or { file, strategy, key }
+ * @property {Array<String>} templateFiles - Files rendered from a .template sibling
+ * @property {Array<Object>} updateFiles - Files with a pattern and template to replace
+ * @property {Array<Object>} changelogSections - Commit type → changelog section mappings
+ * @property {Array<String>} ignorePaths - Globs for paths whose commits never trigger a release or reach the changelog
+ * @property {Boolean} showAuthors - Credit each changelog entry's author and co-authors
+ * @property {Boolean} collapseReverts - Leave reverts and the commits they revert out of the changelog
+ * @property {Number|null} maxEntryLength - Longest a changelog entry's body can get before it's cut short with a link to the rest
+ * @property {Object} changelogSubject - How entry subjects are cleaned up (stripType, trim, capitalize, stripPRNumber)
+ * @property {Object} hooks - Commands per stage (preBump, postBump, prePR, postRelease), each a string or { command, continueOnError }
+ * @property {Object} commitTypes - Commit type → { bump: major|minor|patch|none, section, hidden } (or just the bump level)
+ * @property {Array<String>|null} releaseTypes - Commit types that count toward a release (feat bumps minor, the rest patch)
+ * @property {Array<String>} noBumpScopes - Commit scopes that never bump the version, whatever the type (still in the changelog)
+ * @property {Array<String>} hiddenScopes - Commit scopes left out of the changelog (they still bump the version)
+ * @property {Number} noReleaseExitCode - Exit code when there's nothing to release
+ * @property {Boolean} parseSquashCommits - Split squash-merge bodies into the conventional commits they list
+ * @property {Number} fetchConcurrency - Per-commit API requests (changed files, usernames) to run at once
+ * @property {String} changelogPath - Changelog file to write
+ * @property {String} changelogHeader - Preamble for a changelog file that doesn't exist yet
+ * @property {Object} changelogTable - Commit table shown in the release PR
+ * @property {Array<Object>} packages - Monorepo packages, each versioned on its own
+ * @property {String|null} tagPrefix - Prefix in front of the version in release tags (overrides versionTagPrefix)
+ * @property {String|null} tagPattern - Regex existing release tags have to match, optionally capturing the version
+ * @property {String} firstVersion - Version for the first release, when there's no release tag yet
+ * @property {Array<Object>} prerelease - Prerelease channels ({ channel, branch }) released from their own branch
+ * @property {Array<Object>} maintenance - Maintenance branches ({ branch, range }) releasing an older version line
+ * @property {String} dateFormat - Format used for the {{date}} template placeholder
+ * @property {String|null} markerPrefix - Version file marker prefix (closing marker is always %%)
+ * @property {String} conflictStrategy - What to do with merge conflict markers in version files: abort, ours or theirs
+ * @property {String|null} signingKey - GPG private key (ASCII-armoured) or ID of a key in the keyring
+ * @property {String|null} signingPassphrase - Passphrase for the signing key
+ * @property {Boolean} signCommits - GPG-sign the commits made on the staging branch
+ * @property {Boolean} signTags - Create the release tag as a GPG-signed annotated tag
+ * @property {Boolean} annotateTags - Create the release tag as an annotated tag (always on with autoMerge)
+ * @property {Boolean} createGithubRelease - Publish a release with the changelog as its notes after tagging
+ * @property {String|null} releaseTitle - Release title template (default: the tag name)
+ */
 
 /**
  * Default configuration values
  */
 const DEFAULT_CONFIG = {
+  platform: 'github',         // VCS platform: 'github', 'gitlab' or 'gitea'
+  baseUrl: null,              // Base URL for self-hosted instances (e.g. https://gitlab.example.com) - required for Gitea
+  githubApiUrl: null,         // GitHub Enterprise Server API, e.g. https://github.example.com/api/v3 (default: GITHUB_API_URL or api.github.com)
+  githubUploadUrl: null,      // GitHub uploads URL (default: worked out from githubApiUrl)
+  repository: null,           // Project path like "group/project" (GitLab falls back to CI_PROJECT_PATH, Gitea to GITHUB_REPOSITORY)
+  dryRun: false,              // Log every change instead of making it (same as the dry-run input)
   mergeBranch: 'main',
   stagingBranch: 'staging',
   releaseBranch: 'release',
   deleteStagingBranch: true,  // By default, we'll clean up staging branches after PR closure
-  pullRequestTitle: 'chore: release {version}',
+  staleBranchAge: '7d',       // Staging branches with no PR this old are left over from a crashed run (null keeps them)
+  autoMerge: false,           // Merge the release PR once its required checks pass, then tag the merge commit
+  mergeMethod: 'merge',       // How autoMerge merges the release PR: merge, squash or rebase
+  autoMergeTimeout: '30m',    // How long autoMerge waits for the release PR's checks before leaving it open
+  pullRequestTitle: 'chore: release {version}',  // Also takes {{.Version}}, {{.PreviousVersion}}, {{.Date}} and {{.Package}}
   pullRequestHeader: 'Release PR',
+  pullRequestFooter: null,    // Template replacing the "auto-generated by Release Boss" footer
+  pullRequestChangelog: 'preview', // preview: the changelog section as it'll be committed, table: the commit table
+  releaseCommitMessage: 'chore: update files for release {{.Version}}',
   templateFiles: [],
   versionFiles: [],
+  dateFormat: 'iso',          // Format for the {{date}} placeholder: 'iso' or a pattern like 'YYYY-MM-DD'
+  markerPrefix: null,         // Version file marker prefix (default: %%release-boss: or %%release-manager:)
+  conflictStrategy: 'abort',  // Merge conflict markers in a version file: 'abort' the release, or keep 'ours' or 'theirs'
+  packages: [],               // Monorepo packages, each versioned, changelogged and tagged on its own
+  tagPrefix: null,            // Release tag prefix like 'release-' or '' (default: 'v', or nothing with versionTagPrefix: false)
+  tagPattern: null,           // Regex for finding existing release tags when the prefix is ambiguous, e.g. '^release-(\d+\.\d+\.\d+)$'
+  firstVersion: '0.1.0',      // Version for the very first release (when no release tag matches yet)
+  prerelease: [],             // Prerelease channels like { channel: 'beta', branch: 'develop' }
+  maintenance: [],            // Maintenance branches like { branch: '1.x' } or { branch: 'legacy', range: '1.x' }
+  ignorePaths: [],            // Globs like 'docs/**' - commits touching only these files are left out of bumps and changelogs
+  showAuthors: false,         // Add "(@alice, @bob)" after changelog entries, co-authors included
+  collapseReverts: true,      // Drop a revert and the commit it reverts when both are in the same release
+  maxEntryLength: null,       // Cut changelog entry bodies (breaking change notes) to this many characters, linking the PR for the rest
+  changelogSubject: {},       // Subject cleanup overrides: stripType, trim, capitalize, stripPRNumber
+  commitTypes: {},            // Custom or redefined types like { hotfix: { bump: 'patch', section: 'Hotfixes' } }
+  releaseTypes: null,         // Commit types that count toward a release (default: feat, fix, perf, refactor)
+  noBumpScopes: [],           // Scopes like 'ci' whose commits never bump the version, but still make the changelog
+  hiddenScopes: [],           // Scopes like 'test' whose commits are left out of the changelog, but still bump the version
+  noReleaseExitCode: 0,       // Exit code when there's nothing to release - set it non-zero so CI can branch on it
+  hooks: {},                  // Shell commands to run at preBump, postBump, prePR and postRelease
+  parseSquashCommits: false,  // Split squash-merge bodies into the conventional commits they list
+  fetchConcurrency: 8,        // Per-commit requests (changed files, usernames) in flight at once - lower it if you hit rate limits
+  retry: {},                  // API retry policy overrides: maxAttempts, baseDelay, maxDelay, jitter, timeout
+  signingKey: null,           // GPG private key (ASCII-armoured) or key ID - best passed in with the signing-key input
+  signingPassphrase: null,    // Passphrase for the signing key (signing-passphrase input)
+  signCommits: false,         // GPG-sign the version bump commits
+  signTags: false,            // Create the release tag as a GPG-signed annotated tag
+  annotateTags: false,        // Create the release tag as an annotated tag (autoMerge turns this on)
+  createGithubRelease: false, // Publish a release with the version's changelog section as its notes after tagging
+  releaseTitle: null,         // Release title template like 'Release {{.Version}}' (default: the tag name)
   changelogSections: [
     { type: 'feat', section: 'Features', hidden: false },
     { type: 'fix', section: 'Bug Fixes', hidden: false },
-    { type: 'perf', section: 'Performance Improvements', hidden: false }
+    { type: 'perf', section: 'Performance Improvements', hidden: false },
+    { type: 'revert', section: 'Reverts', hidden: false }
   ],
   changelogPath: 'CHANGELOG.md',
+  changelogHeader: '# Changelog\n', // Preamble used when the changelog file is created
   // Changelog table configuration for PR-based tracking
   changelogTable: {
     enabled: true,
@@ -54,8 +172,90 @@ const DEFAULT_CONFIG = {
   }
 };
 
+/**
+ * An environment variable reference: ${NAME} or ${NAME:-default}, with $${NAME} for a literal one
+ */
+const ENV_REFERENCE = /\$(\$?)\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}/g;
+
+/**
+ * Config keys whose strings are left for something else to expand
+ * Hook commands run in a shell that has the release's own variables (RELEASE_VERSION, ...).
+ */
+const UNINTERPOLATED_KEYS = ['hooks'];
+
+/**
+ * Replace ${NAME} and ${NAME:-default} references in every string of a parsed config
+ * Values come from the environment when the config is loaded, and always stay strings.
+ * Like the shell, the default is used when the variable is unset or empty.
+ * @param {*} value - Parsed config, or any value inside it
+ * @param {Object} env - Environment to resolve from (default: process.env)
+ * @param {String} keyPath - Where value sits in the config, for error messages
+ * @returns {*} - value with the references replaced
+ * @throws {Error} - If a variable without a default isn't set
+ */
+function interpolateEnv(value, env = process.env, keyPath = '') {
+  if (typeof value === 'string') {
+    return value.replace(ENV_REFERENCE, (reference, escaped, name, fallback) => {
+      if (escaped) {
+        return reference.substring(1);
+      }
+      if (env[name] !== undefined && env[name] !== '') {
+        return env[name];
+      }
+      if (fallback !== undefined) {
+        return fallback;
+      }
+      throw new Error(`${keyPath || 'The config'} uses \${${name}}, but ${name} isn't set - set it, or give a default like \${${name}:-value}`);
+    });
+  }
+  if (Array.isArray(value)) {
+    return value.map((item, index) => interpolateEnv(item, env, `${keyPath}[${index}]`));
+  }
+  if (value && typeof value === 'object') {
+    return Object.fromEntries(Object.entries(value).map(([key, item]) => [
+      key,
+      !keyPath && UNINTERPOLATED_KEYS.includes(key) ? item : interpolateEnv(item, env, keyPath ? `${keyPath}.${key}` : key)
+    ]));
+  }
+  return value;
+}
+
+/**
+ * Other names the branch settings go by, and the setting each one stands for
+ */
+const BRANCH_ALIASES = {
+  sourceBranch: 'mergeBranch',
+  targetBranch: 'releaseBranch'
+};
+
+/**
+ * Turn sourceBranch and targetBranch into the mergeBranch and releaseBranch they stand for
+ * This runs on the config as written, before the defaults go in, so a clash is the user's own.
+ * @param {Object} config - Configuration as written (not merged with the defaults)
+ * @returns {Object} - Configuration with only the canonical branch names
+ * @throws {Error} - If an alias and its setting are both given, with different branches
+ */
+function resolveBranchAliases(config) {
+  if (!config || typeof config !== 'object') {
+    return config;
+  }
+
+  const resolved = { ...config };
+  for (const [alias, key] of Object.entries(BRANCH_ALIASES)) {
+    if (resolved[alias] === undefined) continue;
+
+    if (resolved[key] !== undefined && resolved[key] !== resolved[alias]) {
+      throw new Error(`${alias} and ${key} are the same setting, but one says "${resolved[alias]}" and the other "${resolved[key]}" - keep just one`);
+    }
+    resolved[key] = resolved[alias];
+    delete resolved[alias];
+  }
+  return resolved;
+}
+
 /**
  * Load and parse the configuration file
+ * ${NAME} and ${NAME:-default} references in its values are filled in from the environment.
  * @param {String} configFilePath - Path to the configuration file
  * @returns {Object} - Parsed configuration with defaults applied
  */
@@ -63,13 +263,7 @@ async function getConfig(configFilePath) {
   try {
     // If no config file specified, try to find one in order of preference
     if (!configFilePath) {
-      // Check for YAML first (it's more GitHub-friendly!)
-      if (await fileExists('.release-boss.yml') || await fileExists('.release-boss.yaml')) {
-        configFilePath = await fileExists('.release-boss.yml') ? '.release-boss.yml' : '.release-boss.yaml';
-      } else {
-        // Fall back to JSON if no YAML found
-        configFilePath = '.release-boss.json';
-      }
+      configFilePath = await findConfigFile();
     }
     
     // Read config file
@@ -78,28 +272,57 @@ async function getConfig(configFilePath) {
     
     // Parse based on file extension
     if (configFilePath.endsWith('.yml') || configFilePath.endsWith('.yaml')) {
-      console.log(`Reading YAML config from ${configFilePath} - so trendy and GitHub-friendly! 💅`);
+      logger.info(`Reading YAML config from ${configFilePath} - so trendy and GitHub-friendly! 💅`);
       parsedConfig = yaml.load(configData);
     } else {
-      console.log(`Reading JSON config from ${configFilePath}`);
+      logger.info(`Reading JSON config from ${configFilePath}`);
       parsedConfig = JSON.parse(configData);
     }
     
     // Merge with defaults
     return {
       ...DEFAULT_CONFIG,
-      ...parsedConfig
+      ...resolveBranchAliases(interpolateEnv(parsedConfig))
     };
   } catch (error) {
     if (error.code === 'ENOENT') {
-      console.log(`Config file not found: ${configFilePath}. Using default configuration.`);
-      return DEFAULT_CONFIG;
+      logger.info(`Config file not found: ${configFilePath}. Using default configuration.`);
+      return { ...DEFAULT_CONFIG };
     }
     
-    throw new Error(`Failed to load config: ${error.message}`);
+    throw new Error(`Failed to load config: ${error.message}`, { cause: error });
   }
 }
 
+/**
+ * Find the config file to use when none is specified
+ * @returns {Promise<String>} - .release-boss.yml, .release-boss.yaml or .release-boss.json
+ */
+async function findConfigFile() {
+  // Check for YAML first (it's more GitHub-friendly!)
+  if (await fileExists('.release-boss.yml') || await fileExists('.release-boss.yaml')) {
+    return await fileExists('.release-boss.yml') ? '.release-boss.yml' : '.release-boss.yaml';
+  }
+  
+  // Fall back to JSON if no YAML found
+  return '.release-boss.json';
+}
+
+/**
+ * Apply defaults to a config object built in code and validate it
+ * @param {ReleaseBossConfig} config - Partial configuration
+ * @returns {ReleaseBossConfig} - Configuration with defaults applied
+ * @throws {Error} - If configuration is invalid
+ */
+function resolveConfig(config = {}) {
+  const resolved = {
+    ...DEFAULT_CONFIG,
+    ...resolveBranchAliases(config)
+  };
+  validateConfig(resolved);
+  return resolved;
+}
+
 /**
  * Helper function to check if a file exists
  * @param {String} filePath - Path to check
@@ -114,12 +337,63 @@ async function fileExists(filePath) {
   }
 }
 
+/**
+ * Check the entries of a versionFiles list
+ * @param {Array} files - versionFiles entries
+ * @param {String} label - Where the list lives, for error messages
+ * @throws {Error} - If an entry is invalid
+ */
+function validateVersionFiles(files, label) {
+  for (const entry of files) {
+    if (typeof entry === 'string') {
+      continue;
+    }
+    if (!entry || typeof entry.file !== 'string' || !entry.file) {
+      throw new Error(`${label} entries must be a path or { file, strategy }, got ${JSON.stringify(entry)}`);
+    }
+    if (entry.strategy !== undefined && !VERSION_FILE_STRATEGIES.includes(entry.strategy)) {
+      throw new Error(`${label} strategy for ${entry.file} must be one of: ${VERSION_FILE_STRATEGIES.join(', ')}`);
+    }
+    if (entry.key !== undefined && (entry.strategy !== 'json' || typeof entry.key !== 'string' || !entry.key.replace(/^\./, ''))) {
+      throw new Error(`${label} key for ${entry.file} must be a dotted JSON key like ".version" and needs strategy: json`);
+    }
+  }
+}
+
+/**
+ * Check a value is a well-formed http or https URL
+ * @param {*} value - Value to check
+ * @returns {Boolean}
+ */
+function isHttpUrl(value) {
+  if (typeof value !== 'string') {
+    return false;
+  }
+  try {
+    return ['http:', 'https:'].includes(new URL(value).protocol);
+  } catch {
+    return false;
+  }
+}
+
 /**
  * Validate the configuration
  * @param {Object} config - Configuration object
  * @throws {Error} - If configuration is invalid
  */
 function validateConfig(config) {
+  // Ensure the platform is one we can talk to
+  if (config.platform && !PLATFORMS.includes(config.platform)) {
+    throw new Error(`platform must be one of: ${PLATFORMS.join(', ')}`);
+  }
+  
+  // GitHub Enterprise Server URLs have to be real URLs - the API check at startup covers the rest
+  for (const key of ['githubApiUrl', 'githubUploadUrl']) {
+    if (config[key] !== undefined && config[key] !== null && !isHttpUrl(config[key])) {
+      throw new Error(`${key} must be an http(s) URL like https://github.example.com/api/v3, got "${config[key]}"`);
+    }
+  }
+  
   // Ensure required branches are specified
   if (!config.mergeBranch) {
     throw new Error('Configuration must specify a mergeBranch');
@@ -133,9 +407,26 @@ function validateConfig(config) {
     throw new Error('Configuration must specify a releaseBranch');
   }
   
+  // Reading commits from the branch the PR merges into would never find anything new
+  if (config.mergeBranch === config.releaseBranch) {
+    throw new Error(`mergeBranch and releaseBranch are both "${config.mergeBranch}" - release PRs need a branch of their own to merge into`);
+  }
+  
+  // Staging branches are <stagingBranch>-v1.2.3, so the prefix can't be one of the long-lived branches
+  if ([config.mergeBranch, config.releaseBranch].includes(config.stagingBranch)) {
+    throw new Error(`stagingBranch "${config.stagingBranch}" is also the ${config.stagingBranch === config.mergeBranch ? 'mergeBranch' : 'releaseBranch'} - pick a prefix of its own, like "staging"`);
+  }
+  
   // Ensure PR title template includes version placeholder
-  if (!config.pullRequestTitle || !config.pullRequestTitle.includes('{version}')) {
-    throw new Error('pullRequestTitle must contain {version} placeholder');
+  if (!config.pullRequestTitle || !hasVersionField(config.pullRequestTitle)) {
+    throw new Error('pullRequestTitle must contain a {{.Version}} (or {version}) placeholder');
+  }
+  
+  // Check the message templates now rather than halfway through a release
+  for (const key of ['pullRequestTitle', 'pullRequestHeader', 'pullRequestFooter', 'releaseCommitMessage', 'releaseTitle']) {
+    if (config[key] !== undefined && config[key] !== null) {
+      parseMessageTemplate(config[key], key);
+    }
   }
   
   // Validate file arrays if present
@@ -147,6 +438,170 @@ function validateConfig(config) {
     throw new Error('versionFiles must be an array');
   }
   
+  if (config.versionFiles) {
+    validateVersionFiles(config.versionFiles, 'versionFiles');
+  }
+  
+  if (config.firstVersion !== undefined && config.firstVersion !== null &&
+      !semver.valid(String(config.firstVersion).replace(/^v/, ''))) {
+    throw new Error(`firstVersion must be a semantic version like 0.1.0, got "${config.firstVersion}"`);
+  }
+  
+  if (config.staleBranchAge !== undefined && config.staleBranchAge !== null && parseDuration(config.staleBranchAge) === null) {
+    throw new Error(`staleBranchAge must be a duration like "7d", "12h" or "30m", got "${config.staleBranchAge}"`);
+  }
+  
+  if (config.pullRequestChangelog !== undefined && config.pullRequestChangelog !== null &&
+      !PULL_REQUEST_CHANGELOG_FORMATS.includes(config.pullRequestChangelog)) {
+    throw new Error(`pullRequestChangelog must be one of: ${PULL_REQUEST_CHANGELOG_FORMATS.join(', ')}, got "${config.pullRequestChangelog}"`);
+  }
+  
+  if (config.mergeMethod !== undefined && config.mergeMethod !== null && !MERGE_METHODS.includes(config.mergeMethod)) {
+    throw new Error(`mergeMethod must be one of: ${MERGE_METHODS.join(', ')}, got "${config.mergeMethod}"`);
+  }
+  
+  if (config.autoMergeTimeout !== undefined && config.autoMergeTimeout !== null && parseDuration(config.autoMergeTimeout) === null) {
+    throw new Error(`autoMergeTimeout must be a duration like "30m" or "1h", got "${config.autoMergeTimeout}"`);
+  }
+  
+  if (config.ignorePaths !== undefined && config.ignorePaths !== null &&
+      (!Array.isArray(config.ignorePaths) || config.ignorePaths.some(pattern => typeof pattern !== 'string'))) {
+    throw new Error('ignorePaths must be an array of glob patterns like "docs/**"');
+  }
+  
+  if (config.tagPrefix !== undefined && config.tagPrefix !== null && typeof config.tagPrefix !== 'string') {
+    throw new Error('tagPrefix must be a string like "v" or "release-" (use "" for no prefix)');
+  }
+  
+  if (config.tagPattern !== undefined && config.tagPattern !== null) {
+    if (typeof config.tagPattern !== 'string' || !config.tagPattern) {
+      throw new Error('tagPattern must be a regular expression string');
+    }
+    try {
+      new RegExp(config.tagPattern);
+    } catch (error) {
+      throw new Error(`tagPattern is not a valid regular expression: ${error.message}`);
+    }
+  }
+  
+  if (config.commitTypes !== undefined && config.commitTypes !== null) {
+    if (typeof config.commitTypes !== 'object' || Array.isArray(config.commitTypes)) {
+      throw new Error('commitTypes must map commit types to a bump level or { bump, section, hidden }');
+    }
+    
+    for (const [type, entry] of Object.entries(config.commitTypes)) {
+      const bump = typeof entry === 'string' ? entry : entry && entry.bump;
+      if (!BUMP_LEVELS.includes(bump)) {
+        throw new Error(`commitTypes.${type} needs a bump of ${BUMP_LEVELS.join(', ')}, got "${bump}"`);
+      }
+      if (typeof entry === 'object' && entry.section !== undefined && entry.section !== null &&
+          (typeof entry.section !== 'string' || !entry.section.trim())) {
+        throw new Error(`commitTypes.${type}.section must be a changelog heading`);
+      }
+    }
+  }
+  
+  if (config.releaseTypes !== undefined && config.releaseTypes !== null &&
+      (!Array.isArray(config.releaseTypes) || config.releaseTypes.some(type => typeof type !== 'string' || !type))) {
+    throw new Error('releaseTypes must be a list of commit types like ["feat", "fix"]');
+  }
+  
+  for (const key of ['noBumpScopes', 'hiddenScopes']) {
+    if (config[key] !== undefined && config[key] !== null &&
+        (!Array.isArray(config[key]) || config[key].some(scope => typeof scope !== 'string' || !scope))) {
+      throw new Error(`${key} must be a list of commit scopes like ["ci", "test"]`);
+    }
+  }
+  
+  if (config.noReleaseExitCode !== undefined && config.noReleaseExitCode !== null &&
+      (!Number.isInteger(config.noReleaseExitCode) || config.noReleaseExitCode < 0 || config.noReleaseExitCode > 255)) {
+    throw new Error(`noReleaseExitCode must be a whole number from 0 to 255, got "${config.noReleaseExitCode}"`);
+  }
+  
+  if (config.maxEntryLength !== undefined && config.maxEntryLength !== null &&
+      (!Number.isInteger(config.maxEntryLength) || config.maxEntryLength < 1)) {
+    throw new Error(`maxEntryLength must be a whole number of at least 1, got "${config.maxEntryLength}"`);
+  }
+  
+  if (config.changelogSubject) {
+    if (typeof config.changelogSubject !== 'object' || Array.isArray(config.changelogSubject)) {
+      throw new Error('changelogSubject must be an object');
+    }
+    
+    for (const [key, value] of Object.entries(config.changelogSubject)) {
+      if (!Object.prototype.hasOwnProperty.call(DEFAULT_SUBJECT_RULES, key)) {
+        throw new Error(`changelogSubject.${key} isn't a subject rule - pick from: ${Object.keys(DEFAULT_SUBJECT_RULES).join(', ')}`);
+      }
+      if (typeof value !== 'boolean') {
+        throw new Error(`changelogSubject.${key} must be true or false, got "${value}"`);
+      }
+    }
+  }
+  
+  if (config.fetchConcurrency !== undefined && config.fetchConcurrency !== null &&
+      (!Number.isInteger(config.fetchConcurrency) || config.fetchConcurrency < 1)) {
+    throw new Error(`fetchConcurrency must be a whole number of at least 1, got "${config.fetchConcurrency}"`);
+  }
+  
+  if (config.hooks !== undefined && config.hooks !== null) {
+    if (typeof config.hooks !== 'object' || Array.isArray(config.hooks)) {
+      throw new Error(`hooks must map stages (${HOOK_STAGES.join(', ')}) to lists of commands`);
+    }
+    for (const [stage, hooks] of Object.entries(config.hooks)) {
+      if (!HOOK_STAGES.includes(stage)) {
+        throw new Error(`Unknown hook stage "${stage}" - hooks can run at ${HOOK_STAGES.join(', ')}`);
+      }
+      if (!Array.isArray(hooks) || hooks.some(hook => typeof hook !== 'string' && (!hook || typeof hook.command !== 'string'))) {
+        throw new Error(`hooks.${stage} must be a list of commands or { command, continueOnError } entries`);
+      }
+    }
+  }
+  
+  if (config.markerPrefix !== undefined && config.markerPrefix !== null &&
+      (typeof config.markerPrefix !== 'string' || !config.markerPrefix.trim())) {
+    throw new Error('markerPrefix must be a non-empty string like "%%release-boss:"');
+  }
+  
+  if (config.conflictStrategy !== undefined && !CONFLICT_STRATEGIES.includes(config.conflictStrategy)) {
+    throw new Error(`conflictStrategy must be one of: ${CONFLICT_STRATEGIES.join(', ')}, got "${config.conflictStrategy}"`);
+  }
+  
+  // GitLab's and Gitea's APIs make commits and tags server-side, so there's nothing for us to sign
+  if ((config.signCommits || config.signTags) && (config.platform === 'gitlab' || config.platform === 'gitea')) {
+    const platformName = config.platform === 'gitlab' ? 'GitLab' : 'Gitea';
+    throw new Error(`signCommits and signTags are only supported on GitHub - the ${platformName} API can't create signed commits or tags`);
+  }
+  
+  // Validate monorepo packages if present
+  if (config.packages) {
+    if (!Array.isArray(config.packages)) {
+      throw new Error('packages must be an array');
+    }
+    
+    const names = new Set();
+    for (const pkg of config.packages) {
+      if (!pkg || !pkg.path) {
+        throw new Error('Each entry in packages must have a path');
+      }
+      
+      const name = pkg.name || pkg.path.replace(/\/+$/, '').split('/').pop();
+      if (!/^[\w.-]+$/.test(name)) {
+        throw new Error(`Package name "${name}" can only contain letters, numbers, '.', '_' and '-' (it's used in branch and tag names)`);
+      }
+      if (names.has(name)) {
+        throw new Error(`Package name "${name}" is used more than once - give each package a unique name`);
+      }
+      names.add(name);
+      
+      if (pkg.versionFiles && !Array.isArray(pkg.versionFiles)) {
+        throw new Error(`versionFiles for package "${name}" must be an array`);
+      }
+      if (pkg.versionFiles) {
+        validateVersionFiles(pkg.versionFiles, `versionFiles for package "${name}"`);
+      }
+    }
+  }
+  
   // Validate changelog table config if enabled
   if (config.changelogTable) {
     // If not explicitly disabled, ensure it has the required properties
@@ -178,13 +633,83 @@ function validateConfig(config) {
         config.changelogTable.placement = DEFAULT_CONFIG.changelogTable.placement;
       }
       
-      console.log(`Changelog table configuration validated and ready to slay! 💅`);
+      logger.info(`Changelog table configuration validated and ready to slay! 💅`);
     }
   } else {
     // Add default changelog table config
     config.changelogTable = DEFAULT_CONFIG.changelogTable;
   }
   
+  // Validate the retry policy if present
+  if (config.retry) {
+    if (typeof config.retry !== 'object' || Array.isArray(config.retry)) {
+      throw new Error('retry must be an object');
+    }
+    
+    for (const key of ['maxAttempts', 'baseDelay', 'maxDelay', 'jitter', 'timeout']) {
+      if (config.retry[key] !== undefined && (typeof config.retry[key] !== 'number' || config.retry[key] < 0)) {
+        throw new Error(`retry.${key} must be a non-negative number`);
+      }
+    }
+    
+    if (config.retry.maxAttempts !== undefined && (!Number.isInteger(config.retry.maxAttempts) || config.retry.maxAttempts < 1)) {
+      throw new Error('retry.maxAttempts must be a whole number of at least 1');
+    }
+  }
+  
+  // Validate prerelease channels if present
+  if (config.prerelease) {
+    if (!Array.isArray(config.prerelease)) {
+      throw new Error('prerelease must be an array');
+    }
+    
+    for (const entry of config.prerelease) {
+      if (!entry || !entry.channel || !entry.branch) {
+        throw new Error('Each entry in prerelease must have a channel and a branch');
+      }
+      if (!/^[0-9A-Za-z-]+$/.test(entry.channel) || /^[0-9]+$/.test(entry.channel)) {
+        throw new Error(`Prerelease channel "${entry.channel}" must be a name like alpha, beta or rc`);
+      }
+      if (entry.branch === config.releaseBranch) {
+        throw new Error(`Prerelease branch "${entry.branch}" can't be the release branch - merging into ${config.releaseBranch} is what makes a stable release`);
+      }
+    }
+  }
+  
+  // Validate maintenance branches if present
+  if (config.maintenance) {
+    if (!Array.isArray(config.maintenance)) {
+      throw new Error('maintenance must be an array');
+    }
+    
+    for (const entry of config.maintenance) {
+      if (!entry || !entry.branch) {
+        throw new Error('Each entry in maintenance must have a branch');
+      }
+      const range = entry.range || getBranchVersionRange(entry.branch);
+      if (!range) {
+        throw new Error(`Maintenance branch "${entry.branch}" needs a range like 1.x - its name doesn't say which version line it maintains`);
+      }
+      if (typeof range !== 'string' || !semver.validRange(range)) {
+        throw new Error(`Maintenance range for "${entry.branch}" must be a semver range like 1.x or 1.4.x, got "${range}"`);
+      }
+      if (entry.branch === config.mergeBranch || entry.branch === config.releaseBranch) {
+        throw new Error(`Maintenance branch "${entry.branch}" can't be the merge or release branch - those carry the main line`);
+      }
+      if (Array.isArray(config.prerelease) && config.prerelease.some(channel => channel && channel.branch === entry.branch)) {
+        throw new Error(`Branch "${entry.branch}" can't be both a prerelease channel and a maintenance branch`);
+      }
+    }
+    
+    if (config.maintenance.length > 0 && Array.isArray(config.packages) && config.packages.length > 0) {
+      throw new Error('maintenance branches don\'t support monorepo packages yet - each package\'s tags would need a line of their own');
+    }
+  }
+  
+  if (config.changelogHeader !== undefined && config.changelogHeader !== null && typeof config.changelogHeader !== 'string') {
+    throw new Error('changelogHeader must be a string');
+  }
+  
   // Validate changelog sections
   if (config.changelogSections) {
     if (!Array.isArray(config.changelogSections)) {
@@ -203,6 +728,9 @@ function validateConfig(config) {
 
 module.exports = {
   getConfig,
+  findConfigFile,
   validateConfig,
+  resolveConfig,
+  interpolateEnv,
   DEFAULT_CONFIG
 };
@@ -0,0 +1,56 @@
+/**
+ * Format a date for templates 📅
+ *
+ * "iso" (the default) gives a full ISO-8601 timestamp. Anything else is treated
+ * as a pattern where YYYY, MM, DD, HH, mm and ss are replaced with the UTC parts
+ * of the date, e.g. "YYYY-MM-DD" → "2024-03-09".
+ * @param {Date} date - Date to format
+ * @param {String} format - "iso" or a token pattern
+ * @returns {String} - Formatted date
+ */
+function formatDate(date, format = 'iso') {
+  if (!format || format === 'iso') {
+    return date.toISOString();
+  }
+
+  const pad = value => String(value).padStart(2, '0');
+  const tokens = {
+    YYYY: String(date.getUTCFullYear()),
+    MM: pad(date.getUTCMonth() + 1),
+    DD: pad(date.getUTCDate()),
+    HH: pad(date.getUTCHours()),
+    mm: pad(date.getUTCMinutes()),
+    ss: pad(date.getUTCSeconds())
+  };
+
+  return format.replace(/YYYY|MM|DD|HH|mm|ss/g, token => tokens[token]);
+}
+
+/**
+ * Milliseconds per duration unit
+ */
+const DURATION_UNITS = {
+  m: 60 * 1000,
+  h: 60 * 60 * 1000,
+  d: 24 * 60 * 60 * 1000,
+  w: 7 * 24 * 60 * 60 * 1000
+};
+
+/**
+ * Parse a duration like "30m", "12h", "7d" or "2w" (a bare number means days)
+ * @param {String|Number} duration - Duration to parse
+ * @returns {Number|null} - Milliseconds, or null if it isn't a duration
+ */
+function parseDuration(duration) {
+  if (typeof duration === 'number') {
+    return duration >= 0 ? duration * DURATION_UNITS.d : null;
+  }
+
+  const match = String(duration).trim().match(/^(\d+(?:\.\d+)?)\s*([mhdw])$/);
+  return match ? Number(match[1]) * DURATION_UNITS[match[2]] : null;
+}
+
+module.exports = {
+  formatDate,
+  parseDuration
+};
@@ -0,0 +1,57 @@
+/**
+ * Build a simple line diff between two versions of a file 💅
+ * Unchanged lines are skipped, so only the lines that would change are shown.
+ * @param {String} before - Original content
+ * @param {String} after - Updated content
+ * @param {String} file - File name for the diff header
+ * @returns {String} - Diff text, or an empty string if nothing changes
+ */
+function diffLines(before, after, file) {
+  if (before === after) {
+    return '';
+  }
+
+  const a = before ? before.split('\n') : [];
+  const b = after.split('\n');
+
+  // Longest common subsequence table, built from the end so we can walk forwards
+  const lcs = Array.from({ length: a.length + 1 }, () => new Array(b.length + 1).fill(0));
+  for (let i = a.length - 1; i >= 0; i--) {
+    for (let j = b.length - 1; j >= 0; j--) {
+      lcs[i][j] = a[i] === b[j] ? lcs[i + 1][j + 1] + 1 : Math.max(lcs[i + 1][j], lcs[i][j + 1]);
+    }
+  }
+
+  const output = [`--- a/${file}`, `+++ b/${file}`];
+  let i = 0;
+  let j = 0;
+  let inHunk = false;
+
+  while (i < a.length || j < b.length) {
+    if (i < a.length && j < b.length && a[i] === b[j]) {
+      inHunk = false;
+      i++;
+      j++;
+      continue;
+    }
+
+    if (!inHunk) {
+      output.push(`@@ line ${j + 1} @@`);
+      inHunk = true;
+    }
+
+    if (j < b.length && (i >= a.length || lcs[i][j + 1] > lcs[i + 1][j])) {
+      output.push(`+${b[j]}`);
+      j++;
+    } else {
+      output.push(`-${a[i]}`);
+      i++;
+    }
+  }
+
+  return output.join('\n');
+}
+
+module.exports = {
+  diffLines
+};
@@ -0,0 +1,70 @@
+/**
+ * Turn a gitignore-style glob into a regular expression
+ *
+ * - `*` matches anything but a slash, `?` one character, `**` any number of directories
+ * - A pattern without a slash (like `*.md`) matches at any depth
+ * - A leading `/` anchors the pattern to the repository root
+ * - Matching a directory (`docs` or `docs/`) matches everything inside it
+ * @param {String} pattern - Glob pattern
+ * @returns {RegExp}
+ */
+function globToRegExp(pattern) {
+  let glob = pattern.trim().replace(/\/+$/, '');
+  const anchored = glob.startsWith('/') || glob.includes('/');
+  glob = glob.replace(/^\/+/, '');
+
+  let source = '';
+  for (let i = 0; i < glob.length; i++) {
+    const char = glob[i];
+
+    if (char === '*' && glob[i + 1] === '*') {
+      // "**/" is zero or more directories, a trailing "**" is everything below
+      if (glob[i + 2] === '/') {
+        source += '(?:.*/)?';
+        i += 2;
+      } else {
+        source += '.*';
+        i += 1;
+      }
+    } else if (char === '*') {
+      source += '[^/]*';
+    } else if (char === '?') {
+      source += '[^/]';
+    } else {
+      source += char.replace(/[.+^${}()|[\]\\]/g, '\\$&');
+    }
+  }
+
+  return new RegExp(`^${anchored ? '' : '(?:.*/)?'}${source}(?:/.*)?$`);
+}
+
+/**
+ * Build a matcher for a list of gitignore-style patterns
+ * Patterns starting with `!` bring back files an earlier pattern matched - the last match wins.
+ * @param {Array<String>} patterns - Glob patterns
+ * @returns {Function} - (file) => Boolean
+ */
+function createPathMatcher(patterns) {
+  const rules = (patterns || [])
+    .filter(pattern => pattern && pattern.trim())
+    .map(pattern => {
+      const negated = pattern.trim().startsWith('!');
+      return { negated, regex: globToRegExp(negated ? pattern.trim().substring(1) : pattern) };
+    });
+
+  return file => {
+    const path = file.replace(/^\.?\//, '');
+    let matched = false;
+    for (const rule of rules) {
+      if (rule.regex.test(path)) {
+        matched = !rule.negated;
+      }
+    }
+    return matched;
+  };
+}
+
+module.exports = {
+  globToRegExp,
+  createPathMatcher
+};
@@ -0,0 +1,81 @@
+const http = require('http');
+const https = require('https');
+
+/**
+ * Make a JSON HTTP request using Node's built-in http/https modules
+ * We keep this tiny on purpose so providers don't need extra dependencies 💅
+ * @param {String} method - HTTP method (GET, POST, PUT, DELETE)
+ * @param {String} url - Fully qualified URL
+ * @param {Object} options - Request options
+ * @param {Object} options.headers - Extra request headers
+ * @param {Object} options.body - JSON body to send (optional)
+ * @param {Boolean} options.raw - Return the response body as a string instead of parsed JSON
+ * @param {Number} options.timeout - Give up after this many ms without a response (optional)
+ * @returns {Promise<Object>} - Object with status, headers and data
+ * @throws {Error} - With a status property when the response is not 2xx
+ */
+function requestJson(method, url, options = {}) {
+  const target = new URL(url);
+  const transport = target.protocol === 'http:' ? http : https;
+  const payload = options.body !== undefined ? JSON.stringify(options.body) : null;
+
+  const headers = {
+    'Accept': 'application/json',
+    'User-Agent': 'release-boss',
+    ...(options.headers || {})
+  };
+
+  if (payload) {
+    headers['Content-Type'] = 'application/json';
+    headers['Content-Length'] = Buffer.byteLength(payload);
+  }
+
+  return new Promise((resolve, reject) => {
+    const req = transport.request(target, { method, headers }, res => {
+      const chunks = [];
+      res.on('data', chunk => chunks.push(chunk));
+      res.on('end', () => {
+        const text = Buffer.concat(chunks).toString('utf8');
+        let data = text;
+
+        if (!options.raw && text) {
+          try {
+            data = JSON.parse(text);
+          } catch {
+            // Not JSON - keep the raw text so callers can still inspect it
+          }
+        }
+
+        if (res.statusCode >= 400) {
+          const message = (data && data.message) || (data && data.error) || text || res.statusMessage;
+          const error = new Error(`${method} ${target.pathname} failed with ${res.statusCode}: ${typeof message === 'string' ? message : JSON.stringify(message)}`);
+          error.status = res.statusCode;
+          error.response = { status: res.statusCode, headers: res.headers, data };
+          reject(error);
+          return;
+        }
+
+        resolve({ status: res.statusCode, headers: res.headers, data });
+      });
+    });
+
+    req.on('error', reject);
+
+    if (options.timeout) {
+      req.setTimeout(options.timeout, () => {
+        const error = new Error(`${method} ${target.pathname} timed out after ${options.timeout}ms`);
+        error.code = 'ETIMEDOUT';
+        req.destroy(error);
+      });
+    }
+
+    if (payload) {
+      req.write(payload);
+    }
+    req.end();
+  });
+}
+
+module.exports = {
+  requestJson
+};
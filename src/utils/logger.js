@@ -0,0 +1,180 @@
+const core = require('@actions/core');
+
+/**
+ * Log levels, quietest last
+ */
+const LOG_LEVELS = ['debug', 'info', 'warn', 'error'];
+
+/**
+ * Formats the logger writes
+ * human keeps the chatty emoji lines, json prints one record per line to stderr for log pipelines
+ */
+const LOG_FORMATS = ['human', 'json'];
+
+// Emoji (and the joiners and skin tones that go with them) make noise in log search
+const EMOJI_PATTERN = /[\p{Extended_Pictographic}\u{1F3FB}-\u{1F3FF}\u{FE0F}\u{200D}]/gu;
+
+const settings = {
+  level: 'info',
+  format: 'human',
+  context: {}
+};
+
+/**
+ * Set the level and format for the rest of the run
+ * @param {Object} options - Options
+ * @param {String} [options.level] - Lowest level that gets printed (debug, info, warn, error)
+ * @param {String} [options.format] - human or json
+ */
+function configureLogger({ level, format } = {}) {
+  if (level !== undefined) {
+    if (!LOG_LEVELS.includes(level)) {
+      throw new Error(`Log level must be one of: ${LOG_LEVELS.join(', ')}`);
+    }
+    settings.level = level;
+  }
+  if (format !== undefined) {
+    if (!LOG_FORMATS.includes(format)) {
+      throw new Error(`Log format must be one of: ${LOG_FORMATS.join(', ')}`);
+    }
+    settings.format = format;
+  }
+}
+
+/**
+ * Run fn with the level raised to at least the given one, then put the level back
+ * Commands whose own output has to stay clean (validate's problem list, --version-only) use this
+ * to quieten the workflow's chatter - a level that's already quieter is left alone.
+ * @param {String} level - Lowest level that gets printed while fn runs
+ * @param {Function} fn - Sync or async function
+ * @returns {Promise<*>} - Whatever fn returns
+ */
+async function withLogLevel(level, fn) {
+  const previous = settings.level;
+  if (LOG_LEVELS.indexOf(level) > LOG_LEVELS.indexOf(previous)) {
+    settings.level = level;
+  }
+  try {
+    return await fn();
+  } finally {
+    settings.level = previous;
+  }
+}
+
+/**
+ * Add fields to every record from here on - null or undefined drops a field
+ * @param {Object} fields - e.g. { branch, version, pr, package }
+ */
+function setLogContext(fields) {
+  for (const [key, value] of Object.entries(fields)) {
+    if (value === null || value === undefined) {
+      delete settings.context[key];
+    } else {
+      settings.context[key] = value;
+    }
+  }
+}
+
+/**
+ * Forget the context fields, e.g. at the start of a new run
+ */
+function resetLogContext() {
+  settings.context = {};
+}
+
+/**
+ * Check whether a level would get printed
+ * @param {String} level - Log level
+ * @returns {Boolean} - True when the level is at or above the configured one
+ */
+function isLevelEnabled(level) {
+  return LOG_LEVELS.indexOf(level) >= LOG_LEVELS.indexOf(settings.level);
+}
+
+/**
+ * Take the emoji out of a message for the json format
+ * @param {String} message - Human message
+ * @returns {String} - Message without emoji or the spaces they leave behind
+ */
+function stripEmoji(message) {
+  return message
+    .replace(EMOJI_PATTERN, '')
+    .replace(/[ \t]{2,}/g, ' ')
+    .replace(/ +$/gm, '')
+    .trim();
+}
+
+/**
+ * Write one record
+ * @param {String} level - Log level
+ * @param {*} message - Message (anything that's not a string is stringified)
+ * @param {Object} [fields] - Extra fields for the json format - human lines only show the message
+ */
+function write(level, message, fields = {}) {
+  if (!isLevelEnabled(level)) {
+    return;
+  }
+
+  const text = message instanceof Error ? message.message : String(message);
+
+  if (settings.format === 'json') {
+    // Records go to stderr, so stdout stays free for command output (a changelog, --output json)
+    console.error(JSON.stringify({
+      timestamp: new Date().toISOString(),
+      level,
+      message: stripEmoji(text),
+      ...settings.context,
+      ...fields
+    }));
+    return;
+  }
+
+  // Warnings and errors become annotations when running as an action
+  const inActions = process.env.GITHUB_ACTIONS === 'true';
+  if (level === 'warn') {
+    inActions ? core.warning(text) : console.error(text);
+  } else if (level === 'error') {
+    inActions ? core.error(text) : console.error(text);
+  } else {
+    console.log(text);
+  }
+}
+
+/**
+ * Leveled logger everything in release-boss prints through
+ */
+const logger = {
+  debug: (message, fields) => write('debug', message, fields),
+  info: (message, fields) => write('info', message, fields),
+  warn: (message, fields) => write('warn', message, fields),
+  error: (message, fields) => write('error', message, fields),
+
+  /**
+   * Start a collapsible group in GitHub Actions log output (human format only)
+   * @param {String} title - Group title
+   */
+  startGroup(title) {
+    if (settings.format === 'human' && isLevelEnabled('info')) {
+      console.log(`::group::${title}`);
+    }
+  },
+
+  /**
+   * End a collapsible group in GitHub Actions log output (human format only)
+   */
+  endGroup() {
+    if (settings.format === 'human' && isLevelEnabled('info')) {
+      console.log('::endgroup::');
+    }
+  }
+};
+
+module.exports = {
+  logger,
+  configureLogger,
+  withLogLevel,
+  setLogContext,
+  resetLogContext,
+  LOG_LEVELS,
+  LOG_FORMATS
+};
@@ -0,0 +1,94 @@
+const { formatDate } = require('./date');
+
+/**
+ * Fields available to commit message and PR templates
+ */
+const MESSAGE_FIELDS = ['Version', 'PreviousVersion', 'Date', 'Package'];
+
+/**
+ * Matches a template action like {{.Version}} or {{ .Version }}
+ */
+const ACTION = /\{\{\s*(.*?)\s*\}\}/g;
+
+/**
+ * Check a message template, Go text/template style ({{.Version}}, {{.PreviousVersion}}, {{.Date}}, {{.Package}})
+ * Only field lookups are supported - no ifs, ranges or pipelines.
+ * @param {String} template - Template to check
+ * @param {String} name - Config key the template comes from, for the error message
+ * @returns {Array<String>} - Fields the template uses
+ * @throws {Error} - If the template is malformed or uses a field we don't have
+ */
+function parseMessageTemplate(template, name = 'template') {
+  if (typeof template !== 'string') {
+    throw new Error(`${name} must be a string`);
+  }
+
+  const fields = [];
+  for (const [action, body] of template.matchAll(ACTION)) {
+    const match = body.match(/^\.(\w+)$/);
+    if (!match) {
+      throw new Error(`${name} has an unsupported action ${action} - only fields like {{.Version}} are supported`);
+    }
+    if (!MESSAGE_FIELDS.includes(match[1])) {
+      throw new Error(`${name} uses unknown field .${match[1]} - available: ${MESSAGE_FIELDS.map(field => `.${field}`).join(', ')}`);
+    }
+    fields.push(match[1]);
+  }
+
+  const leftover = template.replace(ACTION, '');
+  if (leftover.includes('{{') || leftover.includes('}}')) {
+    throw new Error(`${name} has an unclosed action - every {{ needs a matching }}`);
+  }
+
+  return fields;
+}
+
+/**
+ * Render a message template
+ * The older {version} and {package} placeholders still work too.
+ * @param {String} template - Template (checked with parseMessageTemplate)
+ * @param {Object} fields - Field values from buildMessageFields
+ * @returns {String} - Rendered message
+ */
+function renderMessageTemplate(template, fields) {
+  return template
+    .replace(ACTION, (action, body) => {
+      const match = body.match(/^\.(\w+)$/);
+      return match && Object.prototype.hasOwnProperty.call(fields, match[1]) ? fields[match[1]] : action;
+    })
+    .replace(/\{version\}/g, fields.Version)
+    .replace(/\{package\}/g, fields.Package);
+}
+
+/**
+ * Build the template fields for a release
+ * @param {String} version - Version being released
+ * @param {Object} config - Release Boss configuration (previousVersion and packageName are used when set)
+ * @param {Date} date - Release date (defaults to now)
+ * @returns {Object} - Field name → value
+ */
+function buildMessageFields(version, config = {}, date = new Date()) {
+  return {
+    Version: version,
+    PreviousVersion: config.previousVersion || '',
+    Date: formatDate(date, 'YYYY-MM-DD'),
+    Package: config.packageName || ''
+  };
+}
+
+/**
+ * Check whether a template mentions the version, in either placeholder style
+ * @param {String} template - Template to check
+ * @returns {Boolean}
+ */
+function hasVersionField(template) {
+  return template.includes('{version}') || /\{\{\s*\.Version\s*\}\}/.test(template);
+}
+
+module.exports = {
+  MESSAGE_FIELDS,
+  parseMessageTemplate,
+  renderMessageTemplate,
+  buildMessageFields,
+  hasVersionField
+};
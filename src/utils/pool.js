@@ -0,0 +1,44 @@
+/**
+ * How many per-commit requests run at once when the config doesn't say
+ */
+const DEFAULT_FETCH_CONCURRENCY = 8;
+
+/**
+ * Map over items with at most `concurrency` calls in flight
+ *
+ * Results come back in the same order as the items, however the calls finish,
+ * so whatever is built from them doesn't depend on network timing. The first
+ * error rejects the whole map once the calls already running have settled.
+ * @param {Array} items - Items to map over
+ * @param {Number} concurrency - Maximum calls in flight (at least 1)
+ * @param {Function} fn - Async mapper, called with (item, index)
+ * @returns {Promise<Array>} - Results, in item order
+ */
+async function mapWithConcurrency(items, concurrency, fn) {
+  const results = new Array(items.length);
+  const workerCount = Math.max(1, Math.min(concurrency || DEFAULT_FETCH_CONCURRENCY, items.length));
+  let next = 0;
+  let failure = null;
+
+  const worker = async () => {
+    while (next < items.length && !failure) {
+      const index = next++;
+      try {
+        results[index] = await fn(items[index], index);
+      } catch (error) {
+        failure = failure || error;
+      }
+    }
+  };
+
+  await Promise.all(Array.from({ length: workerCount }, worker));
+  if (failure) {
+    throw failure;
+  }
+  return results;
+}
+
+module.exports = {
+  DEFAULT_FETCH_CONCURRENCY,
+  mapWithConcurrency
+};
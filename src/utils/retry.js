@@ -0,0 +1,152 @@
+const { logger } = require('./logger');
+
+/**
+ * Retry policy used when the config doesn't override it
+ * Five attempts in total, doubling from one second, with up to 20% jitter.
+ */
+const DEFAULT_RETRY_POLICY = {
+  maxAttempts: 5,      // Total attempts, including the first one
+  baseDelay: 1000,     // Delay before the first retry (ms) - doubles every attempt
+  maxDelay: 30000,     // Cap for any wait (ms) - the backoff, Retry-After and X-RateLimit-Reset alike
+  jitter: 0.2,         // Random extra delay, as a fraction of the delay
+  timeout: 30000       // Request timeout (ms) for providers that make their own HTTP calls
+};
+
+/**
+ * Network error codes worth another try
+ */
+const RETRYABLE_ERROR_CODES = ['ETIMEDOUT', 'ECONNRESET', 'ECONNREFUSED', 'ECONNABORTED', 'EPIPE', 'EAI_AGAIN', 'ESOCKETTIMEDOUT'];
+
+/**
+ * Methods that are safe to send twice
+ * A second PATCH writes the same fields again, so it's no worse than the first.
+ */
+const IDEMPOTENT_METHODS = ['GET', 'HEAD', 'PUT', 'PATCH', 'DELETE'];
+
+/**
+ * Failures that mean a POST never reached the API, so sending it again can't create anything twice
+ * A gateway (502/503/504) or a refused connection sits in front of the API - a 500 or a timeout
+ * may have happened after the PR, tag or release was already created.
+ */
+const UNSENT_STATUSES = [502, 503, 504];
+const UNSENT_ERROR_CODES = ['ECONNREFUSED', 'EAI_AGAIN'];
+
+/**
+ * Build the retry policy from config
+ * @param {Object} config - Release Boss configuration
+ * @returns {Object} - Retry policy
+ */
+function getRetryPolicy(config) {
+  return {
+    ...DEFAULT_RETRY_POLICY,
+    ...(config.retry || {})
+  };
+}
+
+/**
+ * Read a response header from an API error (header names are case-insensitive)
+ * @param {Error} error - Error thrown by the API client
+ * @param {String} name - Lower-case header name
+ * @returns {String|undefined}
+ */
+function getErrorHeader(error, name) {
+  const headers = (error.response && error.response.headers) || {};
+  const key = Object.keys(headers).find(header => header.toLowerCase() === name);
+  return key !== undefined ? headers[key] : undefined;
+}
+
+/**
+ * Check whether a 403 is a rate limit rather than a real permission problem
+ * @param {Error} error - Error thrown by the API client
+ * @returns {Boolean}
+ */
+function isRateLimited(error) {
+  return getErrorHeader(error, 'retry-after') !== undefined ||
+    getErrorHeader(error, 'x-ratelimit-remaining') === '0' ||
+    /rate limit/i.test(error.message || '');
+}
+
+/**
+ * Check whether a failed request is worth retrying
+ * Rate limits always are - the API turned the request away before doing anything. Every server
+ * error and network hiccup is for idempotent methods (GET, HEAD, PUT, PATCH, DELETE); a POST only
+ * gets another go when it never got through (a 502/503/504 or a refused connection). Validation
+ * errors (422) and friends never are.
+ * @param {Error} error - Error thrown by the API client
+ * @param {String} method - HTTP method of the request
+ * @returns {Boolean}
+ */
+function isRetryableError(error, method = 'GET') {
+  const status = error.status || (error.response && error.response.status);
+  if (status === 429 || (status === 403 && isRateLimited(error))) {
+    return true;
+  }
+
+  if (!IDEMPOTENT_METHODS.includes(method.toUpperCase())) {
+    return UNSENT_STATUSES.includes(status) || UNSENT_ERROR_CODES.includes(error.code);
+  }
+
+  if (error.code && RETRYABLE_ERROR_CODES.includes(error.code)) {
+    return true;
+  }
+
+  return status >= 500;
+}
+
+/**
+ * Work out how long to wait before the next attempt
+ * Retry-After and X-RateLimit-Reset win over the exponential backoff when the API sends them,
+ * but no wait is longer than maxDelay - a reset an hour away would stall the job for an hour.
+ * @param {Error} error - Error from the failed attempt
+ * @param {Number} attempt - Attempt that just failed (1-based)
+ * @param {Object} policy - Retry policy
+ * @param {Function} random - Random number source (for tests)
+ * @returns {Number} - Delay in ms
+ */
+function getRetryDelay(error, attempt, policy, random = Math.random) {
+  const retryAfter = Number(getErrorHeader(error, 'retry-after'));
+  if (retryAfter > 0) {
+    return Math.min(retryAfter * 1000, policy.maxDelay);
+  }
+
+  const reset = Number(getErrorHeader(error, 'x-ratelimit-reset'));
+  if (reset > 0 && getErrorHeader(error, 'x-ratelimit-remaining') === '0') {
+    return Math.min(Math.max(reset * 1000 - Date.now(), 0) + 1000, policy.maxDelay);
+  }
+
+  const delay = Math.min(policy.baseDelay * Math.pow(2, attempt - 1), policy.maxDelay);
+  return Math.round(delay + delay * policy.jitter * random());
+}
+
+/**
+ * Run an API call, retrying transient failures
+ * @param {Function} fn - Async function making the call
+ * @param {Object} policy - Retry policy (from getRetryPolicy)
+ * @param {String} label - What's being called, as "METHOD path" - for the logs, and the method decides what's retried
+ * @param {Function} sleep - Wait function (for tests)
+ * @returns {Promise<*>} - Whatever fn resolves to
+ */
+async function withRetry(fn, policy, label, sleep = ms => new Promise(resolve => setTimeout(resolve, ms))) {
+  const method = label.split(' ')[0];
+  for (let attempt = 1; ; attempt++) {
+    try {
+      return await fn();
+    } catch (error) {
+      if (attempt >= policy.maxAttempts || !isRetryableError(error, method)) {
+        throw error;
+      }
+
+      const delay = getRetryDelay(error, attempt, policy);
+      logger.info(`⏳ ${label} failed (${error.status || error.code || error.message}) - retry ${attempt} of ${policy.maxAttempts - 1} in ${delay}ms`);
+      await sleep(delay);
+    }
+  }
+}
+
+module.exports = {
+  DEFAULT_RETRY_POLICY,
+  getRetryPolicy,
+  isRetryableError,
+  getRetryDelay,
+  withRetry
+};
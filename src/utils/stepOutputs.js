@@ -0,0 +1,63 @@
+const crypto = require('crypto');
+const fs = require('fs');
+
+/**
+ * Pick the step outputs downstream jobs care about out of a release result
+ * @param {Object} result - ReleaseResult from ReleaseBoss.run()
+ * @returns {Object} - { version, previous_version, tag, pr_url, released, changelog } as strings
+ */
+function getStepOutputs(result) {
+  return {
+    version: result.nextVersion || '',
+    previous_version: result.previousVersion || '',
+    tag: result.releaseTag || '',
+    pr_url: result.prUrl || '',
+    // A dry-run release run didn't actually tag anything
+    released: result.runType === 'release' && !result.dryRun ? 'true' : 'false',
+    changelog: result.changelog || ''
+  };
+}
+
+/**
+ * Format outputs in the GITHUB_OUTPUT file syntax
+ * Single-line values are name=value; anything with a newline uses the heredoc form
+ * (name<<DELIMITER ... DELIMITER) with a delimiter that can't appear in the value.
+ * @param {Object} outputs - Output name → string value
+ * @param {Function} createDelimiter - Delimiter factory (for tests)
+ * @returns {String}
+ */
+function formatStepOutputs(outputs, createDelimiter = () => `ghadelimiter_${crypto.randomUUID()}`) {
+  return Object.entries(outputs).map(([name, value]) => {
+    const text = String(value);
+    if (!text.includes('\n') && !text.includes('\r')) {
+      return `${name}=${text}\n`;
+    }
+
+    let delimiter = createDelimiter();
+    while (text.includes(delimiter)) {
+      delimiter = createDelimiter();
+    }
+    return `${name}<<${delimiter}\n${text.replace(/\n$/, '')}\n${delimiter}\n`;
+  }).join('');
+}
+
+/**
+ * Append outputs to the step's GITHUB_OUTPUT file, when there is one
+ * Outside GitHub Actions (or on older runners) this does nothing.
+ * @param {Object} outputs - Output name → string value
+ * @param {Object} env - Environment variables (default: process.env)
+ * @returns {Boolean} - Whether the outputs were written
+ */
+function writeStepOutputs(outputs, env = process.env) {
+  if (!env.GITHUB_OUTPUT) {
+    return false;
+  }
+  fs.appendFileSync(env.GITHUB_OUTPUT, formatStepOutputs(outputs), 'utf8');
+  return true;
+}
+
+module.exports = {
+  getStepOutputs,
+  formatStepOutputs,
+  writeStepOutputs
+};
@@ -0,0 +1,124 @@
+const semver = require('semver');
+
+/**
+ * Get the prefix put in front of versions in tag names
+ * tagPrefix wins when it's set (even to ''), otherwise versionTagPrefix picks 'v' or nothing.
+ * Monorepo packages add their namespace (e.g. "api/") in front of that.
+ * @param {Object} config - Release Boss configuration
+ * @returns {String} - Tag prefix (e.g. "v", "release-" or "api/v")
+ */
+function getTagPrefix(config) {
+  const namespace = config.tagNamespace || '';
+  return `${namespace}${getVersionPrefix(config)}`;
+}
+
+/**
+ * Get the prefix that goes right before the version, without any package namespace
+ * @param {Object} config - Release Boss configuration
+ * @returns {String}
+ */
+function getVersionPrefix(config) {
+  if (typeof config.tagPrefix === 'string') {
+    return config.tagPrefix;
+  }
+  return config.versionTagPrefix !== false ? 'v' : '';
+}
+
+/**
+ * Get the primary tag name for a release
+ * @param {String} version - Version being released
+ * @param {Object} config - Release Boss configuration
+ * @returns {String} - Tag name (e.g. "v1.2.0" or "api/v1.2.0")
+ */
+function getReleaseTagName(version, config) {
+  return `${getTagPrefix(config)}${version}`;
+}
+
+/**
+ * Get the additional alias tags (latest, major, major.minor) for a release
+ * Prereleases never move the aliases - those always point at the latest stable release - and
+ * maintenance releases never move latest, which belongs to the main line.
+ * @param {String} version - Version being released
+ * @param {Object} config - Release Boss configuration
+ * @returns {Array<String>} - Alias tag names
+ */
+function getAdditionalTagNames(version, config) {
+  if (semver.prerelease(version)) {
+    return [];
+  }
+
+  const prefix = getTagPrefix(config);
+  const [major, minor = '0'] = version.split('.');
+  const tags = [];
+
+  if (config.tagLatest !== false && !config.maintenanceRange) {
+    tags.push(`${config.tagNamespace || ''}latest`);
+  }
+
+  if (config.tagMajor === true) {
+    tags.push(`${prefix}${major}`);
+  }
+
+  if (config.tagMinor === true) {
+    tags.push(`${prefix}${major}.${minor}`);
+  }
+
+  return tags;
+}
+
+/**
+ * Extract the version from a tag name
+ * Tags outside this config's namespace are ignored, so package tags never leak into each other.
+ * With tagPattern set, the rest of the tag has to match it - the version is the "version" group,
+ * the first group, or whatever is left once the prefix is stripped. With tagPrefix set, the tag has
+ * to start with exactly that prefix. Otherwise an optional 'v' is accepted, like always.
+ * @param {String} tagName - Tag name
+ * @param {Object} config - Release Boss configuration
+ * @returns {String|null} - Version, or null if the tag isn't a release tag for this config
+ */
+function parseVersionFromTag(tagName, config) {
+  const namespace = config.tagNamespace || '';
+  if (!tagName.startsWith(namespace)) {
+    return null;
+  }
+
+  const name = tagName.substring(namespace.length);
+  const prefix = getVersionPrefix(config);
+  let version;
+
+  if (config.tagPattern) {
+    const match = name.match(new RegExp(config.tagPattern));
+    if (!match) {
+      return null;
+    }
+    const captured = match.groups && match.groups.version !== undefined ? match.groups.version : match[1];
+    version = captured !== undefined ? captured : stripPrefix(name, prefix);
+  } else if (typeof config.tagPrefix === 'string') {
+    if (!name.startsWith(prefix)) {
+      return null;
+    }
+    version = name.substring(prefix.length);
+  } else {
+    version = name.replace(/^v/, '');
+  }
+
+  // semver.valid would let a stray 'v' through, which an explicit prefix shouldn't
+  return /^\d/.test(version) && semver.valid(version) ? version : null;
+}
+
+/**
+ * Strip a prefix from a tag name if it's there
+ * @param {String} name - Tag name
+ * @param {String} prefix - Prefix to strip
+ * @returns {String}
+ */
+function stripPrefix(name, prefix) {
+  return prefix && name.startsWith(prefix) ? name.substring(prefix.length) : name;
+}
+
+module.exports = {
+  getTagPrefix,
+  getReleaseTagName,
+  getAdditionalTagNames,
+  parseVersionFromTag
+};
@@ -0,0 +1,107 @@
+/**
+ * Tests for author credits
+ *
+ * These tests validate Co-authored-by parsing, how emails become usernames and
+ * the "(@alice, @bob)" credits added to changelog entries with showAuthors.
+ */
+
+/* global jest, describe, test, expect */
+
+const { extractCoAuthors, resolveCommitAuthors } = require('../src/core/authors');
+const { analyzeCommits } = require('../src/core/commitAnalyzer');
+const { generateChangelog } = require('../src/core/changelogGenerator');
+const { GitHubProvider } = require('../src/providers/githubProvider');
+
+const config = {
+  releaseBranch: 'release',
+  mergeBranch: 'main',
+  changelogSections: [
+    { type: 'feat', section: 'Features', hidden: false },
+    { type: 'fix', section: 'Bug Fixes', hidden: false }
+  ]
+};
+
+const pairedMessage = [
+  'feat: pair programmed thing',
+  '',
+  'Co-authored-by: Bob Builder <bob@example.com>',
+  'co-authored-by: Alice <alice@example.com>',
+  'Co-authored-by: Carol <12345+carol@users.noreply.github.com>',
+  'Co-authored-by: Bob Again <BOB@example.com>'
+].join('\n');
+
+describe('extractCoAuthors', () => {
+  test('reads every trailer once', () => {
+    expect(extractCoAuthors(pairedMessage)).toEqual([
+      { name: 'Bob Builder', email: 'bob@example.com' },
+      { name: 'Alice', email: 'alice@example.com' },
+      { name: 'Carol', email: '12345+carol@users.noreply.github.com' }
+    ]);
+    expect(extractCoAuthors('fix: solo')).toEqual([]);
+  });
+});
+
+describe('resolveCommitAuthors', () => {
+  test('puts the author first, dedupes them and falls back to names', async () => {
+    const commits = [
+      { hash: 'a', author: 'alice', username: 'alice', authorName: 'Alice', authorEmail: 'alice@example.com', coAuthors: extractCoAuthors(pairedMessage) },
+      { hash: 'b', author: 'Dave', username: null, authorName: 'Dave', authorEmail: 'dave@example.com', coAuthors: [] }
+    ];
+    const provider = { resolveUsername: jest.fn(async email => (email.includes('carol') ? 'carol' : null)) };
+
+    const credits = await resolveCommitAuthors(commits, provider);
+
+    expect(credits.get('a')).toEqual(['@alice', 'Bob Builder', '@carol']);
+    expect(credits.get('b')).toEqual(['Dave']);
+    // alice's email was already known from her own commit
+    expect(provider.resolveUsername.mock.calls.map(call => call[0])).not.toContain('alice@example.com');
+  });
+});
+
+describe('GitHubProvider.resolveUsername', () => {
+  test('uses noreply addresses, then searches public emails', async () => {
+    const search = jest.fn(async ({ q }) => ({
+      data: q.startsWith('bob@') ? { total_count: 1, items: [{ login: 'bobthebuilder' }] } : { total_count: 0, items: [] }
+    }));
+    const provider = new GitHubProvider({ rest: { search: { users: search } } }, { repo: { owner: 'owner', repo: 'repo' } });
+
+    await expect(provider.resolveUsername('12345+carol@users.noreply.github.com')).resolves.toBe('carol');
+    await expect(provider.resolveUsername('bob@example.com')).resolves.toBe('bobthebuilder');
+    await expect(provider.resolveUsername('bob@example.com')).resolves.toBe('bobthebuilder');
+    await expect(provider.resolveUsername('nobody@example.com')).resolves.toBeNull();
+    expect(search).toHaveBeenCalledTimes(2);
+  });
+});
+
+describe('showAuthors', () => {
+  const provider = {
+    name: 'github',
+    compareCommits: async () => [{
+      sha: '1'.repeat(40),
+      message: pairedMessage,
+      author: 'alice',
+      authorName: 'Alice',
+      authorEmail: 'alice@example.com',
+      username: 'alice',
+      date: '2024-01-01T00:00:00Z',
+      url: 'https://github.com/owner/repo/commit/1'
+    }],
+    resolveUsername: async email => (email === 'bob@example.com' ? 'bob' : null),
+    compareUrl: (from, to) => `https://github.com/owner/repo/compare/${from}...${to}`,
+    pullRequestUrl: number => `https://github.com/owner/repo/pull/${number}`
+  };
+
+  test('credits every author after the entry', async () => {
+    const commits = await analyzeCommits(provider, config);
+    const changelog = await generateChangelog(commits, '1.1.0', '1.0.0', provider, { ...config, showAuthors: true });
+
+    expect(changelog).toContain('* pair programmed thing ([1111111](https://github.com/owner/repo/commit/1)) (@alice, @bob, Carol)');
+  });
+
+  test('is off by default', async () => {
+    const commits = await analyzeCommits(provider, config);
+    const changelog = await generateChangelog(commits, '1.1.0', '1.0.0', provider, config);
+
+    expect(changelog).toContain('* pair programmed thing ([1111111](https://github.com/owner/repo/commit/1))\n');
+  });
+});
@@ -0,0 +1,243 @@
+/**
+ * Tests for auto-merging the release PR and finalizing merged releases
+ *
+ * These tests validate that autoMerge waits for the release PR's checks, merges
+ * it with the configured method, and tags the merge commit it produced - and
+ * that `finalize` tags a PR that was merged some other way.
+ */
+
+/* global describe, test, expect, jest, beforeEach, afterEach */
+
+const fs = require('fs');
+const os = require('os');
+const path = require('path');
+
+const { waitForMergeable, mergeReleasePR } = require('../src/core/autoMerge');
+const { GitHubProvider } = require('../src/providers/githubProvider');
+const { tagRelease } = require('../src/github/prManager');
+const { ReleaseBoss, resolveConfig } = require('../src/releaseBoss');
+const { parseArgs } = require('../src/cli');
+
+const MERGE_SHA = 'f'.repeat(40);
+const HEAD_SHA = 'e'.repeat(40);
+
+/**
+ * Fake clock for waitForMergeable - sleeping just moves time on
+ */
+function createClock() {
+  let time = 0;
+  return { now: () => time, sleep: async ms => { time += ms; } };
+}
+
+/**
+ * Provider whose PR goes through the given merge statuses, one per check
+ */
+function createStatusProvider(states) {
+  const statuses = states.map(state => ({ state, reason: state === 'ready' ? null : `it is ${state}`, sha: HEAD_SHA }));
+  return {
+    getPRMergeStatus: jest.fn(async () => statuses.length > 1 ? statuses.shift() : statuses[0]),
+    mergePR: jest.fn(async () => ({ merged: true, sha: MERGE_SHA }))
+  };
+}
+
+describe('waitForMergeable', () => {
+  test('polls while checks are pending', async () => {
+    const provider = createStatusProvider(['pending', 'pending', 'ready']);
+    const status = await waitForMergeable(provider, 7, { timeout: 60 * 1000, interval: 1000, ...createClock() });
+
+    expect(status.state).toBe('ready');
+    expect(provider.getPRMergeStatus).toHaveBeenCalledTimes(3);
+  });
+
+  test('gives up at the timeout', async () => {
+    const provider = createStatusProvider(['pending']);
+    const status = await waitForMergeable(provider, 7, { timeout: 5000, interval: 1000, ...createClock() });
+
+    expect(status.state).toBe('timeout');
+    expect(provider.getPRMergeStatus).toHaveBeenCalledTimes(6);
+  });
+});
+
+describe('mergeReleasePR', () => {
+  test('merges with the configured method once the checks pass', async () => {
+    const provider = createStatusProvider(['pending', 'ready']);
+    const merge = await mergeReleasePR(provider, { mergeMethod: 'squash' }, 7, createClock());
+
+    expect(merge).toEqual({ sha: MERGE_SHA });
+    // The sha pins the merge to the head whose checks passed
+    expect(provider.mergePR).toHaveBeenCalledWith(7, { method: 'squash', sha: HEAD_SHA });
+  });
+
+  test('leaves a blocked PR alone', async () => {
+    const provider = createStatusProvider(['blocked']);
+
+    await expect(mergeReleasePR(provider, {}, 7, createClock())).resolves.toBeNull();
+    expect(provider.mergePR).not.toHaveBeenCalled();
+  });
+});
+
+describe('GitHubProvider.getPRMergeStatus', () => {
+  /**
+   * GitHub provider over a PR in the given mergeable_state, with some check runs on its head
+   */
+  function createGitHub(mergeableState, checkRuns = []) {
+    const octokit = {
+      rest: {
+        pulls: {
+          get: async () => ({ data: { state: 'open', mergeable_state: mergeableState, head: { sha: HEAD_SHA }, base: { ref: 'release' } } })
+        },
+        checks: { listForRef: async () => ({ data: { check_runs: checkRuns } }) },
+        repos: { getCombinedStatusForRef: async () => ({ data: { statuses: [] } }) }
+      }
+    };
+    return new GitHubProvider(octokit, { repo: { owner: 'owner', repo: 'repo' } });
+  }
+
+  test('a PR branch protection lets through is ready, even with optional checks failing', async () => {
+    await expect(createGitHub('clean').getPRMergeStatus(7)).resolves.toMatchObject({ state: 'ready', sha: HEAD_SHA });
+    await expect(createGitHub('unstable').getPRMergeStatus(7)).resolves.toMatchObject({ state: 'ready' });
+  });
+
+  test('a blocked PR waits on running checks and gives up on failed ones', async () => {
+    const running = createGitHub('blocked', [{ name: 'test', status: 'in_progress', conclusion: null }]);
+    await expect(running.getPRMergeStatus(7)).resolves.toMatchObject({ state: 'pending', reason: 'waiting on test' });
+
+    const failed = createGitHub('blocked', [{ name: 'test', status: 'completed', conclusion: 'failure' }]);
+    await expect(failed.getPRMergeStatus(7)).resolves.toMatchObject({ state: 'blocked', reason: 'checks failed: test' });
+  });
+
+  test('conflicts block the merge', async () => {
+    await expect(createGitHub('dirty').getPRMergeStatus(7)).resolves.toMatchObject({ state: 'blocked' });
+  });
+});
+
+describe('tagRelease at the merge commit', () => {
+  test('tags the given commit, not the head of the release branch, with an annotated tag', async () => {
+    const notFound = Object.assign(new Error('Not Found'), { status: 404 });
+    const octokit = {
+      rest: {
+        git: {
+          getRef: jest.fn(async () => {
+            throw notFound;
+          }),
+          createTag: jest.fn(async () => ({ data: { sha: 't'.repeat(40) } })),
+          createRef: jest.fn(async () => ({}))
+        }
+      }
+    };
+    const context = { repo: { owner: 'owner', repo: 'repo' } };
+
+    const result = await tagRelease(octokit, context, '1.2.0', { releaseBranch: 'release', versionTagPrefix: true }, null, { sha: MERGE_SHA, annotate: true });
+
+    expect(result.sha).toBe(MERGE_SHA);
+    expect(octokit.rest.git.getRef).not.toHaveBeenCalledWith(expect.objectContaining({ ref: 'heads/release' }));
+    expect(octokit.rest.git.createTag).toHaveBeenCalledWith(expect.objectContaining({ tag: 'v1.2.0', object: MERGE_SHA, message: 'Release v1.2.0' }));
+    expect(octokit.rest.git.createRef).toHaveBeenCalledWith(expect.objectContaining({ ref: 'refs/tags/v1.2.0', sha: 't'.repeat(40) }));
+  });
+});
+
+describe('finishing the release', () => {
+  let tmpDir;
+  let cwd;
+
+  beforeEach(() => {
+    cwd = process.cwd();
+    tmpDir = fs.mkdtempSync(path.join(os.tmpdir(), 'release-boss-merge-'));
+    process.chdir(tmpDir);
+  });
+
+  afterEach(() => {
+    process.chdir(cwd);
+    fs.rmSync(tmpDir, { recursive: true, force: true });
+  });
+
+  /**
+   * In-memory provider with one release PR, #7 for 1.2.0
+   */
+  function createProvider(prState) {
+    return {
+      name: 'github',
+      repoUrl: 'https://github.com/owner/repo',
+      compareUrl: (from, to) => `https://github.com/owner/repo/compare/${from}...${to}`,
+      pullRequestUrl: number => `https://github.com/owner/repo/pull/${number}`,
+      compareCommits: async () => [{
+        sha: '1'.repeat(40),
+        message: 'feat: shiny new thing',
+        author: 'kaity',
+        date: '2024-01-01T00:00:00Z',
+        url: 'https://github.com/owner/repo/commit/1'
+      }],
+      listTags: async () => [{ name: 'v1.1.0', sha: 'a'.repeat(40) }],
+      getLatestReleaseTag: async () => null,
+      getFileContent: async () => {
+        throw new Error('not found');
+      },
+      detectMergedReleasePR: async () => null,
+      findOpenReleasePR: async () => null,
+      listPRComments: async () => [],
+      listBranches: async () => [],
+      createReleasePR: jest.fn(async () => ({ prNumber: 7, prUrl: 'https://github.com/owner/repo/pull/7', prStatus: 'open' })),
+      getPR: jest.fn(async () => ({
+        number: 7,
+        state: prState,
+        title: 'chore: release 1.2.0',
+        headBranch: 'staging-v1.2.0',
+        mergeCommitSha: prState === 'merged' ? MERGE_SHA : null
+      })),
+      getPRMergeStatus: async () => ({ state: 'ready', reason: null, sha: HEAD_SHA }),
+      mergePR: jest.fn(async () => ({ merged: true, sha: MERGE_SHA })),
+      createTag: jest.fn(async (version, config, options) => ({ sha: options.sha, tags: ['v1.2.0'] })),
+      deleteBranch: jest.fn(async () => true)
+    };
+  }
+
+  test('autoMerge merges the release PR and tags the merge commit', async () => {
+    const provider = createProvider('open');
+    const releaseBoss = new ReleaseBoss({ versionFiles: [], autoMerge: true, mergeMethod: 'rebase' }, { provider, context: { payload: {} } });
+
+    const result = await releaseBoss.run();
+
+    expect(provider.mergePR).toHaveBeenCalledWith(7, { method: 'rebase', sha: HEAD_SHA });
+    expect(provider.createTag).toHaveBeenCalledWith('1.2.0', expect.any(Object), { sha: MERGE_SHA, annotate: true });
+    expect(provider.deleteBranch).toHaveBeenCalledWith('staging-v1.2.0');
+    expect(result).toMatchObject({ runType: 'release', nextVersion: '1.2.0', releaseCommitSha: MERGE_SHA, prUrl: 'https://github.com/owner/repo/pull/7' });
+    expect(result.changelog).toContain('shiny new thing');
+  });
+
+  test('without autoMerge the release PR is left for a human', async () => {
+    const provider = createProvider('open');
+    const result = await new ReleaseBoss({ versionFiles: [] }, { provider, context: { payload: {} } }).run();
+
+    expect(result.runType).toBe('pr');
+    expect(provider.mergePR).not.toHaveBeenCalled();
+    expect(provider.createTag).not.toHaveBeenCalled();
+  });
+
+  test('finalize tags the merge commit of a PR merged elsewhere', async () => {
+    const provider = createProvider('merged');
+    const result = await new ReleaseBoss({}, { provider, context: { payload: {} } }).finalize({ pr: 7 });
+
+    expect(provider.createTag).toHaveBeenCalledWith('1.2.0', expect.any(Object), { sha: MERGE_SHA, annotate: false });
+    expect(result).toMatchObject({ runType: 'release', releaseTag: 'v1.2.0', prNumber: 7 });
+  });
+
+  test('finalize refuses a PR that is not merged yet', async () => {
+    const provider = createProvider('open');
+
+    await expect(new ReleaseBoss({}, { provider, context: { payload: {} } }).finalize({ pr: 7 })).rejects.toThrow('PR #7 is open, not merged');
+    expect(provider.createTag).not.toHaveBeenCalled();
+  });
+});
+
+describe('autoMerge config', () => {
+  test('mergeMethod and autoMergeTimeout are validated', () => {
+    expect(() => resolveConfig({ mergeMethod: 'octopus' })).toThrow('mergeMethod must be one of: merge, squash, rebase');
+    expect(() => resolveConfig({ autoMergeTimeout: 'soon' })).toThrow('autoMergeTimeout must be a duration');
+    expect(resolveConfig({ autoMerge: true })).toMatchObject({ mergeMethod: 'merge', autoMergeTimeout: '30m' });
+  });
+
+  test('finalize takes a PR number', () => {
+    expect(parseArgs(['finalize', '--pr', '7'])).toEqual({ command: 'finalize', options: { pr: '7' } });
+    expect(() => parseArgs(['finalize', '--pr', 'seven'])).toThrow('--pr must be a PR number');
+  });
+});
@@ -0,0 +1,137 @@
+/**
+ * Tests for working out which branch a run is for
+ *
+ * These tests validate that an explicit branch beats the CI variables, which
+ * beat the provider's guess, that a branch the config doesn't know about
+ * stops the run with a list of the branches it does know, that sourceBranch
+ * and targetBranch stand in for mergeBranch and releaseBranch, and that the
+ * source and target branches have to exist before any commits are read.
+ */
+
+/* global describe, test, expect */
+
+const { resolveBranch, assertConfiguredBranch, assertBranchesExist } = require('../src/utils/branch');
+const { ReleaseBoss, resolveConfig } = require('../src/releaseBoss');
+const { parseArgs } = require('../src/cli');
+
+const config = {
+  mergeBranch: 'main',
+  releaseBranch: 'release',
+  prerelease: [{ channel: 'beta', branch: 'develop' }]
+};
+
+describe('resolveBranch', () => {
+  test('--branch wins over the CI variables', () => {
+    expect(resolveBranch({ branch: 'develop', env: { GITHUB_REF: 'refs/heads/main' } })).toBe('develop');
+    expect(resolveBranch({ branch: 'refs/heads/develop', env: {} })).toBe('develop');
+  });
+
+  test('GITHUB_REF only counts when it is a branch', () => {
+    expect(resolveBranch({ env: { GITHUB_REF: 'refs/heads/release' } })).toBe('release');
+    expect(resolveBranch({ env: { GITHUB_REF: 'refs/tags/v1.2.0', CI_COMMIT_BRANCH: 'main' } })).toBe('main');
+    expect(resolveBranch({ env: { GITHUB_REF: 'refs/pull/7/merge' } })).toBeNull();
+  });
+
+  test('nothing set leaves it to the provider', () => {
+    expect(resolveBranch({ env: {} })).toBeNull();
+  });
+
+  test('--branch takes a value', () => {
+    expect(parseArgs(['release', '--branch', 'release'])).toEqual({ command: 'release', options: { branch: 'release' } });
+  });
+});
+
+describe('assertConfiguredBranch', () => {
+  test('names the rule a configured branch matches', () => {
+    expect(assertConfiguredBranch(config, 'release')).toEqual({ branch: 'release', rule: 'releaseBranch' });
+    expect(assertConfiguredBranch(config, 'develop')).toEqual({ branch: 'develop', rule: 'prerelease beta' });
+  });
+
+  test('lists the configured branches for anything else', () => {
+    expect(() => assertConfiguredBranch(config, 'HEAD'))
+      .toThrow('Branch "HEAD" doesn\'t match any configured branch - release-boss runs on main (mergeBranch), release (releaseBranch), develop (prerelease beta)');
+  });
+});
+
+describe('runs on other branches', () => {
+  /**
+   * Provider that thinks it's on the given branch - any API call fails the test
+   */
+  function createProvider(branch) {
+    return {
+      name: 'github',
+      repoUrl: 'https://github.com/owner/repo',
+      branch,
+      listBranches: async () => {
+        throw new Error('should not get this far');
+      }
+    };
+  }
+
+  test('an unknown branch is a clear error before anything happens', async () => {
+    const releaseBoss = new ReleaseBoss(config, { provider: createProvider('HEAD'), dryRun: true, context: { payload: {} } });
+
+    await expect(releaseBoss.run()).rejects.toThrow('Branch "HEAD" doesn\'t match any configured branch');
+  });
+
+  test('an explicit branch overrides what the provider detected', async () => {
+    const releaseBoss = new ReleaseBoss(config, { provider: createProvider('HEAD'), branch: 'feature/x', dryRun: true, context: { payload: {} } });
+
+    await expect(releaseBoss.run()).rejects.toThrow('Branch "feature/x"');
+    await expect(releaseBoss.finalize({ pr: 7 })).rejects.toThrow('Branch "feature/x"');
+  });
+});
+
+describe('source, staging and target branches', () => {
+  test('sourceBranch and targetBranch are the mergeBranch and releaseBranch', () => {
+    expect(resolveConfig({ sourceBranch: 'main', targetBranch: 'production' })).toMatchObject({
+      mergeBranch: 'main',
+      stagingBranch: 'staging',
+      releaseBranch: 'production'
+    });
+    expect(resolveConfig({ sourceBranch: 'main', mergeBranch: 'main' }).mergeBranch).toBe('main');
+  });
+
+  test('an alias that disagrees with its setting is an error', () => {
+    expect(() => resolveConfig({ targetBranch: 'production', releaseBranch: 'release' }))
+      .toThrow('targetBranch and releaseBranch are the same setting, but one says "production" and the other "release" - keep just one');
+  });
+
+  test('the three have to be different branches', () => {
+    expect(() => resolveConfig({ sourceBranch: 'main', targetBranch: 'main' })).toThrow('mergeBranch and releaseBranch are both "main"');
+    expect(() => resolveConfig({ stagingBranch: 'release' })).toThrow('stagingBranch "release" is also the releaseBranch');
+  });
+
+  /**
+   * Provider where only the given branches exist
+   */
+  function createProvider(branches) {
+    return { name: 'gitea', branchExists: async branch => branches.includes(branch) };
+  }
+
+  test('a missing source branch stops the run', async () => {
+    await expect(assertBranchesExist(createProvider(['release']), { mergeBranch: 'main', releaseBranch: 'release' }))
+      .rejects.toThrow('mergeBranch (sourceBranch) "main" doesn\'t exist on gitea');
+  });
+
+  test('a missing target branch is only fine for the first release', async () => {
+    const branchConfig = { mergeBranch: 'main', releaseBranch: 'release' };
+
+    await expect(assertBranchesExist(createProvider(['main']), branchConfig))
+      .rejects.toThrow('releaseBranch (targetBranch) "release" doesn\'t exist on gitea, but there are releases already');
+    await expect(assertBranchesExist(createProvider(['main']), { ...branchConfig, firstRelease: true })).resolves.toBeUndefined();
+    await expect(assertBranchesExist(createProvider(['main', 'release']), branchConfig)).resolves.toBeUndefined();
+  });
+
+  test('the check happens before any commits are read', async () => {
+    const provider = {
+      ...createProvider(['main']),
+      listTags: async () => [{ name: 'v1.0.0', sha: 'a'.repeat(40) }],
+      compareCommits: async () => {
+        throw new Error('should not get this far');
+      }
+    };
+
+    await expect(new ReleaseBoss({}, { provider }).nextVersion()).rejects.toThrow('releaseBranch (targetBranch) "release" doesn\'t exist');
+  });
+});
@@ -0,0 +1,139 @@
+/**
+ * Tests for the changelog as it's committed
+ *
+ * These tests validate that the section written to CHANGELOG.md, previewed
+ * in the release PR and published as the release notes is generateChangelog's
+ * output - sections, breaking notes, links, issue refs, cleaned subjects and
+ * author credits all survive the trip into the file.
+ */
+
+/* global describe, test, expect */
+
+const { FakeProvider, resolveConfig } = require('../src/releaseBoss');
+const { analyzeCommits } = require('../src/core/commitAnalyzer');
+const { generateChangelog } = require('../src/core/changelogGenerator');
+const { generateFileChangelog } = require('../src/github/changelogTable');
+const { buildChangelogPreview } = require('../src/core/prContent');
+const { publishRelease } = require('../src/core/githubRelease');
+
+const DATE = new Date(Date.UTC(2024, 2, 9));
+
+/**
+ * Fake repo with v1.0.0 released and a fix, a feature, a breaking change and a messy subject since
+ */
+function createRepo() {
+  const provider = new FakeProvider({ branch: 'main', usernames: { 'kaity@example.com': 'kaity' } });
+  provider.addCommits('main', ['feat: first thing']);
+  provider.createBranch('release', 'main');
+  provider.addTag('v1.0.0', 'release');
+  provider.addCommits('main', [
+    { message: 'fix(web): stop the leak\n\nCloses #7', email: 'kaity@example.com', username: 'kaity' },
+    { message: 'feat(api): add the thing (#12)', email: 'kaity@example.com', username: 'kaity' },
+    'feat(auth): new tokens\n\nBREAKING CHANGE: old tokens stop working',
+    'fix: fix:  doubled   prefix'
+  ]);
+  return provider;
+}
+
+/**
+ * Run the fake repo's commits through the generator, then into CHANGELOG.md
+ */
+async function render(overrides = {}) {
+  const provider = createRepo();
+  const config = resolveConfig(overrides);
+  const commits = await analyzeCommits(provider, config);
+  const changelog = await generateChangelog(commits, '2.0.0', '1.0.0', provider, config, { date: DATE });
+  return { provider, config, changelog, file: generateFileChangelog(changelog, '2.0.0', '') };
+}
+
+/**
+ * Get the "### Heading" lines of some markdown
+ */
+function headings(markdown) {
+  return markdown.split('\n').filter(line => line.startsWith('### '));
+}
+
+describe('the committed changelog', () => {
+  test('the file and the PR preview carry the generated section as it is', async () => {
+    const { config, changelog, file } = await render();
+    const notes = changelog.substring(changelog.indexOf('\n') + 1).trim();
+    const preview = buildChangelogPreview(changelog, config, '2.0.0');
+
+    expect(file).toBe(`# Changelog\n\n## [2.0.0](https://github.com/owner/repo/compare/v1.0.0...v2.0.0) (2024-03-09)\n\n${notes}\n`);
+    expect(preview).toBe(`## Unreleased → v2.0.0\n\n${notes}\n\n`);
+    expect(headings(file)).toEqual(['### ⚠️ BREAKING CHANGES', '### Features', '### Bug Fixes']);
+    expect(headings(preview)).toEqual(headings(file));
+  });
+
+  test('breaking changes are highlighted in the file, above the sections', async () => {
+    const { file } = await render();
+    const breaking = file.indexOf('### ⚠️ BREAKING CHANGES\n\n* **auth:** old tokens stop working');
+
+    expect(breaking).toBeGreaterThan(-1);
+    expect(breaking).toBeLessThan(file.indexOf('### Features'));
+  });
+
+  test('entries are grouped in changelogSections order and sorted, not left in commit order', async () => {
+    const { file } = await render({
+      changelogSections: [{ type: 'fix', section: 'Bug Fixes' }, { type: 'feat', section: 'Features' }]
+    });
+    const entries = file.split('\n').filter(line => line.startsWith('* ') || line.startsWith('### '));
+
+    expect(entries.map(line => line.replace(/ \(\[.*$/, ''))).toEqual([
+      '### ⚠️ BREAKING CHANGES',
+      '* **auth:** old tokens stop working',
+      '### Bug Fixes',
+      '* doubled prefix',
+      '* **web:** stop the leak',
+      '### Features',
+      '* **api:** add the thing',
+      '* **auth:** new tokens'
+    ]);
+  });
+
+  test('the release notes are the grouped section, breaking notes included', async () => {
+    const { provider, config, changelog, file } = await render();
+    provider.addCommits('release', [{ message: 'chore: release 2.0.0', files: { 'CHANGELOG.md': file } }]);
+    provider.addTag('v2.0.0', 'release');
+
+    await publishRelease(provider, config, '2.0.0', { ref: 'release' });
+
+    const { body } = provider.releases.get('v2.0.0');
+    expect(body).toBe(changelog.substring(changelog.indexOf('\n') + 1).trim());
+    expect(headings(body)).toEqual(['### ⚠️ BREAKING CHANGES', '### Features', '### Bug Fixes']);
+    expect(body).toContain('* **auth:** old tokens stop working');
+  });
+
+  test('closed issues link to the issue, not a PR', async () => {
+    const { file } = await render();
+    const entry = file.split('\n').find(line => line.includes('stop the leak'));
+
+    expect(entry).toMatch(/\(\[#7\]\(https:\/\/github\.com\/owner\/repo\/issues\/7\)\)/);
+    expect(file).not.toContain('/pull/7');
+  });
+
+  test('entry subjects are cleaned up in the file', async () => {
+    const { file } = await render();
+    const { file: capitalized } = await render({ changelogSubject: { capitalize: true } });
+
+    expect(file).toContain('* doubled prefix ([');
+    expect(file).toContain('* **api:** add the thing ([');
+    expect(file).not.toMatch(/fix: doubled|add the thing \(#12\)/);
+    expect(capitalized).toContain('* Doubled prefix ([');
+  });
+
+  test('showAuthors credits each entry in the file', async () => {
+    const { file } = await render({ showAuthors: true });
+    const { file: uncredited } = await render();
+
+    expect(file).toMatch(/\* \*\*web:\*\* stop the leak .* \(@kaity\)$/m);
+    expect(file).toMatch(/\* \*\*api:\*\* add the thing .* \(@kaity\)$/m);
+    expect(uncredited).not.toContain('@kaity');
+  });
+
+  test('the heading takes the released version, keeping its link and date', () => {
+    const file = generateFileChangelog('## [Unreleased](https://example.com/compare) (2024-03-09)\n\n### Features\n\n* thing\n', '1.3.0', '');
+
+    expect(file).toContain('## [1.3.0](https://example.com/compare) (2024-03-09)\n\n### Features\n\n* thing\n');
+  });
+});
@@ -0,0 +1,295 @@
+/**
+ * Tests for changelog generation
+ *
+ * These tests validate section ordering, shared headings, the
+ * deterministic order of entries within a section, how duplicate
+ * and reverted commits are left out, how closed issues are linked, how
+ * maxEntryLength shortens long breaking change notes, and how entry subjects
+ * are cleaned up.
+ */
+
+/* global describe, test, expect */
+
+const { generateChangelog, truncateEntryBody, cleanSubject, getSubjectRules } = require('../src/core/changelogGenerator');
+const { resolveConfig } = require('../src/releaseBoss');
+
+const provider = {
+  compareUrl: (from, to) => `https://github.com/owner/repo/compare/${from}...${to}`,
+  pullRequestUrl: number => `https://github.com/owner/repo/pull/${number}`,
+  issueUrl: number => `https://github.com/owner/repo/issues/${number}`
+};
+
+/**
+ * Build an analyzed commit like analyzeCommits returns
+ */
+function createCommit(hash, type, scope, subject, date) {
+  return {
+    hash: hash.padEnd(40, '0'),
+    message: `${type}${scope ? `(${scope})` : ''}: ${subject}`,
+    parsed: { type, scope, subject },
+    url: `https://github.com/owner/repo/commit/${hash}`,
+    date,
+    breaking: false,
+    breakingChanges: []
+  };
+}
+
+/**
+ * Get the "### Heading" lines of a changelog
+ */
+function headings(changelog) {
+  return changelog.split('\n').filter(line => line.startsWith('### '));
+}
+
+describe('generateChangelog', () => {
+  const commits = [
+    createCommit('a1', 'fix', 'web', 'late web fix', '2024-01-03T00:00:00Z'),
+    createCommit('b2', 'feat', 'api', 'api feature', '2024-01-02T00:00:00Z'),
+    createCommit('c3', 'fix', 'api', 'api fix', '2024-01-04T00:00:00Z'),
+    createCommit('d4', 'fix', 'web', 'early web fix', '2024-01-01T00:00:00Z'),
+    createCommit('e5', 'fix', null, 'unscoped fix', '2024-01-05T00:00:00Z'),
+    createCommit('f6', 'style', null, 'tabs to spaces', '2024-01-01T00:00:00Z')
+  ];
+
+  test('sections follow the changelogSections order and skip empty ones', async () => {
+    const changelog = await generateChangelog(commits, '1.1.0', '1.0.0', provider, {
+      changelogSections: [
+        { type: 'fix', section: 'Bug Fixes' },
+        { type: 'perf', section: 'Performance' },
+        { type: 'feat', section: 'Features' },
+        { type: 'refactor', section: 'Code Refactoring' },
+        { type: 'style', section: 'Code Refactoring' }
+      ]
+    });
+
+    expect(headings(changelog)).toEqual(['### Bug Fixes', '### Features', '### Code Refactoring']);
+  });
+
+  test('entries are sorted by scope then commit date', async () => {
+    const changelog = await generateChangelog(commits, '1.1.0', '1.0.0', provider, {
+      changelogSections: [{ type: 'fix', section: 'Bug Fixes' }]
+    });
+    const entries = changelog.split('\n').filter(line => line.startsWith('* '));
+
+    expect(entries.map(entry => entry.split(' ([')[0])).toEqual([
+      '* unscoped fix',
+      '* **api:** api fix',
+      '* **web:** early web fix',
+      '* **web:** late web fix'
+    ]);
+  });
+
+  test('output does not depend on the order commits arrive in', async () => {
+    const config = { changelogSections: [{ type: 'fix', section: 'Bug Fixes' }, { type: 'feat', section: 'Features' }] };
+    const forwards = await generateChangelog(commits, '1.1.0', '1.0.0', provider, config);
+    const backwards = await generateChangelog([...commits].reverse(), '1.1.0', '1.0.0', provider, config);
+
+    expect(backwards).toBe(forwards);
+  });
+
+  test('the default sections include reverts', async () => {
+    const changelog = await generateChangelog([
+      createCommit('a1', 'revert', null, 'undo the thing', '2024-01-01T00:00:00Z'),
+      createCommit('b2', 'feat', null, 'the thing', '2024-01-01T00:00:00Z')
+    ], '1.1.0', '1.0.0', provider, {});
+
+    expect(headings(changelog)).toEqual(['### Features', '### Reverts']);
+  });
+});
+
+describe('duplicates and reverts', () => {
+  const config = { changelogSections: [{ type: 'feat', section: 'Features' }, { type: 'revert', section: 'Reverts' }] };
+
+  /**
+   * Get the entry subjects of a changelog
+   */
+  function entries(changelog) {
+    return changelog.split('\n').filter(line => line.startsWith('* ')).map(line => line.split(' ([')[0]);
+  }
+
+  test('a cherry-picked commit is only listed once', async () => {
+    const original = { ...createCommit('a1', 'feat', null, 'add export', '2024-01-01T00:00:00Z'), author: 'alice' };
+    const picked = { ...createCommit('b2', 'feat', null, 'add export', '2024-01-02T00:00:00Z'), author: 'alice', message: 'feat: add export (#14)' };
+    const someoneElse = { ...createCommit('c3', 'feat', null, 'add export', '2024-01-03T00:00:00Z'), author: 'bob' };
+
+    const changelog = await generateChangelog([original, picked, someoneElse], '1.1.0', '1.0.0', provider, config);
+
+    expect(changelog).toContain('([a100000](');
+    expect(changelog).not.toContain('([b200000](');
+    expect(entries(changelog)).toEqual(['* add export', '* add export']);
+  });
+
+  test('a revert and the commit it reverts cancel out', async () => {
+    const feature = createCommit('a1b2c3d', 'feat', null, 'add dark mode', '2024-01-01T00:00:00Z');
+    const bySha = { ...createCommit('e5', 'revert', null, 'feat: add dark mode', '2024-01-02T00:00:00Z'), message: `revert: feat: add dark mode\n\nThis reverts commit ${feature.hash}.` };
+    const other = createCommit('f6', 'feat', null, 'add themes', '2024-01-03T00:00:00Z');
+    const bySubject = { ...createCommit('a7', null, null, undefined, '2024-01-04T00:00:00Z'), message: 'Revert "feat: add themes (#9)"' };
+    const keeper = createCommit('b8', 'feat', null, 'add light mode', '2024-01-05T00:00:00Z');
+
+    const changelog = await generateChangelog([feature, bySha, other, bySubject, keeper], '1.1.0', '1.0.0', provider, config);
+
+    expect(entries(changelog)).toEqual(['* add light mode']);
+    expect(changelog).not.toContain('### Reverts');
+  });
+
+  test('reverts of earlier releases and collapseReverts: false keep the entries', async () => {
+    const feature = createCommit('a1', 'feat', null, 'add dark mode', '2024-01-01T00:00:00Z');
+    const revert = createCommit('e5', 'revert', null, 'feat: add dark mode', '2024-01-02T00:00:00Z');
+    const olderRevert = { ...createCommit('c3', 'revert', null, 'feat: add sparkles', '2024-01-03T00:00:00Z'), message: 'revert: feat: add sparkles\n\nThis reverts commit 0123abc.' };
+
+    const collapsed = await generateChangelog([feature, revert, olderRevert], '1.1.0', '1.0.0', provider, config);
+    const kept = await generateChangelog([feature, revert, olderRevert], '1.1.0', '1.0.0', provider, { ...config, collapseReverts: false });
+
+    expect(entries(collapsed)).toEqual(['* feat: add sparkles']);
+    expect(entries(kept)).toEqual(['* add dark mode', '* feat: add dark mode', '* feat: add sparkles']);
+  });
+});
+
+describe('closed issues', () => {
+  const config = { changelogSections: [{ type: 'fix', section: 'Bug Fixes' }] };
+
+  /**
+   * A fix commit with footers, as analyzeCommits returns it
+   */
+  function createFix(subject, footers, issues) {
+    return {
+      ...createCommit('a1', 'fix', null, subject, '2024-01-01T00:00:00Z'),
+      message: `fix: ${subject}\n\n${footers}`,
+      issues
+    };
+  }
+
+  test('each closed issue is linked after the entry', async () => {
+    const changelog = await generateChangelog([createFix('handle timeouts', 'Closes #12, #14', [12, 14])], '1.1.0', '1.0.0', provider, config);
+
+    expect(changelog).toContain('* handle timeouts ([a100000](https://github.com/owner/repo/commit/a1)) ' +
+      '([#12](https://github.com/owner/repo/issues/12)) ([#14](https://github.com/owner/repo/issues/14))');
+    // The closing footer isn't taken for the PR number
+    expect(changelog).not.toContain('/pull/12');
+  });
+
+  test('issues the subject already mentions are not repeated', async () => {
+    const changelog = await generateChangelog([createFix('handle timeouts (#12)', 'Fixes #12\nFixes #13', [12, 13])], '1.1.0', '1.0.0', provider, config);
+
+    expect(changelog).toContain('([#12](https://github.com/owner/repo/pull/12)) ([#13](https://github.com/owner/repo/issues/13))');
+    expect(changelog).not.toContain('/issues/12');
+  });
+});
+
+describe('maxEntryLength', () => {
+  const NOTE = 'settings moved from .release-boss.json to .release-boss.yml - copy each key across and delete the old file';
+
+  /**
+   * A breaking feat commit as analyzeCommits returns it
+   */
+  function createBreaking(message, note) {
+    return {
+      ...createCommit('b2', 'feat', 'config', 'switch to YAML', '2024-01-01T00:00:00Z'),
+      message,
+      breaking: true,
+      breakingChanges: [note]
+    };
+  }
+
+  /**
+   * Get the breaking change entries of a changelog
+   */
+  function breakingEntries(changelog) {
+    const section = changelog.split('### ⚠️ BREAKING CHANGES\n\n')[1] || '';
+    return section.split('\n\n')[0].split('\n');
+  }
+
+  test('long notes are cut at a word boundary with a link to the PR', async () => {
+    const commit = createBreaking(`feat(config): switch to YAML (#42)\n\nBREAKING CHANGE: ${NOTE}`, NOTE);
+
+    const changelog = await generateChangelog([commit], '2.0.0', '1.0.0', provider, { maxEntryLength: 50 });
+
+    expect(breakingEntries(changelog)).toEqual([
+      '* **config:** settings moved from .release-boss.json to… ([see PR #42](https://github.com/owner/repo/pull/42)) ([b200000](https://github.com/owner/repo/commit/b2))'
+    ]);
+    // The subject line under Features is left whole
+    expect(changelog).toContain('* **config:** switch to YAML ([b200000]');
+  });
+
+  test('without a PR the link goes to the commit', async () => {
+    const commit = createBreaking(`feat(config): switch to YAML\n\nBREAKING CHANGE: ${NOTE}`, NOTE);
+
+    const changelog = await generateChangelog([commit], '2.0.0', '1.0.0', provider, { maxEntryLength: 50 });
+
+    expect(breakingEntries(changelog)[0]).toContain('to… ([see the full commit](https://github.com/owner/repo/commit/b2))');
+  });
+
+  test('is off by default, and a subject-only note is never cut', async () => {
+    const commit = createBreaking(`feat(config)!: ${NOTE}`, NOTE);
+    commit.parsed.subject = NOTE;
+
+    expect(breakingEntries(await generateChangelog([commit], '2.0.0', '1.0.0', provider, {}))[0]).toContain(NOTE);
+    expect(breakingEntries(await generateChangelog([commit], '2.0.0', '1.0.0', provider, { maxEntryLength: 10 }))[0]).toContain(NOTE);
+  });
+
+  test('multibyte characters are never split', () => {
+    expect(truncateEntryBody('設定ファイルの形式が変わりました', 5)).toEqual({ text: '設定ファイ', truncated: true });
+    expect(truncateEntryBody('👩‍💻👩‍💻👩‍💻', 2)).toEqual({ text: '👩‍💻👩‍💻', truncated: true });
+    expect(truncateEntryBody('ship it 👩‍💻👩‍💻 now', 10)).toEqual({ text: 'ship it 👩‍💻👩‍💻', truncated: true });
+    expect(truncateEntryBody('short', 10)).toEqual({ text: 'short', truncated: false });
+  });
+});
+
+describe('subject cleanup', () => {
+  const rules = getSubjectRules({});
+  const types = ['feat', 'fix', 'chore'];
+
+  test('a leftover type prefix goes, with or without a scope', () => {
+    expect(cleanSubject('feat(api): add token auth', rules, { types })).toBe('add token auth');
+    expect(cleanSubject('fix: handle timeouts', rules, { types })).toBe('handle timeouts');
+    expect(cleanSubject('feat(api)!: feat: drop v1 routes', rules, { types })).toBe('drop v1 routes');
+    expect(cleanSubject('handle timeouts', rules, { types })).toBe('handle timeouts');
+  });
+
+  test('only known types count as a prefix', () => {
+    expect(cleanSubject('Note: keep this', rules, { types })).toBe('Note: keep this');
+    expect(cleanSubject('feat:', rules, { types })).toBe('feat:');
+  });
+
+  test('whitespace is trimmed and collapsed', () => {
+    expect(cleanSubject('  handle \t timeouts  ', rules, { types })).toBe('handle timeouts');
+  });
+
+  test('a trailing PR reference goes only when the entry links that PR', () => {
+    expect(cleanSubject('handle timeouts (#12)', rules, { types, prNumber: '12' })).toBe('handle timeouts');
+    expect(cleanSubject('handle timeouts (#12)', rules, { types, prNumber: '13' })).toBe('handle timeouts (#12)');
+    expect(cleanSubject('handle timeouts (#12)', rules, { types })).toBe('handle timeouts (#12)');
+    expect(cleanSubject('fix #12 (then more)', rules, { types, prNumber: '12' })).toBe('fix #12 (then more)');
+  });
+
+  test('every rule can be turned off, and capitalize on', () => {
+    const off = getSubjectRules({ changelogSubject: { stripType: false, trim: false, stripPRNumber: false } });
+    expect(cleanSubject(' feat: add thing (#12)', off, { types, prNumber: '12' })).toBe(' feat: add thing (#12)');
+
+    const capitalized = getSubjectRules({ changelogSubject: { capitalize: true } });
+    expect(cleanSubject('feat: add thing', capitalized, { types })).toBe('Add thing');
+    expect(cleanSubject('éclair support', capitalized, { types })).toBe('Éclair support');
+  });
+
+  test('entries come out clean, while reverts keep the type they undo', async () => {
+    const squashed = {
+      ...createCommit('a1', 'feat', 'api', 'feat(api): add token auth  (#7)', '2024-01-01T00:00:00Z'),
+      message: 'feat(api): feat(api): add token auth  (#7)'
+    };
+    const revert = createCommit('b2', 'revert', null, 'feat: web sockets', '2024-01-02T00:00:00Z');
+    const config = {
+      changelogSections: [{ type: 'feat', section: 'Features' }, { type: 'revert', section: 'Reverts' }],
+      changelogSubject: { capitalize: true }
+    };
+
+    const changelog = await generateChangelog([squashed, revert], '1.1.0', '1.0.0', provider, config);
+
+    expect(changelog).toContain('* **api:** Add token auth ([a100000](https://github.com/owner/repo/commit/a1)) ([#7](https://github.com/owner/repo/pull/7))');
+    expect(changelog).toContain('* Feat: web sockets ([b200000]');
+  });
+
+  test('the config only takes the known rules, as true or false', () => {
+    expect(() => resolveConfig({ changelogSubject: { capitalise: true } })).toThrow('changelogSubject.capitalise isn\'t a subject rule - pick from: stripType, trim, capitalize, stripPRNumber');
+    expect(() => resolveConfig({ changelogSubject: { trim: 'yes' } })).toThrow('changelogSubject.trim must be true or false, got "yes"');
+  });
+});
@@ -0,0 +1,88 @@
+/**
+ * Tests for the changelog command
+ *
+ * These tests validate that a changelog can be generated for any commit range,
+ * open-ended or not, straight from the local git checkout.
+ */
+
+/* global describe, test, expect */
+
+const { generateRangeChangelog } = require('../src/core/changelogRange');
+const { LocalGitProvider, remoteToWebUrl } = require('../src/providers/localGitProvider');
+const { parseArgs } = require('../src/cli');
+
+const config = {
+  changelogSections: [
+    { type: 'feat', section: 'Features', hidden: false },
+    { type: 'fix', section: 'Bug Fixes', hidden: false }
+  ]
+};
+
+/**
+ * Fake git runner for a repo with v1.2.0 and v1.5.0 tags
+ */
+function createGit(calls = []) {
+  const refs = { 'v1.2.0': 'a'.repeat(40), 'v1.5.0': 'c'.repeat(40), HEAD: 'd'.repeat(40) };
+  const record = (sha, date, message) => `${sha}\x1fkaity\x1fkaity@example.com\x1f${date}\x1f${message}\n\x1e\n`;
+
+  return async args => {
+    calls.push(args);
+    if (args[0] === 'rev-parse') {
+      return `${refs[args[3].replace('^{commit}', '')] || ''}\n`;
+    }
+    if (args[0] === 'log') {
+      return record('b'.repeat(40), '2024-02-01T10:00:00+00:00', 'feat: backfilled feature') +
+        record('c'.repeat(40), '2024-03-01T10:00:00+00:00', 'fix: backfilled fix (#7)') +
+        (args[3].endsWith('d'.repeat(40)) ? record('d'.repeat(40), '2024-04-01T10:00:00+00:00', 'fix: not released yet') : '');
+    }
+    throw new Error(`unexpected git ${args.join(' ')}`);
+  };
+}
+
+describe('generateRangeChangelog', () => {
+  test('renders the range between two tags', async () => {
+    const calls = [];
+    const provider = new LocalGitProvider({ repoUrl: 'https://github.com/owner/repo', git: createGit(calls) });
+
+    const { changelog } = await generateRangeChangelog(provider, config, { since: 'v1.2.0', until: 'v1.5.0' });
+
+    expect(calls.find(args => args[0] === 'log')[3]).toBe(`${'a'.repeat(40)}..${'c'.repeat(40)}`);
+    expect(changelog).toContain('## [1.5.0](https://github.com/owner/repo/compare/v1.2.0...v1.5.0) (2024-03-01)');
+    expect(changelog).toContain('* backfilled feature ([bbbbbbb](https://github.com/owner/repo/commit/' + 'b'.repeat(40) + '))');
+    expect(changelog).toContain('([#7](https://github.com/owner/repo/pull/7))');
+  });
+
+  test('--since alone runs up to HEAD', async () => {
+    const provider = new LocalGitProvider({ platform: 'gitlab', repoUrl: 'https://gitlab.com/group/repo', git: createGit() });
+
+    const { changelog } = await generateRangeChangelog(provider, config, { since: 'v1.2.0' });
+
+    expect(changelog).toContain(`## [Unreleased](https://gitlab.com/group/repo/-/compare/v1.2.0...${'d'.repeat(40)}) (2024-04-01)`);
+    expect(changelog).toContain('not released yet');
+  });
+
+  test('unknown refs are an error', async () => {
+    const provider = new LocalGitProvider({ repoUrl: 'https://github.com/owner/repo', git: createGit() });
+    await expect(generateRangeChangelog(provider, config, { since: 'v0.9.0' })).rejects.toThrow("Couldn't find v0.9.0");
+  });
+});
+
+describe('remoteToWebUrl', () => {
+  test('handles https, ssh and scp-style remotes', () => {
+    expect(remoteToWebUrl('https://github.com/owner/repo.git\n')).toBe('https://github.com/owner/repo');
+    expect(remoteToWebUrl('https://token@gitlab.example.com/group/sub/repo.git')).toBe('https://gitlab.example.com/group/sub/repo');
+    expect(remoteToWebUrl('git@github.com:owner/repo.git')).toBe('https://github.com/owner/repo');
+    expect(remoteToWebUrl('ssh://git@gitlab.com:2222/group/repo.git')).toBe('https://gitlab.com/group/repo');
+    expect(remoteToWebUrl('/some/local/path')).toBeNull();
+  });
+});
+
+describe('parseArgs', () => {
+  test('reads the range options in both spellings', () => {
+    expect(parseArgs(['changelog', '--since', 'v1.2.0', '--until=v1.5.0'])).toEqual({
+      command: 'changelog',
+      options: { since: 'v1.2.0', until: 'v1.5.0' }
+    });
+    expect(() => parseArgs(['changelog', '--since'])).toThrow('--since needs a value');
+  });
+});
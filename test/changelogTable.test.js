@@ -14,7 +14,8 @@ const {
   parseChangelogTable,
   mergeChangelogEntries,
   updatePRDescriptionWithChangelog,
-  generateFileChangelog
+  generateFileChangelog,
+  insertChangelogSection
 } = require('../src/github/changelogTable');
 
 const { 
@@ -306,3 +307,48 @@ describe('Changelog Integration Module', () => {
     expect(result.content).toContain('* **feat(auth):** Add OAuth2 support #123 abc1234');
   });
 });
+
+describe('insertChangelogSection', () => {
+  const existing = [
+    '# Changelog',
+    '',
+    'All notable changes, lovingly curated.',
+    '',
+    '## [1.1.0] - 2024-02-01',
+    '',
+    '* hand-edited entry   with odd  spacing',
+    '',
+    '## 1.0.0 (2024-01-01)',
+    '',
+    '* first release',
+    ''
+  ].join('\n');
+
+  test('adds the new release above the newest one and keeps the rest intact', () => {
+    const result = insertChangelogSection(existing, '## 1.2.0 (2024-03-01)\n\n* new thing\n', '1.2.0');
+    const newest = existing.indexOf('## [1.1.0]');
+
+    expect(result.substring(0, newest)).toBe(existing.substring(0, newest));
+    expect(result).toBe(existing.substring(0, newest) + '## 1.2.0 (2024-03-01)\n\n* new thing\n\n' + existing.substring(newest));
+  });
+
+  test('replaces the section when the version is already there', () => {
+    const result = insertChangelogSection(existing, '## 1.1.0 (2024-02-02)\n\n* redone\n', '1.1.0');
+
+    expect(result.match(/1\.1\.0/g)).toHaveLength(1);
+    expect(result).toContain('## 1.1.0 (2024-02-02)\n\n* redone\n\n## 1.0.0 (2024-01-01)');
+    expect(result).toContain('All notable changes, lovingly curated.');
+  });
+
+  test('creates a new changelog with the configured header', () => {
+    expect(insertChangelogSection('', '## 1.0.0 (2024-01-01)\n\n* hi\n', '1.0.0', '# Release Notes\n\nEvery release, ever.'))
+      .toBe('# Release Notes\n\nEvery release, ever.\n\n## 1.0.0 (2024-01-01)\n\n* hi\n');
+    expect(insertChangelogSection('', '## 1.0.0 (2024-01-01)\n\n* hi\n', '1.0.0'))
+      .toBe('# Changelog\n\n## 1.0.0 (2024-01-01)\n\n* hi\n');
+  });
+
+  test('a changelog with no releases yet keeps its preamble', () => {
+    expect(insertChangelogSection('# History\n', '## 1.0.0 (2024-01-01)\n\n* hi\n', '1.0.0'))
+      .toBe('# History\n\n## 1.0.0 (2024-01-01)\n\n* hi\n');
+  });
+});
@@ -0,0 +1,403 @@
+/**
+ * Tests for commit analysis
+ *
+ * These tests validate breaking change detection from commit footers and the
+ * ! marker, how
+ * breaking changes show up in the generated changelog, squash commit splitting,
+ * ignorePaths, releaseTypes, custom commitTypes, noBumpScopes and hiddenScopes,
+ * and how the very first release is versioned.
+ */
+
+/* global describe, test, expect */
+
+const { analyzeCommits, determineVersionBump, extractBreakingChanges, extractIssueReferences, splitSquashCommit } = require('../src/core/commitAnalyzer');
+const { generateChangelog } = require('../src/core/changelogGenerator');
+const { resolveConfig } = require('../src/utils/config');
+
+const config = {
+  releaseBranch: 'release',
+  mergeBranch: 'main',
+  changelogSections: [
+    { type: 'feat', section: 'Features', hidden: false },
+    { type: 'fix', section: 'Bug Fixes', hidden: false }
+  ]
+};
+
+/**
+ * Provider returning a fixed list of commit messages
+ */
+function createProvider(messages) {
+  return {
+    name: 'github',
+    compareCommits: async () => messages.map((message, index) => ({
+      sha: String(index + 1).repeat(40),
+      message,
+      author: 'kaity',
+      date: '2024-01-01T00:00:00Z',
+      url: `https://github.com/owner/repo/commit/${index + 1}`
+    })),
+    compareUrl: (from, to) => `https://github.com/owner/repo/compare/${from}...${to}`,
+    pullRequestUrl: number => `https://github.com/owner/repo/pull/${number}`
+  };
+}
+
+describe('extractBreakingChanges', () => {
+  test('finds footers in all the supported spellings', () => {
+    expect(extractBreakingChanges('feat: a\n\nBREAKING CHANGE: one')).toEqual(['one']);
+    expect(extractBreakingChanges('feat: a\n\nBREAKING-CHANGE: two')).toEqual(['two']);
+    expect(extractBreakingChanges('feat: a\n\nBREAKING-CHANGES: three')).toEqual(['three']);
+  });
+
+  test('collects multiple footers and multi-line notes', () => {
+    const message = [
+      'feat(api): new auth',
+      '',
+      'Some explanation.',
+      '',
+      'BREAKING CHANGE: tokens are now required',
+      'on every request',
+      'BREAKING CHANGE: the /v1 routes are gone',
+      'Refs: #42'
+    ].join('\n');
+
+    expect(extractBreakingChanges(message)).toEqual([
+      'tokens are now required on every request',
+      'the /v1 routes are gone'
+    ]);
+  });
+
+  test('ignores the header and ordinary bodies', () => {
+    expect(extractBreakingChanges('fix: BREAKING CHANGE: not a footer')).toEqual([]);
+    expect(extractBreakingChanges('fix: thing\n\nnothing breaking here')).toEqual([]);
+  });
+});
+
+describe('extractIssueReferences', () => {
+  test('reads Closes, Fixes and Resolves footers in any case', () => {
+    const message = [
+      'fix(api): stop dropping requests',
+      '',
+      'Closes #12, #14',
+      'fixes: #3',
+      'RESOLVED #20,#12'
+    ].join('\n');
+
+    expect(extractIssueReferences(message)).toEqual([12, 14, 3, 20]);
+  });
+
+  test('ignores the header and mentions in the body', () => {
+    expect(extractIssueReferences('fix: closes #4 for real\n\nSee #5, which fixes #6 eventually')).toEqual([]);
+  });
+});
+
+describe('breaking change bumps', () => {
+  test('a footer forces a major bump, even on excluded types', async () => {
+    const commits = await analyzeCommits(createProvider([
+      'fix: small thing\n\nBREAKING CHANGE: config keys renamed',
+      'chore: drop node 14\n\nBREAKING-CHANGES: node 16 is required',
+      'chore: tidy up'
+    ]), config);
+
+    expect(commits.map(commit => commit.bumpType)).toEqual(['major', 'major']);
+    expect(commits[1].breakingChanges).toEqual(['node 16 is required']);
+  });
+
+  test('breaking changes get their own changelog section', async () => {
+    const commits = await analyzeCommits(createProvider([
+      'feat(api): new auth\n\nBREAKING CHANGE: tokens required\nBREAKING CHANGE: v1 removed'
+    ]), config);
+
+    const changelog = await generateChangelog(commits, '2.0.0', '1.0.0', createProvider([]), config);
+    const breakingSection = changelog.split('### ⚠️ BREAKING CHANGES\n\n')[1].split('\n\n')[0];
+
+    expect(breakingSection).toBe([
+      '* **api:** tokens required ([1111111](https://github.com/owner/repo/commit/1))',
+      '* **api:** v1 removed ([1111111](https://github.com/owner/repo/commit/1))'
+    ].join('\n'));
+    expect(changelog.indexOf('BREAKING CHANGES')).toBeLessThan(changelog.indexOf('### Features'));
+  });
+
+  test('a ! after the type or scope is a major bump with a breaking changes entry', async () => {
+    const commits = await analyzeCommits(createProvider([
+      'feat!: drop node 14',
+      'fix(api)!: stricter tokens\n\nBREAKING CHANGE: unsigned tokens are refused'
+    ]), config);
+
+    expect(commits.map(commit => commit.parsed.type)).toEqual(['feat', 'fix']);
+    expect(commits.map(commit => commit.bumpType)).toEqual(['major', 'major']);
+    expect(commits[0].breakingChanges).toEqual(['drop node 14']);
+    // The footer says more than the subject, so it's the note
+    expect(commits[1].breakingChanges).toEqual(['unsigned tokens are refused']);
+
+    const changelog = await generateChangelog(commits, '2.0.0', '1.0.0', createProvider([]), config);
+    const breakingSection = changelog.split('### ⚠️ BREAKING CHANGES\n\n')[1].split('\n\n')[0];
+    expect(breakingSection).toBe([
+      '* drop node 14 ([1111111](https://github.com/owner/repo/commit/1))',
+      '* **api:** unsigned tokens are refused ([2222222](https://github.com/owner/repo/commit/2))'
+    ].join('\n'));
+    expect(changelog).toContain('### Features\n\n* drop node 14');
+  });
+});
+
+describe('releaseTypes', () => {
+  const withTags = provider => ({ ...provider, listTags: async () => [{ name: 'v1.1.0', sha: 'a'.repeat(40) }] });
+
+  test('chore, docs and style commits alone mean no release', async () => {
+    const provider = withTags(createProvider(['docs: fix typo', 'style: reformat', 'chore: tidy up']));
+    const commits = await analyzeCommits(provider, config);
+    const result = await determineVersionBump(commits, provider, config);
+
+    expect(result.bumpType).toBeNull();
+    expect(result.newVersion).toBe('1.1.0');
+    expect(result.reason).toBe('Only docs, style commits since 1.1.0 - none of them count toward a release (releaseTypes: feat, fix, perf, refactor)');
+  });
+
+  test('picks the types that count', async () => {
+    const releaseConfig = { ...config, releaseTypes: ['feat', 'fix', 'docs', 'chore'] };
+    const commits = await analyzeCommits(createProvider(['docs: new guide', 'chore: bump deps', 'perf: faster', 'feat: shiny']), releaseConfig);
+
+    expect(commits.map(commit => [commit.parsed.type, commit.bumpType])).toEqual([
+      ['docs', 'patch'],
+      ['chore', 'patch'],
+      ['perf', null],
+      ['feat', 'minor']
+    ]);
+  });
+});
+
+describe('commitTypes', () => {
+  const withTags = provider => ({ ...provider, listTags: async () => [{ name: 'v1.1.0', sha: 'a'.repeat(40) }] });
+  const customConfig = {
+    ...config,
+    commitTypes: {
+      hotfix: { bump: 'patch', section: 'Hotfixes' },
+      deps: 'patch',
+      breaking: { bump: 'major', section: 'Breaking' },
+      refactor: 'none'
+    }
+  };
+
+  test('custom types bump the version they declare', async () => {
+    const commits = await analyzeCommits(createProvider(['hotfix: stop the bleeding', 'deps: bump lodash', 'refactor: tidy', 'wip: half done']), customConfig);
+
+    expect(commits.map(commit => [commit.parsed.type, commit.bumpType])).toEqual([
+      ['hotfix', 'patch'],
+      ['deps', 'patch'],
+      ['refactor', null],
+      ['wip', null]
+    ]);
+  });
+
+  test('a breaking type is a major release', async () => {
+    const provider = withTags(createProvider(['fix: small thing', 'breaking: drop Node 14']));
+    const commits = await analyzeCommits(provider, customConfig);
+    const result = await determineVersionBump(commits, provider, customConfig);
+
+    expect(result).toMatchObject({ bumpType: 'major', newVersion: '2.0.0' });
+  });
+
+  test('custom headings show up in the changelog', async () => {
+    const commits = await analyzeCommits(createProvider(['hotfix: stop the bleeding', 'feat: shiny']), customConfig);
+    const changelog = await generateChangelog(commits, '1.2.0', '1.1.0', createProvider([]), customConfig);
+
+    expect(changelog.split('\n').filter(line => line.startsWith('### '))).toEqual(['### Features', '### Hotfixes']);
+  });
+
+  test('bad entries fail when the config loads', () => {
+    expect(() => resolveConfig({ commitTypes: { hotfix: 'huge' } })).toThrow('commitTypes.hotfix needs a bump of major, minor, patch, none');
+    expect(() => resolveConfig({ commitTypes: ['hotfix'] })).toThrow('commitTypes must map commit types');
+  });
+});
+
+describe('scope rules', () => {
+  const withTags = provider => ({ ...provider, listTags: async () => [{ name: 'v1.1.0', sha: 'a'.repeat(40) }] });
+
+  /**
+   * Analyze the commits, work out the bump and write the changelog, all with scopeConfig
+   */
+  async function release(messages, scopeConfig) {
+    const provider = withTags(createProvider(messages));
+    const commits = await analyzeCommits(provider, scopeConfig);
+    const result = await determineVersionBump(commits, provider, scopeConfig);
+    const changelog = await generateChangelog(commits, result.newVersion, '1.1.0', provider, scopeConfig);
+    return { result, entries: changelog.split('\n').filter(line => line.startsWith('* ')) };
+  }
+
+  test('without either list, feat(ci) is a feature like any other', async () => {
+    const { result, entries } = await release(['feat(ci): cache node_modules'], config);
+
+    expect(result.bumpType).toBe('minor');
+    expect(entries).toEqual(['* **ci:** cache node_modules ([1111111](https://github.com/owner/repo/commit/1))']);
+  });
+
+  test('noBumpScopes keeps feat(ci) out of the version but in the changelog', async () => {
+    const scopeConfig = { ...config, noBumpScopes: ['ci'] };
+
+    const alone = await release(['feat(ci): cache node_modules'], scopeConfig);
+    expect(alone.result.bumpType).toBeNull();
+    expect(alone.result.reason).toBe('The only releasable commits since 1.1.0 are scoped ci, which noBumpScopes keeps out of the version');
+
+    const { result, entries } = await release(['feat(ci): cache node_modules', 'fix(api): handle timeouts', 'feat(CI)!: drop Node 14'], scopeConfig);
+    expect(result).toMatchObject({ bumpType: 'patch', newVersion: '1.1.1' });
+    expect(entries).toContain('* **ci:** cache node_modules ([1111111](https://github.com/owner/repo/commit/1))');
+  });
+
+  test('hiddenScopes keeps feat(ci) out of the changelog but still bumps', async () => {
+    const { result, entries } = await release(['feat(ci): cache node_modules', 'fix(api): handle timeouts'], { ...config, hiddenScopes: ['ci'] });
+
+    expect(result).toMatchObject({ bumpType: 'minor', newVersion: '1.2.0' });
+    expect(entries).toEqual(['* **api:** handle timeouts ([2222222](https://github.com/owner/repo/commit/2))']);
+  });
+
+  test('both lists together mean neither a bump nor an entry', async () => {
+    const { result, entries } = await release(['feat(ci): cache node_modules', 'fix(api): handle timeouts'], { ...config, noBumpScopes: ['ci'], hiddenScopes: ['ci'] });
+
+    expect(result).toMatchObject({ bumpType: 'patch', newVersion: '1.1.1' });
+    expect(entries).toEqual(['* **api:** handle timeouts ([2222222](https://github.com/owner/repo/commit/2))']);
+  });
+
+  test('bad lists fail when the config loads', () => {
+    expect(() => resolveConfig({ noBumpScopes: 'ci' })).toThrow('noBumpScopes must be a list of commit scopes like ["ci", "test"]');
+    expect(() => resolveConfig({ hiddenScopes: [''] })).toThrow('hiddenScopes must be a list of commit scopes');
+  });
+});
+
+describe('squash commits', () => {
+  const squash = [
+    'Auth overhaul (#42)',
+    '',
+    '* feat(api): add token auth',
+    '',
+    'BREAKING CHANGE: tokens are required',
+    '',
+    '* wip',
+    '',
+    'fix: unbulleted entries count too',
+    '',
+    '* fix(web): handle expired sessions',
+    '',
+    'Co-authored-by: Someone <someone@example.com>'
+  ].join('\n');
+
+  test('splitSquashCommit keeps the conventional entries with the PR number', () => {
+    const messages = splitSquashCommit(squash);
+
+    expect(messages.map(message => message.split('\n')[0])).toEqual([
+      'feat(api): add token auth (#42)',
+      'fix: unbulleted entries count too (#42)',
+      'fix(web): handle expired sessions (#42)'
+    ]);
+    expect(messages[0]).toContain('BREAKING CHANGE: tokens are required');
+  });
+
+  test('messages without conventional entries are left alone', () => {
+    expect(splitSquashCommit('fix: one thing\n\nJust some details.')).toEqual(['fix: one thing\n\nJust some details.']);
+  });
+
+  test('analyzeCommits only splits when parseSquashCommits is on', async () => {
+    const plain = await analyzeCommits(createProvider([squash]), config);
+    expect(plain.map(commit => commit.parsed.header)).toEqual(['Auth overhaul (#42)']);
+
+    const split = await analyzeCommits(createProvider([squash]), { ...config, parseSquashCommits: true });
+    expect(split.map(commit => commit.parsed.type)).toEqual(['feat', 'fix', 'fix']);
+    expect(split.map(commit => commit.hash)).toEqual([split[0].hash, split[0].hash, split[0].hash]);
+    expect(split[0].bumpType).toBe('major');
+
+    const changelog = await generateChangelog(split, '2.0.0', '1.0.0', createProvider([]), config);
+    expect(changelog).toContain('**web:** handle expired sessions ([1111111](https://github.com/owner/repo/commit/1)) ([#42](https://github.com/owner/repo/pull/42))');
+  });
+});
+
+describe('first release', () => {
+  /**
+   * Provider with the given tags and no releases
+   */
+  function createTagProvider(tags) {
+    return {
+      ...createProvider([]),
+      listTags: async () => tags.map(name => ({ name, sha: 'a'.repeat(40) })),
+      getLatestReleaseTag: async () => null
+    };
+  }
+
+  test('starts at firstVersion when there are no tags', async () => {
+    const commits = await analyzeCommits(createProvider(['feat: everything\n\nBREAKING CHANGE: it is all new', 'fix: a bug']), config, 'v0.0.0');
+
+    const result = await determineVersionBump(commits, createTagProvider([]), config);
+    expect(result).toMatchObject({ bumpType: 'major', newVersion: '0.1.0', currentVersion: '0.0.0' });
+
+    const custom = await determineVersionBump(commits, createTagProvider([]), { ...config, firstVersion: 'v1.0.0' });
+    expect(custom.newVersion).toBe('1.0.0');
+  });
+
+  test('tags that do not match the tag format do not count', async () => {
+    const commits = await analyzeCommits(createProvider(['fix: a bug']), config, 'v0.0.0');
+    const provider = createTagProvider(['nightly', 'other-package-v3.0.0']);
+
+    const result = await determineVersionBump(commits, provider, { ...config, tagNamespace: 'api' });
+    expect(result.newVersion).toBe('0.1.0');
+  });
+
+  test('prerelease channels start from firstVersion', async () => {
+    const commits = await analyzeCommits(createProvider(['feat: a thing']), config, 'v0.0.0');
+
+    const result = await determineVersionBump(commits, createTagProvider([]), { ...config, prereleaseChannel: 'beta' });
+    expect(result.newVersion).toBe('0.1.0-beta.1');
+  });
+
+  test('analyzeCommits reads the whole history', async () => {
+    const provider = {
+      ...createProvider([]),
+      compareCommits: async () => {
+        throw new Error('there is nothing to compare against');
+      },
+      listCommits: async head => ['fix: first commit', `feat: start ${head}`].map((message, index) => ({
+        sha: String(index + 1).repeat(40),
+        message,
+        author: 'kaity',
+        date: '2024-01-01T00:00:00Z',
+        url: `https://github.com/owner/repo/commit/${index + 1}`
+      }))
+    };
+
+    const commits = await analyzeCommits(provider, { ...config, firstRelease: true });
+    expect(commits.map(commit => commit.parsed.header)).toEqual(['fix: first commit', 'feat: start main']);
+  });
+});
+
+describe('ignorePaths', () => {
+  const files = {
+    '1111111111111111111111111111111111111111': ['test/fixtures/users.json'],
+    '2222222222222222222222222222222222222222': ['docs/setup.md', '.github/workflows/ci.yml'],
+    '3333333333333333333333333333333333333333': ['docs/setup.md', 'src/login.js']
+  };
+
+  /**
+   * Provider whose commits touch the files above
+   */
+  function createFileProvider() {
+    return {
+      ...createProvider(['feat: update test fixture', 'fix: typo in the docs', 'feat: login form']),
+      getCommitFiles: async sha => files[sha]
+    };
+  }
+
+  test('commits only touching ignored paths are left out', async () => {
+    const commits = await analyzeCommits(createFileProvider(), { ...config, ignorePaths: ['docs/', '.github/**', 'test/fixtures/'] });
+
+    expect(commits.map(commit => commit.parsed.header)).toEqual(['feat: login form']);
+    expect(commits[0].files).toEqual(['docs/setup.md', 'src/login.js']);
+  });
+
+  test('files are only fetched when ignorePaths is set', async () => {
+    const provider = {
+      ...createFileProvider(),
+      getCommitFiles: async () => {
+        throw new Error('should not be called');
+      }
+    };
+    const commits = await analyzeCommits(provider, config);
+
+    expect(commits).toHaveLength(3);
+  });
+});
@@ -0,0 +1,105 @@
+/**
+ * Tests for releasing an explicit list of commits
+ *
+ * These tests validate that handing over commit SHAs replaces the range -
+ * only the listed commits count towards the bump and the changelog, even when
+ * they aren't on the source branch - that a SHA the repo doesn't have fails
+ * the run, and how --commits files are read.
+ */
+
+/* global describe, test, expect, beforeEach, afterEach */
+
+const fs = require('fs');
+const os = require('os');
+const path = require('path');
+
+const { ReleaseBoss, FakeProvider, resolveConfig } = require('../src/releaseBoss');
+const { analyzeCommits } = require('../src/core/commitAnalyzer');
+const { parseArgs, readCommitList } = require('../src/cli');
+
+const CONTEXT = { payload: {} };
+
+/**
+ * Fake repo with v1.0.0 released, a feature and a fix on main since, and a fix on a side branch
+ */
+function createRepo() {
+  const provider = new FakeProvider({ branch: 'main' });
+  provider.addCommits('main', ['feat: first thing']);
+  provider.createBranch('release', 'main');
+  provider.addTag('v1.0.0', 'release');
+  provider.createBranch('hotfix', 'main');
+  const [sideFix] = provider.addCommits('hotfix', ['fix: side branch thing']);
+  const [feature, fix] = provider.addCommits('main', ['feat: not picked yet', 'fix: picked thing']);
+  return { provider, feature, fix, sideFix };
+}
+
+describe('analyzing a list of commits', () => {
+  test('only the listed commits are analyzed, in the order given', async () => {
+    const { provider, fix, sideFix } = createRepo();
+
+    const commits = await analyzeCommits(provider, resolveConfig({}), null, null, { shas: [fix.sha, sideFix.sha.substring(0, 7), fix.sha] });
+
+    expect(commits.map(commit => commit.message)).toEqual(['fix: picked thing', 'fix: side branch thing']);
+    expect(commits[1].hash).toBe(sideFix.sha);
+  });
+
+  test('a SHA the repo doesn\'t have fails', async () => {
+    const { provider, fix } = createRepo();
+
+    await expect(analyzeCommits(provider, resolveConfig({}), null, null, { shas: [fix.sha, 'deadbeef'] }))
+      .rejects.toThrow('Commit deadbeef isn\'t in this repo');
+  });
+});
+
+describe('running the workflow with commits', () => {
+  test('the bump and changelog come from the listed commits alone', async () => {
+    const { provider, fix } = createRepo();
+
+    const result = await new ReleaseBoss({}, { provider, context: CONTEXT, commits: [fix.sha] }).run();
+
+    expect(result).toMatchObject({ runType: 'pr', bumpType: 'patch', nextVersion: '1.0.1' });
+    expect(result.changelog).toContain('picked thing');
+    expect(result.changelog).not.toContain('not picked yet');
+  });
+
+  test('nextVersion uses them too', async () => {
+    const { provider, feature, fix } = createRepo();
+
+    expect(await new ReleaseBoss({}, { provider, commits: [fix.sha] }).nextVersion()).toMatchObject({ nextVersion: '1.0.1' });
+    expect(await new ReleaseBoss({}, { provider, commits: [fix.sha, feature.sha] }).nextVersion()).toMatchObject({ nextVersion: '1.1.0' });
+  });
+});
+
+describe('--commits', () => {
+  let tmpDir;
+
+  beforeEach(() => {
+    tmpDir = fs.mkdtempSync(path.join(os.tmpdir(), 'release-boss-'));
+  });
+
+  afterEach(() => {
+    fs.rmSync(tmpDir, { recursive: true, force: true });
+  });
+
+  const write = content => {
+    const file = path.join(tmpDir, 'commits.txt');
+    fs.writeFileSync(file, content);
+    return file;
+  };
+
+  test('takes a file', () => {
+    expect(parseArgs(['release', '--commits', 'picked.txt'])).toEqual({ command: 'release', options: { commits: 'picked.txt' } });
+  });
+
+  test('reads SHAs by line, space or comma, skipping comments', () => {
+    const file = write('# picked for rc-1\n4f2c9e1d8b7a6c5d4e3f2a1b0c9d8e7f6a5b4c3d\nabc1234, ABCDEF0 # the fix\n\n');
+
+    expect(readCommitList(file)).toEqual(['4f2c9e1d8b7a6c5d4e3f2a1b0c9d8e7f6a5b4c3d', 'abc1234', 'ABCDEF0']);
+  });
+
+  test('anything that isn\'t a SHA, or nothing at all, is an error', () => {
+    expect(() => readCommitList(write('abc1234\nmain\n'))).toThrow('lists "main", which isn\'t a commit SHA');
+    expect(() => readCommitList(write('# nothing yet\n'))).toThrow('doesn\'t list any commit SHAs');
+    expect(() => readCommitList(path.join(tmpDir, 'missing.txt'))).toThrow('Couldn\'t read --commits from');
+  });
+});
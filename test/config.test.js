@@ -0,0 +1,81 @@
+/**
+ * Tests for loading the config file
+ *
+ * These tests validate that ${NAME} references are filled in from the
+ * environment in YAML and JSON configs, that ${NAME:-fallback} covers unset
+ * and empty variables, that an unset variable without one names the key
+ * using it, and that hook commands and $${NAME} are left alone.
+ */
+
+/* global describe, test, expect, beforeAll, afterAll */
+
+const fs = require('fs');
+const os = require('os');
+const path = require('path');
+const { getConfig, interpolateEnv } = require('../src/utils/config');
+
+describe('interpolateEnv', () => {
+  const env = { REPO_OWNER: 'atikayda', EMPTY: '' };
+
+  test('fills in references anywhere in a string, in nested values too', () => {
+    expect(interpolateEnv({
+      repository: '${REPO_OWNER}/widgets',
+      packages: [{ name: 'api', tagNamespace: '${REPO_OWNER}-api/' }],
+      retry: { maxAttempts: 3 }
+    }, env)).toEqual({
+      repository: 'atikayda/widgets',
+      packages: [{ name: 'api', tagNamespace: 'atikayda-api/' }],
+      retry: { maxAttempts: 3 }
+    });
+  });
+
+  test('the fallback covers unset and empty variables', () => {
+    expect(interpolateEnv({ baseUrl: '${GITEA_URL:-https://gitea.example.com}' }, env)).toEqual({ baseUrl: 'https://gitea.example.com' });
+    expect(interpolateEnv({ tagPrefix: '${EMPTY:-release-}' }, env)).toEqual({ tagPrefix: 'release-' });
+    expect(interpolateEnv({ tagPrefix: '${EMPTY:-}' }, env)).toEqual({ tagPrefix: '' });
+  });
+
+  test('an unset variable without a fallback names the key using it', () => {
+    expect(() => interpolateEnv({ packages: [{ path: '${PACKAGE_DIR}' }] }, env))
+      .toThrow('packages[0].path uses ${PACKAGE_DIR}, but PACKAGE_DIR isn\'t set - set it, or give a default like ${PACKAGE_DIR:-value}');
+    expect(() => interpolateEnv({ repository: '${EMPTY}' }, env)).toThrow('repository uses ${EMPTY}, but EMPTY isn\'t set');
+  });
+
+  test('hook commands, $${NAME} and other dollar signs are left alone', () => {
+    expect(interpolateEnv({
+      hooks: { postRelease: ['echo ${RELEASE_VERSION}'] },
+      pullRequestFooter: 'Costs $5 - set $${REPO_OWNER} or ${{ secrets.X }}'
+    }, env)).toEqual({
+      hooks: { postRelease: ['echo ${RELEASE_VERSION}'] },
+      pullRequestFooter: 'Costs $5 - set ${REPO_OWNER} or ${{ secrets.X }}'
+    });
+  });
+});
+
+describe('getConfig', () => {
+  let dir;
+
+  beforeAll(() => {
+    dir = fs.mkdtempSync(path.join(os.tmpdir(), 'release-boss-config-'));
+    process.env.RELEASE_BOSS_TEST_OWNER = 'atikayda';
+  });
+
+  afterAll(() => {
+    delete process.env.RELEASE_BOSS_TEST_OWNER;
+    fs.rmSync(dir, { recursive: true, force: true });
+  });
+
+  test('YAML and JSON configs are both interpolated', async () => {
+    fs.writeFileSync(path.join(dir, 'config.yml'), 'repository: ${RELEASE_BOSS_TEST_OWNER}/widgets\nreleaseBranch: ${RELEASE_BOSS_TEST_BRANCH:-release}\n');
+    fs.writeFileSync(path.join(dir, 'config.json'), JSON.stringify({ repository: '${RELEASE_BOSS_TEST_OWNER}/gadgets' }));
+
+    await expect(getConfig(path.join(dir, 'config.yml'))).resolves.toMatchObject({ repository: 'atikayda/widgets', releaseBranch: 'release' });
+    await expect(getConfig(path.join(dir, 'config.json'))).resolves.toMatchObject({ repository: 'atikayda/gadgets' });
+  });
+
+  test('a missing variable fails the load', async () => {
+    fs.writeFileSync(path.join(dir, 'missing.yml'), 'repository: ${RELEASE_BOSS_TEST_MISSING}/widgets\n');
+
+    await expect(getConfig(path.join(dir, 'missing.yml'))).rejects.toThrow('Failed to load config: repository uses ${RELEASE_BOSS_TEST_MISSING}');
+  });
+});
@@ -0,0 +1,133 @@
+/**
+ * Tests for the in-memory fake provider
+ *
+ * These tests validate that the fake keeps a commit graph that compare,
+ * list and file lookups read like a real repo, that the whole workflow runs
+ * against it - release PR opened, re-runs updating it in place, merging and
+ * finalizing it tagging the merge commit - and that rollback undoes a release
+ * it made.
+ */
+
+/* global describe, test, expect */
+
+const { ReleaseBoss, FakeProvider, resolveConfig } = require('../src/releaseBoss');
+const { rollbackRelease } = require('../src/core/rollback');
+const { Provider } = require('../src/providers/provider');
+
+const CONTEXT = { payload: {} };
+
+/**
+ * Fake repo with v1.0.0 released from main into release, and two new commits on main since
+ */
+function createRepo() {
+  const provider = new FakeProvider({ branch: 'main' });
+  provider.addCommits('main', [{ message: 'feat: first thing', files: { 'README.md': '# repo\n' } }]);
+  provider.createBranch('release', 'main');
+  provider.addTag('v1.0.0', 'release');
+  provider.addCommits('main', [
+    { message: 'feat: shiny new thing', email: 'kaity@example.com', files: { 'src/shiny.js': 'shiny\n' } },
+    'fix: small thing'
+  ]);
+  return provider;
+}
+
+describe('FakeProvider', () => {
+  test('implements the whole Provider interface', () => {
+    // The base class's link formats and no-op connection check are already right for the fake
+    const inherited = ['constructor', 'checkConnection', 'compareUrl', 'pullRequestUrl', 'issueUrl'];
+    const missing = Object.getOwnPropertyNames(Provider.prototype)
+      .filter(name => !inherited.includes(name) && !Object.prototype.hasOwnProperty.call(FakeProvider.prototype, name));
+    const provider = new FakeProvider();
+
+    expect(missing).toEqual([]);
+    expect(provider.name).toBe('fake');
+    expect(provider.pullRequestUrl(3)).toBe('https://github.com/owner/repo/pull/3');
+  });
+
+  test('compare, list and file lookups read the commit graph', async () => {
+    const provider = createRepo();
+
+    const compared = await provider.compareCommits('release', 'main');
+    expect(compared.map(commit => commit.message)).toEqual(['feat: shiny new thing', 'fix: small thing']);
+    expect(compared[0]).toMatchObject({ authorEmail: 'kaity@example.com', url: `https://github.com/owner/repo/commit/${compared[0].sha}` });
+    expect((await provider.listCommits('main')).map(commit => commit.message)).toEqual(['feat: first thing', 'feat: shiny new thing', 'fix: small thing']);
+    expect(await provider.getCommitFiles(compared[0].sha)).toEqual(['src/shiny.js']);
+
+    expect(await provider.getFileContent('src/shiny.js', 'main')).toBe('shiny\n');
+    expect(await provider.getFileContent('src/shiny.js', 'v1.0.0')).toBeNull();
+    expect(await provider.resolveRef(compared[0].sha.substring(0, 7))).toBe(compared[0].sha);
+    await expect(provider.compareCommits('v9.9.9', 'main')).rejects.toMatchObject({ status: 404 });
+  });
+
+  test('shas and dates are the same every time', () => {
+    const [first] = createRepo().addCommits('main', ['chore: again']);
+    const [second] = createRepo().addCommits('main', ['chore: again']);
+
+    expect(first.sha).toBe(second.sha);
+    expect(first.date).toBe('2024-01-01T00:03:00.000Z');
+  });
+});
+
+describe('running the workflow', () => {
+  test('opens a release PR with the changelog committed to the staging branch', async () => {
+    const provider = createRepo();
+
+    const result = await new ReleaseBoss({}, { provider, context: CONTEXT }).run();
+
+    expect(result).toMatchObject({ runType: 'pr', bumpType: 'minor', previousVersion: '1.0.0', nextVersion: '1.1.0', prNumber: 1, prStatus: 'open' });
+    expect(provider.pullRequests).toEqual([expect.objectContaining({
+      number: 1,
+      state: 'open',
+      title: 'chore: release 1.1.0',
+      headBranch: 'staging-v1.1.0',
+      baseBranch: 'release'
+    })]);
+    expect(await provider.getFileContent('CHANGELOG.md', 'staging-v1.1.0')).toContain('shiny new thing');
+    expect(await provider.getFileContent('src/shiny.js', 'staging-v1.1.0')).toBe('shiny\n');
+  });
+
+  test('a re-run after new commits moves the open PR to the new version', async () => {
+    const provider = createRepo();
+    await new ReleaseBoss({}, { provider, context: CONTEXT }).run();
+    provider.addCommits('main', ['feat: rename everything\n\nBREAKING CHANGE: every name is new']);
+
+    const result = await new ReleaseBoss({}, { provider, context: CONTEXT }).run();
+
+    expect(result).toMatchObject({ nextVersion: '2.0.0', prNumber: 1 });
+    expect(provider.pullRequests).toHaveLength(1);
+    expect(provider.pullRequests[0]).toMatchObject({ title: 'chore: release 2.0.0', headBranch: 'staging-v1.1.0' });
+  });
+
+  test('merging the PR and running on the release branch tags the merge commit', async () => {
+    const provider = createRepo();
+    await new ReleaseBoss({}, { provider, context: CONTEXT }).run();
+
+    const { sha } = await provider.mergePR(1, { method: 'merge' });
+    provider.checkout('release');
+    const result = await new ReleaseBoss({ createGithubRelease: true }, { provider, context: CONTEXT }).run();
+
+    expect(result).toMatchObject({ runType: 'release', releaseTag: 'v1.1.0', releaseCommitSha: sha });
+    expect(provider.tags.get('v1.1.0')).toBe(sha);
+    expect(provider.releases.get('v1.1.0')).toMatchObject({ title: 'v1.1.0', body: expect.stringContaining('shiny new thing') });
+    expect(await provider.getFileContent('CHANGELOG.md', 'release')).toContain('1.1.0');
+    expect(provider.events.map(event => event.type)).toEqual(expect.arrayContaining(['createPR', 'mergePR', 'createTag', 'createRelease']));
+  });
+
+  test('rollback deletes the tag and release it made', async () => {
+    const provider = createRepo();
+    await new ReleaseBoss({}, { provider, context: CONTEXT }).run();
+    await provider.mergePR(1, { method: 'merge' });
+    await new ReleaseBoss({ createGithubRelease: true }, { provider, context: CONTEXT }).finalize({ pr: 1 });
+
+    // No local checkout to clean up - the tests' own repo keeps its tags
+    const git = async () => {
+      throw new Error('not a git repository');
+    };
+    const rolledBack = await rollbackRelease(provider, resolveConfig({}), '1.1.0', { revert: true, git });
+
+    expect(rolledBack).toMatchObject({ tag: 'v1.1.0', releaseDeleted: true, tagDeleted: true });
+    expect(provider.tags.has('v1.1.0')).toBe(false);
+    expect(provider.releases.has('v1.1.0')).toBe(false);
+    expect(await provider.getFileContent('CHANGELOG.md', 'release')).toBeNull();
+  });
+});
@@ -0,0 +1,28 @@
+// Build information - every placeholder the template engine knows about
+
+/* %%release-boss:
+const BUILD = {
+  version: '{{version}}',
+  major: {{major}},
+  minor: {{minor}},
+  patch: {{patch}},
+  prerelease: '{{prerelease}}',
+  sha: '{{sha}}',
+  shortSha: '{{shortSha}}',
+  date: '{{date}}',
+  custom: '{{notAPlaceholder}}'
+};
+%% */
+const BUILD = {
+  version: '0.1.0',
+  major: 0,
+  minor: 1,
+  patch: 0,
+  prerelease: '',
+  sha: '',
+  shortSha: '',
+  date: '',
+  custom: ''
+};
+
+module.exports = BUILD;
@@ -0,0 +1,12 @@
+package main
+
+// Version file where a hand edit on the release branch clashed with the last release
+
+// %%release-boss: const Version = "v{{version}}"%%
+<<<<<<< HEAD
+const Version = "v1.2.0"
+=======
+const Version = "v1.2.0-hotfix"
+>>>>>>> hotfix/version
+
+const Name = "app"
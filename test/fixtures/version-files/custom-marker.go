@@ -0,0 +1,18 @@
+package main
+
+// Version file using a custom marker prefix
+
+// %%releaseboss const Version = "v{{version}}"%%
+const Version = "v0.1.0"
+
+// %%release-boss: const Ignored = "v{{version}}"%%
+const Ignored = "untouched"
+
+/* %%releaseboss
+const Major = "{{major}}"
+const Minor = "{{minor}}"
+const Patch = "{{patch}}"
+%% */
+const Major = "0"
+const Minor = "1"
+const Patch = "0"
@@ -0,0 +1,34 @@
+package version
+
+// Build information served by the /version endpoint - the markers sit inside
+// the function so the rendered lines have to keep its indentation
+
+type Build struct {
+	Version string
+	Major   int
+	Source  Source
+}
+
+type Source struct {
+	SHA string
+}
+
+func Current() Build {
+	// %%release-boss: const version = "v{{version}}"%%
+	const version = "v0.1.0"
+
+	return Build{
+		/* %%release-boss:
+		Version: version,
+		Major:   {{major}},
+		Source: Source{
+			SHA: "{{shortSha}}",
+		},
+		%% */
+		Version: version,
+		Major:   0,
+		Source: Source{
+			SHA: "",
+		},
+	}
+}
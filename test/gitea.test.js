@@ -0,0 +1,332 @@
+/**
+ * Tests for the Gitea provider
+ *
+ * These tests run the provider against a small in-memory Gitea server over
+ * real HTTP, and validate that every page of a capped list is fetched, that
+ * release PRs are opened (and refreshed) with the contents API, that merges
+ * are pinned to the checked head and that the release tag is published as a
+ * Gitea release, whose notes can be filled in afterwards.
+ */
+
+/* global describe, test, expect, beforeAll, afterAll, beforeEach, afterEach */
+
+const fs = require('fs');
+const http = require('http');
+const os = require('os');
+const path = require('path');
+
+const { GiteaProvider } = require('../src/providers/giteaProvider');
+const { createProvider } = require('../src/providers');
+const { resolveConfig } = require('../src/utils/config');
+
+const config = {
+  mergeBranch: 'main',
+  releaseBranch: 'release',
+  stagingBranch: 'staging',
+  pullRequestTitle: 'chore: release {version}',
+  commitMessage: 'chore: release {version}',
+  changelogPath: 'CHANGELOG.md',
+  versionTagPrefix: true
+};
+
+const MAIN_SHA = 'a'.repeat(40);
+const RELEASE_SHA = 'b'.repeat(40);
+const MERGE_SHA = 'c'.repeat(40);
+
+/**
+ * Repository state the fake server serves - reset before every test
+ */
+function createRepo() {
+  return {
+    // Like a real server, pages are capped below the limit we ask for
+    maxItems: 30,
+    tags: Array.from({ length: 70 }, (_, index) => ({ name: `v0.${index}.0`, commit: { sha: String(index).padStart(40, '0') } })),
+    branches: {
+      main: { name: 'main', commit: { id: MAIN_SHA, timestamp: '2024-01-02T00:00:00Z' } },
+      release: { name: 'release', commit: { id: RELEASE_SHA, timestamp: '2024-01-01T00:00:00Z' } }
+    },
+    files: { main: { 'CHANGELOG.md': '# Changelog\n' }, release: { 'CHANGELOG.md': '# Changelog\n' } },
+    pulls: [],
+    releases: [],
+    status: { state: 'pending', statuses: [{ context: 'ci/test', status: 'pending' }] },
+    requests: []
+  };
+}
+
+/**
+ * Handle one request against the repository state
+ * @returns {Array} - [status, body, headers]
+ */
+function route(repo, method, url, body) {
+  const base = '/api/v1/repos/owner/repo';
+  const { pathname, searchParams } = url;
+  const page = Number(searchParams.get('page') || 1);
+  const limit = Math.min(Number(searchParams.get('limit') || repo.maxItems), repo.maxItems);
+  const paged = items => [200, items.slice((page - 1) * limit, page * limit), { 'X-Total-Count': String(items.length) }];
+  const findPR = number => repo.pulls.find(pr => pr.number === Number(number));
+
+  if (pathname === '/api/v1/version') return [200, { version: '1.22.0' }];
+  if (!pathname.startsWith(base)) return [404, { message: 'not found' }];
+  const apiPath = pathname.substring(base.length);
+  let match;
+
+  if (method === 'GET' && apiPath === '/tags') return paged(repo.tags);
+  if (method === 'POST' && apiPath === '/tags') {
+    repo.tags.push({ name: body.tag_name, commit: { sha: body.target }, message: body.message });
+    return [201, {}];
+  }
+  if (method === 'DELETE' && (match = apiPath.match(/^\/tags\/(.+)$/))) {
+    const index = repo.tags.findIndex(tag => tag.name === decodeURIComponent(match[1]));
+    if (index === -1) return [404, { message: 'tag not found' }];
+    repo.tags.splice(index, 1);
+    return [204, ''];
+  }
+  if (method === 'POST' && apiPath === '/releases') {
+    if (!repo.tags.some(tag => tag.name === body.tag_name)) {
+      repo.tags.push({ name: body.tag_name, commit: { sha: body.target_commitish } });
+    }
+    repo.releases.push(body);
+    return [201, { id: repo.releases.length, ...body }];
+  }
+  if (method === 'GET' && (match = apiPath.match(/^\/releases\/tags\/(.+)$/))) {
+    const index = repo.releases.findIndex(release => release.tag_name === decodeURIComponent(match[1]));
+    return index === -1 ? [404, { message: 'release not found' }] : [200, { id: index + 1, ...repo.releases[index] }];
+  }
+  if (method === 'PATCH' && (match = apiPath.match(/^\/releases\/(\d+)$/))) {
+    Object.assign(repo.releases[Number(match[1]) - 1], body);
+    return [200, { id: Number(match[1]), html_url: `https://gitea.example.com/owner/repo/releases/tag/${repo.releases[Number(match[1]) - 1].tag_name}` }];
+  }
+  if (method === 'GET' && apiPath === '/branches') return paged(Object.values(repo.branches));
+  if ((match = apiPath.match(/^\/branches\/(.+)$/))) {
+    const name = decodeURIComponent(match[1]);
+    if (!repo.branches[name]) return [404, { message: 'branch not found' }];
+    if (method === 'DELETE') {
+      delete repo.branches[name];
+      return [204, ''];
+    }
+    return [200, repo.branches[name]];
+  }
+  if (method === 'POST' && apiPath === '/branches') {
+    repo.branches[body.new_branch_name] = { name: body.new_branch_name, commit: { ...repo.branches[body.old_branch_name].commit } };
+    repo.files[body.new_branch_name] = { ...(repo.files[body.old_branch_name] || {}) };
+    return [201, repo.branches[body.new_branch_name]];
+  }
+  if (method === 'GET' && (match = apiPath.match(/^\/contents\/(.+)$/))) {
+    const content = (repo.files[searchParams.get('ref')] || {})[decodeURIComponent(match[1])];
+    if (content === undefined) return [404, { message: 'file not found' }];
+    return [200, { type: 'file', content: Buffer.from(content).toString('base64'), sha: `blob-${content.length}` }];
+  }
+  if (method === 'POST' && apiPath === '/contents') {
+    for (const file of body.files) {
+      repo.files[body.branch][file.path] = Buffer.from(file.content, 'base64').toString('utf8');
+    }
+    return [201, {}];
+  }
+  if (method === 'GET' && apiPath === '/pulls') {
+    const state = searchParams.get('state');
+    return paged(repo.pulls.filter(pr => state === 'all' || pr.state === state));
+  }
+  if (method === 'POST' && apiPath === '/pulls') {
+    const pr = {
+      number: repo.pulls.length + 1,
+      html_url: `https://gitea.example.com/owner/repo/pulls/${repo.pulls.length + 1}`,
+      state: 'open',
+      merged: false,
+      mergeable: true,
+      title: body.title,
+      body: body.body,
+      head: { ref: body.head, sha: MAIN_SHA },
+      base: { ref: body.base },
+      merge_commit_sha: null
+    };
+    repo.pulls.push(pr);
+    return [201, pr];
+  }
+  if ((match = apiPath.match(/^\/pulls\/(\d+)$/))) {
+    const pr = findPR(match[1]);
+    if (method === 'PATCH') Object.assign(pr, body);
+    return [200, pr];
+  }
+  if (method === 'POST' && (match = apiPath.match(/^\/pulls\/(\d+)\/merge$/))) {
+    const pr = findPR(match[1]);
+    if (body.head_commit_id && body.head_commit_id !== pr.head.sha) return [409, { message: 'head out of date' }];
+    Object.assign(pr, { state: 'closed', merged: true, merge_commit_sha: MERGE_SHA });
+    return [200, ''];
+  }
+  if (method === 'GET' && (match = apiPath.match(/^\/commits\/(\w+)\/status$/))) return [200, repo.status];
+  if (method === 'GET' && (match = apiPath.match(/^\/commits\/(\w+)\/pull$/))) {
+    const pr = repo.pulls.find(candidate => candidate.merge_commit_sha === match[1]);
+    return pr ? [200, pr] : [404, { message: 'no pull request' }];
+  }
+  return [404, { message: `no route for ${method} ${apiPath}` }];
+}
+
+describe('GiteaProvider', () => {
+  let server;
+  let baseUrl;
+  let repo;
+  let tmpDir;
+  let cwd;
+
+  beforeAll(async () => {
+    server = http.createServer((req, res) => {
+      const chunks = [];
+      req.on('data', chunk => chunks.push(chunk));
+      req.on('end', () => {
+        const body = chunks.length > 0 ? JSON.parse(Buffer.concat(chunks).toString('utf8')) : undefined;
+        const url = new URL(req.url, 'http://localhost');
+        repo.requests.push({ method: req.method, path: url.pathname, query: url.search, body, auth: req.headers.authorization });
+
+        const [status, data, headers = {}] = route(repo, req.method, url, body);
+        res.writeHead(status, { 'Content-Type': 'application/json', ...headers });
+        res.end(typeof data === 'string' ? data : JSON.stringify(data));
+      });
+    });
+    await new Promise(resolve => server.listen(0, '127.0.0.1', resolve));
+    baseUrl = `http://127.0.0.1:${server.address().port}`;
+  });
+
+  afterAll(async () => {
+    await new Promise(resolve => server.close(resolve));
+  });
+
+  beforeEach(() => {
+    repo = createRepo();
+    cwd = process.cwd();
+    tmpDir = fs.mkdtempSync(path.join(os.tmpdir(), 'release-boss-gitea-'));
+    process.chdir(tmpDir);
+  });
+
+  afterEach(() => {
+    process.chdir(cwd);
+    fs.rmSync(tmpDir, { recursive: true, force: true });
+  });
+
+  /**
+   * Provider talking to the fake server
+   */
+  function createGitea(options = {}) {
+    return new GiteaProvider({ token: 'gitea-token', repository: 'owner/repo', baseUrl: `${baseUrl}/`, ...options });
+  }
+
+  test('follows X-Total-Count past pages the server capped', async () => {
+    const tags = await createGitea().listTags();
+
+    expect(tags).toHaveLength(70);
+    expect(tags[69]).toEqual({ name: 'v0.69.0', sha: '69'.padStart(40, '0') });
+    // 30 + 30 + 10 - a short page of 30 when we asked for 50 isn't the end
+    expect(repo.requests.map(request => request.query)).toEqual(['?page=1&limit=50', '?page=2&limit=50', '?page=3&limit=50']);
+    expect(repo.requests[0].auth).toBe('token gitea-token');
+  });
+
+  test('opens the release PR with the contents API, then refreshes it on the next run', async () => {
+    const provider = createGitea();
+    fs.writeFileSync(path.join(tmpDir, 'version.txt'), '1.2.0\n');
+
+    const created = await provider.createReleasePR('1.2.0', '## 1.2.0\n\n* shiny\n', config, [path.join(tmpDir, 'version.txt')]);
+
+    expect(created).toEqual({ prNumber: 1, prUrl: 'https://gitea.example.com/owner/repo/pulls/1', prStatus: 'open' });
+    expect(repo.pulls[0]).toMatchObject({ title: 'chore: release 1.2.0', head: { ref: 'staging-v1.2.0' }, base: { ref: 'release' } });
+    expect(repo.files['staging-v1.2.0']['version.txt']).toBe('1.2.0\n');
+    expect(repo.files['staging-v1.2.0']['CHANGELOG.md']).toContain('* shiny');
+
+    // The changelog already existed, so it's an update with the old blob's sha
+    const commit = repo.requests.find(request => request.method === 'POST' && request.path.endsWith('/contents'));
+    expect(commit.body.files.map(file => [file.operation, file.path, file.sha])).toEqual([
+      ['create', 'version.txt', undefined],
+      ['update', 'CHANGELOG.md', 'blob-12']
+    ]);
+
+    const refreshed = await provider.createReleasePR('1.2.0', '## 1.2.0\n\n* shinier\n', config, []);
+    expect(refreshed.prNumber).toBe(1);
+    expect(repo.pulls).toHaveLength(1);
+    expect(repo.pulls[0].body).toContain('shinier');
+  });
+
+  test('waits for pending statuses and merges at the checked head', async () => {
+    const provider = createGitea();
+    await provider.createReleasePR('1.2.0', '## 1.2.0\n', config, []);
+
+    await expect(provider.getPRMergeStatus(1)).resolves.toEqual({ state: 'pending', reason: 'waiting on the checks', sha: MAIN_SHA });
+    repo.status = { state: 'failure', statuses: [{ context: 'ci/test', status: 'failure' }] };
+    await expect(provider.getPRMergeStatus(1)).resolves.toMatchObject({ state: 'blocked', reason: 'checks failed: ci/test' });
+    repo.status = { state: 'success', statuses: [] };
+    await expect(provider.getPRMergeStatus(1)).resolves.toMatchObject({ state: 'ready' });
+
+    // A head that moved since the checks is a 409, not a crash
+    await expect(provider.mergePR(1, { method: 'squash', sha: 'd'.repeat(40) })).resolves.toEqual({ merged: false, sha: null });
+    await expect(provider.mergePR(1, { method: 'squash', sha: MAIN_SHA })).resolves.toEqual({ merged: true, sha: MERGE_SHA });
+    expect(repo.requests.filter(request => request.path.endsWith('/merge')).pop().body).toEqual({ Do: 'squash', head_commit_id: MAIN_SHA });
+    expect((await provider.getPR(1)).state).toBe('merged');
+  });
+
+  test('finds the merged release PR from the merge commit', async () => {
+    const provider = createGitea({ sha: MERGE_SHA, ref: 'release' });
+    await provider.createReleasePR('1.2.0', '## 1.2.0\n', config, []);
+    repo.status = { state: 'success', statuses: [] };
+    await provider.mergePR(1, { method: 'merge' });
+
+    await expect(provider.detectMergedReleasePR(config)).resolves.toMatchObject({
+      number: 1,
+      headBranch: 'staging-v1.2.0',
+      version: '1.2.0',
+      merged: true,
+      mergeCommitSha: MERGE_SHA
+    });
+    await expect(createGitea({ sha: MERGE_SHA, ref: 'main' }).detectMergedReleasePR(config)).resolves.toBeNull();
+  });
+
+  test('publishes a prerelease tag as a Gitea prerelease', async () => {
+    repo.tags = [];
+
+    const result = await createGitea().createTag('2.0.0-beta.1', config, { sha: MERGE_SHA, annotate: true });
+
+    expect(result).toEqual({ sha: MERGE_SHA, tags: ['v2.0.0-beta.1'] });
+    // The annotated tag has to exist before the release, or Gitea makes a lightweight one
+    expect(repo.tags).toEqual([{ name: 'v2.0.0-beta.1', commit: { sha: MERGE_SHA }, message: 'Release v2.0.0-beta.1' }]);
+    expect(repo.releases).toEqual([{ tag_name: 'v2.0.0-beta.1', target_commitish: MERGE_SHA, name: 'v2.0.0-beta.1', prerelease: true }]);
+  });
+
+  test('tags the head of the release branch by default and recreates alias tags', async () => {
+    repo.tags = [{ name: 'latest', commit: { sha: '9'.repeat(40) } }];
+
+    await expect(createGitea().createTag('1.2.0', config)).resolves.toEqual({ sha: RELEASE_SHA, tags: ['v1.2.0', 'latest'] });
+    expect(repo.releases).toEqual([{ tag_name: 'v1.2.0', target_commitish: RELEASE_SHA, name: 'v1.2.0', prerelease: false }]);
+    expect(repo.tags.find(tag => tag.name === 'latest').commit.sha).toBe(RELEASE_SHA);
+    // Without annotate the release makes the tag itself
+    const tagPosts = repo.requests.filter(request => request.method === 'POST' && request.path.endsWith('/tags'));
+    expect(tagPosts.map(request => request.body.tag_name)).toEqual(['latest']);
+  });
+
+  test('fills in the notes of the release createTag made', async () => {
+    repo.tags = [];
+    const provider = createGitea();
+    await provider.createTag('1.2.0', config);
+
+    const release = await provider.createRelease('v1.2.0', { title: 'Release 1.2.0', body: '* shiny', prerelease: false });
+
+    expect(release).toEqual({ url: 'https://gitea.example.com/owner/repo/releases/tag/v1.2.0', updated: true });
+    expect(repo.releases).toEqual([{ tag_name: 'v1.2.0', target_commitish: RELEASE_SHA, name: 'Release 1.2.0', body: '* shiny', prerelease: false }]);
+  });
+
+  test('a baseUrl that is not Gitea fails the connection check', async () => {
+    await expect(new GiteaProvider({ repository: 'owner/repo', baseUrl: `${baseUrl}/gitea` }).checkConnection())
+      .rejects.toThrow('/gitea/api/v1/version doesn\'t exist, so that\'s not a Gitea instance');
+    await expect(createGitea().checkConnection()).resolves.toBeUndefined();
+  });
+});
+
+describe('gitea platform config', () => {
+  test('platform: gitea picks the Gitea provider', () => {
+    const provider = createProvider({ platform: 'gitea', baseUrl: 'https://gitea.example.com', repository: 'owner/repo' }, { token: 'gitea-token' });
+
+    expect(provider).toBeInstanceOf(GiteaProvider);
+    expect(provider.pullRequestUrl(7)).toBe('https://gitea.example.com/owner/repo/pulls/7');
+    expect(() => new GiteaProvider({ repository: 'owner/repo' })).toThrow('Gitea provider needs the instance URL');
+  });
+
+  test('signing is GitHub-only', () => {
+    expect(resolveConfig({ platform: 'gitea' }).platform).toBe('gitea');
+    expect(() => resolveConfig({ platform: 'gitea', signTags: true })).toThrow('the Gitea API can\'t create signed commits or tags');
+  });
+});
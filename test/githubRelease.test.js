@@ -0,0 +1,181 @@
+/**
+ * Tests for publishing releases
+ *
+ * These tests validate that the release notes are the version's section of
+ * the merged changelog, that the title template and prerelease flag are
+ * applied, and that a tag that already has a release gets it updated
+ * instead of a second one.
+ */
+
+/* global describe, test, expect, jest */
+
+const { extractChangelogSection } = require('../src/github/changelogTable');
+const { publishRelease, buildReleaseTitle } = require('../src/core/githubRelease');
+const { GitHubProvider } = require('../src/providers/githubProvider');
+const { ReleaseBoss, resolveConfig } = require('../src/releaseBoss');
+
+const MERGE_SHA = 'f'.repeat(40);
+
+const CHANGELOG = [
+  '# Changelog',
+  '',
+  '## 1.3.0-beta.1 (2024-02-01)',
+  '',
+  '* **feat:** shiny beta thing',
+  '',
+  '## 1.2.0 (2024-01-01)',
+  '',
+  '* **feat:** shiny new thing',
+  '* **fix:** small thing',
+  '',
+  '## 1.1.0 (2023-12-01)',
+  '',
+  '* **feat:** older thing',
+  ''
+].join('\n');
+
+describe('extractChangelogSection', () => {
+  test('takes one release without its heading', () => {
+    expect(extractChangelogSection(CHANGELOG, '1.2.0')).toBe('* **feat:** shiny new thing\n* **fix:** small thing');
+    expect(extractChangelogSection(CHANGELOG, 'v1.1.0')).toBe('* **feat:** older thing');
+  });
+
+  test('is null for a version the changelog does not have', () => {
+    expect(extractChangelogSection(CHANGELOG, '1.2')).toBeNull();
+    expect(extractChangelogSection(CHANGELOG, '2.0.0')).toBeNull();
+    expect(extractChangelogSection(null, '1.2.0')).toBeNull();
+  });
+});
+
+describe('publishRelease', () => {
+  /**
+   * Provider whose changelog file is CHANGELOG, wherever it's read from
+   */
+  function createProvider() {
+    return {
+      getFileContent: jest.fn(async () => CHANGELOG),
+      createRelease: jest.fn(async () => ({ url: 'https://github.com/owner/repo/releases/tag/v1.2.0', updated: false }))
+    };
+  }
+
+  test('uses the changelog section as it was merged', async () => {
+    const provider = createProvider();
+    const config = { changelogPath: 'CHANGELOG.md', versionTagPrefix: true };
+
+    await publishRelease(provider, config, '1.2.0', { ref: MERGE_SHA });
+
+    expect(provider.getFileContent).toHaveBeenCalledWith('CHANGELOG.md', MERGE_SHA);
+    expect(provider.createRelease).toHaveBeenCalledWith('v1.2.0', {
+      title: 'v1.2.0',
+      body: '* **feat:** shiny new thing\n* **fix:** small thing',
+      prerelease: false
+    });
+  });
+
+  test('templates the title and flags prereleases', async () => {
+    const provider = createProvider();
+    const config = { changelogPath: 'CHANGELOG.md', versionTagPrefix: true, releaseTitle: 'Release {{.Version}}' };
+
+    await publishRelease(provider, config, '1.3.0-beta.1', { ref: MERGE_SHA });
+
+    expect(provider.createRelease).toHaveBeenCalledWith('v1.3.0-beta.1', {
+      title: 'Release 1.3.0-beta.1',
+      body: '* **feat:** shiny beta thing',
+      prerelease: true
+    });
+  });
+
+  test('a missing section still publishes, with empty notes', async () => {
+    const provider = createProvider();
+
+    await publishRelease(provider, { changelogPath: null, versionTagPrefix: true }, '1.2.0', { ref: MERGE_SHA });
+
+    expect(provider.getFileContent).not.toHaveBeenCalled();
+    expect(provider.createRelease).toHaveBeenCalledWith('v1.2.0', expect.objectContaining({ body: '' }));
+  });
+
+  test('the title defaults to the tag name', () => {
+    expect(buildReleaseTitle('1.2.0', { tagPrefix: 'release-' })).toBe('release-1.2.0');
+  });
+});
+
+describe('GitHubProvider.createRelease', () => {
+  /**
+   * GitHub provider whose repo has the given release for v1.2.0 (or none)
+   */
+  function createGitHub(existing) {
+    const notFound = Object.assign(new Error('Not Found'), { status: 404 });
+    const octokit = {
+      rest: {
+        repos: {
+          getReleaseByTag: jest.fn(async () => {
+            if (!existing) throw notFound;
+            return { data: existing };
+          }),
+          createRelease: jest.fn(async () => ({ data: { html_url: 'https://github.com/owner/repo/releases/tag/v1.2.0' } })),
+          updateRelease: jest.fn(async () => ({ data: { html_url: 'https://github.com/owner/repo/releases/tag/v1.2.0' } }))
+        }
+      }
+    };
+    return { octokit, provider: new GitHubProvider(octokit, { repo: { owner: 'owner', repo: 'repo' } }) };
+  }
+
+  const release = { title: 'v1.2.0', body: '* shiny', prerelease: false };
+
+  test('creates the release when the tag has none', async () => {
+    const { octokit, provider } = createGitHub(null);
+
+    await expect(provider.createRelease('v1.2.0', release)).resolves.toEqual({ url: 'https://github.com/owner/repo/releases/tag/v1.2.0', updated: false });
+    expect(octokit.rest.repos.createRelease).toHaveBeenCalledWith({
+      owner: 'owner', repo: 'repo', tag_name: 'v1.2.0', name: 'v1.2.0', body: '* shiny', prerelease: false
+    });
+  });
+
+  test('updates the release that is already there', async () => {
+    const { octokit, provider } = createGitHub({ id: 42 });
+
+    await expect(provider.createRelease('v1.2.0', release)).resolves.toMatchObject({ updated: true });
+    expect(octokit.rest.repos.createRelease).not.toHaveBeenCalled();
+    expect(octokit.rest.repos.updateRelease).toHaveBeenCalledWith(expect.objectContaining({ release_id: 42, body: '* shiny' }));
+  });
+});
+
+describe('createGithubRelease', () => {
+  /**
+   * Provider with release PR #7 for 1.2.0, merged at MERGE_SHA
+   */
+  function createProvider() {
+    return {
+      name: 'github',
+      repoUrl: 'https://github.com/owner/repo',
+      listPRComments: async () => [],
+      getPR: async () => ({ number: 7, state: 'merged', title: 'chore: release 1.2.0', headBranch: 'staging-v1.2.0', mergeCommitSha: MERGE_SHA }),
+      getFileContent: jest.fn(async () => CHANGELOG),
+      createTag: jest.fn(async (version, config, options) => ({ sha: options.sha, tags: ['v1.2.0'] })),
+      createRelease: jest.fn(async () => ({ url: 'https://github.com/owner/repo/releases/tag/v1.2.0', updated: false })),
+      deleteBranch: async () => true
+    };
+  }
+
+  test('finalize publishes the release after tagging', async () => {
+    const provider = createProvider();
+
+    await new ReleaseBoss({ createGithubRelease: true }, { provider, context: { payload: {} } }).finalize({ pr: 7 });
+
+    expect(provider.createTag).toHaveBeenCalled();
+    expect(provider.getFileContent).toHaveBeenCalledWith('CHANGELOG.md', MERGE_SHA);
+    expect(provider.createRelease).toHaveBeenCalledWith('v1.2.0', expect.objectContaining({ body: expect.stringContaining('shiny new thing') }));
+  });
+
+  test('is off by default', async () => {
+    const provider = createProvider();
+
+    await new ReleaseBoss({}, { provider, context: { payload: {} } }).finalize({ pr: 7 });
+
+    expect(provider.createRelease).not.toHaveBeenCalled();
+  });
+
+  test('releaseTitle is checked like the other templates', () => {
+    expect(() => resolveConfig({ releaseTitle: 'Release {{.Tag}}' })).toThrow('releaseTitle uses unknown field .Tag');
+  });
+});
@@ -0,0 +1,41 @@
+/**
+ * Tests for path globs
+ *
+ * These tests validate the gitignore-style patterns used by ignorePaths.
+ */
+
+/* global describe, test, expect */
+
+const { createPathMatcher } = require('../src/utils/glob');
+
+describe('createPathMatcher', () => {
+  test('directories match everything inside them', () => {
+    const matches = createPathMatcher(['docs/', '.github']);
+    expect(matches('docs/guide/intro.md')).toBe(true);
+    expect(matches('packages/api/docs/readme.md')).toBe(true);
+    expect(matches('.github/workflows/ci.yml')).toBe(true);
+    expect(matches('src/docs.js')).toBe(false);
+  });
+
+  test('stars stop at slashes, double stars do not', () => {
+    const matches = createPathMatcher(['test/fixtures/*', 'src/**/*.snap']);
+    expect(matches('test/fixtures/data.json')).toBe(true);
+    expect(matches('src/snapshots/deep/view.snap')).toBe(true);
+    expect(matches('src/view.snap')).toBe(true);
+    expect(matches('test/unit.test.js')).toBe(false);
+  });
+
+  test('patterns without a slash match at any depth, a leading slash anchors them', () => {
+    const matches = createPathMatcher(['*.md', '/CHANGELOG.txt']);
+    expect(matches('README.md')).toBe(true);
+    expect(matches('services/api/NOTES.md')).toBe(true);
+    expect(matches('CHANGELOG.txt')).toBe(true);
+    expect(matches('services/api/CHANGELOG.txt')).toBe(false);
+  });
+
+  test('negated patterns bring files back', () => {
+    const matches = createPathMatcher(['docs/**', '!docs/api/openapi.yml']);
+    expect(matches('docs/index.md')).toBe(true);
+    expect(matches('docs/api/openapi.yml')).toBe(false);
+  });
+});
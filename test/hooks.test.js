@@ -0,0 +1,131 @@
+/**
+ * Tests for release hooks
+ *
+ * These tests validate that hooks run in order with the release environment,
+ * that failures stop the release unless continueOnError is set, and how files
+ * written by postBump hooks are found.
+ */
+
+/* global jest, describe, test, expect, beforeAll, afterAll */
+
+const { execFileSync } = require('child_process');
+const fs = require('fs');
+const os = require('os');
+const path = require('path');
+
+const { runHooks, buildHookEnv, snapshotWorkingTree, findChangedFiles } = require('../src/core/hooks');
+const { resolveConfig } = require('../src/utils/config');
+
+const release = { version: '1.2.0', previousVersion: '1.1.0', bumpType: 'minor', tag: 'v1.2.0' };
+
+describe('runHooks', () => {
+  test('runs every hook in order with the release environment', async () => {
+    const run = jest.fn(async () => ({ code: 0, stdout: 'ok\n', stderr: '' }));
+    const config = { changelogPath: 'CHANGELOG.md', hooks: { postBump: ['go generate ./...', { command: 'npm install --package-lock-only' }] } };
+
+    const results = await runHooks('postBump', config, release, { run, cwd: '/repo' });
+
+    expect(run.mock.calls.map(call => call[0])).toEqual(['go generate ./...', 'npm install --package-lock-only']);
+    expect(run.mock.calls[0][1]).toMatchObject({ RELEASE_VERSION: '1.2.0', PREVIOUS_VERSION: '1.1.0', CHANGELOG_PATH: 'CHANGELOG.md' });
+    expect(results).toEqual([{ command: 'go generate ./...', code: 0 }, { command: 'npm install --package-lock-only', code: 0 }]);
+  });
+
+  test('a failing hook stops the release', async () => {
+    const run = jest.fn(async command => ({ code: command === 'false' ? 1 : 0, stdout: '', stderr: 'nope' }));
+    const config = { hooks: { prePR: ['false', 'echo never'] } };
+
+    await expect(runHooks('prePR', config, release, { run })).rejects.toThrow('prePR hook "false" exited with code 1');
+    expect(run).toHaveBeenCalledTimes(1);
+  });
+
+  test('continueOnError carries on past a failure', async () => {
+    const run = jest.fn(async command => ({ code: command === 'false' ? 1 : 0, stdout: '', stderr: '' }));
+    const config = { hooks: { postRelease: [{ command: 'false', continueOnError: true }, 'echo posted'] } };
+
+    const results = await runHooks('postRelease', config, release, { run });
+
+    expect(results.map(result => result.code)).toEqual([1, 0]);
+  });
+
+  test('dry-run only logs the hooks', async () => {
+    const run = jest.fn();
+    await runHooks('preBump', { hooks: { preBump: ['rm -rf build'] } }, release, { run, dryRun: true });
+
+    expect(run).not.toHaveBeenCalled();
+  });
+
+  test('runs real shell commands and captures their output', async () => {
+    const log = jest.spyOn(console, 'log').mockImplementation(() => {});
+    try {
+      await runHooks('postRelease', { hooks: { postRelease: ['echo "released $RELEASE_VERSION ($RELEASE_COMMIT_SHA)"'] } },
+        { ...release, commitSha: 'abc1234' });
+      expect(log.mock.calls.map(call => call[0])).toContain('    released 1.2.0 (abc1234)');
+    } finally {
+      log.mockRestore();
+    }
+  });
+
+  test('buildHookEnv names the package in monorepos', () => {
+    expect(buildHookEnv(release, { packageName: 'api', changelogPath: 'services/api/CHANGELOG.md' })).toEqual({
+      RELEASE_VERSION: '1.2.0',
+      PREVIOUS_VERSION: '1.1.0',
+      RELEASE_BUMP_TYPE: 'minor',
+      RELEASE_TAG: 'v1.2.0',
+      CHANGELOG_PATH: 'services/api/CHANGELOG.md',
+      RELEASE_PACKAGE: 'api'
+    });
+  });
+
+  test('bad hooks fail when the config loads', () => {
+    expect(() => resolveConfig({ hooks: { afterParty: ['echo hi'] } })).toThrow('Unknown hook stage "afterParty"');
+    expect(() => resolveConfig({ hooks: { preBump: 'echo hi' } })).toThrow('hooks.preBump must be a list');
+  });
+});
+
+describe('files changed by hooks', () => {
+  let dir;
+
+  beforeAll(() => {
+    dir = fs.mkdtempSync(path.join(os.tmpdir(), 'release-boss-hooks-'));
+    const env = { ...process.env, GIT_AUTHOR_NAME: 'Test', GIT_AUTHOR_EMAIL: 'test@example.com', GIT_COMMITTER_NAME: 'Test', GIT_COMMITTER_EMAIL: 'test@example.com' };
+    const git = (...args) => execFileSync('git', args, { cwd: dir, env });
+
+    git('init', '-q');
+    fs.writeFileSync(path.join(dir, 'go.sum'), 'old\n');
+    fs.writeFileSync(path.join(dir, 'version.go'), 'const Version = "1.1.0"\n');
+    git('add', '.');
+    git('commit', '-qm', 'initial');
+  });
+
+  afterAll(() => {
+    fs.rmSync(dir, { recursive: true, force: true });
+  });
+
+  test('finds new and rewritten files, not ones that were already changed', async () => {
+    // The version file was bumped before the hooks ran
+    fs.writeFileSync(path.join(dir, 'version.go'), 'const Version = "1.2.0"\n');
+    const before = await snapshotWorkingTree({ cwd: dir });
+
+    fs.writeFileSync(path.join(dir, 'go.sum'), 'new\n');
+    fs.mkdirSync(path.join(dir, 'gen'));
+    fs.writeFileSync(path.join(dir, 'gen', 'version_gen.go'), 'package gen\n');
+
+    const changed = await findChangedFiles(before, { cwd: dir });
+
+    expect(changed.sort()).toEqual([path.join(dir, 'gen', 'version_gen.go'), path.join(dir, 'go.sum')]);
+  });
+
+  test('finds files across the repo when the run starts in a subdirectory', async () => {
+    const web = path.join(dir, 'web');
+    fs.mkdirSync(web);
+    fs.writeFileSync(path.join(web, 'package.json'), '{ "version": "1.2.0" }\n');
+    const before = await snapshotWorkingTree({ cwd: web });
+
+    fs.writeFileSync(path.join(dir, 'go.sum'), 'newer\n');
+    fs.writeFileSync(path.join(web, 'package-lock.json'), '{}\n');
+
+    const changed = await findChangedFiles(before, { cwd: web });
+
+    expect(changed.sort()).toEqual([path.join(dir, 'go.sum'), path.join(web, 'package-lock.json')]);
+  });
+});
@@ -0,0 +1,173 @@
+/**
+ * Tests for the leveled logger
+ *
+ * These tests validate that records below the configured level are dropped,
+ * that human lines keep their emoji while json records lose them and carry
+ * the run's context fields, that withLogLevel quietens a command and puts
+ * the level back, and that the CLI checks --log-level and --log-format like
+ * its other options and prints through the logger.
+ */
+
+/* global describe, test, expect */
+
+const os = require('os');
+const path = require('path');
+
+const { logger, configureLogger, withLogLevel, setLogContext, resetLogContext } = require('../src/utils/logger');
+const { main, parseArgs } = require('../src/cli');
+
+/**
+ * Run fn with the given logger settings, collecting what went to stdout and stderr
+ * An async fn gets a promise back, settled once it has finished.
+ * @returns {Object|Promise<Object>} - { stdout, stderr } - one entry per line written
+ */
+function capture(settings, fn) {
+  const log = console.log;
+  const error = console.error;
+  const actions = process.env.GITHUB_ACTIONS;
+  const output = { stdout: [], stderr: [] };
+  console.log = line => output.stdout.push(line);
+  console.error = line => output.stderr.push(line);
+  delete process.env.GITHUB_ACTIONS;
+  try {
+    configureLogger(settings);
+    const result = fn();
+    if (result && typeof result.then === 'function') {
+      return result.then(() => output).finally(restore);
+    }
+  } catch (failure) {
+    restore();
+    throw failure;
+  }
+  restore();
+  return output;
+
+  function restore() {
+    console.log = log;
+    console.error = error;
+    if (actions !== undefined) {
+      process.env.GITHUB_ACTIONS = actions;
+    }
+    configureLogger({ level: 'info', format: 'human' });
+    resetLogContext();
+  }
+}
+
+describe('levels', () => {
+  test('info is the default - debug is dropped', () => {
+    const output = capture({}, () => {
+      logger.debug('commit #1 details');
+      logger.info('Found 3 commits 💅');
+    });
+
+    expect(output.stdout).toEqual(['Found 3 commits 💅']);
+  });
+
+  test('warnings and errors go to stderr, and survive a quieter level', () => {
+    const output = capture({ level: 'warn' }, () => {
+      logger.info('chatter');
+      logger.warn('Could not compare branches');
+      logger.error('Error creating tag: boom');
+    });
+
+    expect(output.stdout).toEqual([]);
+    expect(output.stderr).toEqual(['Could not compare branches', 'Error creating tag: boom']);
+  });
+
+  test('groups only show when info does', () => {
+    expect(capture({}, () => logger.startGroup('🔍 Commit Analysis')).stdout).toEqual(['::group::🔍 Commit Analysis']);
+    expect(capture({ level: 'error' }, () => logger.startGroup('🔍 Commit Analysis')).stdout).toEqual([]);
+  });
+
+  test('unknown levels and formats are rejected', () => {
+    expect(() => configureLogger({ level: 'loud' })).toThrow('Log level must be one of: debug, info, warn, error');
+    expect(() => configureLogger({ format: 'xml' })).toThrow('Log format must be one of: human, json');
+  });
+});
+
+describe('withLogLevel', () => {
+  test('raises the level while fn runs, then puts it back', async () => {
+    const output = await capture({}, async () => {
+      await withLogLevel('warn', () => {
+        logger.info('loader chatter');
+        logger.warn('heads up');
+      });
+      logger.info('back to normal');
+    });
+
+    expect(output.stdout).toEqual(['back to normal']);
+    expect(output.stderr).toEqual(['heads up']);
+  });
+
+  test('never makes a quieter level chattier', async () => {
+    const output = await capture({ level: 'error' }, () => withLogLevel('info', () => logger.info('chatter')));
+
+    expect(output.stdout).toEqual([]);
+  });
+});
+
+describe('json format', () => {
+  test('one record per line on stderr, emoji stripped, context and fields included', () => {
+    const output = capture({ format: 'json' }, () => {
+      setLogContext({ branch: 'main', version: '1.3.0' });
+      logger.info('New version: 1.3.0 - looking FABULOUS, darling! ✨💃', { bump: 'minor' });
+      logger.startGroup('💎 Version Bump');
+    });
+
+    expect(output.stdout).toEqual([]);
+    expect(output.stderr).toHaveLength(1);
+    const record = JSON.parse(output.stderr[0]);
+    expect(record).toEqual({
+      timestamp: expect.any(String),
+      level: 'info',
+      message: 'New version: 1.3.0 - looking FABULOUS, darling!',
+      branch: 'main',
+      version: '1.3.0',
+      bump: 'minor'
+    });
+    expect(Number.isNaN(Date.parse(record.timestamp))).toBe(false);
+  });
+
+  test('setting a context field to null drops it', () => {
+    const output = capture({ format: 'json' }, () => {
+      setLogContext({ package: 'api', pr: 7 });
+      setLogContext({ package: 'web', pr: null });
+      logger.warn('⚠️ heads up');
+    });
+
+    const record = JSON.parse(output.stderr[0]);
+    expect(record).toMatchObject({ level: 'warn', message: 'heads up', package: 'web' });
+    expect(record.pr).toBeUndefined();
+  });
+});
+
+describe('CLI options', () => {
+  test('--log-level and --log-format take a value', () => {
+    expect(parseArgs(['release', '--log-level', 'debug', '--log-format=json'])).toEqual({
+      command: 'release',
+      options: { logLevel: 'debug', logFormat: 'json' }
+    });
+  });
+
+  test('values outside the list are an error', () => {
+    expect(() => parseArgs(['release', '--log-level', 'loud'])).toThrow('--log-level must be one of: debug, info, warn, error');
+    expect(() => parseArgs(['release', '--log-format', 'xml'])).toThrow('--log-format must be one of: human, json');
+  });
+});
+
+describe('CLI output', () => {
+  test('with --log-format json nothing but records is printed', async () => {
+    const config = path.join(os.tmpdir(), 'release-boss-missing.yml');
+    let code;
+    const output = await capture({}, async () => {
+      code = await main(['validate', '--config', config, '--log-format', 'json']);
+    });
+
+    expect(code).toBe(1);
+    expect(output.stdout).toEqual([]);
+    expect(output.stderr.map(line => JSON.parse(line))).toEqual([
+      expect.objectContaining({ level: 'error', message: `${config}: config file not found` }),
+      expect.objectContaining({ level: 'error', message: 'Found 1 problem - fix them before releasing!' })
+    ]);
+  });
+});
@@ -0,0 +1,188 @@
+/**
+ * Tests for maintenance branches
+ *
+ * These tests validate that version lines are read from branch names, that a
+ * run on a maintenance branch only builds on its own line's tags - a fix on
+ * 1.x releases v1.4.2 even though v2.0.0 exists - and releases back into that
+ * branch without moving latest, that a bump off the line is refused, that the
+ * line's tags are found however many newer releases there are, and that the
+ * config is checked.
+ */
+
+/* global jest, describe, test, expect */
+
+const { ReleaseBoss, FakeProvider, resolveConfig } = require('../src/releaseBoss');
+const { getBranchVersionRange, findMaintenanceLine, filterVersionLineTags } = require('../src/core/maintenance');
+const { findLatestReleaseTag } = require('../src/core/prerelease');
+const { rollbackRelease } = require('../src/core/rollback');
+const { GitHubProvider } = require('../src/providers/githubProvider');
+const { GitLabProvider } = require('../src/providers/gitlabProvider');
+
+const CONTEXT = { payload: {} };
+const CONFIG = { maintenance: [{ branch: '1.x' }] };
+
+/**
+ * Fake repo where v1.4.1 was released, 1.x was cut from it, and main has moved on to v2.0.0
+ */
+function createRepo() {
+  const provider = new FakeProvider({ branch: '1.x' });
+  provider.addCommits('main', ['feat: first thing']);
+  provider.createBranch('release', 'main');
+  provider.addTag('v1.4.1', 'release');
+  provider.createBranch('1.x', 'v1.4.1');
+
+  provider.addCommits('main', ['feat: rename everything\n\nBREAKING CHANGE: every name is new']);
+  // release fast-forwards to main for v2
+  provider.createBranch('release', 'main');
+  provider.addTag('v2.0.0', 'release');
+  provider.addTag('latest', 'v2.0.0');
+  return provider;
+}
+
+describe('getBranchVersionRange', () => {
+  test('reads the line from version-like branch names', () => {
+    expect(getBranchVersionRange('1.x')).toBe('1.x');
+    expect(getBranchVersionRange('v1.x')).toBe('1.x');
+    expect(getBranchVersionRange('1.x.x')).toBe('1.x');
+    expect(getBranchVersionRange('1.4.x')).toBe('1.4.x');
+  });
+
+  test('is null for anything else', () => {
+    expect(getBranchVersionRange('legacy')).toBeNull();
+    expect(getBranchVersionRange('1.4.2')).toBeNull();
+    expect(getBranchVersionRange('release-1.x')).toBeNull();
+  });
+
+  test('an explicit range wins over the branch name', () => {
+    const config = { maintenance: [{ branch: 'legacy', range: '1.x' }, { branch: '2.3.x' }] };
+
+    expect(findMaintenanceLine(config, '1.9.0')).toEqual({ branch: 'legacy', range: '1.x' });
+    expect(findMaintenanceLine(config, '2.3.4')).toEqual({ branch: '2.3.x', range: '2.3.x' });
+    expect(findMaintenanceLine(config, '2.4.0')).toBeNull();
+  });
+});
+
+describe('running on a maintenance branch', () => {
+  test('a fix on 1.x releases v1.4.2 even though v2.0.0 exists', async () => {
+    const provider = createRepo();
+    provider.addCommits('1.x', ['fix: patch the old thing']);
+
+    const result = await new ReleaseBoss(CONFIG, { provider, context: CONTEXT }).run();
+
+    expect(result).toMatchObject({ runType: 'pr', bumpType: 'patch', previousVersion: '1.4.1', nextVersion: '1.4.2' });
+    expect(result.changelog).toContain('patch the old thing');
+    expect(result.changelog).not.toContain('rename everything');
+    expect(provider.pullRequests[0]).toMatchObject({ headBranch: 'staging-v1.4.2', baseBranch: '1.x' });
+  });
+
+  test('merging tags the release on 1.x and leaves latest on the main line', async () => {
+    const provider = createRepo();
+    provider.addCommits('1.x', ['fix: patch the old thing']);
+    await new ReleaseBoss(CONFIG, { provider, context: CONTEXT }).run();
+
+    const { sha } = await provider.mergePR(1);
+    const result = await new ReleaseBoss(CONFIG, { provider, context: CONTEXT }).run();
+
+    expect(result).toMatchObject({ runType: 'release', releaseTag: 'v1.4.2', releaseCommitSha: sha });
+    expect(provider.branches.get('1.x')).toBe(sha);
+    expect(provider.tags.get('latest')).toBe(provider.tags.get('v2.0.0'));
+  });
+
+  test('a feature moves the line on to the next minor', async () => {
+    const provider = createRepo();
+    provider.addCommits('1.x', ['feat: backported thing']);
+
+    const result = await new ReleaseBoss(CONFIG, { provider, context: CONTEXT }).run();
+
+    expect(result.nextVersion).toBe('1.5.0');
+  });
+
+  test('a bump off the line is refused', async () => {
+    const provider = createRepo();
+    provider.addCommits('1.x', ['fix: drop the old api\n\nBREAKING CHANGE: it is gone']);
+
+    await expect(new ReleaseBoss(CONFIG, { provider, context: CONTEXT }).run()).rejects.toThrow("A major bump would make 1.x release 2.0.0, which isn't on its 1.x line");
+  });
+
+  test('a line without a release yet has nothing to patch', async () => {
+    const provider = createRepo();
+    provider.createBranch('3.x', 'main');
+    provider.checkout('3.x');
+    provider.addCommits('3.x', ['fix: something']);
+
+    await expect(new ReleaseBoss({ maintenance: [{ branch: '3.x' }] }, { provider, context: CONTEXT }).run())
+      .rejects.toThrow("There's no release on the 3.x line yet");
+  });
+
+  test('rolling back a maintenance release ignores newer main-line releases', async () => {
+    const provider = createRepo();
+    provider.addCommits('1.x', ['fix: patch the old thing']);
+    await new ReleaseBoss(CONFIG, { provider, context: CONTEXT }).run();
+    await provider.mergePR(1);
+    await new ReleaseBoss(CONFIG, { provider, context: CONTEXT }).run();
+
+    // No local checkout to clean up - the tests' own repo keeps its tags
+    const git = async () => {
+      throw new Error('not a git repository');
+    };
+    const rolledBack = await rollbackRelease(provider, resolveConfig(CONFIG), '1.4.2', { revert: true, git });
+
+    expect(rolledBack).toMatchObject({ tag: 'v1.4.2', tagDeleted: true });
+    expect(provider.events[provider.events.length - 1]).toMatchObject({ type: 'commit', branch: '1.x' });
+  });
+});
+
+describe('version line tags from the API', () => {
+  // 150 main-line releases come first, newest first - the 1.x line is on the second page
+  const TAGS = [...Array.from({ length: 150 }, (_, i) => `v2.${149 - i}.0`), 'v1.4.1', 'v1.4.0'];
+  const config = { ...resolveConfig({}), maintenanceRange: '1.x' };
+  const page = (number, size) => TAGS.slice((number - 1) * size, number * size);
+
+  test('GitHub reads every page of tags', async () => {
+    const listTags = jest.fn(async ({ page: number, per_page: size }) => ({
+      data: page(number, size).map(name => ({ name, commit: { sha: 'a'.repeat(40) } }))
+    }));
+    const provider = new GitHubProvider({ rest: { repos: { listTags } } }, { repo: { owner: 'owner', repo: 'repo' } });
+
+    const tags = filterVersionLineTags(await provider.listTags(), config);
+    expect(findLatestReleaseTag(tags, config)).toBe('v1.4.1');
+    expect(listTags).toHaveBeenCalledTimes(2);
+  });
+
+  test('GitLab reads every page of tags', async () => {
+    const request = jest.fn(async (method, url) => {
+      const query = new URL(url).searchParams;
+      return {
+        status: 200,
+        headers: {},
+        data: page(Number(query.get('page')), Number(query.get('per_page'))).map(name => ({ name, commit: { id: 'a'.repeat(40) } }))
+      };
+    });
+    const provider = new GitLabProvider({ token: 'x', project: 'group/project', request });
+
+    const tags = filterVersionLineTags(await provider.listTags(), config);
+    expect(findLatestReleaseTag(tags, config)).toBe('v1.4.1');
+    expect(request).toHaveBeenCalledTimes(2);
+  });
+});
+
+describe('config', () => {
+  test('the branch has to name a line, or come with a range', () => {
+    expect(() => resolveConfig({ maintenance: [{ branch: 'legacy' }] })).toThrow('Maintenance branch "legacy" needs a range like 1.x');
+    expect(() => resolveConfig({ maintenance: [{ branch: 'legacy', range: 'one' }] })).toThrow('must be a semver range like 1.x or 1.4.x, got "one"');
+    expect(resolveConfig({ maintenance: [{ branch: 'legacy', range: '^1.2.0' }] }).maintenance).toHaveLength(1);
+  });
+
+  test('it can\'t be the main line or a prerelease channel', () => {
+    expect(() => resolveConfig({ maintenance: [{ branch: 'main', range: '1.x' }] })).toThrow('can\'t be the merge or release branch');
+    expect(() => resolveConfig({ maintenance: [{ branch: '1.x' }], prerelease: [{ channel: 'beta', branch: '1.x' }] }))
+      .toThrow('Branch "1.x" can\'t be both a prerelease channel and a maintenance branch');
+  });
+
+  test('without an entry, the branch isn\'t one she runs on', async () => {
+    const provider = createRepo();
+    provider.addCommits('1.x', ['fix: patch the old thing']);
+
+    await expect(new ReleaseBoss({}, { provider, context: CONTEXT }).run()).rejects.toThrow('Branch "1.x" doesn\'t match any configured branch');
+  });
+});
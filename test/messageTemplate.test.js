@@ -0,0 +1,107 @@
+/**
+ * Tests for commit message and PR templates
+ *
+ * These tests validate the {{.Field}} syntax, the older {version}
+ * placeholders and that bad templates are caught when the config loads,
+ * and that the release PR body previews the changelog section exactly as
+ * it gets committed.
+ */
+
+/* global describe, test, expect */
+
+const { parseMessageTemplate, renderMessageTemplate, buildMessageFields } = require('../src/utils/messageTemplate');
+const { buildPRTitle, buildPRBody, buildCommitMessage } = require('../src/core/prContent');
+const { resolveConfig } = require('../src/utils/config');
+const { generateFileChangelog } = require('../src/github/changelogTable');
+
+const date = new Date('2024-03-09T10:00:00Z');
+
+describe('parseMessageTemplate', () => {
+  test('accepts fields with or without spaces', () => {
+    expect(parseMessageTemplate('chore(release): {{.Version}} from {{ .PreviousVersion }}')).toEqual(['Version', 'PreviousVersion']);
+    expect(parseMessageTemplate('chore: release {version}')).toEqual([]);
+  });
+
+  test('rejects unknown fields, actions and unclosed braces', () => {
+    expect(() => parseMessageTemplate('{{.Versoin}}', 'releaseCommitMessage'))
+      .toThrow('releaseCommitMessage uses unknown field .Versoin - available: .Version, .PreviousVersion, .Date, .Package');
+    expect(() => parseMessageTemplate('{{if .Package}}x{{end}}', 'pullRequestTitle')).toThrow('unsupported action {{if .Package}}');
+    expect(() => parseMessageTemplate('release {{.Version', 'pullRequestTitle')).toThrow('unclosed action');
+  });
+});
+
+describe('renderMessageTemplate', () => {
+  test('fills in every field', () => {
+    const fields = buildMessageFields('1.3.0', { previousVersion: '1.2.0', packageName: 'api' }, date);
+    expect(renderMessageTemplate('chore(release): {{.Package}} {{.PreviousVersion}} → {{ .Version }} ({{.Date}})', fields))
+      .toBe('chore(release): api 1.2.0 → 1.3.0 (2024-03-09)');
+  });
+});
+
+describe('release messages', () => {
+  test('default to the current messages', () => {
+    const config = resolveConfig({});
+    expect(buildCommitMessage('1.3.0', config)).toBe('chore: update files for release 1.3.0');
+    expect(buildPRTitle('1.3.0', config)).toBe('chore: release 1.3.0');
+  });
+
+  test('use the configured templates', () => {
+    const config = resolveConfig({
+      releaseCommitMessage: 'chore(release): {{.Version}}',
+      pullRequestTitle: 'chore(release): {{.Version}} [skip ci]',
+      pullRequestHeader: 'Release {{.Version}} (was {{.PreviousVersion}})',
+      pullRequestFooter: 'Generated for {{.Version}}',
+      changelogTable: { enabled: false }
+    });
+    const releaseConfig = { ...config, previousVersion: '1.2.0' };
+
+    expect(buildCommitMessage('1.3.0', releaseConfig)).toBe('chore(release): 1.3.0');
+    expect(buildPRTitle('1.3.0', releaseConfig)).toBe('chore(release): 1.3.0 [skip ci]');
+
+    const body = buildPRBody('', releaseConfig, [], '1.3.0');
+    expect(body.startsWith('Release 1.3.0 (was 1.2.0)')).toBe(true);
+    expect(body.endsWith('Generated for 1.3.0')).toBe(true);
+    expect(body).not.toContain('auto-generated by the fabulous Release Boss');
+  });
+
+  test('bad templates fail when the config loads', () => {
+    expect(() => resolveConfig({ releaseCommitMessage: 'chore: {{.Verison}}' })).toThrow('unknown field .Verison');
+    expect(() => resolveConfig({ pullRequestTitle: 'chore(release): {{.PreviousVersion}}' })).toThrow('pullRequestTitle must contain');
+  });
+});
+
+describe('release PR body', () => {
+  const changelog = [
+    '## [1.3.0](https://github.com/owner/repo/compare/v1.2.0...v1.3.0) (2024-03-09)',
+    '',
+    '### Features',
+    '',
+    '* **api:** add the thing ([abc1234](https://github.com/owner/repo/commit/abc1234))',
+    ''
+  ].join('\n');
+  const compareUrl = 'https://github.com/owner/repo/compare/v1.2.0...staging-v1.3.0';
+
+  test('previews the changelog section exactly as it is committed', () => {
+    const config = { ...resolveConfig({}), previousVersion: '1.2.0', compareUrl };
+
+    const body = buildPRBody(changelog, config, ['package.json'], '1.3.0');
+    const committed = generateFileChangelog(changelog, '1.3.0', '');
+    const notes = committed.substring(committed.indexOf('\n\n', committed.indexOf('## [1.3.0]')) + 2).trim();
+
+    expect(body).toContain(`## Unreleased → v1.3.0\n\n[Compare the changes since v1.2.0](${compareUrl})\n\n${notes}\n\n`);
+    expect(body).toContain('- `package.json`');
+    expect(body).not.toContain('RELEASE_BOSS_CHANGELOG_START');
+  });
+
+  test('pullRequestChangelog: table keeps the commit table', () => {
+    const config = { ...resolveConfig({ pullRequestChangelog: 'table' }), previousVersion: '1.2.0', compareUrl };
+
+    const body = buildPRBody(changelog, config, [], '1.3.0');
+    expect(body).toContain('<!-- RELEASE_BOSS_CHANGELOG_START -->');
+    expect(body).not.toContain('Unreleased →');
+  });
+
+  test('unknown formats fail when the config loads', () => {
+    expect(() => resolveConfig({ pullRequestChangelog: 'list' })).toThrow('pullRequestChangelog must be one of: preview, table, got "list"');
+  });
+});
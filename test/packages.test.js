@@ -0,0 +1,179 @@
+/**
+ * Tests for monorepo package support
+ *
+ * These tests validate package resolution, commit assignment and the
+ * package-scoped tag handling, including commit files and tags that span
+ * several API pages.
+ */
+
+/* global jest, describe, test, expect */
+
+const {
+  resolvePackages,
+  getPackageConfig,
+  findPackageForBranch,
+  assignCommitsToPackages
+} = require('../src/core/packages');
+const { getReleaseTagName, getAdditionalTagNames, parseVersionFromTag } = require('../src/utils/tags');
+const { determineVersionBump } = require('../src/core/commitAnalyzer');
+const { GitHubProvider } = require('../src/providers/githubProvider');
+
+/**
+ * Build a GitHub provider whose octokit mock hands out tags and commit files a page at a time
+ * @param {Object} data - { tags, files } - every tag name and changed file
+ */
+function createPagedGitHub({ tags = [], files = [] }) {
+  const page = (items, params) => items.slice((params.page - 1) * params.per_page, params.page * params.per_page);
+  const octokit = {
+    rest: {
+      repos: {
+        listTags: jest.fn(async params => ({ data: page(tags, params).map(name => ({ name, commit: { sha: 'a'.repeat(40) } })) })),
+        getCommit: jest.fn(async params => ({ data: { files: page(files, params).map(filename => ({ filename })) } }))
+      }
+    }
+  };
+  return { octokit, provider: new GitHubProvider(octokit, { repo: { owner: 'owner', repo: 'repo' } }) };
+}
+
+const config = {
+  stagingBranch: 'staging',
+  pullRequestTitle: 'chore: release {version}',
+  packages: [
+    { path: './services/api/', commitScope: 'api', versionFiles: ['version.go'] },
+    { path: 'services/web', name: 'web' },
+    { path: 'services/api-v2' }
+  ]
+};
+
+/**
+ * Build an analyzed commit like analyzeCommits returns
+ */
+function createCommit(hash, scope, files) {
+  return {
+    hash,
+    message: `fix${scope ? `(${scope})` : ''}: change`,
+    parsed: { type: 'fix', scope, subject: 'change', notes: [] },
+    bumpType: 'patch',
+    files
+  };
+}
+
+describe('resolvePackages', () => {
+  test('fills in names, scopes and package-relative paths', () => {
+    const [api, web] = resolvePackages(config);
+
+    expect(api).toMatchObject({
+      name: 'api',
+      path: 'services/api',
+      commitScope: 'api',
+      versionFiles: ['services/api/version.go'],
+      changelogPath: 'services/api/CHANGELOG.md'
+    });
+    expect(web.commitScope).toBe('web');
+  });
+
+  test('returns nothing for a regular repo', () => {
+    expect(resolvePackages({})).toEqual([]);
+  });
+});
+
+describe('getPackageConfig', () => {
+  test('scopes branches, tags and titles to the package', () => {
+    const [api] = resolvePackages(config);
+    const packageConfig = getPackageConfig(config, api);
+
+    expect(packageConfig.stagingBranch).toBe('staging-api');
+    expect(packageConfig.pullRequestTitle).toBe('chore: release {version} (api)');
+    expect(getReleaseTagName('1.2.0', packageConfig)).toBe('api/v1.2.0');
+    expect(getAdditionalTagNames('1.2.0', { ...packageConfig, tagMajor: true })).toEqual(['api/latest', 'api/v1']);
+  });
+});
+
+describe('findPackageForBranch', () => {
+  test('prefers the longest matching package name', () => {
+    const packages = resolvePackages(config);
+    expect(findPackageForBranch('staging-api-v1.2.0', packages, config).name).toBe('api');
+    expect(findPackageForBranch('staging-api-v2-v1.0.0', packages, config).name).toBe('api-v2');
+    expect(findPackageForBranch('staging-v1.0.0', packages, config)).toBeNull();
+  });
+});
+
+describe('assignCommitsToPackages', () => {
+  test('uses explicit scopes first, then changed files', async () => {
+    const packages = resolvePackages(config);
+    const provider = { getCommitFiles: jest.fn(async () => ['services/web/index.js']) };
+    const commits = [
+      createCommit('a000000', 'api', ['services/web/index.js']),
+      createCommit('b000000', null, ['services/api/handler.go']),
+      createCommit('c000000', 'deps', ['services/api/go.mod', 'services/web/package.json']),
+      createCommit('d000000', null, ['README.md']),
+      createCommit('e000000', null)
+    ];
+
+    const assigned = await assignCommitsToPackages(commits, packages, provider);
+
+    expect(assigned.get('api').map(commit => commit.hash)).toEqual(['a000000', 'b000000', 'c000000']);
+    expect(assigned.get('web').map(commit => commit.hash)).toEqual(['c000000', 'e000000']);
+    expect(assigned.get('api-v2')).toEqual([]);
+    expect(provider.getCommitFiles).toHaveBeenCalledWith('e000000');
+  });
+
+  test('lists files once per hash and keeps them on the commit', async () => {
+    const packages = resolvePackages(config);
+    const provider = { getCommitFiles: jest.fn(async () => ['services/web/index.js']) };
+    // Two commits split out of one squash share its hash
+    const commits = [createCommit('f000000', null), createCommit('f000000', null)];
+
+    const assigned = await assignCommitsToPackages(commits, packages, provider);
+
+    expect(assigned.get('web')).toHaveLength(2);
+    expect(provider.getCommitFiles).toHaveBeenCalledTimes(1);
+    expect(commits[1].files).toEqual(['services/web/index.js']);
+  });
+
+  test('reads every page of a big commit\'s files', async () => {
+    const packages = resolvePackages(config);
+    // 250 docs files come first - the package's file is on the third page
+    const files = [...Array.from({ length: 250 }, (_, i) => `docs/page-${i}.md`), 'services/web/index.js'];
+    const { octokit, provider } = createPagedGitHub({ files });
+
+    const assigned = await assignCommitsToPackages([createCommit('b000000', null)], packages, provider);
+
+    expect(assigned.get('web')).toHaveLength(1);
+    expect(octokit.rest.repos.getCommit).toHaveBeenCalledTimes(3);
+  });
+});
+
+describe('package tags', () => {
+  test('only versions in the package namespace are picked up', async () => {
+    const [api] = resolvePackages(config);
+    const packageConfig = getPackageConfig(config, api);
+    const provider = {
+      name: 'github',
+      listTags: async () => [
+        { name: 'v3.0.0' },
+        { name: 'web/v2.0.0' },
+        { name: 'api/v1.1.0' },
+        { name: 'api/v1.0.0' }
+      ]
+    };
+
+    expect(parseVersionFromTag('api/v1.1.0', packageConfig)).toBe('1.1.0');
+    expect(parseVersionFromTag('api/v1.1.0', {})).toBeNull();
+
+    const result = await determineVersionBump([createCommit('a000000', 'api')], provider, packageConfig);
+    expect(result.currentVersion).toBe('1.1.0');
+    expect(result.newVersion).toBe('1.1.1');
+  });
+
+  test('a package is found behind another package\'s hundred tags', async () => {
+    const [api] = resolvePackages(config);
+    const packageConfig = getPackageConfig(config, api);
+    const tags = [...Array.from({ length: 120 }, (_, i) => `web/v1.${119 - i}.0`), 'api/v1.1.0'];
+    const { provider } = createPagedGitHub({ tags });
+
+    const result = await determineVersionBump([createCommit('a000000', 'api')], provider, packageConfig);
+    expect(result.currentVersion).toBe('1.1.0');
+    expect(result.newVersion).toBe('1.1.1');
+  });
+});
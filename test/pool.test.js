@@ -0,0 +1,104 @@
+/**
+ * Tests for the per-commit fetch pool
+ *
+ * These tests validate that the pool keeps results in item order, never has
+ * more than its limit in flight, and fetches a large synthetic history's
+ * changed files several at a time with the same result as one at a time.
+ */
+
+/* global describe, test, expect */
+
+const { mapWithConcurrency } = require('../src/utils/pool');
+const { analyzeCommits } = require('../src/core/commitAnalyzer');
+
+const sleep = ms => new Promise(resolve => setTimeout(resolve, ms));
+
+describe('mapWithConcurrency', () => {
+  test('results keep the item order, whatever order the calls finish in', async () => {
+    const items = Array.from({ length: 20 }, (_, i) => i);
+    const finished = [];
+
+    const results = await mapWithConcurrency(items, 4, async item => {
+      await sleep((item * 7) % 5);
+      finished.push(item);
+      return item * 2;
+    });
+
+    expect(results).toEqual(items.map(item => item * 2));
+    expect(finished).not.toEqual(items);
+  });
+
+  test('never has more than the limit in flight', async () => {
+    let inFlight = 0;
+    let peak = 0;
+
+    await mapWithConcurrency(Array.from({ length: 30 }), 3, async () => {
+      inFlight++;
+      peak = Math.max(peak, inFlight);
+      await sleep(1);
+      inFlight--;
+    });
+
+    expect(peak).toBe(3);
+  });
+
+  test('the first error rejects the map and stops handing out work', async () => {
+    const started = [];
+    const run = mapWithConcurrency(Array.from({ length: 50 }, (_, i) => i), 2, async item => {
+      started.push(item);
+      await sleep(1);
+      if (item === 3) throw new Error('rate limited');
+    });
+
+    await expect(run).rejects.toThrow('rate limited');
+    expect(started.length).toBeLessThan(10);
+  });
+
+  test('handles empty lists', async () => {
+    await expect(mapWithConcurrency([], 8, async () => 1)).resolves.toEqual([]);
+  });
+});
+
+describe('fetching changed files for a large history', () => {
+  // 240 commits, each taking ~1ms to list its files - like a slow API on a big first release
+  const history = Array.from({ length: 240 }, (_, i) => ({
+    sha: `${i}`.padStart(40, '0'),
+    message: i % 3 === 0 ? `docs: page ${i}` : `fix: bug ${i}`,
+    author: 'dev',
+    date: '2024-01-01T00:00:00Z',
+    url: `https://github.com/owner/repo/commit/${i}`
+  }));
+  const config = { releaseBranch: 'release', mergeBranch: 'main', ignorePaths: ['docs/**'] };
+
+  /**
+   * Analyze the history, counting how many getCommitFiles calls were in flight at once
+   */
+  async function analyze(fetchConcurrency) {
+    let inFlight = 0;
+    let peak = 0;
+    const provider = {
+      compareCommits: async () => history.map(commit => ({ ...commit })),
+      getCommitFiles: async sha => {
+        inFlight++;
+        peak = Math.max(peak, inFlight);
+        await sleep(1);
+        inFlight--;
+        return Number(sha) % 3 === 0 ? ['docs/page.md'] : ['src/index.js'];
+      }
+    };
+
+    const commits = await analyzeCommits(provider, { ...config, fetchConcurrency });
+    return { commits, peak };
+  }
+
+  test('files are fetched several at a time, with the same result as one at a time', async () => {
+    const sequential = await analyze(1);
+    const pooled = await analyze(8);
+
+    expect(sequential.peak).toBe(1);
+    expect(pooled.peak).toBeGreaterThanOrEqual(2);
+    expect(pooled.peak).toBeLessThanOrEqual(8);
+    expect(pooled.commits.map(commit => commit.hash)).toEqual(sequential.commits.map(commit => commit.hash));
+    expect(pooled.commits).toHaveLength(160);
+  });
+});
@@ -0,0 +1,109 @@
+/**
+ * Tests for prerelease channels
+ *
+ * These tests validate the prerelease counter, channel switches and that
+ * stable releases drop the prerelease suffix.
+ */
+
+/* global describe, test, expect */
+
+const {
+  findPrereleaseChannel,
+  getPrereleaseConfig,
+  findLatestReleaseTag,
+  getNextPrereleaseVersion
+} = require('../src/core/prerelease');
+const { determineVersionBump } = require('../src/core/commitAnalyzer');
+const { getAdditionalTagNames } = require('../src/utils/tags');
+
+const config = {
+  mergeBranch: 'main',
+  releaseBranch: 'release',
+  prerelease: [
+    { channel: 'beta', branch: 'develop' },
+    { channel: 'rc', branch: 'next' }
+  ]
+};
+
+/**
+ * Provider with a fixed list of tags
+ */
+function createProvider(tags) {
+  return {
+    name: 'github',
+    listTags: async () => tags.map(name => ({ name, sha: 'a'.repeat(40) }))
+  };
+}
+
+/**
+ * Build an analyzed commit like analyzeCommits returns
+ */
+function createCommit(bumpType) {
+  return { hash: 'a000000', message: 'x', parsed: {}, bumpType };
+}
+
+describe('findPrereleaseChannel', () => {
+  test('maps branches to channels', () => {
+    expect(findPrereleaseChannel(config, 'develop').channel).toBe('beta');
+    expect(findPrereleaseChannel(config, 'main')).toBeNull();
+    expect(findPrereleaseChannel({}, 'develop')).toBeNull();
+  });
+
+  test('channel config releases from and into the channel branch', () => {
+    const betaConfig = getPrereleaseConfig(config, config.prerelease[0]);
+    expect(betaConfig).toMatchObject({
+      prereleaseChannel: 'beta',
+      stableReleaseBranch: 'release',
+      mergeBranch: 'develop',
+      releaseBranch: 'develop'
+    });
+  });
+});
+
+describe('getNextPrereleaseVersion', () => {
+  test('starts at 1 and increments while the base is unchanged', () => {
+    expect(getNextPrereleaseVersion('1.2.0', 'minor', 'beta', ['1.2.0'])).toBe('1.3.0-beta.1');
+    expect(getNextPrereleaseVersion('1.2.0', 'minor', 'beta', ['1.3.0-beta.1', '1.2.0'])).toBe('1.3.0-beta.2');
+  });
+
+  test('a smaller bump keeps the pending base', () => {
+    expect(getNextPrereleaseVersion('1.2.0', 'patch', 'beta', ['1.3.0-beta.2', '1.2.0'])).toBe('1.3.0-beta.3');
+  });
+
+  test('resets the counter when the base bumps', () => {
+    expect(getNextPrereleaseVersion('1.2.0', 'major', 'beta', ['1.3.0-beta.2', '1.2.0'])).toBe('2.0.0-beta.1');
+  });
+
+  test('resets the counter when switching from beta to rc', () => {
+    expect(getNextPrereleaseVersion('1.2.0', 'patch', 'rc', ['1.3.0-beta.4', '1.2.0'])).toBe('1.3.0-rc.1');
+  });
+});
+
+describe('determineVersionBump with prereleases', () => {
+  const tags = ['v1.3.0-beta.2', 'v1.3.0-beta.1', 'v1.2.0'];
+
+  test('channel runs build the next prerelease', async () => {
+    const betaConfig = getPrereleaseConfig(config, config.prerelease[0]);
+    const result = await determineVersionBump([createCommit('patch')], createProvider(tags), betaConfig);
+
+    expect(result.currentVersion).toBe('1.3.0-beta.2');
+    expect(result.newVersion).toBe('1.3.0-beta.3');
+  });
+
+  test('stable runs drop the prerelease suffix', async () => {
+    const result = await determineVersionBump([createCommit('minor')], createProvider(tags), config);
+
+    expect(result.currentVersion).toBe('1.2.0');
+    expect(result.newVersion).toBe('1.3.0');
+  });
+
+  test('prereleases compare against the latest release on any channel', () => {
+    expect(findLatestReleaseTag(tags.map(name => ({ name })), config)).toBe('v1.3.0-beta.2');
+    expect(findLatestReleaseTag([], config)).toBeNull();
+  });
+
+  test('prereleases never move the alias tags', () => {
+    expect(getAdditionalTagNames('1.3.0-beta.1', { tagMajor: true })).toEqual([]);
+    expect(getAdditionalTagNames('1.3.0', { tagMajor: true })).toEqual(['latest', 'v1']);
+  });
+});
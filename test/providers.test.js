@@ -0,0 +1,250 @@
+/**
+ * Tests for the VCS provider layer
+ *
+ * These tests make sure the right provider is picked for the configured
+ * platform, that GitLab responses are normalised into the shared shapes, that
+ * long GitHub compares are read page by page and that GitHub Enterprise Server
+ * URLs are worked out from the config.
+ */
+
+/* global jest, describe, test, expect */
+
+const { createProvider } = require('../src/providers');
+const { GitLabProvider, normalizeMR } = require('../src/providers/gitlabProvider');
+const { DryRunProvider } = require('../src/providers/dryRunProvider');
+const { GitHubProvider, getGitHubUrls } = require('../src/providers/githubProvider');
+const { resolveConfig } = require('../src/utils/config');
+
+const config = {
+  releaseBranch: 'release',
+  stagingBranch: 'staging',
+  mergeBranch: 'main'
+};
+
+/**
+ * Build a GitLab provider with a mocked request function
+ * @param {Function} respond - Returns response data for (method, url, options)
+ */
+function createGitLab(respond) {
+  const request = jest.fn(async (method, url, options) => ({
+    status: 200,
+    headers: {},
+    data: respond(method, url, options)
+  }));
+  const provider = new GitLabProvider({
+    token: 'glpat-test',
+    project: 'group/project',
+    baseUrl: 'https://gitlab.example.com/',
+    request
+  });
+  return { provider, request };
+}
+
+describe('createProvider', () => {
+  test('rejects unsupported platforms', () => {
+    expect(() => createProvider({ platform: 'bitbucket' }, { token: 'x' })).toThrow('Unsupported platform');
+  });
+
+  test('creates a GitLab provider from config', () => {
+    const provider = createProvider({ platform: 'gitlab', repository: 'group/project' }, { token: 'x' });
+    expect(provider).toBeInstanceOf(GitLabProvider);
+    expect(provider.repoUrl).toBe('https://gitlab.com/group/project');
+  });
+});
+
+describe('GitHubProvider', () => {
+  test('reads every page of a compare over 250 commits', async () => {
+    const history = Array.from({ length: 620 }, (_, i) => ({
+      sha: `${i}`.padStart(40, '0'),
+      commit: { message: `fix: bug ${i}`, author: { name: 'dev', email: 'dev@example.com', date: '2024-01-01T00:00:00Z' } },
+      author: null,
+      html_url: `https://github.com/owner/repo/commit/${i}`
+    }));
+    const compareCommits = jest.fn(async ({ page, per_page: size }) => ({
+      data: { total_commits: history.length, commits: history.slice((page - 1) * size, page * size) }
+    }));
+    const provider = new GitHubProvider({ rest: { repos: { compareCommits } } }, { repo: { owner: 'owner', repo: 'repo' } });
+
+    const commits = await provider.compareCommits('v1.0.0', 'main');
+    expect(commits).toHaveLength(620);
+    expect(commits[0].message).toBe('fix: bug 0');
+    expect(commits[619].message).toBe('fix: bug 619');
+    expect(compareCommits).toHaveBeenCalledTimes(7);
+  });
+});
+
+describe('GitHub Enterprise Server', () => {
+  const context = { repo: { owner: 'platform', repo: 'widgets' } };
+
+  test('github.com is the default', () => {
+    expect(getGitHubUrls({}, {})).toEqual({
+      apiUrl: 'https://api.github.com',
+      uploadUrl: 'https://uploads.github.com',
+      webUrl: 'https://github.com'
+    });
+  });
+
+  test('a GHES API URL gives the web and upload hosts too', () => {
+    expect(getGitHubUrls({ githubApiUrl: 'https://github.example.com/api/v3/' }, {})).toEqual({
+      apiUrl: 'https://github.example.com/api/v3',
+      uploadUrl: 'https://github.example.com/api/uploads',
+      webUrl: 'https://github.example.com'
+    });
+    expect(getGitHubUrls({ githubUploadUrl: 'https://uploads.example.com' },
+      { GITHUB_API_URL: 'https://github.example.com/api/v3', GITHUB_SERVER_URL: 'https://github.example.com' })).toMatchObject({
+      apiUrl: 'https://github.example.com/api/v3',
+      uploadUrl: 'https://uploads.example.com'
+    });
+  });
+
+  test('links point at the enterprise host', () => {
+    const provider = new GitHubProvider({}, context, { urls: getGitHubUrls({ githubApiUrl: 'https://github.example.com/api/v3' }, {}) });
+
+    expect(provider.repoUrl).toBe('https://github.example.com/platform/widgets');
+    expect(provider.pullRequestUrl(12)).toBe('https://github.example.com/platform/widgets/pull/12');
+  });
+
+  test('a URL without /meta fails with a hint', async () => {
+    const notFound = Object.assign(new Error('Not Found'), { status: 404 });
+    const octokit = { request: jest.fn(async () => { throw notFound; }) };
+    const provider = new GitHubProvider(octokit, context, { urls: getGitHubUrls({ githubApiUrl: 'https://github.example.com' }, {}) });
+
+    await expect(provider.checkConnection()).rejects.toThrow('https://github.example.com/meta doesn\'t exist');
+    expect(octokit.request).toHaveBeenCalledWith('GET /meta');
+  });
+
+  test('github.com is not checked and a reachable GHES passes', async () => {
+    const octokit = { request: jest.fn(async () => ({ data: { installed_version: '3.12.0' } })) };
+
+    await new GitHubProvider(octokit, context).checkConnection();
+    expect(octokit.request).not.toHaveBeenCalled();
+
+    await new GitHubProvider(octokit, context, { urls: getGitHubUrls({ githubApiUrl: 'https://github.example.com/api/v3' }, {}) }).checkConnection();
+    expect(octokit.request).toHaveBeenCalledTimes(1);
+  });
+
+  test('malformed URLs fail when the config loads', () => {
+    expect(() => resolveConfig({ githubApiUrl: 'github.example.com/api/v3' })).toThrow('githubApiUrl must be an http(s) URL');
+    expect(() => resolveConfig({ githubUploadUrl: 'ftp://github.example.com' })).toThrow('githubUploadUrl must be an http(s) URL');
+  });
+});
+
+describe('GitLabProvider', () => {
+  test('calls the project API with the private token', async () => {
+    const { provider, request } = createGitLab(() => ({ commits: [] }));
+    await provider.compareCommits('v1.0.0', 'main');
+
+    const [method, url, options] = request.mock.calls[0];
+    expect(method).toBe('GET');
+    expect(url).toBe('https://gitlab.example.com/api/v4/projects/group%2Fproject/repository/compare?from=v1.0.0&to=main');
+    expect(options.headers['PRIVATE-TOKEN']).toBe('glpat-test');
+  });
+
+  test('normalises compared commits', async () => {
+    const { provider } = createGitLab(() => ({
+      commits: [{
+        id: 'abc123',
+        message: 'feat: add thing',
+        author_name: 'Jane',
+        author_email: 'jane@example.com',
+        authored_date: '2024-01-01T00:00:00Z'
+      }]
+    }));
+
+    const commits = await provider.compareCommits('v1.0.0', 'main');
+    expect(commits).toEqual([{
+      sha: 'abc123',
+      message: 'feat: add thing',
+      author: 'Jane',
+      authorName: 'Jane',
+      authorEmail: 'jane@example.com',
+      username: null,
+      date: '2024-01-01T00:00:00Z',
+      url: 'https://gitlab.example.com/group/project/-/commit/abc123'
+    }]);
+  });
+
+  test('reads every page of a commit\'s diff', async () => {
+    const diffs = Array.from({ length: 130 }, (_, i) => ({ new_path: `docs/page-${i}.md`, old_path: `docs/page-${i}.md` }));
+    diffs.push({ new_path: 'src/index.js', old_path: 'src/main.js' });
+    const { provider, request } = createGitLab((method, url) => {
+      const query = new URL(url).searchParams;
+      const size = Number(query.get('per_page'));
+      return diffs.slice((Number(query.get('page')) - 1) * size, Number(query.get('page')) * size);
+    });
+
+    const files = await provider.getCommitFiles('abc123');
+    expect(files).toHaveLength(132);
+    expect(files.slice(-2)).toEqual(['src/index.js', 'src/main.js']);
+    expect(request).toHaveBeenCalledTimes(2);
+  });
+
+  test('finds the open release MR', async () => {
+    const { provider, request } = createGitLab(() => ([
+      { iid: 7, web_url: 'https://gitlab.example.com/group/project/-/merge_requests/7', state: 'opened', title: 'Release v1.1.0', source_branch: 'staging-v1.1.0', target_branch: 'release' }
+    ]));
+
+    const pr = await provider.findOpenReleasePR(config, '1.1.0');
+    expect(pr.number).toBe(7);
+    expect(pr.state).toBe('open');
+    expect(request.mock.calls[0][1]).toContain('source_branch=staging-v1.1.0');
+  });
+
+  test('moves an open release MR to a new version in place', async () => {
+    const releasePR = { number: 7, url: 'https://gitlab.example.com/group/project/-/merge_requests/7', state: 'open', headBranch: 'staging-v1.1.0' };
+    const { provider, request } = createGitLab((method, url) => {
+      if (method === 'PUT') {
+        return { iid: 7, web_url: releasePR.url, state: 'opened', title: 'Release 1.2.0', source_branch: 'staging-v1.1.0', target_branch: 'release' };
+      }
+      return url.includes('/repository/files/') ? '' : {};
+    });
+
+    const result = await provider.createReleasePR('1.2.0', 'changes', { ...config, pullRequestTitle: 'Release {version}', releasePR }, []);
+
+    expect(result).toEqual({ prNumber: 7, prUrl: releasePR.url, prStatus: 'open' });
+    const calls = request.mock.calls.map(([method, url]) => `${method} ${url.replace(/^.*\/projects\/[^/]+/, '')}`);
+    expect(calls).toContain('POST /repository/branches?branch=staging-v1.1.0&ref=main');
+    expect(calls).toContain('PUT /merge_requests/7');
+    expect(calls.some(call => call.startsWith('POST /merge_requests'))).toBe(false);
+    expect(request.mock.calls.find(([method]) => method === 'PUT')[2].body.title).toBe('Release 1.2.0');
+  });
+
+  test('maps merge request states', () => {
+    expect(normalizeMR({ iid: 1, state: 'opened' }).state).toBe('open');
+    expect(normalizeMR({ iid: 1, state: 'merged' }).state).toBe('merged');
+    expect(normalizeMR({ iid: 1, state: 'locked' }).state).toBe('closed');
+  });
+
+  test('links to merge requests and compare pages', () => {
+    const { provider } = createGitLab(() => null);
+    expect(provider.pullRequestUrl(3)).toBe('https://gitlab.example.com/group/project/-/merge_requests/3');
+    expect(provider.compareUrl('v1.0.0', 'v1.1.0')).toBe('https://gitlab.example.com/group/project/-/compare/v1.0.0...v1.1.0');
+  });
+});
+
+describe('DryRunProvider', () => {
+  test('passes reads through and never calls mutating methods', async () => {
+    const inner = {
+      name: 'github',
+      compareCommits: jest.fn(async () => [{ sha: 'abc' }]),
+      findOpenReleasePR: jest.fn(async () => null),
+      createReleasePR: jest.fn(),
+      createTag: jest.fn(),
+      deleteBranch: jest.fn()
+    };
+    const provider = new DryRunProvider(inner);
+
+    expect(await provider.compareCommits('v1.0.0', 'main')).toEqual([{ sha: 'abc' }]);
+
+    const pr = await provider.createReleasePR('1.1.0', 'changes', { ...config, pullRequestTitle: 'Release {version}' }, []);
+    expect(pr).toEqual({ prNumber: null, prUrl: null, prStatus: 'dry-run' });
+
+    const tag = await provider.createTag('1.1.0', { releaseBranch: 'release', tagMajor: true });
+    expect(tag.tags).toEqual(['v1.1.0', 'latest', 'v1']);
+
+    expect(await provider.deleteBranch('staging-v1.1.0')).toBe(true);
+    expect(inner.createReleasePR).not.toHaveBeenCalled();
+    expect(inner.createTag).not.toHaveBeenCalled();
+    expect(inner.deleteBranch).not.toHaveBeenCalled();
+  });
+});
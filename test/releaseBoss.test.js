@@ -0,0 +1,236 @@
+/**
+ * Tests for the programmatic API
+ *
+ * These tests validate that ReleaseBoss runs the workflow against a provider
+ * passed in code and returns a fully populated result in dry-run mode, that
+ * re-runs update the open release PR instead of opening another, that
+ * the result comes out as stable, versioned JSON for the CLI, that the
+ * next version can be worked out without running the workflow, and that a
+ * run reads the tags and commits only once.
+ */
+
+/* global describe, test, expect, jest, beforeEach, afterEach */
+
+const fs = require('fs');
+const os = require('os');
+const path = require('path');
+
+const { ReleaseBoss, resolveConfig } = require('../src/releaseBoss');
+const { formatReleaseOutput, formatErrorOutput } = require('../src/core/releaseOutput');
+const { parseArgs } = require('../src/cli');
+
+/**
+ * In-memory provider with a fixed commit list and tags
+ */
+function createProvider(messages, tags = []) {
+  return {
+    name: 'github',
+    repoUrl: 'https://github.com/owner/repo',
+    headSha: '4f2c9e1d8b7a6c5d4e3f2a1b0c9d8e7f6a5b4c3d',
+    compareUrl: (from, to) => `https://github.com/owner/repo/compare/${from}...${to}`,
+    pullRequestUrl: number => `https://github.com/owner/repo/pull/${number}`,
+    compareCommits: async () => messages.map((message, index) => ({
+      sha: String(index + 1).repeat(40),
+      message,
+      author: 'kaity',
+      date: '2024-01-01T00:00:00Z',
+      url: `https://github.com/owner/repo/commit/${index + 1}`
+    })),
+    listTags: async () => tags.map(name => ({ name, sha: 'a'.repeat(40) })),
+    getLatestReleaseTag: async () => null,
+    getFileContent: async () => {
+      throw new Error('not found');
+    },
+    detectMergedReleasePR: async () => null,
+    findOpenReleasePR: async () => null,
+    listPRComments: async () => []
+  };
+}
+
+describe('ReleaseBoss', () => {
+  let tmpDir;
+  let cwd;
+
+  beforeEach(() => {
+    cwd = process.cwd();
+    tmpDir = fs.mkdtempSync(path.join(os.tmpdir(), 'release-boss-'));
+    fs.writeFileSync(path.join(tmpDir, 'version.txt'), '// %%release-boss: {{version}}%%\n1.1.0\n');
+    process.chdir(tmpDir);
+  });
+
+  afterEach(() => {
+    process.chdir(cwd);
+    fs.rmSync(tmpDir, { recursive: true, force: true });
+  });
+
+  test('dry-run returns the next version, changelog and files', async () => {
+    const provider = createProvider(['feat: shiny new thing', 'fix: small thing'], ['v1.1.0']);
+    const releaseBoss = new ReleaseBoss({ versionFiles: ['version.txt'] }, {
+      dryRun: true,
+      provider,
+      context: { payload: {} }
+    });
+
+    const result = await releaseBoss.run();
+
+    expect(result).toMatchObject({
+      runType: 'pr',
+      dryRun: true,
+      bumpType: 'minor',
+      previousVersion: '1.1.0',
+      nextVersion: '1.2.0',
+      prStatus: 'dry-run',
+      releaseTag: 'v1.2.0',
+      packages: []
+    });
+    expect(result.changelog).toContain('shiny new thing');
+    expect(result.filesChanged.map(file => path.basename(file))).toEqual(['version.txt']);
+
+    // Nothing was written locally either
+    expect(fs.readFileSync(path.join(tmpDir, 'version.txt'), 'utf8')).toContain('1.1.0');
+  });
+
+  test('re-runs update the open release PR, even when the version has moved on', async () => {
+    const provider = createProvider(['feat: shiny new thing'], ['v1.1.0']);
+    provider.findOpenReleasePR = async () => ({
+      number: 12,
+      url: 'https://github.com/owner/repo/pull/12',
+      state: 'open',
+      title: 'chore: release 1.1.1',
+      headBranch: 'staging-v1.1.1'
+    });
+    const releaseBoss = new ReleaseBoss({ versionFiles: ['version.txt'] }, { dryRun: true, provider, context: { payload: {} } });
+
+    const result = await releaseBoss.run();
+
+    expect(result).toMatchObject({ nextVersion: '1.2.0', prNumber: 12, prUrl: 'https://github.com/owner/repo/pull/12' });
+  });
+
+  test('merging a moved release PR tags the version from its title', async () => {
+    const provider = createProvider([], ['v1.1.0']);
+    const releaseBoss = new ReleaseBoss({}, {
+      dryRun: true,
+      provider,
+      context: {
+        payload: {
+          action: 'closed',
+          pull_request: { number: 12, merged: true, title: 'chore: release 1.2.0', head: { ref: 'staging-v1.1.1' } }
+        }
+      }
+    });
+
+    const result = await releaseBoss.run();
+
+    expect(result).toMatchObject({ runType: 'release', nextVersion: '1.2.0', releaseTag: 'v1.2.0' });
+  });
+
+  test('nothing to release still reports the current version', async () => {
+    const releaseBoss = new ReleaseBoss({}, {
+      dryRun: true,
+      provider: createProvider(['chore: tidy up'], ['v1.1.0']),
+      context: { payload: {} }
+    });
+
+    const result = await releaseBoss.run();
+
+    expect(result.runType).toBe('none');
+    expect(result.reason).toBe('No releasable commits since 1.1.0');
+    expect(result.nextVersion).toBe('1.1.0');
+    expect(result.changelog).toBeNull();
+  });
+
+  test('JSON output has a stable, versioned shape', async () => {
+    const releaseBoss = new ReleaseBoss({ versionFiles: ['version.txt'] }, {
+      dryRun: true,
+      provider: createProvider(['feat: shiny new thing'], ['v1.1.0']),
+      context: { payload: {} }
+    });
+
+    const output = formatReleaseOutput(await releaseBoss.run());
+
+    expect(Object.keys(output)).toEqual([
+      'schemaVersion', 'runType', 'reason', 'dryRun', 'bumpType', 'previousVersion', 'version', 'tag', 'tags',
+      'commitSha', 'pr', 'updatedFiles', 'changelog', 'package', 'packages'
+    ]);
+    expect(output).toMatchObject({
+      schemaVersion: 1,
+      runType: 'pr',
+      previousVersion: '1.1.0',
+      version: '1.2.0',
+      tag: 'v1.2.0',
+      pr: { number: null, url: null, status: 'dry-run' },
+      package: null
+    });
+    expect(output.updatedFiles.map(file => path.basename(file))).toEqual(['version.txt']);
+    expect(JSON.parse(JSON.stringify(output))).toEqual(output);
+
+    expect(formatErrorOutput(new Error('no token'))).toEqual({ schemaVersion: 1, error: { message: 'no token' } });
+  });
+
+  test('the release command takes --dry-run and --output', () => {
+    expect(parseArgs(['release', '--dry-run', '--output', 'json'])).toEqual({
+      command: 'release',
+      options: { dryRun: true, output: 'json' }
+    });
+    expect(() => parseArgs(['release', '--output=yaml'])).toThrow('--output must be one of: text, json');
+  });
+
+  test('docs and style commits alone are a clean no-release', async () => {
+    const releaseBoss = new ReleaseBoss({}, {
+      dryRun: true,
+      provider: createProvider(['docs: fix typo', 'style: reformat'], ['v1.1.0']),
+      context: { payload: {} }
+    });
+
+    const result = await releaseBoss.run();
+
+    expect(result).toMatchObject({ runType: 'none', bumpType: null, prNumber: null });
+    expect(result.reason).toContain('Only docs, style commits since 1.1.0');
+    expect(formatReleaseOutput(result).reason).toBe(result.reason);
+  });
+
+  test('nextVersion only reads tags and commits', async () => {
+    // No PR, branch or file methods - any write would throw
+    const provider = createProvider(['feat: shiny new thing'], ['v1.1.0', 'v1.2.0-beta.1']);
+    const { detectMergedReleasePR, findOpenReleasePR, ...readOnly } = provider;
+
+    await expect(new ReleaseBoss({ versionFiles: ['version.txt'] }, { provider: readOnly }).nextVersion())
+      .resolves.toEqual({ bumpType: 'minor', previousVersion: '1.1.0', nextVersion: '1.2.0', reason: null });
+    expect(fs.readFileSync(path.join(tmpDir, 'version.txt'), 'utf8')).toContain('1.1.0');
+
+    // Prerelease channels still count
+    const beta = { ...readOnly, branch: 'develop' };
+    const prerelease = await new ReleaseBoss({ prerelease: [{ channel: 'beta', branch: 'develop' }] }, { provider: beta }).nextVersion();
+    expect(prerelease.nextVersion).toBe('1.2.0-beta.2');
+  });
+
+  test('the version and the changelog share one fetch of tags and commits', async () => {
+    const provider = createProvider(['feat: shiny new thing\n\nBREAKING CHANGE: node 20 is required', 'fix: small thing'], ['v1.1.0']);
+    provider.listTags = jest.fn(provider.listTags);
+    provider.compareCommits = jest.fn(provider.compareCommits);
+    const releaseBoss = new ReleaseBoss({ versionFiles: ['version.txt'] }, { dryRun: true, provider, context: { payload: {} } });
+
+    const result = await releaseBoss.run();
+
+    expect(result).toMatchObject({ bumpType: 'major', nextVersion: '2.0.0' });
+    expect(result.changelog).toContain('BREAKING CHANGES');
+    expect(provider.listTags).toHaveBeenCalledTimes(1);
+    expect(provider.compareCommits).toHaveBeenCalledTimes(1);
+
+    await releaseBoss.nextVersion();
+    expect(provider.listTags).toHaveBeenCalledTimes(2);
+  });
+
+  test('--version-only is a switch', () => {
+    expect(parseArgs(['--version-only', '--config', 'ci.yml'])).toEqual({
+      command: null,
+      options: { versionOnly: true, config: 'ci.yml' }
+    });
+  });
+
+  test('config built in code gets defaults and validation', () => {
+    expect(resolveConfig({ releaseBranch: 'prod' })).toMatchObject({ releaseBranch: 'prod', mergeBranch: 'main' });
+    expect(() => resolveConfig({ platform: 'svn' })).toThrow('platform must be one of');
+    expect(() => resolveConfig({ noReleaseExitCode: 'yes' })).toThrow('noReleaseExitCode must be a whole number');
+  });
+});
@@ -0,0 +1,183 @@
+/**
+ * Tests for API retries
+ *
+ * These tests validate which failures are retried, how long we wait between
+ * attempts and that providers send their requests through the retry policy.
+ */
+
+/* global jest, describe, test, expect */
+
+const { getRetryPolicy, isRetryableError, getRetryDelay, withRetry } = require('../src/utils/retry');
+const { GitLabProvider } = require('../src/providers/gitlabProvider');
+const { parseArgs, loadConfig } = require('../src/cli');
+
+/**
+ * Build an API error like octokit and requestJson throw
+ */
+function apiError(status, headers = {}, message = `failed with ${status}`) {
+  const error = new Error(message);
+  error.status = status;
+  error.response = { status, headers };
+  return error;
+}
+
+const noJitter = () => 0;
+
+describe('isRetryableError', () => {
+  test('retries server errors, rate limits and network hiccups', () => {
+    expect(isRetryableError(apiError(502))).toBe(true);
+    expect(isRetryableError(apiError(429))).toBe(true);
+    expect(isRetryableError(apiError(403, { 'x-ratelimit-remaining': '0' }))).toBe(true);
+    expect(isRetryableError(apiError(403, {}, 'You have exceeded a secondary rate limit'))).toBe(true);
+    expect(isRetryableError(Object.assign(new Error('socket hang up'), { code: 'ECONNRESET' }))).toBe(true);
+  });
+
+  test('never retries validation or permission errors', () => {
+    expect(isRetryableError(apiError(422))).toBe(false);
+    expect(isRetryableError(apiError(404))).toBe(false);
+    expect(isRetryableError(apiError(403, { 'x-ratelimit-remaining': '12' }, 'Resource not accessible'))).toBe(false);
+    expect(isRetryableError(new Error('boom'))).toBe(false);
+  });
+
+  test('only retries a POST that never got through', () => {
+    expect(isRetryableError(apiError(502), 'POST')).toBe(true);
+    expect(isRetryableError(apiError(503), 'POST')).toBe(true);
+    expect(isRetryableError(apiError(504), 'POST')).toBe(true);
+    expect(isRetryableError(Object.assign(new Error('connect refused'), { code: 'ECONNREFUSED' }), 'POST')).toBe(true);
+    expect(isRetryableError(apiError(429), 'POST')).toBe(true);
+    expect(isRetryableError(apiError(403, {}, 'You have exceeded a secondary rate limit'), 'POST')).toBe(true);
+
+    // The PR or release may already exist
+    expect(isRetryableError(apiError(500), 'POST')).toBe(false);
+    expect(isRetryableError(Object.assign(new Error('socket hang up'), { code: 'ECONNRESET' }), 'POST')).toBe(false);
+  });
+
+  test('retries idempotent methods on server errors', () => {
+    ['GET', 'HEAD', 'PUT', 'PATCH', 'DELETE', 'get'].forEach(method => {
+      expect(isRetryableError(apiError(500), method)).toBe(true);
+      expect(isRetryableError(Object.assign(new Error('socket hang up'), { code: 'ECONNRESET' }), method)).toBe(true);
+    });
+  });
+});
+
+describe('getRetryDelay', () => {
+  const policy = getRetryPolicy({});
+
+  test('backs off exponentially up to maxDelay', () => {
+    expect(getRetryDelay(apiError(500), 1, policy, noJitter)).toBe(1000);
+    expect(getRetryDelay(apiError(500), 3, policy, noJitter)).toBe(4000);
+    expect(getRetryDelay(apiError(500), 10, policy, noJitter)).toBe(30000);
+    expect(getRetryDelay(apiError(500), 1, policy, () => 1)).toBe(1200);
+  });
+
+  test('respects Retry-After and X-RateLimit-Reset', () => {
+    expect(getRetryDelay(apiError(403, { 'Retry-After': '7' }), 1, policy)).toBe(7000);
+
+    const reset = Math.floor(Date.now() / 1000) + 20;
+    const delay = getRetryDelay(apiError(403, { 'x-ratelimit-remaining': '0', 'x-ratelimit-reset': String(reset) }), 1, policy);
+    expect(delay).toBeGreaterThan(15000);
+    expect(delay).toBeLessThan(22000);
+  });
+
+  test('never waits longer than maxDelay, whatever the headers say', () => {
+    expect(getRetryDelay(apiError(429, { 'Retry-After': '3600' }), 1, policy)).toBe(30000);
+
+    const reset = Math.floor(Date.now() / 1000) + 3600;
+    expect(getRetryDelay(apiError(403, { 'x-ratelimit-remaining': '0', 'x-ratelimit-reset': String(reset) }), 1, policy)).toBe(30000);
+    expect(getRetryDelay(apiError(429, { 'Retry-After': '3600' }), 1, getRetryPolicy({ retry: { maxDelay: 5000 } }))).toBe(5000);
+  });
+});
+
+describe('withRetry', () => {
+  const policy = getRetryPolicy({ retry: { maxAttempts: 3 } });
+
+  test('retries until the call succeeds', async () => {
+    const sleep = jest.fn(async () => {});
+    const fn = jest.fn()
+      .mockRejectedValueOnce(apiError(502))
+      .mockRejectedValueOnce(apiError(503))
+      .mockResolvedValueOnce('ok');
+
+    await expect(withRetry(fn, policy, 'GET /thing', sleep)).resolves.toBe('ok');
+    expect(fn).toHaveBeenCalledTimes(3);
+    expect(sleep).toHaveBeenCalledTimes(2);
+  });
+
+  test('gives up after maxAttempts', async () => {
+    const fn = jest.fn(async () => {
+      throw apiError(500);
+    });
+
+    await expect(withRetry(fn, policy, 'GET /thing', async () => {})).rejects.toThrow('failed with 500');
+    expect(fn).toHaveBeenCalledTimes(3);
+  });
+
+  test('fails straight away on a 422', async () => {
+    const fn = jest.fn(async () => {
+      throw apiError(422);
+    });
+
+    await expect(withRetry(fn, policy, 'POST /thing', async () => {})).rejects.toThrow('failed with 422');
+    expect(fn).toHaveBeenCalledTimes(1);
+  });
+
+  test('never sends a POST twice after a 500', async () => {
+    const fn = jest.fn(async () => {
+      throw apiError(500);
+    });
+
+    await expect(withRetry(fn, policy, 'POST /repos/owner/repo/pulls', async () => {})).rejects.toThrow('failed with 500');
+    expect(fn).toHaveBeenCalledTimes(1);
+  });
+
+  test('retries a POST that hit a bad gateway', async () => {
+    const fn = jest.fn()
+      .mockRejectedValueOnce(apiError(502))
+      .mockResolvedValueOnce('created');
+
+    await expect(withRetry(fn, policy, 'POST /repos/owner/repo/git/refs', async () => {})).resolves.toBe('created');
+    expect(fn).toHaveBeenCalledTimes(2);
+  });
+
+  test('retries a rate limited POST', async () => {
+    const fn = jest.fn()
+      .mockRejectedValueOnce(apiError(429))
+      .mockResolvedValueOnce('created');
+
+    await expect(withRetry(fn, policy, 'POST /repos/owner/repo/releases', async () => {})).resolves.toBe('created');
+    expect(fn).toHaveBeenCalledTimes(2);
+  });
+});
+
+describe('--max-retries', () => {
+  test('is parsed as a whole number', () => {
+    expect(parseArgs(['release', '--max-retries', '2'])).toEqual({ command: 'release', options: { maxRetries: '2' } });
+    expect(parseArgs(['--version-only', '--max-retries=0'])).toEqual({ command: null, options: { versionOnly: true, maxRetries: '0' } });
+    expect(() => parseArgs(['release', '--max-retries', 'lots'])).toThrow('--max-retries must be a whole number, got "lots"');
+  });
+
+  test('overrides maxAttempts from the config', async () => {
+    const config = await loadConfig({ config: 'missing-release-boss.yml', maxRetries: '2' });
+    expect(config.retry.maxAttempts).toBe(3);
+
+    const untouched = await loadConfig({ config: 'missing-release-boss.yml' });
+    expect(untouched.retry.maxAttempts).toBeUndefined();
+  });
+});
+
+describe('provider retries', () => {
+  test('GitLab API calls go through the retry policy', async () => {
+    const request = jest.fn()
+      .mockRejectedValueOnce(apiError(502))
+      .mockResolvedValueOnce({ status: 200, data: [{ name: 'v1.0.0', commit: { id: 'abc' } }] });
+    const provider = new GitLabProvider({
+      token: 'x',
+      project: 'group/project',
+      request,
+      retry: getRetryPolicy({ retry: { baseDelay: 0, jitter: 0 } })
+    });
+
+    await expect(provider.listTags()).resolves.toEqual([{ name: 'v1.0.0', sha: 'abc' }]);
+    expect(request).toHaveBeenCalledTimes(2);
+  });
+});
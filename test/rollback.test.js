@@ -0,0 +1,216 @@
+/**
+ * Tests for rolling back a release
+ *
+ * These tests validate that a rollback deletes the release before the tag
+ * (remote, then local), that it refuses a version that isn't tagged or that
+ * a newer release was cut on top of unless forced, that --revert finds the
+ * bump commit and reverts it on the release branch, and that GitHub's
+ * tree-based revert won't clobber a file that changed since.
+ */
+
+/* global describe, test, expect, jest */
+
+const { rollbackRelease } = require('../src/core/rollback');
+const { GitHubProvider } = require('../src/providers/githubProvider');
+const { parseArgs } = require('../src/cli');
+
+const TAG_SHA = 'a'.repeat(40);
+const BUMP_SHA = 'b'.repeat(40);
+
+const CONFIG = {
+  releaseBranch: 'release',
+  versionTagPrefix: true,
+  pullRequestTitle: 'chore: release {{.Version}}',
+  releaseCommitMessage: 'chore: bump version to {{.Version}}'
+};
+
+/**
+ * Provider with v1.1.0 and v1.2.0 released (plus any extra tags), recording the order of every change
+ */
+function createProvider(extraTags = []) {
+  const steps = [];
+  const provider = {
+    steps,
+    listTags: jest.fn(async () => [
+      ...extraTags,
+      { name: 'latest', sha: TAG_SHA },
+      { name: 'v1.2.0', sha: TAG_SHA },
+      { name: 'v1.1.0', sha: 'c'.repeat(40) }
+    ]),
+    compareCommits: jest.fn(async () => [
+      { sha: 'd'.repeat(40), message: 'feat: shiny' },
+      { sha: BUMP_SHA, message: 'chore: bump version to 1.2.0\n\nRelease-As: 1.2.0' },
+      { sha: TAG_SHA, message: 'Merge pull request #7' }
+    ]),
+    listCommits: jest.fn(async () => []),
+    deleteRelease: jest.fn(async tag => steps.push(`release ${tag}`) > 0),
+    deleteTag: jest.fn(async tag => steps.push(`tag ${tag}`) > 0),
+    revertCommit: jest.fn(async sha => {
+      steps.push(`revert ${sha}`);
+      return { sha: 'e'.repeat(40) };
+    })
+  };
+  return provider;
+}
+
+/**
+ * git runner for a checkout that has the given local tags
+ */
+function createGit(localTags, steps) {
+  return jest.fn(async args => {
+    if (args[0] === 'rev-parse') {
+      if (!localTags.includes(args[3].replace('refs/tags/', ''))) {
+        throw new Error('git rev-parse failed: ');
+      }
+      return `${TAG_SHA}\n`;
+    }
+    steps.push(`git ${args.join(' ')}`);
+    return '';
+  });
+}
+
+describe('rollbackRelease', () => {
+  test('deletes the release, then the tag, then the local tag', async () => {
+    const provider = createProvider();
+    const git = createGit(['v1.2.0'], provider.steps);
+
+    const result = await rollbackRelease(provider, CONFIG, '1.2.0', { git });
+
+    expect(provider.steps).toEqual(['release v1.2.0', 'tag v1.2.0', 'git tag -d v1.2.0']);
+    expect(provider.revertCommit).not.toHaveBeenCalled();
+    expect(result).toEqual({
+      version: '1.2.0',
+      tag: 'v1.2.0',
+      releaseDeleted: true,
+      tagDeleted: true,
+      localTagDeleted: true,
+      revertSha: null
+    });
+  });
+
+  test('takes the tag name as well as the version, and skips a local tag that is not there', async () => {
+    const provider = createProvider();
+    const git = createGit([], provider.steps);
+
+    const result = await rollbackRelease(provider, CONFIG, 'v1.2.0', { git });
+
+    expect(provider.steps).toEqual(['release v1.2.0', 'tag v1.2.0']);
+    expect(result.localTagDeleted).toBe(false);
+  });
+
+  test('a version that was never tagged is an error', async () => {
+    const provider = createProvider();
+
+    await expect(rollbackRelease(provider, CONFIG, '1.3.0', { git: createGit([], []) })).rejects.toThrow('There\'s no v1.3.0 tag - nothing to roll back');
+    await expect(rollbackRelease(provider, CONFIG, 'latest', { git: createGit([], []) })).rejects.toThrow('"latest" isn\'t a version or a release tag');
+    expect(provider.deleteTag).not.toHaveBeenCalled();
+  });
+
+  test('refuses when a newer release is out, unless forced', async () => {
+    const provider = createProvider([{ name: 'v1.3.0', sha: 'f'.repeat(40) }]);
+    const git = createGit([], provider.steps);
+
+    await expect(rollbackRelease(provider, CONFIG, '1.2.0', { git })).rejects.toThrow('v1.3.0 was released after v1.2.0 - pass --force to roll it back anyway');
+    expect(provider.steps).toEqual([]);
+
+    await rollbackRelease(provider, CONFIG, '1.2.0', { git, force: true });
+    expect(provider.steps).toEqual(['release v1.2.0', 'tag v1.2.0']);
+  });
+
+  test('--revert reverts the bump commit on the release branch', async () => {
+    const provider = createProvider();
+
+    const result = await rollbackRelease(provider, CONFIG, '1.2.0', { git: createGit([], provider.steps), revert: true });
+
+    expect(provider.compareCommits).toHaveBeenCalledWith('v1.1.0', 'v1.2.0');
+    expect(provider.revertCommit).toHaveBeenCalledWith(BUMP_SHA, {
+      branch: 'release',
+      message: `chore: roll back release 1.2.0\n\nThis reverts commit ${BUMP_SHA}.`
+    });
+    expect(provider.steps[provider.steps.length - 1]).toBe(`revert ${BUMP_SHA}`);
+    expect(result.revertSha).toBe('e'.repeat(40));
+  });
+
+  test('--revert without a bump commit to find changes nothing', async () => {
+    const provider = createProvider();
+    provider.compareCommits = jest.fn(async () => [{ sha: TAG_SHA, message: 'fix: unrelated' }]);
+
+    await expect(rollbackRelease(provider, CONFIG, '1.2.0', { git: createGit([], []), revert: true })).rejects.toThrow('Couldn\'t find the version bump commit for v1.2.0');
+    expect(provider.steps).toEqual([]);
+  });
+
+  test('monorepo configs are turned away', async () => {
+    await expect(rollbackRelease(createProvider(), { ...CONFIG, packages: [{ name: 'api', path: 'api' }] }, '1.2.0'))
+      .rejects.toThrow('rollback doesn\'t support monorepo packages yet');
+  });
+});
+
+describe('GitHubProvider.revertCommit', () => {
+  /**
+   * GitHub provider where BUMP_SHA changed package.json and added VERSION on top of 'parent',
+   * and the release branch head has the given package.json blob
+   */
+  function createGitHub(headPackageBlob) {
+    const trees = {
+      parent: [{ path: 'package.json', mode: '100644', type: 'blob', sha: 'pkg-old' }, { path: 'src/index.js', mode: '100644', type: 'blob', sha: 'src' }],
+      [BUMP_SHA]: [{ path: 'package.json', mode: '100644', type: 'blob', sha: 'pkg-new' }, { path: 'VERSION', mode: '100644', type: 'blob', sha: 'version' }, { path: 'src/index.js', mode: '100644', type: 'blob', sha: 'src' }],
+      head: [{ path: 'package.json', mode: '100644', type: 'blob', sha: headPackageBlob }, { path: 'VERSION', mode: '100644', type: 'blob', sha: 'version' }, { path: 'src/index.js', mode: '100644', type: 'blob', sha: 'src2' }]
+    };
+    const octokit = {
+      rest: {
+        repos: {
+          getCommit: jest.fn(async () => ({
+            data: { parents: [{ sha: 'parent' }], files: [{ filename: 'package.json', status: 'modified' }, { filename: 'VERSION', status: 'added' }] }
+          }))
+        },
+        git: {
+          getRef: jest.fn(async () => ({ data: { object: { sha: 'head' } } })),
+          getCommit: jest.fn(async ({ commit_sha: sha }) => ({ data: { tree: { sha: `tree-${sha}` } } })),
+          getTree: jest.fn(async ({ tree_sha: treeSha }) => ({ data: { tree: trees[treeSha.replace('tree-', '')] } })),
+          createTree: jest.fn(async () => ({ data: { sha: 'new-tree' } })),
+          createCommit: jest.fn(async () => ({ data: { sha: 'revert' } })),
+          updateRef: jest.fn(async () => ({ data: {} }))
+        }
+      }
+    };
+    return { octokit, provider: new GitHubProvider(octokit, { repo: { owner: 'owner', repo: 'repo' } }) };
+  }
+
+  test('puts back the parent blobs on top of the branch head', async () => {
+    const { octokit, provider } = createGitHub('pkg-new');
+
+    await expect(provider.revertCommit(BUMP_SHA, { branch: 'release', message: 'chore: roll back release 1.2.0' })).resolves.toEqual({ sha: 'revert' });
+    expect(octokit.rest.git.createTree).toHaveBeenCalledWith({
+      owner: 'owner',
+      repo: 'repo',
+      base_tree: 'tree-head',
+      tree: [
+        { path: 'package.json', mode: '100644', type: 'blob', sha: 'pkg-old' },
+        { path: 'VERSION', mode: '100644', type: 'blob', sha: null }
+      ]
+    });
+    expect(octokit.rest.git.createCommit).toHaveBeenCalledWith({ owner: 'owner', repo: 'repo', message: 'chore: roll back release 1.2.0', tree: 'new-tree', parents: ['head'] });
+    expect(octokit.rest.git.updateRef).toHaveBeenCalledWith({ owner: 'owner', repo: 'repo', ref: 'heads/release', sha: 'revert' });
+  });
+
+  test('a file edited since the commit stops the revert', async () => {
+    const { octokit, provider } = createGitHub('pkg-newer');
+
+    await expect(provider.revertCommit(BUMP_SHA, { branch: 'release', message: 'x' })).rejects.toThrow('package.json changed on release since commit bbbbbbb - revert it by hand');
+    expect(octokit.rest.git.updateRef).not.toHaveBeenCalled();
+  });
+});
+
+describe('CLI', () => {
+  test('rollback takes the version as an argument', () => {
+    expect(parseArgs(['rollback', '1.2.0', '--force', '--revert'])).toEqual({
+      command: 'rollback',
+      options: { version: '1.2.0', force: true, revert: true }
+    });
+  });
+
+  test('other commands still turn away a second argument', () => {
+    expect(() => parseArgs(['release', '1.2.0'])).toThrow('Unexpected argument: 1.2.0');
+    expect(() => parseArgs(['rollback', '1.2.0', '1.1.0'])).toThrow('Unexpected argument: 1.1.0');
+  });
+});
@@ -0,0 +1,114 @@
+/**
+ * Tests for the stale staging branch sweep
+ *
+ * These tests validate which leftover staging branches are deleted at the start
+ * of a run, that branches with an open release PR are always kept, and that
+ * branch dates are only looked up for the branches that need one.
+ */
+
+/* global jest, describe, test, expect */
+
+const { cleanupStaleStagingBranches } = require('../src/core/stagingCleanup');
+const { GitHubProvider } = require('../src/providers/githubProvider');
+const { parseDuration } = require('../src/utils/date');
+const { resolveConfig } = require('../src/utils/config');
+
+const now = new Date('2024-06-30T12:00:00Z');
+
+/**
+ * Provider with the given branches (name → { date, prs }) that records deletions
+ */
+function createProvider(branches) {
+  const deleted = [];
+  return {
+    deleted,
+    listBranches: async prefix => Object.entries(branches)
+      .filter(([name]) => name.startsWith(prefix))
+      .map(([name, { date }]) => ({ name, sha: 'a'.repeat(40), date })),
+    listBranchPRs: async branch => (branches[branch].prs || []).map((state, index) => ({ number: index + 1, state })),
+    deleteBranch: async branch => {
+      deleted.push(branch);
+      return true;
+    }
+  };
+}
+
+describe('cleanupStaleStagingBranches', () => {
+  const branches = {
+    'staging-v1.0.0': { date: '2024-06-01T00:00:00Z' },
+    'staging-v1.1.0': { date: '2024-06-29T00:00:00Z' },
+    'staging-v1.2.0': { date: '2024-06-29T00:00:00Z', prs: ['merged'] },
+    'staging-api-v2.0.0': { date: '2024-06-29T00:00:00Z', prs: ['closed'] },
+    'staging-v1.3.0': { date: '2024-01-01T00:00:00Z', prs: ['closed', 'open'] },
+    'staging-area-tidy': { date: '2020-01-01T00:00:00Z' }
+  };
+
+  test('deletes finished and abandoned staging branches only', async () => {
+    const provider = createProvider(branches);
+    const deleted = await cleanupStaleStagingBranches(provider, { stagingBranch: 'staging', staleBranchAge: '7d' }, now);
+
+    expect(deleted).toEqual(['staging-v1.0.0', 'staging-v1.2.0', 'staging-api-v2.0.0']);
+  });
+
+  test('staleBranchAge controls when a branch without a PR is abandoned', async () => {
+    const keepOld = await cleanupStaleStagingBranches(createProvider(branches), { stagingBranch: 'staging', staleBranchAge: null }, now);
+    expect(keepOld).toEqual(['staging-v1.2.0', 'staging-api-v2.0.0']);
+
+    const impatient = await cleanupStaleStagingBranches(createProvider(branches), { stagingBranch: 'staging', staleBranchAge: '12h' }, now);
+    expect(impatient).toContain('staging-v1.1.0');
+  });
+
+  test('deleteStagingBranch: false turns the sweep off', async () => {
+    const provider = createProvider(branches);
+    await cleanupStaleStagingBranches(provider, { stagingBranch: 'staging', deleteStagingBranch: false }, now);
+
+    expect(provider.deleted).toEqual([]);
+  });
+
+  test('GitHub only looks up the date of branches without a PR', async () => {
+    const shas = Object.fromEntries(Object.keys(branches).map((name, index) => [`${index}`.padStart(40, '0'), name]));
+    const octokit = {
+      rest: {
+        git: {
+          listMatchingRefs: jest.fn(async () => ({
+            data: Object.entries(shas).map(([sha, name]) => ({ ref: `refs/heads/${name}`, object: { sha } }))
+          }))
+        },
+        repos: {
+          getCommit: jest.fn(async ({ ref }) => ({
+            data: {
+              sha: ref,
+              commit: { message: 'chore: release', author: { name: 'bot', date: branches[shas[ref]].date } },
+              author: null,
+              html_url: `https://github.com/owner/repo/commit/${ref}`
+            }
+          }))
+        }
+      }
+    };
+    const provider = new GitHubProvider(octokit, { repo: { owner: 'owner', repo: 'repo' } });
+    const fake = createProvider(branches);
+    provider.listBranchPRs = fake.listBranchPRs;
+    provider.deleteBranch = fake.deleteBranch;
+
+    const deleted = await cleanupStaleStagingBranches(provider, { stagingBranch: 'staging', staleBranchAge: '7d' }, now);
+
+    expect(deleted).toEqual(['staging-v1.0.0', 'staging-v1.2.0', 'staging-api-v2.0.0']);
+    // staging-v1.0.0 and staging-v1.1.0 have no PR - the rest never needed a date
+    expect(octokit.rest.repos.getCommit.mock.calls.map(([{ ref }]) => shas[ref])).toEqual(['staging-v1.0.0', 'staging-v1.1.0']);
+  });
+});
+
+describe('parseDuration', () => {
+  test('reads minutes, hours, days and weeks', () => {
+    expect(parseDuration('30m')).toBe(30 * 60 * 1000);
+    expect(parseDuration('12h')).toBe(12 * 60 * 60 * 1000);
+    expect(parseDuration('7d')).toBe(7 * 24 * 60 * 60 * 1000);
+    expect(parseDuration(2)).toBe(2 * 24 * 60 * 60 * 1000);
+    expect(parseDuration('a week')).toBeNull();
+  });
+
+  test('bad ages fail when the config loads', () => {
+    expect(() => resolveConfig({ staleBranchAge: 'forever' })).toThrow('staleBranchAge must be a duration');
+  });
+});
@@ -0,0 +1,79 @@
+/**
+ * Tests for GitHub Actions step outputs
+ *
+ * These tests validate which outputs come out of a release result, the
+ * GITHUB_OUTPUT file syntax (heredocs for multi-line values) and that
+ * nothing is written outside Actions.
+ */
+
+/* global describe, test, expect, beforeEach, afterEach */
+
+const fs = require('fs');
+const os = require('os');
+const path = require('path');
+
+const { getStepOutputs, formatStepOutputs, writeStepOutputs } = require('../src/utils/stepOutputs');
+
+const released = {
+  runType: 'release',
+  dryRun: false,
+  previousVersion: '1.1.0',
+  nextVersion: '1.2.0',
+  releaseTag: 'v1.2.0',
+  prUrl: null,
+  changelog: '## [1.2.0](...)\n\n### Features\n\n* shiny ([abc1234](...))\n'
+};
+
+describe('getStepOutputs', () => {
+  test('maps a release onto the step outputs', () => {
+    expect(getStepOutputs(released)).toEqual({
+      version: '1.2.0',
+      previous_version: '1.1.0',
+      tag: 'v1.2.0',
+      pr_url: '',
+      released: 'true',
+      changelog: released.changelog
+    });
+  });
+
+  test('PR and dry-run runs are not released', () => {
+    expect(getStepOutputs({ ...released, runType: 'pr', prUrl: 'https://github.com/owner/repo/pull/7' }))
+      .toMatchObject({ released: 'false', pr_url: 'https://github.com/owner/repo/pull/7' });
+    expect(getStepOutputs({ ...released, dryRun: true }).released).toBe('false');
+  });
+});
+
+describe('formatStepOutputs', () => {
+  test('uses heredocs for multi-line values only', () => {
+    const delimiters = ['EOF_1', 'EOF_2'];
+    const output = formatStepOutputs({ version: '1.2.0', changelog: 'line one\nEOF_1\nline three\n' }, () => delimiters.shift());
+
+    // The first delimiter is in the value, so the next one is used
+    expect(output).toBe('version=1.2.0\nchangelog<<EOF_2\nline one\nEOF_1\nline three\nEOF_2\n');
+  });
+});
+
+describe('writeStepOutputs', () => {
+  let tmpDir;
+
+  beforeEach(() => {
+    tmpDir = fs.mkdtempSync(path.join(os.tmpdir(), 'release-boss-outputs-'));
+  });
+
+  afterEach(() => {
+    fs.rmSync(tmpDir, { recursive: true, force: true });
+  });
+
+  test('appends to GITHUB_OUTPUT when it is set', () => {
+    const file = path.join(tmpDir, 'output');
+    fs.writeFileSync(file, 'earlier=step\n');
+
+    expect(writeStepOutputs({ version: '1.2.0', released: 'true' }, { GITHUB_OUTPUT: file })).toBe(true);
+    expect(fs.readFileSync(file, 'utf8')).toBe('earlier=step\nversion=1.2.0\nreleased=true\n');
+  });
+
+  test('does nothing outside GitHub Actions', () => {
+    expect(writeStepOutputs({ version: '1.2.0' }, {})).toBe(false);
+    expect(fs.readdirSync(tmpDir)).toEqual([]);
+  });
+});
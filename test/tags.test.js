@@ -0,0 +1,74 @@
+/**
+ * Tests for release tag names
+ *
+ * These tests validate that tagPrefix is used both for the tags we create and
+ * the ones we look for, and that tagPattern narrows down which tags count.
+ */
+
+/* global describe, test, expect */
+
+const { getReleaseTagName, getAdditionalTagNames, parseVersionFromTag } = require('../src/utils/tags');
+const { resolveConfig } = require('../src/utils/config');
+
+describe('tagPrefix', () => {
+  test('defaults to v', () => {
+    expect(getReleaseTagName('1.2.3', {})).toBe('v1.2.3');
+    expect(parseVersionFromTag('v1.2.3', {})).toBe('1.2.3');
+    expect(parseVersionFromTag('1.2.3', {})).toBe('1.2.3');
+  });
+
+  test('v only accepts v tags', () => {
+    const config = { tagPrefix: 'v' };
+    expect(getReleaseTagName('1.2.3', config)).toBe('v1.2.3');
+    expect(parseVersionFromTag('v1.2.3', config)).toBe('1.2.3');
+    expect(parseVersionFromTag('1.2.3', config)).toBeNull();
+  });
+
+  test('an empty prefix means bare versions', () => {
+    const config = { tagPrefix: '', versionTagPrefix: true };
+    expect(getReleaseTagName('1.2.3', config)).toBe('1.2.3');
+    expect(getAdditionalTagNames('1.2.3', { ...config, tagMajor: true, tagMinor: true })).toEqual(['latest', '1', '1.2']);
+    expect(parseVersionFromTag('1.2.3', config)).toBe('1.2.3');
+    expect(parseVersionFromTag('v1.2.3', config)).toBeNull();
+  });
+
+  test('release- is stripped before the version is parsed', () => {
+    const config = { tagPrefix: 'release-' };
+    expect(getReleaseTagName('1.2.3', config)).toBe('release-1.2.3');
+    expect(parseVersionFromTag('release-1.2.3', config)).toBe('1.2.3');
+    expect(parseVersionFromTag('release-2.0.0-beta.1', config)).toBe('2.0.0-beta.1');
+    expect(parseVersionFromTag('v1.2.3', config)).toBeNull();
+    expect(parseVersionFromTag('release-candidate', config)).toBeNull();
+  });
+
+  test('packages put their namespace in front of the prefix', () => {
+    const config = { tagPrefix: 'release-', tagNamespace: 'api/' };
+    expect(getReleaseTagName('1.2.3', config)).toBe('api/release-1.2.3');
+    expect(parseVersionFromTag('api/release-1.2.3', config)).toBe('1.2.3');
+    expect(parseVersionFromTag('release-1.2.3', config)).toBeNull();
+  });
+});
+
+describe('tagPattern', () => {
+  test('only matching tags count, with the version taken from the capture group', () => {
+    const config = { tagPrefix: '', tagPattern: '^(\\d+\\.\\d+\\.\\d+)$' };
+    expect(parseVersionFromTag('1.2.3', config)).toBe('1.2.3');
+    expect(parseVersionFromTag('1.2.3-rc.1', config)).toBeNull();
+  });
+
+  test('a named version group wins over the other groups', () => {
+    const config = { tagPrefix: 'release-', tagPattern: '^(release|hotfix)-(?<version>.+)$' };
+    expect(parseVersionFromTag('hotfix-1.2.4', config)).toBe('1.2.4');
+  });
+
+  test('without a group the prefix is stripped', () => {
+    const config = { tagPrefix: 'release-', tagPattern: '^release-\\d' };
+    expect(parseVersionFromTag('release-1.2.3', config)).toBe('1.2.3');
+    expect(parseVersionFromTag('release-x1.2.3', config)).toBeNull();
+  });
+
+  test('bad options fail when the config loads', () => {
+    expect(() => resolveConfig({ tagPattern: '^release-(' })).toThrow('tagPattern is not a valid regular expression');
+    expect(() => resolveConfig({ tagPrefix: false })).toThrow('tagPrefix must be a string');
+  });
+});
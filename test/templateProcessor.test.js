@@ -0,0 +1,287 @@
+/**
+ * Tests for template placeholder rendering
+ *
+ * These tests validate the placeholders available inside version file
+ * templates, that unknown placeholders are left alone, the configurable
+ * marker prefix, that markers inside indented code keep its indentation, the
+ * json and plain version file strategies, and that merge conflict markers stop
+ * the release unless conflictStrategy picks a side.
+ */
+
+/* global describe, test, expect, beforeEach, afterEach */
+
+const { execFileSync } = require('child_process');
+const fs = require('fs');
+const os = require('os');
+const path = require('path');
+
+const {
+  processVersionFiles,
+  processUpdateFiles,
+  resolveConflicts,
+  buildTemplateVariables,
+  renderTemplate
+} = require('../src/core/templateProcessor');
+const { resolveConfig } = require('../src/utils/config');
+
+const fixturePath = path.join(__dirname, 'fixtures', 'version-files', 'build-info.js');
+const customMarkerPath = path.join(__dirname, 'fixtures', 'version-files', 'custom-marker.go');
+const legacyMarkerPath = path.join(__dirname, 'fixtures', 'version-files', 'version.go');
+const packageJsonPath = path.join(__dirname, 'fixtures', 'version-files', 'package.json');
+const plainVersionPath = path.join(__dirname, 'fixtures', 'version-files', 'VERSION');
+const indentedMarkerPath = path.join(__dirname, 'fixtures', 'version-files', 'indented.go');
+const conflictedPath = path.join(__dirname, 'fixtures', 'version-files', 'conflicted.go');
+const sha = '4f2c9e1d8b7a6c5d4e3f2a1b0c9d8e7f6a5b4c3d';
+const date = new Date(Date.UTC(2024, 2, 9, 12, 30, 5));
+
+describe('Template placeholders', () => {
+  test('buildTemplateVariables exposes version parts, prerelease, sha and date', () => {
+    const variables = buildTemplateVariables('1.3.0-beta.1', { sha, date });
+
+    expect(variables).toEqual({
+      version: '1.3.0-beta.1',
+      major: '1',
+      minor: '3',
+      patch: '0',
+      prerelease: 'beta.1',
+      sha,
+      shortSha: '4f2c9e1',
+      date: '2024-03-09T12:30:05.000Z'
+    });
+  });
+
+  test('dateFormat customises the date placeholder', () => {
+    const variables = buildTemplateVariables('1.0.0', { date, dateFormat: 'YYYY-MM-DD HH:mm:ss' });
+    expect(variables.date).toBe('2024-03-09 12:30:05');
+  });
+
+  test('unknown placeholders are left untouched', () => {
+    const variables = buildTemplateVariables('1.0.0', { date });
+    expect(renderTemplate('{{version}} {{sha}} {{nope}}', variables)).toBe('1.0.0 {{sha}} {{nope}}');
+  });
+
+  describe('multi-line version file', () => {
+    let tmpDir;
+    let file;
+
+    beforeEach(() => {
+      tmpDir = fs.mkdtempSync(path.join(os.tmpdir(), 'release-boss-'));
+      file = path.join(tmpDir, 'build-info.js');
+      fs.copyFileSync(fixturePath, file);
+    });
+
+    afterEach(() => {
+      fs.rmSync(tmpDir, { recursive: true, force: true });
+    });
+
+    test('renders every placeholder', async () => {
+      await processVersionFiles([file], '2.1.0-rc.2', { sha, date, dateFormat: 'YYYY-MM-DD' });
+      const content = fs.readFileSync(file, 'utf8');
+      const rendered = content.split('%% */')[1];
+
+      expect(rendered).toContain("version: '2.1.0-rc.2'");
+      expect(rendered).toContain('major: 2');
+      expect(rendered).toContain('minor: 1');
+      expect(rendered).toContain('patch: 0');
+      expect(rendered).toContain("prerelease: 'rc.2'");
+      expect(rendered).toContain(`sha: '${sha}'`);
+      expect(rendered).toContain("shortSha: '4f2c9e1'");
+      expect(rendered).toContain("date: '2024-03-09'");
+      expect(rendered).toContain("custom: '{{notAPlaceholder}}'");
+      expect(rendered).toContain('module.exports = BUILD;');
+    });
+  });
+
+  describe('marker prefix', () => {
+    let tmpDir;
+
+    beforeEach(() => {
+      tmpDir = fs.mkdtempSync(path.join(os.tmpdir(), 'release-boss-'));
+    });
+
+    afterEach(() => {
+      fs.rmSync(tmpDir, { recursive: true, force: true });
+    });
+
+    /**
+     * Copy a fixture into the temp dir, process it and return the result
+     */
+    async function render(fixture, options) {
+      const file = path.join(tmpDir, path.basename(fixture));
+      fs.copyFileSync(fixture, file);
+      await processVersionFiles([file], '2.3.4', options);
+      return fs.readFileSync(file, 'utf8');
+    }
+
+    test('a custom prefix works for inline and block markers', async () => {
+      const content = await render(customMarkerPath, { markerPrefix: '%%releaseboss' });
+
+      expect(content).toContain('// %%releaseboss const Version = "v{{version}}"%%\nconst Version = "v2.3.4"');
+      expect(content).toContain('%% */\nconst Major = "2"\nconst Minor = "3"\nconst Patch = "4"');
+    });
+
+    test('other prefixes are ignored once a custom one is set', async () => {
+      const content = await render(customMarkerPath, { markerPrefix: '%%releaseboss' });
+      expect(content).toContain('const Ignored = "untouched"');
+    });
+
+    test('the legacy %%release-manager: prefix still works by default', async () => {
+      const content = await render(legacyMarkerPath, {});
+
+      expect(content).toContain('const Version = "v2.3.4"');
+      expect(content).toContain('const Patch = "4"');
+    });
+  });
+
+  describe('indented markers', () => {
+    let tmpDir;
+    let file;
+
+    beforeEach(() => {
+      tmpDir = fs.mkdtempSync(path.join(os.tmpdir(), 'release-boss-'));
+      file = path.join(tmpDir, 'indented.go');
+      fs.copyFileSync(indentedMarkerPath, file);
+    });
+
+    afterEach(() => {
+      fs.rmSync(tmpDir, { recursive: true, force: true });
+    });
+
+    /**
+     * gofmt -l output for a file, or null when Go isn't installed
+     */
+    function gofmtList(target) {
+      try {
+        return execFileSync('gofmt', ['-l', target], { encoding: 'utf8' });
+      } catch (error) {
+        if (error.code === 'ENOENT') return null;
+        throw error;
+      }
+    }
+
+    test('rendered lines take the marker line\'s indentation and keep their nesting', async () => {
+      await processVersionFiles([file], '2.3.4', { sha });
+
+      expect(fs.readFileSync(file, 'utf8')).toBe(fs.readFileSync(indentedMarkerPath, 'utf8')
+        .replace('\tconst version = "v0.1.0"', '\tconst version = "v2.3.4"')
+        .replace('\t\tMajor:   0,', '\t\tMajor:   2,')
+        .replace('\t\t\tSHA: "",', '\t\t\tSHA: "4f2c9e1",'));
+    });
+
+    test('the output is still gofmt-clean', async () => {
+      await processVersionFiles([file], '2.3.4', { sha });
+
+      const unformatted = gofmtList(file);
+      if (unformatted !== null) {
+        expect(unformatted).toBe('');
+      }
+    });
+  });
+
+  describe('version file strategies', () => {
+    let tmpDir;
+
+    beforeEach(() => {
+      tmpDir = fs.mkdtempSync(path.join(os.tmpdir(), 'release-boss-'));
+      for (const fixture of [legacyMarkerPath, packageJsonPath, plainVersionPath]) {
+        fs.copyFileSync(fixture, path.join(tmpDir, path.basename(fixture)));
+      }
+    });
+
+    afterEach(() => {
+      fs.rmSync(tmpDir, { recursive: true, force: true });
+    });
+
+    const read = name => fs.readFileSync(path.join(tmpDir, name), 'utf8');
+
+    test('each entry uses its own strategy', async () => {
+      await processVersionFiles([
+        path.join(tmpDir, 'version.go'),
+        { file: path.join(tmpDir, 'package.json'), strategy: 'json' },
+        { file: path.join(tmpDir, 'VERSION'), strategy: 'plain' }
+      ], '2.3.4', {});
+
+      expect(read('version.go')).toContain('const Version = "v2.3.4"');
+      expect(read('package.json')).toBe(fs.readFileSync(packageJsonPath, 'utf8').replace('"version": "0.1.0",', '"version": "2.3.4",'));
+      expect(read('VERSION')).toBe('2.3.4\n');
+    });
+
+    test('json follows a dotted key', async () => {
+      await processVersionFiles([{ file: path.join(tmpDir, 'package.json'), strategy: 'json', key: '.tool.version' }], '2.3.4', {});
+
+      const data = JSON.parse(read('package.json'));
+      expect(data.version).toBe('0.1.0');
+      expect(data.tool.version).toBe('2.3.4');
+    });
+
+    test('json fails when the key is missing', async () => {
+      await expect(processVersionFiles([{ file: path.join(tmpDir, 'package.json'), strategy: 'json', key: '.nope.version' }], '2.3.4', {}))
+        .rejects.toThrow('has no .nope.version key to update');
+    });
+
+    test('bad strategies fail when the config loads', () => {
+      expect(() => resolveConfig({ versionFiles: [{ file: 'VERSION', strategy: 'regex' }] })).toThrow('must be one of: marker, json, plain');
+      expect(() => resolveConfig({ versionFiles: [{ file: 'VERSION', key: '.version' }] })).toThrow('needs strategy: json');
+    });
+  });
+
+  describe('merge conflicts', () => {
+    let tmpDir;
+    let file;
+
+    beforeEach(() => {
+      tmpDir = fs.mkdtempSync(path.join(os.tmpdir(), 'release-boss-'));
+      file = path.join(tmpDir, 'conflicted.go');
+      fs.copyFileSync(conflictedPath, file);
+    });
+
+    afterEach(() => {
+      fs.rmSync(tmpDir, { recursive: true, force: true });
+    });
+
+    test('conflict markers stop the release and leave the file alone', async () => {
+      await expect(processVersionFiles([file], '1.3.0', {}))
+        .rejects.toThrow(`${file} has merge conflict markers (line 6, read from local)`);
+      expect(fs.readFileSync(file, 'utf8')).toBe(fs.readFileSync(conflictedPath, 'utf8'));
+    });
+
+    test('conflicts on the release branch are caught too', async () => {
+      const provider = { name: 'fake', getFileContent: async () => fs.readFileSync(conflictedPath, 'utf8') };
+
+      await expect(processVersionFiles([file], '1.3.0', { provider, releaseBranch: 'release' }))
+        .rejects.toThrow('read from release branch');
+      await expect(processUpdateFiles([{ file, findLine: 'const Name', replaceLine: 'const Name = "app"' }], '1.3.0', { provider }))
+        .rejects.toThrow(`${file} has merge conflict markers`);
+    });
+
+    test('ours and theirs keep one side before the version is rendered', async () => {
+      await processVersionFiles([file], '1.3.0', { conflictStrategy: 'ours' });
+      const content = fs.readFileSync(file, 'utf8');
+
+      expect(content).toContain('const Version = "v1.3.0"\n\nconst Name = "app"');
+      expect(content).not.toMatch(/<<<<<<<|=======|>>>>>>>|hotfix/);
+
+      const source = fs.readFileSync(conflictedPath, 'utf8');
+      expect(resolveConflicts(source, file, 'theirs')).toContain('const Version = "v1.2.0-hotfix"\n\nconst Name');
+      expect(resolveConflicts(source, file, 'ours')).not.toContain('hotfix');
+    });
+
+    test('a diff3 base section is dropped and broken blocks still fail', () => {
+      const diff3 = ['a', '<<<<<<< ours', 'mine', '||||||| base', 'original', '=======', 'yours', '>>>>>>> theirs', 'b'].join('\n');
+
+      expect(resolveConflicts(diff3, 'f', 'ours')).toBe('a\nmine\nb');
+      expect(resolveConflicts(diff3, 'f', 'theirs')).toBe('a\nyours\nb');
+      expect(() => resolveConflicts('<<<<<<< ours\nmine\n', 'f', 'ours')).toThrow('f has a conflict starting on line 1 that doesn\'t close properly');
+    });
+
+    test('a ======= heading underline isn\'t a conflict', () => {
+      const markdown = 'Release notes\n=======\n\nAll good.\n';
+      expect(resolveConflicts(markdown, 'NOTES.md')).toBe(markdown);
+    });
+
+    test('conflictStrategy is checked when the config loads', () => {
+      expect(resolveConfig({}).conflictStrategy).toBe('abort');
+      expect(() => resolveConfig({ conflictStrategy: 'mine' })).toThrow('conflictStrategy must be one of: abort, ours, theirs, got "mine"');
+    });
+  });
+});
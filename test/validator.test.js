@@ -0,0 +1,123 @@
+/**
+ * Tests for the validate command
+ *
+ * These tests validate that broken configs, missing files, unclosed markers
+ * and unknown placeholders are all reported together with file:line.
+ */
+
+/* global describe, test, expect, beforeAll, afterAll */
+
+const fs = require('fs');
+const os = require('os');
+const path = require('path');
+const { validateProject, checkVersionFile, formatProblem } = require('../src/core/validator');
+const { DEFAULT_MARKER_PREFIXES } = require('../src/core/templateProcessor');
+
+const fixtures = path.join(__dirname, 'fixtures/version-files');
+
+describe('checkVersionFile', () => {
+  test('passes the version file fixtures', () => {
+    const content = fs.readFileSync(path.join(fixtures, 'version.go'), 'utf8');
+    expect(checkVersionFile('version.go', content, DEFAULT_MARKER_PREFIXES)).toEqual([]);
+  });
+
+  test('reports unknown placeholders on their own line', () => {
+    const content = fs.readFileSync(path.join(fixtures, 'build-info.js'), 'utf8');
+    const problems = checkVersionFile('build-info.js', content, DEFAULT_MARKER_PREFIXES);
+
+    expect(problems.map(formatProblem)).toEqual([
+      'build-info.js:13: unknown placeholder {{notAPlaceholder}} (available: version, major, minor, patch, prerelease, date, sha, shortSha)'
+    ]);
+  });
+
+  test('reports markers that are never closed', () => {
+    const content = [
+      '/* %%release-boss:',
+      'const Version = "{{version}}"',
+      'const Version = "1.0.0"',
+      '// %%release-boss: const Major = {{major}}%%',
+      'const Major = 1'
+    ].join('\n');
+    const problems = checkVersionFile('version.js', content, DEFAULT_MARKER_PREFIXES);
+
+    expect(problems).toEqual([{ file: 'version.js', line: 1, message: 'template marker is never closed with %%' }]);
+  });
+});
+
+describe('validateProject', () => {
+  let dir;
+  let cwd;
+
+  beforeAll(() => {
+    cwd = process.cwd();
+    dir = fs.mkdtempSync(path.join(os.tmpdir(), 'release-boss-validate-'));
+    process.chdir(dir);
+
+    fs.copyFileSync(path.join(fixtures, 'version.go'), 'version.go');
+    fs.writeFileSync('unclosed.js', '/* %%release-boss:\nconst v = "{{version}}"\n');
+    fs.copyFileSync(path.join(fixtures, 'package.json'), 'package.json');
+    fs.copyFileSync(path.join(fixtures, 'VERSION'), 'VERSION');
+    fs.writeFileSync('package.tpl.json', '{\n  "version": "{{version}}",\n  "build": "{{buildNumber}}"\n}\n');
+  });
+
+  afterAll(() => {
+    process.chdir(cwd);
+    fs.rmSync(dir, { recursive: true, force: true });
+  });
+
+  test('a good config has no problems', async () => {
+    fs.writeFileSync('good.yml', 'versionFiles:\n  - version.go\n');
+    await expect(validateProject('good.yml')).resolves.toEqual([]);
+  });
+
+  test('reports every problem in one go', async () => {
+    fs.writeFileSync('broken.yml', [
+      'pullRequestTitle: release',
+      'versionFiles:',
+      '  - version.go',
+      '  - unclosed.js',
+      '  - missing.go',
+      'templateFiles:',
+      '  - package.tpl.json'
+    ].join('\n'));
+
+    const problems = (await validateProject('broken.yml')).map(formatProblem);
+
+    expect(problems).toEqual([
+      'broken.yml: pullRequestTitle must contain a {{.Version}} (or {version}) placeholder',
+      'unclosed.js:1: template marker is never closed with %%',
+      'broken.yml: versionFiles references missing.go, which does not exist',
+      'package.tpl.json:3: unknown placeholder {{buildNumber}} (available: version, major, minor, patch, prerelease, date, sha, shortSha)'
+    ]);
+  });
+
+  test('checks json version files for their key', async () => {
+    fs.writeFileSync('strategies.yml', [
+      'versionFiles:',
+      '  - file: package.json',
+      '    strategy: json',
+      '  - file: package.json',
+      '    strategy: json',
+      '    key: .build.version',
+      '  - file: VERSION',
+      '    strategy: plain'
+    ].join('\n'));
+
+    const problems = (await validateProject('strategies.yml')).map(formatProblem);
+
+    expect(problems).toEqual(['package.json: no .build.version key to update']);
+  });
+
+  test('reports where a YAML config stops parsing', async () => {
+    fs.writeFileSync('bad.yml', 'versionFiles:\n  - version.go\n  bad: [\n');
+    const [problem] = await validateProject('bad.yml');
+
+    expect(problem.file).toBe('bad.yml');
+    expect(problem.line).toBeGreaterThan(1);
+    expect(problem.message).toMatch(/^invalid YAML/);
+  });
+
+  test('a missing config file is a problem', async () => {
+    await expect(validateProject('nope.yml')).resolves.toEqual([{ file: 'nope.yml', line: null, message: 'config file not found' }]);
+  });
+});